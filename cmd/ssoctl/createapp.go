@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"unicode/utf8"
+
+	"sso.service/internal/config"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/auth"
+)
+
+// createApp registers the app directly in the database, so that the first app (the one admins log in with
+// to manage the rest through the API) can be created. The secret is encrypted with the current master key.
+func createApp(args []string) error {
+	flags := flag.NewFlagSet("create-app", flag.ExitOnError)
+	name := flags.String("name", "", "unique name of the app")
+	secret := flags.String("secret", "", "secret tokens issued for the app are signed with")
+	description := flags.String("description", "", "description of the app")
+	orgID := flags.Int64("org-id", entity.NoOrgID, "org owning the app, the app is shared by all of the orgs if 0")
+	embedPermissions := flags.Bool("embed-permissions", false, "embed role and permissions of the user in access tokens")
+	configPath := flags.String("config", "", "path to config file")
+	flags.Parse(args)
+	// same rules as the GetOrCreateApp RPC applies
+	switch {
+	case *name == "" || utf8.RuneCountInString(*name) > 70:
+		return errors.New("-name is required and must be at most 70 characters long")
+	case *description == "" || utf8.RuneCountInString(*description) > 300:
+		return errors.New("-description is required and must be at most 300 characters long")
+	case utf8.RuneCountInString(*secret) < 12 || utf8.RuneCountInString(*secret) > 64:
+		return errors.New("-secret is required and must be from 12 to 64 characters long")
+	case *orgID < 0:
+		return errors.New("-org-id must not be negative")
+	}
+	e, err := setup(*configPath)
+	if err != nil {
+		return err
+	}
+	defer e.close()
+	m := e.models
+	service := auth.New(e.log, m.User, m.App, m.Permission, m.Org, m.Audit, config.MustLoadPasswordPolicy(e.cfg.PasswordPolicy), e.cfg)
+	app := &entity.App{
+		Name:             *name,
+		Description:      *description,
+		Secret:           *secret,
+		OrgID:            *orgID,
+		EmbedPermissions: *embedPermissions,
+	}
+	result, err := service.GetOrCreateApp(context.Background(), 0, app)
+	if err != nil {
+		return err
+	}
+	if !result.IsCreated {
+		e.log.Info("App already exists", "id", result.AppID)
+	}
+	fmt.Println(result.AppID)
+	return nil
+}
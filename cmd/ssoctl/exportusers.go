@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"sso.service/internal/config"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/auth"
+	"sso.service/internal/services/dtos"
+)
+
+// exportUsers writes users matching the filters (the ones of ListUsers), for reporting and backups.
+func exportUsers(args []string) error {
+	flags := flag.NewFlagSet("export-users", flag.ExitOnError)
+	out := flags.String("out", "", "path of the JSONL or CSV file, stdout if empty")
+	format := flags.String("format", "", "jsonl or csv, guessed from the file extension if empty")
+	search := flags.String("search", "", "fuzzy match against username and email")
+	role := flags.String("role", "", "global role of the users")
+	active := flags.String("active", "", "true or false to export only active or inactive users")
+	createdAfter := flags.String("created-after", "", "RFC 3339 time the users are created at or after")
+	createdBefore := flags.String("created-before", "", "RFC 3339 time the users are created before")
+	appID := flags.Int("app-id", 0, "app the users registered with or logged in to")
+	permission := flags.String("permission", "", "permission code the users are granted")
+	permissionAppID := flags.Int("permission-app-id", 0, "app of the permission, global namespace if 0")
+	sortBy := flags.String("sort-by", string(dtos.UsersSortByCreatedAt), "created_at or updated_at")
+	descending := flags.Bool("desc", false, "sort in descending order")
+	withPermissions := flags.Bool("with-permissions", false, "export permissions granted directly to the users")
+	withOrgRoles := flags.Bool("with-org-roles", false, "export roles of the users in orgs")
+	withPasswordHashes := flags.Bool("with-password-hashes", false, "export password hashes, keep the output secret")
+	actorID := flags.Int64("actor", 0, "id of the admin exporting password hashes, recorded in the audit log")
+	configPath := flags.String("config", "", "path to config file")
+	flags.Parse(args)
+	recordsFormat, err := recordsFormat(*format, *out)
+	if err != nil {
+		return err
+	}
+	if *withPasswordHashes && *actorID <= 0 {
+		return errors.New("-actor is required with -with-password-hashes")
+	}
+	filter := dtos.ListUsersOptionsDTO{
+		Search:     strings.TrimSpace(*search),
+		Role:       *role,
+		AppID:      int32(*appID),
+		SortBy:     dtos.UsersSortField(*sortBy),
+		Descending: *descending,
+	}
+	if filter.SortBy != dtos.UsersSortByCreatedAt && filter.SortBy != dtos.UsersSortByUpdatedAt {
+		return fmt.Errorf("unknown sort field %q", *sortBy)
+	}
+	if *active != "" {
+		isActive, err := strconv.ParseBool(*active)
+		if err != nil {
+			return fmt.Errorf("-active: %w", err)
+		}
+		filter.IsActive = &isActive
+	}
+	if filter.CreatedAfter, err = parseTimeFlag(*createdAfter); err != nil {
+		return fmt.Errorf("-created-after: %w", err)
+	}
+	if filter.CreatedBefore, err = parseTimeFlag(*createdBefore); err != nil {
+		return fmt.Errorf("-created-before: %w", err)
+	}
+	if *permission != "" {
+		filter.Permission = &dtos.UserPermissionFilterDTO{AppID: int32(*permissionAppID), Code: *permission}
+	}
+	options := dtos.ExportUsersOptionsDTO{
+		Filter:             filter,
+		WithPermissions:    *withPermissions,
+		WithOrgRoles:       *withOrgRoles,
+		WithPasswordHashes: *withPasswordHashes,
+	}
+	e, err := setup(*configPath)
+	if err != nil {
+		return err
+	}
+	defer e.close()
+	m := e.models
+	service := auth.New(e.log, m.User, m.App, m.Permission, m.Org, m.Audit, config.MustLoadPasswordPolicy(e.cfg.PasswordPolicy), e.cfg)
+	if *actorID != 0 {
+		isAdmin, err := service.IsAdmin(context.Background(), *actorID)
+		if err != nil {
+			return fmt.Errorf("-actor: %w", err)
+		}
+		if !isAdmin {
+			return fmt.Errorf("-actor: user %d is not an admin", *actorID)
+		}
+	}
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	buffered := bufio.NewWriter(w)
+	writer, err := newUsersWriter(buffered, recordsFormat, options)
+	if err != nil {
+		return err
+	}
+	err = service.ExportUsers(context.Background(), *actorID, options, func(user *entity.ExportedUser) error {
+		return writer.Write(user)
+	})
+	if err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+func parseTimeFlag(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
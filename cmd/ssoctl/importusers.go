@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"sso.service/internal/config"
+	"sso.service/internal/services/auth"
+	"sso.service/internal/services/dtos"
+)
+
+// importUsers creates users migrated from other systems along with their password hashes,
+// rows failed to import (including ones duplicating previous rows) are reported and don't stop the rest.
+func importUsers(args []string) error {
+	flags := flag.NewFlagSet("import-users", flag.ExitOnError)
+	file := flags.String("file", "", "path of the JSONL or CSV file with users, stdin if empty")
+	format := flags.String("format", "", "jsonl or csv, guessed from the file extension if empty")
+	dryRun := flags.Bool("dry-run", false, "check the rows against the database without saving anything")
+	configPath := flags.String("config", "", "path to config file")
+	flags.Parse(args)
+	recordsFormat, err := recordsFormat(*format, *file)
+	if err != nil {
+		return err
+	}
+	var r io.Reader = os.Stdin
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	e, err := setup(*configPath)
+	if err != nil {
+		return err
+	}
+	defer e.close()
+	m := e.models
+	service := auth.New(e.log, m.User, m.App, m.Permission, m.Org, m.Audit, config.MustLoadPasswordPolicy(e.cfg.PasswordPolicy), e.cfg)
+	ctx := context.Background()
+	batch := auth.NewImportBatch()
+	var imported, failed int
+	err = readImportRecords(r, recordsFormat, func(row int, record dtos.ImportUserDTO, err error) error {
+		if err == nil {
+			err = batch.Check(record)
+		}
+		if err == nil {
+			_, err = service.ImportUser(ctx, 0, record, *dryRun)
+			if err != nil && !auth.IsImportRowError(err) {
+				return fmt.Errorf("row %d: %w", row, err)
+			}
+		}
+		if err != nil {
+			failed++
+			fmt.Printf("row %d: %s\n", row, err)
+			return nil
+		}
+		batch.Add(int64(row), record)
+		imported++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	e.log.Info("Users imported", "imported", imported, "failed", failed, "dry_run", *dryRun)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d rows failed", failed, imported+failed)
+	}
+	return nil
+}
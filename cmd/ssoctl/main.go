@@ -0,0 +1,95 @@
+// Command ssoctl performs administrative tasks directly against the SSO database.
+//
+//	ssoctl create-app -name name -description text -secret secret [-org-id id] [-embed-permissions] [-config path]
+//	ssoctl export-user-data -user-id 42 [-out archive.json] [-config path]
+//	ssoctl export-users [-out users.jsonl|users.csv] [-format jsonl|csv] [filters] [-with-permissions] [-with-org-roles] [-with-password-hashes -actor admin-id] [-config path]
+//	ssoctl import-users [-file users.jsonl|users.csv] [-format jsonl|csv] [-dry-run] [-config path]
+//	ssoctl reencrypt-secrets [-config path]
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"sso.service/internal/config"
+	"sso.service/internal/entity"
+	"sso.service/internal/storage/postgres"
+	"sso.service/internal/storage/postgres/models"
+)
+
+type command struct {
+	description string
+	run         func(args []string) error
+}
+
+var commands = map[string]command{
+	"create-app":        {"register the app, e.g. the first one admins log in with", createApp},
+	"export-user-data":  {"write data stored about the user as JSON archive", exportUserData},
+	"export-users":      {"write users matching the filters as JSONL or CSV", exportUsers},
+	"import-users":      {"create users migrated from other systems with their password hashes", importUsers},
+	"reencrypt-secrets": {"encrypt stored secrets with the current master key", reencryptSecrets},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: ssoctl <command> [flags]\n\ncommands:")
+	for name, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-20s %s\n", name, cmd.description)
+	}
+}
+
+// env holds dependencies shared by commands, logs are written to stderr to keep stdout for the output.
+type env struct {
+	cfg     *config.Config
+	log     *slog.Logger
+	storage *postgres.Storage
+	models  *models.Models
+}
+
+func setup(configPath string) (*env, error) {
+	if configPath == "" {
+		configPath = config.ResolveConfigPath()
+	}
+	cfg := config.MustLoad(configPath)
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	storage, err := postgres.New(ctx, cfg.DB.Dsn)
+	if err != nil {
+		return nil, err
+	}
+	entity.PasswordHasher = config.MustLoadPasswordHasher(cfg.PasswordHashing)
+	m := models.New(storage.DB)
+	keyring, err := config.MustLoadMasterKeys(cfg.Encryption).Keyring()
+	if err != nil {
+		storage.DB.Close()
+		return nil, err
+	}
+	if keyring != nil {
+		m.App.Cipher = keyring
+	}
+	return &env{cfg: cfg, log: log, storage: storage, models: m}, nil
+}
+
+func (e *env) close() {
+	e.storage.DB.Close()
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+)
+
+const (
+	formatJSONL = "jsonl"
+	formatCSV   = "csv"
+)
+
+// Columns of CSV files with users, permissions are separated by spaces
+var userColumns = []string{"username", "email", "role", "is_active", "app_id", "permissions", "password_hash"}
+
+// recordsFormat returns the format set by the flag or guessed from the file extension, JSONL by default.
+func recordsFormat(format string, path string) (string, error) {
+	if format == "" {
+		if strings.EqualFold(filepath.Ext(path), ".csv") {
+			return formatCSV, nil
+		}
+		return formatJSONL, nil
+	}
+	if format != formatJSONL && format != formatCSV {
+		return "", fmt.Errorf("unknown format %q, expected %s or %s", format, formatJSONL, formatCSV)
+	}
+	return format, nil
+}
+
+// readImportRecords calls fn with every record of users to import, numbering rows from 1 (the CSV header
+// and blank lines aren't counted). Rows which can't be parsed are passed with an error, so that they're
+// reported along with the rest, errors returned by fn stop reading.
+func readImportRecords(r io.Reader, format string, fn func(row int, record dtos.ImportUserDTO, err error) error) error {
+	if format == formatCSV {
+		return readImportCSV(r, fn)
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	row := 0
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		row++
+		var record dtos.ImportUserDTO
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(&record)
+		if err := fn(row, record, err); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func readImportCSV(r io.Reader, fn func(row int, record dtos.ImportUserDTO, err error) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+	index := make(map[string]int, len(header))
+	for i, column := range header {
+		column = strings.TrimSpace(column)
+		if !slices.Contains(userColumns, column) {
+			return fmt.Errorf("unknown column %q, expected some of %s", column, strings.Join(userColumns, ", "))
+		}
+		index[column] = i
+	}
+	for row := 1; ; row++ {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var record dtos.ImportUserDTO
+		if err == nil {
+			record, err = parseImportCSV(fields, index)
+		}
+		if err := fn(row, record, err); err != nil {
+			return err
+		}
+	}
+}
+
+func parseImportCSV(fields []string, index map[string]int) (dtos.ImportUserDTO, error) {
+	field := func(column string) string {
+		i, ok := index[column]
+		if !ok || i >= len(fields) {
+			return ""
+		}
+		return strings.TrimSpace(fields[i])
+	}
+	record := dtos.ImportUserDTO{
+		Username:     field("username"),
+		Email:        field("email"),
+		Role:         field("role"),
+		Permissions:  strings.Fields(field("permissions")),
+		PasswordHash: field("password_hash"),
+	}
+	if value := field("is_active"); value != "" {
+		isActive, err := strconv.ParseBool(value)
+		if err != nil {
+			return record, fmt.Errorf("is_active: %w", err)
+		}
+		record.IsActive = isActive
+	}
+	if value := field("app_id"); value != "" {
+		appID, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return record, fmt.Errorf("app_id: %w", err)
+		}
+		record.AppID = int32(appID)
+	}
+	return record, nil
+}
+
+// usersWriter writes exported users as JSONL or as CSV with optional columns included only if requested.
+// In CSV permissions are separated by spaces, each one is the code followed by "@<app id>" unless it's global,
+// org roles are "<org id>:<role>".
+type usersWriter struct {
+	format  string
+	options dtos.ExportUsersOptionsDTO
+	json    *json.Encoder
+	csv     *csv.Writer
+}
+
+func newUsersWriter(w io.Writer, format string, options dtos.ExportUsersOptionsDTO) (*usersWriter, error) {
+	writer := &usersWriter{format: format, options: options}
+	if format == formatJSONL {
+		writer.json = json.NewEncoder(w)
+		return writer, nil
+	}
+	writer.csv = csv.NewWriter(w)
+	header := []string{"id", "username", "email", "role", "is_active", "created_at", "updated_at"}
+	if options.WithPermissions {
+		header = append(header, "permissions")
+	}
+	if options.WithOrgRoles {
+		header = append(header, "org_roles")
+	}
+	if options.WithPasswordHashes {
+		header = append(header, "password_hash")
+	}
+	return writer, writer.csv.Write(header)
+}
+
+func (w *usersWriter) Write(user *entity.ExportedUser) error {
+	if w.format == formatJSONL {
+		return w.json.Encode(user)
+	}
+	fields := []string{
+		strconv.FormatInt(user.ID, 10),
+		user.Username,
+		user.Email,
+		user.Role,
+		strconv.FormatBool(user.IsActive),
+		user.CreatedAt.UTC().Format(time.RFC3339),
+		user.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if w.options.WithPermissions {
+		permissions := make([]string, len(user.Grants))
+		for i, grant := range user.Grants {
+			permissions[i] = grant.Permission.Code
+			if grant.Permission.AppID != entity.GlobalAppID {
+				permissions[i] += "@" + strconv.Itoa(int(grant.Permission.AppID))
+			}
+		}
+		fields = append(fields, strings.Join(permissions, " "))
+	}
+	if w.options.WithOrgRoles {
+		roles := make([]string, len(user.OrgRoles))
+		for i, member := range user.OrgRoles {
+			roles[i] = strconv.FormatInt(member.OrgID, 10) + ":" + member.Role
+		}
+		fields = append(fields, strings.Join(roles, " "))
+	}
+	if w.options.WithPasswordHashes {
+		fields = append(fields, user.PasswordHash)
+	}
+	return w.csv.Write(fields)
+}
+
+// Flush writes buffered CSV records, it must be called once all users are written.
+func (w *usersWriter) Flush() error {
+	if w.csv == nil {
+		return nil
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
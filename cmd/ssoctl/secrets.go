@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+)
+
+// reencryptSecrets encrypts secrets stored in plaintext or with an old master key with the current one.
+// It's run after a new master key version is added, the old one may be removed once it's done.
+func reencryptSecrets(args []string) error {
+	flags := flag.NewFlagSet("reencrypt-secrets", flag.ExitOnError)
+	configPath := flags.String("config", "", "path to config file")
+	flags.Parse(args)
+	e, err := setup(*configPath)
+	if err != nil {
+		return err
+	}
+	defer e.close()
+	if e.models.App.Cipher == nil {
+		return errors.New("master keys are not configured")
+	}
+	updated, err := e.models.App.ReencryptSecrets(context.Background())
+	if err != nil {
+		return err
+	}
+	e.log.Info("Secrets re-encrypted", "apps", updated)
+	return nil
+}
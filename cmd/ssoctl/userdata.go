@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"os"
+
+	"sso.service/internal/services/userdata"
+)
+
+func exportUserData(args []string) error {
+	flags := flag.NewFlagSet("export-user-data", flag.ExitOnError)
+	userID := flags.Int64("user-id", 0, "id of the user whose data is exported")
+	out := flags.String("out", "", "path of the archive file, stdout if empty")
+	configPath := flags.String("config", "", "path to config file")
+	flags.Parse(args)
+	if *userID <= 0 {
+		return errors.New("-user-id is required")
+	}
+	e, err := setup(*configPath)
+	if err != nil {
+		return err
+	}
+	defer e.close()
+	service := userdata.New(e.log, e.models.User, e.models.Permission, e.models.Audit, e.models.DataExport)
+	archive, err := service.BuildArchive(context.Background(), *userID)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(archive)
+}
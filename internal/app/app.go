@@ -10,8 +10,12 @@ import (
 
 	"sso.service/internal/config"
 	grpcV1 "sso.service/internal/controller/grpc/v1"
+	"sso.service/internal/entity"
 	"sso.service/internal/services/auth"
+	"sso.service/internal/services/orgs"
 	"sso.service/internal/services/permissions"
+	"sso.service/internal/services/relations"
+	"sso.service/internal/services/userdata"
 	"sso.service/internal/storage/postgres"
 	"sso.service/internal/storage/postgres/models"
 	"sso.service/pkg/grpcserver"
@@ -26,11 +30,44 @@ func Run(log *slog.Logger, cfg *config.Config) {
 	}
 	log.Info("Database connected", "dsn", cfg.DB.Dsn)
 	models := models.New(storage.DB)
-	authService := auth.New(log, models.User, models.App, cfg)
-	permissionsService := permissions.New(log, models.Permission, models.User)
-	servers := grpcV1.New(authService, permissionsService, log)
-	gRPCServer := grpcserver.New(log, cfg.Server.Host, cfg.Server.Port, servers.AuthServer, servers.PermissionsServer)
+	keyring, _ := config.MustLoadMasterKeys(cfg.Encryption).Keyring()
+	if keyring != nil {
+		models.App.Cipher = keyring
+		log.Info("App secrets are encrypted", "master_key_version", keyring.CurrentVersion())
+	} else {
+		log.Warn("Master keys are not configured, app secrets are stored unencrypted")
+	}
+	entity.PasswordHasher = config.MustLoadPasswordHasher(cfg.PasswordHashing)
+	passwordPolicy := config.MustLoadPasswordPolicy(cfg.PasswordPolicy)
+	if passwordPolicy.Breached != nil {
+		log.Info("Breached passwords list loaded", "prefixes", passwordPolicy.Breached.Len())
+	}
+	authService := auth.New(log, models.User, models.App, models.Permission, models.Org, models.Audit, passwordPolicy, cfg)
+	permissionsService := permissions.New(log, models.Permission, models.User, models.Role, models.Audit, models.Policy, models.Group, models.Org, cfg.PermissionsCache)
+	relationsSchema := config.MustLoadRelationsSchema(cfg.Relations.SchemaPath)
+	relationsService := relations.New(log, models.RelationTuple, relationsSchema, cfg.Relations.MaxDepth)
+	orgsService := orgs.New(log, models.Org, models.Role)
+	userDataService := userdata.New(log, models.User, models.Permission, models.Audit, models.DataExport)
+	servers := grpcV1.New(authService, permissionsService, relationsService, orgsService, userDataService, log)
+	gRPCServer := grpcserver.New(
+		log,
+		cfg.Server.Host,
+		cfg.Server.Port,
+		servers.AuthServer,
+		servers.PermissionsServer,
+		servers.RelationsServer,
+		servers.OrgsServer,
+		servers.UserDataServer,
+	)
 	go gRPCServer.Run()
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go permissionsService.RunExpiredGrantsSweeper(jobsCtx, cfg.PermissionsSweepInterval)
+	go permissionsService.RunCacheInvalidationListener(jobsCtx, storage)
+	go permissionsService.RunCacheStatsLogger(jobsCtx, cfg.PermissionsCache.StatsLogInterval)
+	go authService.RunErasureJob(jobsCtx, cfg.UserDeletion.ErasureInterval)
+	go userDataService.RunExportWorker(jobsCtx, cfg.DataExports.Interval)
+	go authService.RunSecretCleanupJob(jobsCtx, cfg.AppSecrets.CleanupInterval)
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
 	select {
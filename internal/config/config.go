@@ -16,13 +16,97 @@ type (
 		RefreshTokenTTL    time.Duration `yaml:"refresh_token_ttl" env-default:"24h"`
 		ActivationTokenTTL time.Duration `yaml:"activation_token_ttl" env-default:"30m"`
 		TokenSigningAlg    string        `yaml:"token_signing_alg" env-default:"HS256"`
-		Server             Server        `yaml:"server" env-required:"true"`
-		DB                 DB            `yaml:"db" env-required:"true"`
+		// Defaults of registration settings apps don't override
+		Registration Registration `yaml:"registration"`
+		// Max length of the scope embedded in access tokens, longer scopes are omitted
+		MaxEmbeddedScopeLen int `yaml:"max_embedded_scope_len" env-default:"2048"`
+		// How often expired permission grants are deleted
+		PermissionsSweepInterval time.Duration    `yaml:"permissions_sweep_interval" env-default:"1m"`
+		PermissionsCache         PermissionsCache `yaml:"permissions_cache"`
+		Relations                Relations        `yaml:"relations"`
+		UserDeletion             UserDeletion     `yaml:"user_deletion"`
+		DataExports              DataExports      `yaml:"data_exports"`
+		AppSecrets               AppSecrets       `yaml:"app_secrets"`
+		PasswordPolicy           PasswordPolicy   `yaml:"password_policy"`
+		PasswordReset            PasswordReset    `yaml:"password_reset"`
+		PasswordHashing          PasswordHashing  `yaml:"password_hashing"`
+		Encryption               Encryption       `yaml:"encryption"`
+		Server                   Server           `yaml:"server" env-required:"true"`
+		DB                       DB               `yaml:"db" env-required:"true"`
+	}
+	Registration struct {
+		// Whether users may register through apps themselves
+		SelfRegistration bool `yaml:"self_registration" env-default:"true"`
+		// Whether registered users have to activate their accounts before logging in
+		RequireActivation bool `yaml:"require_activation" env-default:"true"`
 	}
 	Server struct {
 		Port string `yaml:"port"`
 		Host string `yaml:"host"`
 	}
+	PermissionsCache struct {
+		Enabled    bool          `yaml:"enabled" env-default:"true"`
+		TTL        time.Duration `yaml:"ttl" env-default:"1m"`
+		MaxEntries int           `yaml:"max_entries" env-default:"10000"`
+		// How often cache hit/miss counters are logged
+		StatsLogInterval time.Duration `yaml:"stats_log_interval" env-default:"5m"`
+	}
+	Relations struct {
+		// Path to the YAML file with RelationsSchema
+		SchemaPath string `yaml:"schema_path" env:"RELATIONS_SCHEMA_PATH"`
+		// Max depth of nested usersets followed by Check and Expand
+		MaxDepth int `yaml:"max_depth" env-default:"25"`
+	}
+	UserDeletion struct {
+		// How long deleted users can be restored before their personal data is erased
+		GracePeriod time.Duration `yaml:"grace_period" env-default:"720h"`
+		// How often users past the grace period are looked for
+		ErasureInterval time.Duration `yaml:"erasure_interval" env-default:"1h"`
+	}
+	DataExports struct {
+		// How often pending user data exports are looked for
+		Interval time.Duration `yaml:"interval" env-default:"10s"`
+	}
+	AppSecrets struct {
+		// Longest grace period of secret rotation, during which tokens signed with the old secret are accepted
+		MaxGracePeriod time.Duration `yaml:"max_grace_period" env-default:"720h"`
+		// How often previous secrets past their grace period are dropped
+		CleanupInterval time.Duration `yaml:"cleanup_interval" env-default:"1h"`
+	}
+	PasswordPolicy struct {
+		// Lengths are counted in characters
+		MinLength int `yaml:"min_length" env-default:"8"`
+		MaxLength int `yaml:"max_length" env-default:"64"`
+		// Min number of character classes (lowercase and uppercase letters, digits, other characters) used
+		MinCharClasses int `yaml:"min_char_classes" env-default:"2"`
+		// Whether passwords containing the username or email are rejected
+		ForbidPersonalInfo bool `yaml:"forbid_personal_info" env-default:"true"`
+		// Path to the file with hex SHA-1 prefixes of breached passwords, one per line, the check is off if empty
+		BreachedListPath string `yaml:"breached_list_path" env:"BREACHED_PASSWORDS_PATH"`
+	}
+	PasswordReset struct {
+		TokenTTL time.Duration `yaml:"token_ttl" env-default:"30m"`
+	}
+	PasswordHashing struct {
+		// Algorithm new hashes are made with, argon2id or bcrypt. Hashes of the other one
+		// or with outdated parameters, including imported legacy ones, are upgraded on login.
+		Algorithm  string         `yaml:"algorithm" env-default:"argon2id"`
+		Argon2id   Argon2idParams `yaml:"argon2id"`
+		BcryptCost int            `yaml:"bcrypt_cost" env-default:"12"`
+		// Secret passwords are keyed with before hashing, left out when the config is logged
+		Pepper string `yaml:"pepper" env:"PASSWORD_PEPPER" json:"-"`
+	}
+	Argon2idParams struct {
+		// Memory in KiB
+		Memory      uint32 `yaml:"memory" env-default:"19456"`
+		Iterations  uint32 `yaml:"iterations" env-default:"2"`
+		Parallelism uint8  `yaml:"parallelism" env-default:"1"`
+	}
+	Encryption struct {
+		// Path to the YAML file with MasterKeys, takes precedence over MasterKeys
+		MasterKeysPath string     `yaml:"master_keys_path" env:"MASTER_KEYS_PATH"`
+		MasterKeys     MasterKeys `yaml:"master_keys"`
+	}
 	DB struct {
 		Dsn string `yaml:"dsn" env:"DB_DSN"`
 	}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/ilyakaznacheev/cleanenv"
+	"sso.service/pkg/envelope"
+)
+
+// MasterKeys are versioned keys secrets stored in database (such as app secrets) are encrypted with.
+// To rotate the master key add a new version, restart SSO and run "ssoctl reencrypt-secrets",
+// the old version can be removed afterwards.
+type MasterKeys struct {
+	// Version of the key new values are encrypted with, the highest version if zero
+	Current int `yaml:"current"`
+	// Base64 encoded 32 byte long keys by version, left out when the config is logged
+	Keys map[int]string `yaml:"keys" json:"-"`
+}
+
+// MustLoadMasterKeys returns master keys from the file if its path is set, from the config otherwise.
+func MustLoadMasterKeys(cfg Encryption) *MasterKeys {
+	keys := cfg.MasterKeys
+	if cfg.MasterKeysPath != "" {
+		keys = MasterKeys{}
+		if err := cleanenv.ReadConfig(cfg.MasterKeysPath, &keys); err != nil {
+			panic(err)
+		}
+	}
+	if _, err := keys.Keyring(); err != nil {
+		panic(err)
+	}
+	return &keys
+}
+
+// Keyring returns keyring of the master keys, nil if there are none and secrets are stored unencrypted.
+func (k *MasterKeys) Keyring() (*envelope.Keyring, error) {
+	if len(k.Keys) == 0 {
+		return nil, nil
+	}
+	decoded := make(map[int][]byte, len(k.Keys))
+	for version, key := range k.Keys {
+		var err error
+		if decoded[version], err = base64.StdEncoding.DecodeString(key); err != nil {
+			return nil, fmt.Errorf("master key %d: %w", version, err)
+		}
+	}
+	return envelope.NewKeyring(decoded, k.Current)
+}
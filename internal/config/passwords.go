@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+
+	"sso.service/pkg/passhash"
+	"sso.service/pkg/passwordpolicy"
+)
+
+// MustLoadPasswordPolicy returns the password policy, reading the breached passwords list if its path is set.
+func MustLoadPasswordPolicy(cfg PasswordPolicy) *passwordpolicy.Policy {
+	policy := &passwordpolicy.Policy{
+		MinLength:          cfg.MinLength,
+		MaxLength:          cfg.MaxLength,
+		MinCharClasses:     cfg.MinCharClasses,
+		ForbidPersonalInfo: cfg.ForbidPersonalInfo,
+	}
+	if cfg.BreachedListPath != "" {
+		breached, err := passwordpolicy.LoadBreachedList(cfg.BreachedListPath)
+		if err != nil {
+			panic(err)
+		}
+		policy.Breached = breached
+	}
+	return policy
+}
+
+// MustLoadPasswordHasher returns the hasher making new hashes with the configured algorithm
+// and verifying hashes of any of the supported ones, including the legacy ones users are imported with.
+func MustLoadPasswordHasher(cfg PasswordHashing) *passhash.Hasher {
+	var pepper []byte
+	if cfg.Pepper != "" {
+		pepper = []byte(cfg.Pepper)
+	}
+	argon2id := passhash.Argon2id{
+		Memory:      cfg.Argon2id.Memory,
+		Iterations:  cfg.Argon2id.Iterations,
+		Parallelism: cfg.Argon2id.Parallelism,
+	}
+	if err := argon2id.CheckParams(); err != nil {
+		panic(err)
+	}
+	bcrypt := passhash.Bcrypt{Cost: cfg.BcryptCost}
+	legacy := []passhash.Algorithm{passhash.PBKDF2SHA256{}, passhash.Scrypt{}, passhash.SaltedSHA256{}}
+	switch cfg.Algorithm {
+	case "argon2id":
+		return passhash.New(argon2id, pepper, append([]passhash.Algorithm{bcrypt}, legacy...)...)
+	case "bcrypt":
+		return passhash.New(bcrypt, pepper, append([]passhash.Algorithm{argon2id}, legacy...)...)
+	}
+	panic(fmt.Sprintf("unknown password hashing algorithm %q", cfg.Algorithm))
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/ilyakaznacheev/cleanenv"
+)
+
+type (
+	// RelationsSchema defines namespaces of objects and relations which may be stored
+	// in relation tuples, along with rules of deriving relations from each other, e.g.
+	//
+	//	namespaces:
+	//	  folder:
+	//	    relations:
+	//	      owner:
+	//	      editor:
+	//	        union:
+	//	          - this: {}
+	//	          - computed_userset: owner
+	//	  document:
+	//	    relations:
+	//	      parent:
+	//	      editor:
+	//	        union:
+	//	          - this: {}
+	//	          - tuple_to_userset: {tupleset: parent, computed_userset: editor}
+	//
+	// Relation without rewrite contains only subjects of its own tuples (same as "this").
+	RelationsSchema struct {
+		Namespaces map[string]NamespaceSchema `yaml:"namespaces"`
+	}
+	NamespaceSchema struct {
+		Relations map[string]*RelationRewrite `yaml:"relations"`
+	}
+	// RelationRewrite must have exactly one of the fields set.
+	RelationRewrite struct {
+		// Subjects of the relation's own tuples
+		This *struct{} `yaml:"this"`
+		// Subjects having another relation to the same object
+		ComputedUserset string `yaml:"computed_userset"`
+		// Subjects having ComputedUserset relation to the objects related via Tupleset relation
+		TupleToUserset *TupleToUserset   `yaml:"tuple_to_userset"`
+		Union          []RelationRewrite `yaml:"union"`
+	}
+	TupleToUserset struct {
+		Tupleset        string `yaml:"tupleset"`
+		ComputedUserset string `yaml:"computed_userset"`
+	}
+)
+
+// MustLoadRelationsSchema reads schema from the file, empty path gives an empty schema.
+func MustLoadRelationsSchema(schemaPath string) *RelationsSchema {
+	var schema RelationsSchema
+	if schemaPath != "" {
+		if err := cleanenv.ReadConfig(schemaPath, &schema); err != nil {
+			panic(err)
+		}
+	}
+	if err := schema.Validate(); err != nil {
+		panic(err)
+	}
+	return &schema
+}
+
+// Validate checks that every relation referenced by rewrites exists.
+func (s *RelationsSchema) Validate() error {
+	for nsName, ns := range s.Namespaces {
+		for relName, rewrite := range ns.Relations {
+			if rewrite == nil {
+				continue
+			}
+			if err := s.validateRewrite(ns, rewrite); err != nil {
+				return fmt.Errorf("relations schema: %s#%s: %w", nsName, relName, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *RelationsSchema) validateRewrite(ns NamespaceSchema, rewrite *RelationRewrite) error {
+	set := 0
+	if rewrite.This != nil {
+		set++
+	}
+	if rewrite.ComputedUserset != "" {
+		set++
+		if _, ok := ns.Relations[rewrite.ComputedUserset]; !ok {
+			return fmt.Errorf("unknown computed userset relation %q", rewrite.ComputedUserset)
+		}
+	}
+	if rewrite.TupleToUserset != nil {
+		set++
+		if _, ok := ns.Relations[rewrite.TupleToUserset.Tupleset]; !ok {
+			return fmt.Errorf("unknown tupleset relation %q", rewrite.TupleToUserset.Tupleset)
+		}
+		// computed userset of tuple to userset refers to relation of another namespace,
+		// it's checked to exist in namespace of the related object during evaluation
+		if rewrite.TupleToUserset.ComputedUserset == "" {
+			return fmt.Errorf("tuple to userset has no computed userset")
+		}
+	}
+	if rewrite.Union != nil {
+		set++
+		for i := range rewrite.Union {
+			if err := s.validateRewrite(ns, &rewrite.Union[i]); err != nil {
+				return err
+			}
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("rewrite must have exactly one of this, computed_userset, tuple_to_userset, union")
+	}
+	return nil
+}
@@ -2,31 +2,107 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/controller/grpc/v1/authn"
 	"sso.service/internal/entity"
+	"sso.service/internal/services/auth"
+	"sso.service/internal/services/dtos"
 	"sso.service/pkg/validator"
 )
 
+// appToProto converts the app, leaving out its secret. Unset settings are left unset.
+func appToProto(app *entity.App) *ssov1.App {
+	return &ssov1.App{
+		Id:                 app.ID,
+		Name:               app.Name,
+		Description:        app.Description,
+		EmbedPermissions:   app.EmbedPermissions,
+		OrgId:              app.OrgID,
+		MetadataSchema:     app.MetadataSchema,
+		AccessTokenTtl:     durationToProto(app.AccessTokenTTL),
+		RefreshTokenTtl:    durationToProto(app.RefreshTokenTTL),
+		ActivationTokenTtl: durationToProto(app.ActivationTokenTTL),
+		LoginMethods:       app.LoginMethods,
+		SelfRegistration:   boolToProto(app.SelfRegistration),
+		RequireActivation:  boolToProto(app.RequireActivation),
+	}
+}
+
+func durationToProto(d *time.Duration) *durationpb.Duration {
+	if d == nil {
+		return nil
+	}
+	return durationpb.New(*d)
+}
+
+func durationFromProto(d *durationpb.Duration) *time.Duration {
+	if d == nil {
+		return nil
+	}
+	duration := d.AsDuration()
+	return &duration
+}
+
+func boolToProto(b *bool) *wrapperspb.BoolValue {
+	if b == nil {
+		return nil
+	}
+	return wrapperspb.Bool(*b)
+}
+
+func boolFromProto(b *wrapperspb.BoolValue) *bool {
+	if b == nil {
+		return nil
+	}
+	value := b.GetValue()
+	return &value
+}
+
+func appErrorToStatus(err error, fallbackMsg string) error {
+	switch {
+	case errors.Is(err, auth.ErrAppNotFound) || errors.Is(err, auth.ErrOrgNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, auth.ErrAppAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, auth.ErrInvalidAppSettings):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, fallbackMsg)
+}
+
+var appValidationRules = map[string]string{"AppId": "required,gt=0"}
+
 func (s *AuthServer) GetOrCreateApp(ctx context.Context, req *ssov1.GetOrCreateAppRequest) (*ssov1.GetOrCreateAppResponse, error) {
+	admin, err := authn.RequireAdmin(ctx, s.service)
+	if err != nil {
+		return nil, err
+	}
 	validationRules := map[string]string{
 		"Name":        "required,max=70",
 		"Description": "required,max=300",
 		"Secret":      "required,min=12,max=64",
+		"OrgId":       "gte=0",
 	}
 	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
 		return nil, status.Error(codes.InvalidArgument, errs)
 	}
 
-	data, err := s.service.GetOrCreateApp(ctx, &entity.App{
-		Name:        req.GetName(),
-		Description: req.GetDescription(),
-		Secret:      req.GetSecret(),
+	data, err := s.service.GetOrCreateApp(ctx, admin.ID, &entity.App{
+		Name:             req.GetName(),
+		Description:      req.GetDescription(),
+		Secret:           req.GetSecret(),
+		EmbedPermissions: req.GetEmbedPermissions(),
+		OrgID:            req.GetOrgId(),
 	})
 	if err != nil {
-		return nil, status.Error(codes.Internal, "failed to get or create app")
+		return nil, appErrorToStatus(err, "failed to get or create app")
 	}
 
 	return &ssov1.GetOrCreateAppResponse{
@@ -34,3 +110,134 @@ func (s *AuthServer) GetOrCreateApp(ctx context.Context, req *ssov1.GetOrCreateA
 		Created: data.IsCreated,
 	}, nil
 }
+
+func (s *AuthServer) GetApp(ctx context.Context, req *ssov1.GetAppRequest) (*ssov1.GetAppResponse, error) {
+	if _, err := authn.RequireAdmin(ctx, s.service); err != nil {
+		return nil, err
+	}
+	if errs := validator.Validate(req, appValidationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	app, err := s.service.GetApp(ctx, req.GetAppId())
+	if err != nil {
+		return nil, appErrorToStatus(err, "failed to get app")
+	}
+	return &ssov1.GetAppResponse{App: appToProto(app)}, nil
+}
+
+func (s *AuthServer) ListApps(ctx context.Context, req *ssov1.ListAppsRequest) (*ssov1.ListAppsResponse, error) {
+	if _, err := authn.RequireAdmin(ctx, s.service); err != nil {
+		return nil, err
+	}
+	apps, err := s.service.ListApps(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to list apps")
+	}
+	resp := &ssov1.ListAppsResponse{Apps: make([]*ssov1.App, len(apps))}
+	for i := range apps {
+		resp.Apps[i] = appToProto(&apps[i])
+	}
+	return resp, nil
+}
+
+func (s *AuthServer) UpdateApp(ctx context.Context, req *ssov1.UpdateAppRequest) (*ssov1.UpdateAppResponse, error) {
+	admin, err := authn.RequireAdmin(ctx, s.service)
+	if err != nil {
+		return nil, err
+	}
+	if errs := validator.Validate(req, appValidationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	params := dtos.UpdateAppDTO{ID: req.GetAppId()}
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "update_mask must list at least one field")
+	}
+	fieldRules := map[string]string{}
+	for _, path := range paths {
+		switch path {
+		case "name":
+			name := req.GetName()
+			params.Name = &name
+			fieldRules["Name"] = "required,max=70"
+		case "description":
+			description := req.GetDescription()
+			params.Description = &description
+			fieldRules["Description"] = "max=300"
+		case "embed_permissions":
+			embedPermissions := req.GetEmbedPermissions()
+			params.EmbedPermissions = &embedPermissions
+		// unset settings fall back to the global config
+		case "access_token_ttl":
+			params.Settings.AccessTokenTTL = durationFromProto(req.GetAccessTokenTtl())
+			params.SettingsFields = append(params.SettingsFields, path)
+		case "refresh_token_ttl":
+			params.Settings.RefreshTokenTTL = durationFromProto(req.GetRefreshTokenTtl())
+			params.SettingsFields = append(params.SettingsFields, path)
+		case "activation_token_ttl":
+			params.Settings.ActivationTokenTTL = durationFromProto(req.GetActivationTokenTtl())
+			params.SettingsFields = append(params.SettingsFields, path)
+		case "login_methods":
+			// an empty list allows all of the methods
+			if len(req.GetLoginMethods()) > 0 {
+				params.Settings.LoginMethods = req.GetLoginMethods()
+			}
+			params.SettingsFields = append(params.SettingsFields, path)
+		case "self_registration":
+			params.Settings.SelfRegistration = boolFromProto(req.GetSelfRegistration())
+			params.SettingsFields = append(params.SettingsFields, path)
+		case "require_activation":
+			params.Settings.RequireActivation = boolFromProto(req.GetRequireActivation())
+			params.SettingsFields = append(params.SettingsFields, path)
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "update_mask: unknown field %q", path)
+		}
+	}
+	if errs := validator.Validate(req, fieldRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	app, err := s.service.UpdateApp(ctx, admin.ID, params)
+	if err != nil {
+		return nil, appErrorToStatus(err, "failed to update app")
+	}
+	return &ssov1.UpdateAppResponse{App: appToProto(app)}, nil
+}
+
+func (s *AuthServer) DeleteApp(ctx context.Context, req *ssov1.DeleteAppRequest) (*ssov1.DeleteAppResponse, error) {
+	admin, err := authn.RequireAdmin(ctx, s.service)
+	if err != nil {
+		return nil, err
+	}
+	if errs := validator.Validate(req, appValidationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	if err := s.service.DeleteApp(ctx, admin.ID, req.GetAppId()); err != nil {
+		return nil, appErrorToStatus(err, "failed to delete app")
+	}
+	return &ssov1.DeleteAppResponse{}, nil
+}
+
+// RotateAppSecret replaces the secret of the app with a generated one, returned to the caller only here.
+// Tokens signed with the old secret are accepted for the grace period.
+func (s *AuthServer) RotateAppSecret(ctx context.Context, req *ssov1.RotateAppSecretRequest) (*ssov1.RotateAppSecretResponse, error) {
+	admin, err := authn.RequireAdmin(ctx, s.service)
+	if err != nil {
+		return nil, err
+	}
+	if errs := validator.Validate(req, appValidationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	gracePeriod := req.GetGracePeriod().AsDuration()
+	app, err := s.service.RotateAppSecret(ctx, admin.ID, req.GetAppId(), gracePeriod)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidGracePeriod) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, appErrorToStatus(err, "failed to rotate app secret")
+	}
+	resp := &ssov1.RotateAppSecretResponse{Secret: app.Secret}
+	if app.PreviousSecretExpiresAt != nil {
+		resp.PreviousSecretExpiresAt = app.PreviousSecretExpiresAt.Unix()
+	}
+	return resp, nil
+}
@@ -0,0 +1,259 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/controller/grpc/v1/authn"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/auth"
+	"sso.service/internal/services/dtos"
+	"sso.service/pkg/validator"
+)
+
+const defaultUsersPageSize = 50
+
+func (s *AuthServer) ListUsers(ctx context.Context, req *ssov1.ListUsersRequest) (*ssov1.ListUsersResponse, error) {
+	if _, err := authn.RequireAdmin(ctx, s.service); err != nil {
+		return nil, err
+	}
+	options, err := listUsersOptions(req)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.service.ListUsers(ctx, options)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidPermissionCode) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to list users")
+	}
+	resp := &ssov1.ListUsersResponse{Users: make([]*ssov1.User, len(users))}
+	for i := range users {
+		resp.Users[i] = userToProto(&users[i])
+	}
+	if len(users) == options.Limit {
+		resp.NextPageToken = encodeUsersCursor(&users[len(users)-1], options.SortBy)
+	}
+	return resp, nil
+}
+
+// listUsersOptions validates the request and converts it to the options, errors are gRPC statuses.
+func listUsersOptions(req *ssov1.ListUsersRequest) (dtos.ListUsersOptionsDTO, error) {
+	validationRules := map[string]string{
+		"Search":          "max=255",
+		"Role":            "max=64",
+		"CreatedAfter":    "gte=0",
+		"CreatedBefore":   "gte=0",
+		"AppId":           "gte=0",
+		"Permission":      "max=255",
+		"PermissionAppId": "gte=0",
+		"SortBy":          "omitempty,oneof=created_at updated_at",
+		"PageSize":        "gte=0,lte=1000",
+	}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return dtos.ListUsersOptionsDTO{}, status.Error(codes.InvalidArgument, errs)
+	}
+	options := dtos.ListUsersOptionsDTO{
+		Search:     strings.TrimSpace(req.GetSearch()),
+		Role:       req.GetRole(),
+		IsActive:   req.IsActive,
+		AppID:      req.GetAppId(),
+		SortBy:     dtos.UsersSortField(req.GetSortBy()),
+		Descending: req.GetDescending(),
+		Limit:      int(req.GetPageSize()),
+	}
+	if options.SortBy == "" {
+		options.SortBy = dtos.UsersSortByCreatedAt
+	}
+	if options.Limit == 0 {
+		options.Limit = defaultUsersPageSize
+	}
+	if req.GetCreatedAfter() != 0 {
+		createdAfter := time.Unix(req.GetCreatedAfter(), 0)
+		options.CreatedAfter = &createdAfter
+	}
+	if req.GetCreatedBefore() != 0 {
+		createdBefore := time.Unix(req.GetCreatedBefore(), 0)
+		options.CreatedBefore = &createdBefore
+	}
+	if req.GetPermission() != "" {
+		options.Permission = &dtos.UserPermissionFilterDTO{AppID: req.GetPermissionAppId(), Code: req.GetPermission()}
+	}
+	if req.GetPageToken() != "" {
+		cursor, err := decodeUsersCursor(req.GetPageToken(), options.SortBy)
+		if err != nil {
+			return dtos.ListUsersOptionsDTO{}, status.Error(codes.InvalidArgument, "invalid page token")
+		}
+		options.After = cursor
+	}
+	return options, nil
+}
+
+// Page tokens of ListUsers are bound to the sort field, they can't be reused with another one.
+func encodeUsersCursor(user *entity.User, sortBy dtos.UsersSortField) string {
+	sortValue := user.CreatedAt
+	if sortBy == dtos.UsersSortByUpdatedAt {
+		sortValue = user.UpdatedAt
+	}
+	token := fmt.Sprintf("%s:%d:%d", sortBy, sortValue.UnixNano(), user.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(token))
+}
+
+func decodeUsersCursor(pageToken string, sortBy dtos.UsersSortField) (*dtos.UsersCursorDTO, error) {
+	token, err := base64.RawURLEncoding.DecodeString(pageToken)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(string(token), ":")
+	if len(parts) != 3 || parts[0] != string(sortBy) {
+		return nil, errors.New("page token doesn't match sort field")
+	}
+	sortValue, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &dtos.UsersCursorDTO{SortValue: time.Unix(0, sortValue).UTC(), ID: userID}, nil
+}
+
+// ImportUsers creates users migrated from other systems along with their password hashes, whether it's a dry run
+// is taken from the first message. Rows are numbered from 1 across all messages of the stream,
+// invalid or conflicting rows (including ones duplicating previous rows) are reported in the response
+// without aborting the import.
+func (s *AuthServer) ImportUsers(stream ssov1.Auth_ImportUsersServer) error {
+	ctx := stream.Context()
+	admin, err := authn.RequireAdmin(ctx, s.service)
+	if err != nil {
+		return err
+	}
+	resp := &ssov1.ImportUsersResponse{}
+	batch := auth.NewImportBatch()
+	var row int64
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if row == 0 {
+			resp.DryRun = req.GetDryRun()
+		}
+		for _, user := range req.GetUsers() {
+			row++
+			record := importUserFromProto(user)
+			err := batch.Check(record)
+			if err == nil {
+				_, err = s.service.ImportUser(ctx, admin.ID, record, resp.DryRun)
+			}
+			if err != nil {
+				if !auth.IsImportRowError(err) {
+					return status.Errorf(codes.Internal, "failed to import user at row %d", row)
+				}
+				resp.Failed++
+				resp.Errors = append(resp.Errors, &ssov1.ImportUserError{Row: row, Message: err.Error()})
+				continue
+			}
+			batch.Add(row, record)
+			resp.Imported++
+		}
+	}
+	return stream.SendAndClose(resp)
+}
+
+func importUserFromProto(user *ssov1.ImportedUser) dtos.ImportUserDTO {
+	return dtos.ImportUserDTO{
+		Username:     user.GetUsername(),
+		Email:        user.GetEmail(),
+		Role:         user.GetRole(),
+		IsActive:     user.GetIsActive(),
+		AppID:        user.GetAppId(),
+		Permissions:  user.GetPermissions(),
+		PasswordHash: user.GetPasswordHash(),
+	}
+}
+
+// ExportUsers streams users matching the filter, which is the one of ListUsers with paging fields ignored,
+// optionally along with their permission grants, org roles and password hashes.
+func (s *AuthServer) ExportUsers(req *ssov1.ExportUsersRequest, stream ssov1.Auth_ExportUsersServer) error {
+	ctx := stream.Context()
+	admin, err := authn.RequireAdmin(ctx, s.service)
+	if err != nil {
+		return err
+	}
+	filter := req.GetFilter()
+	if filter == nil {
+		filter = &ssov1.ListUsersRequest{}
+	}
+	listOptions, err := listUsersOptions(filter)
+	if err != nil {
+		return err
+	}
+	options := dtos.ExportUsersOptionsDTO{
+		Filter:             listOptions,
+		WithPermissions:    req.GetIncludePermissions(),
+		WithOrgRoles:       req.GetIncludeOrgRoles(),
+		WithPasswordHashes: req.GetIncludePasswordHashes(),
+	}
+	var sendErr error
+	err = s.service.ExportUsers(ctx, admin.ID, options, func(user *entity.ExportedUser) error {
+		sendErr = stream.Send(&ssov1.ExportUsersResponse{User: exportedUserToProto(user)})
+		return sendErr
+	})
+	if err != nil {
+		switch {
+		case sendErr != nil:
+			return sendErr
+		case errors.Is(err, auth.ErrInvalidPermissionCode):
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		return status.Error(codes.Internal, "failed to export users")
+	}
+	return nil
+}
+
+func exportedUserToProto(user *entity.ExportedUser) *ssov1.ExportedUser {
+	exported := &ssov1.ExportedUser{
+		User:         userToProto(&user.User),
+		PasswordHash: user.PasswordHash,
+		Permissions:  make([]*ssov1.UserPermissionGrant, len(user.Grants)),
+		OrgRoles:     make([]*ssov1.OrgMember, len(user.OrgRoles)),
+	}
+	for i, grant := range user.Grants {
+		exported.Permissions[i] = &ssov1.UserPermissionGrant{
+			Permission: &ssov1.Permission{
+				Id:    grant.Permission.ID,
+				Code:  grant.Permission.Code,
+				AppId: grant.Permission.AppID,
+			},
+			OrgId:     grant.OrgID,
+			GrantedAt: grant.GrantedAt.Unix(),
+		}
+		if grant.ExpiresAt != nil {
+			exported.Permissions[i].ExpiresAt = grant.ExpiresAt.Unix()
+		}
+	}
+	for i, member := range user.OrgRoles {
+		exported.OrgRoles[i] = &ssov1.OrgMember{
+			OrgId:    member.OrgID,
+			UserId:   member.UserID,
+			Role:     member.Role,
+			JoinedAt: member.JoinedAt.Unix(),
+		}
+	}
+	return exported
+}
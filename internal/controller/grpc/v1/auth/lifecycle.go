@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/controller/grpc/v1/authn"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/auth"
+	"sso.service/internal/services/dtos"
+	"sso.service/pkg/validator"
+)
+
+// Layout of time.Time.String, the format of User.updated_at
+const userVersionLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+func userToProto(user *entity.User) *ssov1.User {
+	return &ssov1.User{
+		Id:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Role:      user.Role,
+		IsActive:  user.IsActive,
+		CreatedAt: user.CreatedAt.String(),
+		UpdatedAt: user.UpdatedAt.String(),
+	}
+}
+
+// parseUserVersion parses expected updated_at of the user, empty version skips the concurrent edits check.
+func parseUserVersion(version string) (*time.Time, error) {
+	if version == "" {
+		return nil, nil
+	}
+	updatedAt, err := time.Parse(userVersionLayout, version)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "expected_updated_at must be updated_at of the user as returned by the service")
+	}
+	return &updatedAt, nil
+}
+
+func userChangeErrorToStatus(err error, fallbackMsg string) error {
+	switch {
+	case errors.Is(err, auth.ErrUserNotFound) || errors.Is(err, auth.ErrRoleNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, auth.ErrUserAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, auth.ErrLastAdmin):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, auth.ErrEditConflict):
+		return status.Error(codes.Aborted, err.Error())
+	}
+	return status.Error(codes.Internal, fallbackMsg)
+}
+
+var userLifecycleValidationRules = map[string]string{"UserId": "required,gt=0"}
+
+func (s *AuthServer) UpdateUser(ctx context.Context, req *ssov1.UpdateUserRequest) (*ssov1.UpdateUserResponse, error) {
+	admin, err := authn.RequireAdmin(ctx, s.service)
+	if err != nil {
+		return nil, err
+	}
+	if errs := validator.Validate(req, userLifecycleValidationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	params := dtos.UpdateUserDTO{ID: req.GetUserId()}
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "update_mask must list at least one field")
+	}
+	fieldRules := map[string]string{}
+	for _, path := range paths {
+		switch path {
+		case "username":
+			username := req.GetUsername()
+			params.Username = &username
+			fieldRules["Username"] = "required,min=3,max=255"
+		case "email":
+			email := req.GetEmail()
+			params.Email = &email
+			fieldRules["Email"] = "required,email,max=100"
+		case "role":
+			role := req.GetRole()
+			params.Role = &role
+			fieldRules["Role"] = "required,max=64"
+		case "is_active":
+			isActive := req.GetIsActive()
+			params.IsActive = &isActive
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "update_mask: unknown field %q", path)
+		}
+	}
+	if errs := validator.Validate(req, fieldRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	if params.ExpectedUpdatedAt, err = parseUserVersion(req.GetExpectedUpdatedAt()); err != nil {
+		return nil, err
+	}
+	user, err := s.service.UpdateUser(ctx, admin.ID, params)
+	if err != nil {
+		return nil, userChangeErrorToStatus(err, "failed to update user")
+	}
+	return &ssov1.UpdateUserResponse{User: userToProto(user)}, nil
+}
+
+func (s *AuthServer) SetUserRole(ctx context.Context, req *ssov1.SetUserRoleRequest) (*ssov1.SetUserRoleResponse, error) {
+	admin, err := authn.RequireAdmin(ctx, s.service)
+	if err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{"UserId": "required,gt=0", "Role": "required,max=64"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	user, err := s.service.SetUserRole(ctx, admin.ID, req.GetUserId(), req.GetRole())
+	if err != nil {
+		return nil, userChangeErrorToStatus(err, "failed to set user role")
+	}
+	return &ssov1.SetUserRoleResponse{User: userToProto(user)}, nil
+}
+
+func (s *AuthServer) DeactivateUser(ctx context.Context, req *ssov1.DeactivateUserRequest) (*ssov1.DeactivateUserResponse, error) {
+	admin, err := authn.RequireAdmin(ctx, s.service)
+	if err != nil {
+		return nil, err
+	}
+	if errs := validator.Validate(req, userLifecycleValidationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	expectedUpdatedAt, err := parseUserVersion(req.GetExpectedUpdatedAt())
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.service.DeactivateUser(ctx, admin.ID, req.GetUserId(), expectedUpdatedAt)
+	if err != nil {
+		return nil, userChangeErrorToStatus(err, "failed to deactivate user")
+	}
+	return &ssov1.DeactivateUserResponse{User: userToProto(user)}, nil
+}
+
+func (s *AuthServer) ReactivateUser(ctx context.Context, req *ssov1.ReactivateUserRequest) (*ssov1.ReactivateUserResponse, error) {
+	admin, err := authn.RequireAdmin(ctx, s.service)
+	if err != nil {
+		return nil, err
+	}
+	if errs := validator.Validate(req, userLifecycleValidationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	expectedUpdatedAt, err := parseUserVersion(req.GetExpectedUpdatedAt())
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.service.ReactivateUser(ctx, admin.ID, req.GetUserId(), expectedUpdatedAt)
+	if err != nil {
+		return nil, userChangeErrorToStatus(err, "failed to reactivate user")
+	}
+	return &ssov1.ReactivateUserResponse{User: userToProto(user)}, nil
+}
+
+func (s *AuthServer) DeleteUser(ctx context.Context, req *ssov1.DeleteUserRequest) (*ssov1.DeleteUserResponse, error) {
+	admin, err := authn.RequireAdmin(ctx, s.service)
+	if err != nil {
+		return nil, err
+	}
+	if errs := validator.Validate(req, userLifecycleValidationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	expectedUpdatedAt, err := parseUserVersion(req.GetExpectedUpdatedAt())
+	if err != nil {
+		return nil, err
+	}
+	erasureAfter, err := s.service.DeleteUser(ctx, admin.ID, req.GetUserId(), expectedUpdatedAt)
+	if err != nil {
+		return nil, userChangeErrorToStatus(err, "failed to delete user")
+	}
+	return &ssov1.DeleteUserResponse{ErasureAfter: erasureAfter.Unix()}, nil
+}
+
+func (s *AuthServer) RestoreUser(ctx context.Context, req *ssov1.RestoreUserRequest) (*ssov1.RestoreUserResponse, error) {
+	admin, err := authn.RequireAdmin(ctx, s.service)
+	if err != nil {
+		return nil, err
+	}
+	if errs := validator.Validate(req, userLifecycleValidationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	user, err := s.service.RestoreUser(ctx, admin.ID, req.GetUserId())
+	if err != nil {
+		if errors.Is(err, auth.ErrDeletedUserNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to restore user")
+	}
+	return &ssov1.RestoreUserResponse{User: userToProto(user)}, nil
+}
+
+func (s *AuthServer) RequestAccountDeletion(ctx context.Context, req *ssov1.RequestAccountDeletionRequest) (*ssov1.RequestAccountDeletionResponse, error) {
+	caller, err := authn.Caller(ctx, s.service)
+	if err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{"Password": "required"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	erasureAfter, err := s.service.RequestAccountDeletion(ctx, caller.ID, req.GetPassword())
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			return nil, status.Error(codes.PermissionDenied, "password doesn't match")
+		}
+		return nil, userChangeErrorToStatus(err, "failed to delete account")
+	}
+	return &ssov1.RequestAccountDeletionResponse{ErasureAfter: erasureAfter.Unix()}, nil
+}
+
+func (s *AuthServer) GetErasureReport(ctx context.Context, req *ssov1.GetErasureReportRequest) (*ssov1.GetErasureReportResponse, error) {
+	if _, err := authn.RequireAdmin(ctx, s.service); err != nil {
+		return nil, err
+	}
+	if errs := validator.Validate(req, userLifecycleValidationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	report, err := s.service.GetErasureReport(ctx, req.GetUserId())
+	if err != nil {
+		if errors.Is(err, auth.ErrErasureReportNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to get erasure report")
+	}
+	details, err := structpb.NewStruct(report.Details)
+	if err != nil {
+		s.log.Error("Failed to convert erasure report details", "report_id", report.ID, "msg", err.Error())
+		return nil, status.Error(codes.Internal, "failed to get erasure report")
+	}
+	return &ssov1.GetErasureReportResponse{
+		Report: &ssov1.ErasureReport{
+			Id:        report.ID,
+			UserId:    report.UserID,
+			DeletedAt: report.DeletedAt.Unix(),
+			ErasedAt:  report.ErasedAt.Unix(),
+			Details:   details,
+		},
+	}, nil
+}
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/controller/grpc/v1/authn"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/auth"
+	"sso.service/internal/services/dtos"
+	"sso.service/pkg/validator"
+)
+
+// metadataToProto converts the metadata area, nil areas (like the admin one hidden from the caller) stay nil.
+func metadataToProto(metadata map[string]any) (*structpb.Struct, error) {
+	if metadata == nil {
+		return nil, nil
+	}
+	return structpb.NewStruct(metadata)
+}
+
+func metadataPatch(patch *structpb.Struct) map[string]any {
+	if patch == nil {
+		return nil
+	}
+	return patch.AsMap()
+}
+
+func metadataErrorToStatus(err error, fallbackMsg string) error {
+	switch {
+	case errors.Is(err, auth.ErrUserNotFound) || errors.Is(err, auth.ErrAppNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, auth.ErrInvalidMetadata) || errors.Is(err, auth.ErrInvalidMetadataSchema):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, fallbackMsg)
+}
+
+func (s *AuthServer) userMetadataResponse(userID int64, metadata *entity.UserMetadata, isAdmin bool) (*ssov1.UserMetadata, error) {
+	if !isAdmin {
+		metadata.Admin = nil
+	}
+	var converted [3]*structpb.Struct
+	for i, area := range []map[string]any{metadata.Public, metadata.App, metadata.Admin} {
+		var err error
+		if converted[i], err = metadataToProto(area); err != nil {
+			s.log.Error("Failed to convert user metadata", "user_id", userID, "msg", err.Error())
+			return nil, status.Error(codes.Internal, "failed to convert user metadata")
+		}
+	}
+	return &ssov1.UserMetadata{Public: converted[0], App: converted[1], Admin: converted[2]}, nil
+}
+
+// GetUserMetadata returns metadata of the user to the user itself or an admin, admin-only metadata to admins only.
+func (s *AuthServer) GetUserMetadata(ctx context.Context, req *ssov1.GetUserMetadataRequest) (*ssov1.GetUserMetadataResponse, error) {
+	caller, err := authn.Caller(ctx, s.service)
+	if err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{"UserId": "required,gt=0", "AppId": "omitempty,gt=0"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	isAdmin := caller.Role == entity.RoleAdmin
+	if caller.ID != req.GetUserId() && !isAdmin {
+		return nil, status.Error(codes.PermissionDenied, "only admins can see metadata of other users")
+	}
+	metadata, err := s.service.GetUserMetadata(ctx, req.GetUserId(), req.GetAppId())
+	if err != nil {
+		return nil, metadataErrorToStatus(err, "failed to get user metadata")
+	}
+	converted, err := s.userMetadataResponse(req.GetUserId(), metadata, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	return &ssov1.GetUserMetadataResponse{Metadata: converted}, nil
+}
+
+// PatchUserMetadata merges JSON merge patches into metadata areas of the user.
+// Users may patch their public metadata, app-private and admin-only metadata is patched by admins.
+func (s *AuthServer) PatchUserMetadata(ctx context.Context, req *ssov1.PatchUserMetadataRequest) (*ssov1.PatchUserMetadataResponse, error) {
+	caller, err := authn.Caller(ctx, s.service)
+	if err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{"UserId": "required,gt=0", "AppId": "omitempty,gt=0"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	params := dtos.PatchUserMetadataDTO{
+		UserID: req.GetUserId(),
+		AppID:  req.GetAppId(),
+		Public: metadataPatch(req.GetPublic()),
+		App:    metadataPatch(req.GetApp()),
+		Admin:  metadataPatch(req.GetAdmin()),
+	}
+	if params.Public == nil && params.App == nil && params.Admin == nil {
+		return nil, status.Error(codes.InvalidArgument, "at least one of public, app and admin patches must be provided")
+	}
+	if params.App != nil && params.AppID == 0 {
+		return nil, status.Error(codes.InvalidArgument, "app_id is required to patch app metadata")
+	}
+	isAdmin := caller.Role == entity.RoleAdmin
+	if !isAdmin && (caller.ID != params.UserID || params.App != nil || params.Admin != nil) {
+		return nil, status.Error(codes.PermissionDenied, "users can patch their own public metadata only")
+	}
+	metadata, err := s.service.PatchUserMetadata(ctx, caller.ID, params)
+	if err != nil {
+		return nil, metadataErrorToStatus(err, "failed to patch user metadata")
+	}
+	converted, err := s.userMetadataResponse(params.UserID, metadata, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	return &ssov1.PatchUserMetadataResponse{Metadata: converted}, nil
+}
+
+func (s *AuthServer) SetAppMetadataSchema(ctx context.Context, req *ssov1.SetAppMetadataSchemaRequest) (*ssov1.SetAppMetadataSchemaResponse, error) {
+	admin, err := authn.RequireAdmin(ctx, s.service)
+	if err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{"AppId": "required,gt=0", "Schema": "omitempty,json"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	if err := s.service.SetAppMetadataSchema(ctx, admin.ID, req.GetAppId(), req.GetSchema()); err != nil {
+		return nil, metadataErrorToStatus(err, "failed to set app metadata schema")
+	}
+	return &ssov1.SetAppMetadataSchemaResponse{}, nil
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/controller/grpc/v1/authn"
+	"sso.service/internal/services/auth"
+	"sso.service/pkg/validator"
+)
+
+// passwordPolicyErrorToStatus returns InvalidArgument listing violations of the password policy
+// as {"<field>": [{"code": ..., "message": ...}]} if err is auth.PasswordPolicyError, nil otherwise.
+func (s *AuthServer) passwordPolicyErrorToStatus(err error, field string) error {
+	var policyErr *auth.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return nil
+	}
+	errorMsg, err := json.Marshal(map[string]any{field: policyErr.Violations})
+	if err != nil {
+		s.log.Error("Failed to marshal error message", "error", err)
+		return status.Error(codes.Internal, "failed to check password")
+	}
+	return status.Error(codes.InvalidArgument, string(errorMsg))
+}
+
+// ChangePassword replaces the password of the caller. Its tokens are revoked, so it has to log in again.
+func (s *AuthServer) ChangePassword(ctx context.Context, req *ssov1.ChangePasswordRequest) (*ssov1.ChangePasswordResponse, error) {
+	caller, err := authn.Caller(ctx, s.service)
+	if err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{"CurrentPassword": "required", "NewPassword": "required"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	if err := s.service.ChangePassword(ctx, caller.ID, req.GetCurrentPassword(), req.GetNewPassword()); err != nil {
+		if policyStatus := s.passwordPolicyErrorToStatus(err, "new_password"); policyStatus != nil {
+			return nil, policyStatus
+		}
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			return nil, status.Error(codes.PermissionDenied, "password doesn't match")
+		}
+		return nil, userChangeErrorToStatus(err, "failed to change password")
+	}
+	return &ssov1.ChangePasswordResponse{}, nil
+}
+
+// NewPasswordResetToken issues a password reset token to an admin, who delivers it to the user.
+func (s *AuthServer) NewPasswordResetToken(ctx context.Context, req *ssov1.NewPasswordResetTokenRequest) (*ssov1.NewPasswordResetTokenResponse, error) {
+	admin, err := authn.RequireAdmin(ctx, s.service)
+	if err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{"Email": "required,email", "AppId": "required,gt=0"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	token, err := s.service.NewPasswordResetToken(ctx, admin.ID, req.GetEmail(), req.GetAppId())
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) || errors.Is(err, auth.ErrAppNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to create password reset token")
+	}
+	return &ssov1.NewPasswordResetTokenResponse{ResetToken: token}, nil
+}
+
+func (s *AuthServer) ResetPassword(ctx context.Context, req *ssov1.ResetPasswordRequest) (*ssov1.ResetPasswordResponse, error) {
+	validationRules := map[string]string{"ResetToken": "required", "AppId": "required,gt=0", "NewPassword": "required"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	if err := s.service.ResetPassword(ctx, req.GetResetToken(), req.GetAppId(), req.GetNewPassword()); err != nil {
+		if policyStatus := s.passwordPolicyErrorToStatus(err, "new_password"); policyStatus != nil {
+			return nil, policyStatus
+		}
+		switch {
+		case errors.Is(err, auth.ErrAppNotFound):
+			return nil, status.Error(codes.NotFound, err.Error())
+		case errors.Is(err, auth.ErrInvalidToken):
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		case errors.Is(err, auth.ErrAppIdsMismatch):
+			return nil, status.Error(codes.InvalidArgument, "Mismatch between app id provided in request and token")
+		}
+		return nil, status.Error(codes.Internal, "failed to reset password")
+	}
+	return &ssov1.ResetPasswordResponse{}, nil
+}
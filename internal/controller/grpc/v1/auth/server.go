@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"log/slog"
+	"time"
 
 	ssov1 "sso.service/api/proto/gen/v1"
 	"sso.service/internal/entity"
@@ -10,15 +11,38 @@ import (
 )
 
 type AuthService interface {
-	Login(ctx context.Context, username string, password string, appId int32) (*dtos.AuthTokens, error)
+	Login(ctx context.Context, username string, password string, appId int32, orgID int64) (*dtos.AuthTokens, error)
 	Register(ctx context.Context, username string, password string, email string, appId int32) (*dtos.UserIDAndToken, error)
 	IsAdmin(ctx context.Context, userID int64) (bool, error)
-	GetOrCreateApp(ctx context.Context, app *entity.App) (*dtos.GetOrCreateAppDTO, error)
+	GetOrCreateApp(ctx context.Context, actorID int64, app *entity.App) (*dtos.GetOrCreateAppDTO, error)
+	GetApp(ctx context.Context, appID int32) (*entity.App, error)
+	ListApps(ctx context.Context) ([]entity.App, error)
+	UpdateApp(ctx context.Context, actorID int64, params dtos.UpdateAppDTO) (*entity.App, error)
+	DeleteApp(ctx context.Context, actorID int64, appID int32) error
+	RotateAppSecret(ctx context.Context, actorID int64, appID int32, gracePeriod time.Duration) (*entity.App, error)
 	RenewAccessToken(ctx context.Context, refreshToken string, appId int32) (string, error)
 	GetUser(ctx context.Context, params dtos.GetUserOptionsDTO) (*entity.User, error)
 	ActivateUser(ctx context.Context, token string, appID int32) (*entity.User, error)
 	NewActivationToken(ctx context.Context, email string, appID int32) (string, error)
 	VerifyToken(ctx context.Context, appID int32, token string) error
+	AuthenticateAccessToken(ctx context.Context, token string) (*entity.User, error)
+	ListUsers(ctx context.Context, options dtos.ListUsersOptionsDTO) ([]entity.User, error)
+	ImportUser(ctx context.Context, actorID int64, record dtos.ImportUserDTO, dryRun bool) (int64, error)
+	ExportUsers(ctx context.Context, actorID int64, options dtos.ExportUsersOptionsDTO, fn func(*entity.ExportedUser) error) error
+	UpdateUser(ctx context.Context, actorID int64, params dtos.UpdateUserDTO) (*entity.User, error)
+	SetUserRole(ctx context.Context, actorID int64, userID int64, role entity.Role) (*entity.User, error)
+	DeactivateUser(ctx context.Context, actorID int64, userID int64, expectedUpdatedAt *time.Time) (*entity.User, error)
+	ReactivateUser(ctx context.Context, actorID int64, userID int64, expectedUpdatedAt *time.Time) (*entity.User, error)
+	DeleteUser(ctx context.Context, actorID int64, userID int64, expectedUpdatedAt *time.Time) (time.Time, error)
+	RequestAccountDeletion(ctx context.Context, userID int64, password string) (time.Time, error)
+	ChangePassword(ctx context.Context, userID int64, currentPassword string, newPassword string) error
+	NewPasswordResetToken(ctx context.Context, actorID int64, email string, appID int32) (string, error)
+	ResetPassword(ctx context.Context, token string, appID int32, newPassword string) error
+	RestoreUser(ctx context.Context, actorID int64, userID int64) (*entity.User, error)
+	GetErasureReport(ctx context.Context, userID int64) (*entity.ErasureReport, error)
+	GetUserMetadata(ctx context.Context, userID int64, appID int32) (*entity.UserMetadata, error)
+	PatchUserMetadata(ctx context.Context, actorID int64, params dtos.PatchUserMetadataDTO) (*entity.UserMetadata, error)
+	SetAppMetadataSchema(ctx context.Context, actorID int64, appID int32, schema string) error
 }
 
 type AuthServer struct {
@@ -21,6 +21,12 @@ func (s *AuthServer) RenewAccessToken(ctx context.Context, req *ssov1.RenewAcces
 	}
 	token, err := s.service.RenewAccessToken(ctx, req.GetRefreshToken(), req.GetAppId())
 	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrNotOrgMember) || errors.Is(err, auth.ErrOrgMismatch):
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		case errors.Is(err, auth.ErrInvalidToken):
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
 		return nil, status.Error(codes.Internal, "failed to refresh token")
 	}
 	return &ssov1.RenewAccessTokenResponse{
@@ -40,6 +46,8 @@ func (s *AuthServer) NewActivationToken(ctx context.Context, req *ssov1.NewActiv
 			return nil, status.Error(codes.NotFound, err.Error())
 		case errors.Is(err, auth.ErrUserAlreadyActivated):
 			return nil, status.Error(codes.AlreadyExists, err.Error())
+		case errors.Is(err, auth.ErrUserDeactivated):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
 		default:
 			return nil, status.Error(codes.Internal, "failed to create activation token")
 		}
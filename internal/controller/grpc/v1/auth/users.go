@@ -14,19 +14,24 @@ import (
 )
 
 func (s *AuthServer) Login(ctx context.Context, req *ssov1.LoginRequest) (*ssov1.LoginResponse, error) {
+	// passwords set before the password policy (imported ones included) may be shorter than it requires now
 	validationRules := map[string]string{
 		"Email":    "required,email",
-		"Password": "required,min=8",
+		"Password": "required",
 		"AppId":    "required,gt=0",
+		"OrgId":    "gte=0",
 	}
 	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
 		return nil, status.Error(codes.InvalidArgument, errs)
 	}
 
-	tokens, err := s.service.Login(ctx, req.GetEmail(), req.GetPassword(), req.GetAppId())
+	tokens, err := s.service.Login(ctx, req.GetEmail(), req.GetPassword(), req.GetAppId(), req.GetOrgId())
 	if err != nil {
-		if errors.Is(err, auth.ErrInvalidCredentials) {
+		switch {
+		case errors.Is(err, auth.ErrInvalidCredentials):
 			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+		case errors.Is(err, auth.ErrNotOrgMember) || errors.Is(err, auth.ErrOrgMismatch) || errors.Is(err, auth.ErrLoginMethodNotAllowed):
+			return nil, status.Error(codes.PermissionDenied, err.Error())
 		}
 		return nil, status.Error(codes.Internal, "failed to login")
 	}
@@ -59,9 +64,10 @@ func (s *AuthServer) Register(
 	ctx context.Context,
 	req *ssov1.RegisterRequest,
 ) (*ssov1.RegisterResponse, error) {
+	// strength of the password is checked against the password policy by the service
 	validationRules := map[string]string{
 		"Username": "required",
-		"Password": "required,min=8",
+		"Password": "required",
 		"Email":    "required,email",
 		"AppId":    "required,gt=0",
 	}
@@ -70,6 +76,9 @@ func (s *AuthServer) Register(
 	}
 	data, err := s.service.Register(ctx, req.GetUsername(), req.GetPassword(), req.GetEmail(), req.GetAppId())
 	if err != nil {
+		if policyStatus := s.passwordPolicyErrorToStatus(err, "password"); policyStatus != nil {
+			return nil, policyStatus
+		}
 		switch {
 		case errors.Is(err, auth.ErrUserAlreadyExists):
 			errorMsg, err := json.Marshal(map[string]string{"email": err.Error()})
@@ -80,6 +89,8 @@ func (s *AuthServer) Register(
 			return nil, status.Error(codes.AlreadyExists, string(errorMsg))
 		case errors.Is(err, auth.ErrAppNotFound):
 			return nil, status.Error(codes.NotFound, err.Error())
+		case errors.Is(err, auth.ErrRegistrationClosed):
+			return nil, status.Error(codes.PermissionDenied, err.Error())
 		default:
 			return nil, status.Error(codes.Internal, "failed to register")
 		}
@@ -105,6 +116,10 @@ func (s *AuthServer) ActivateUser(ctx context.Context, req *ssov1.ActivateUserRe
 			return nil, status.Error(codes.InvalidArgument, "Mismatch between app id provided in request and token")
 		case errors.Is(err, auth.ErrUserAlreadyActivated):
 			return nil, status.Error(codes.AlreadyExists, err.Error())
+		case errors.Is(err, auth.ErrUserDeactivated):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		case errors.Is(err, auth.ErrEditConflict):
+			return nil, status.Error(codes.Aborted, err.Error())
 		case errors.Is(err, auth.ErrInvalidToken):
 			return nil, status.Error(codes.InvalidArgument, err.Error())
 		default:
@@ -149,15 +164,21 @@ func (s *AuthServer) GetUser(ctx context.Context, req *ssov1.GetUserRequest) (*s
 		}
 		return nil, status.Error(codes.Internal, "failed to get user")
 	}
+	publicMetadata, err := metadataToProto(user.PublicMetadata)
+	if err != nil {
+		s.log.Error("Failed to convert user metadata", "user_id", user.ID, "msg", err.Error())
+		return nil, status.Error(codes.Internal, "failed to get user")
+	}
 	return &ssov1.GetUserResponse{
 		User: &ssov1.User{
-			Id:        user.ID,
-			Username:  user.Username,
-			Email:     user.Email,
-			Role:      user.Role,
-			IsActive:  user.IsActive,
-			CreatedAt: user.CreatedAt.String(),
-			UpdatedAt: user.UpdatedAt.String(),
+			Id:             user.ID,
+			Username:       user.Username,
+			Email:          user.Email,
+			Role:           user.Role,
+			IsActive:       user.IsActive,
+			CreatedAt:      user.CreatedAt.String(),
+			UpdatedAt:      user.UpdatedAt.String(),
+			PublicMetadata: publicMetadata,
 		},
 	}, nil
 }
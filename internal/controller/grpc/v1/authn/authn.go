@@ -0,0 +1,64 @@
+// Package authn identifies callers of RPCs by access tokens passed as "authorization: Bearer <token>" metadata.
+package authn
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/auth"
+)
+
+const (
+	authorizationHeader = "authorization"
+	bearerPrefix        = "bearer "
+)
+
+type Authenticator interface {
+	AuthenticateAccessToken(ctx context.Context, token string) (*entity.User, error)
+}
+
+func bearerToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	for _, value := range md.Get(authorizationHeader) {
+		if len(value) > len(bearerPrefix) && strings.EqualFold(value[:len(bearerPrefix)], bearerPrefix) {
+			return strings.TrimSpace(value[len(bearerPrefix):]), true
+		}
+	}
+	return "", false
+}
+
+// Caller returns the user calling the RPC, errors are gRPC statuses ready to be returned to the caller.
+func Caller(ctx context.Context, authenticator Authenticator) (*entity.User, error) {
+	token, ok := bearerToken(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "bearer access token is required")
+	}
+	user, err := authenticator.AuthenticateAccessToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to authenticate")
+	}
+	return user, nil
+}
+
+// RequireAdmin returns the user calling the RPC if it's an admin.
+func RequireAdmin(ctx context.Context, authenticator Authenticator) (*entity.User, error) {
+	user, err := Caller(ctx, authenticator)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != entity.RoleAdmin {
+		return nil, status.Error(codes.PermissionDenied, "only admins are allowed to do this")
+	}
+	return user, nil
+}
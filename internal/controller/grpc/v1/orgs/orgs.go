@@ -0,0 +1,182 @@
+package orgs
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/controller/grpc/v1/authn"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/orgs"
+	"sso.service/pkg/validator"
+)
+
+func orgToProto(org *entity.Org) *ssov1.Org {
+	return &ssov1.Org{Id: org.ID, Name: org.Name, CreatedAt: org.CreatedAt.Unix()}
+}
+
+func memberToProto(member *entity.OrgMember) *ssov1.OrgMember {
+	return &ssov1.OrgMember{
+		OrgId:    member.OrgID,
+		UserId:   member.UserID,
+		Role:     member.Role,
+		JoinedAt: member.JoinedAt.Unix(),
+	}
+}
+
+func orgErrorToStatus(err error, fallbackMsg string) error {
+	switch {
+	case errors.Is(err, orgs.ErrOrgNotFound) ||
+		errors.Is(err, orgs.ErrUserNotFound) ||
+		errors.Is(err, orgs.ErrRoleNotFound) ||
+		errors.Is(err, orgs.ErrMemberNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, orgs.ErrOrgAlreadyExists) || errors.Is(err, orgs.ErrAlreadyOrgMember):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, orgs.ErrOrgInUse):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, fallbackMsg)
+	}
+}
+
+// requireOrgAdmin returns the user calling the RPC if it's a global admin or an admin of the org.
+func (s *OrgsServer) requireOrgAdmin(ctx context.Context, orgID int64) (*entity.User, error) {
+	user, err := authn.Caller(ctx, s.authenticator)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == entity.RoleAdmin {
+		return user, nil
+	}
+	member, err := s.service.GetMember(ctx, orgID, user.ID)
+	if err != nil {
+		if errors.Is(err, orgs.ErrMemberNotFound) {
+			return nil, status.Error(codes.PermissionDenied, "only admins of the org are allowed to do this")
+		}
+		return nil, status.Error(codes.Internal, "failed to authorize")
+	}
+	if member.Role != entity.RoleAdmin {
+		return nil, status.Error(codes.PermissionDenied, "only admins of the org are allowed to do this")
+	}
+	return user, nil
+}
+
+func (s *OrgsServer) CreateOrg(ctx context.Context, req *ssov1.CreateOrgRequest) (*ssov1.CreateOrgResponse, error) {
+	if _, err := authn.RequireAdmin(ctx, s.authenticator); err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{"Name": "required,max=128"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	org, err := s.service.CreateOrg(ctx, &entity.Org{Name: req.GetName()})
+	if err != nil {
+		return nil, orgErrorToStatus(err, "failed to create org")
+	}
+	return &ssov1.CreateOrgResponse{Org: orgToProto(org)}, nil
+}
+
+func (s *OrgsServer) GetOrg(ctx context.Context, req *ssov1.GetOrgRequest) (*ssov1.GetOrgResponse, error) {
+	validationRules := map[string]string{"Id": "required,gt=0"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	org, err := s.service.GetOrg(ctx, req.GetId())
+	if err != nil {
+		return nil, orgErrorToStatus(err, "failed to get org")
+	}
+	return &ssov1.GetOrgResponse{Org: orgToProto(org)}, nil
+}
+
+func (s *OrgsServer) ListUserOrgs(ctx context.Context, req *ssov1.ListUserOrgsRequest) (*ssov1.ListUserOrgsResponse, error) {
+	validationRules := map[string]string{"UserId": "required,gt=0"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	userOrgs, err := s.service.ListUserOrgs(ctx, req.GetUserId())
+	if err != nil {
+		return nil, orgErrorToStatus(err, "failed to list orgs")
+	}
+	mappedOrgs := make([]*ssov1.Org, len(userOrgs))
+	for i := range userOrgs {
+		mappedOrgs[i] = orgToProto(&userOrgs[i])
+	}
+	return &ssov1.ListUserOrgsResponse{Orgs: mappedOrgs}, nil
+}
+
+func (s *OrgsServer) DeleteOrg(ctx context.Context, req *ssov1.DeleteOrgRequest) (*ssov1.DeleteOrgResponse, error) {
+	validationRules := map[string]string{"Id": "required,gt=0"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	if _, err := s.requireOrgAdmin(ctx, req.GetId()); err != nil {
+		return nil, err
+	}
+	if err := s.service.DeleteOrg(ctx, req.GetId()); err != nil {
+		return nil, orgErrorToStatus(err, "failed to delete org")
+	}
+	return &ssov1.DeleteOrgResponse{}, nil
+}
+
+func (s *OrgsServer) AddOrgMember(ctx context.Context, req *ssov1.AddOrgMemberRequest) (*ssov1.AddOrgMemberResponse, error) {
+	validationRules := map[string]string{"OrgId": "required,gt=0", "UserId": "required,gt=0", "Role": "max=64"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	if _, err := s.requireOrgAdmin(ctx, req.GetOrgId()); err != nil {
+		return nil, err
+	}
+	member, err := s.service.AddMember(ctx, req.GetOrgId(), req.GetUserId(), req.GetRole())
+	if err != nil {
+		return nil, orgErrorToStatus(err, "failed to add org member")
+	}
+	return &ssov1.AddOrgMemberResponse{Member: memberToProto(member)}, nil
+}
+
+func (s *OrgsServer) UpdateOrgMemberRole(ctx context.Context, req *ssov1.UpdateOrgMemberRoleRequest) (*ssov1.UpdateOrgMemberRoleResponse, error) {
+	validationRules := map[string]string{"OrgId": "required,gt=0", "UserId": "required,gt=0", "Role": "required,max=64"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	if _, err := s.requireOrgAdmin(ctx, req.GetOrgId()); err != nil {
+		return nil, err
+	}
+	member, err := s.service.UpdateMemberRole(ctx, req.GetOrgId(), req.GetUserId(), req.GetRole())
+	if err != nil {
+		return nil, orgErrorToStatus(err, "failed to update org member role")
+	}
+	return &ssov1.UpdateOrgMemberRoleResponse{Member: memberToProto(member)}, nil
+}
+
+func (s *OrgsServer) RemoveOrgMember(ctx context.Context, req *ssov1.RemoveOrgMemberRequest) (*ssov1.RemoveOrgMemberResponse, error) {
+	validationRules := map[string]string{"OrgId": "required,gt=0", "UserId": "required,gt=0"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	if _, err := s.requireOrgAdmin(ctx, req.GetOrgId()); err != nil {
+		return nil, err
+	}
+	if err := s.service.RemoveMember(ctx, req.GetOrgId(), req.GetUserId()); err != nil {
+		return nil, orgErrorToStatus(err, "failed to remove org member")
+	}
+	return &ssov1.RemoveOrgMemberResponse{}, nil
+}
+
+func (s *OrgsServer) ListOrgMembers(ctx context.Context, req *ssov1.ListOrgMembersRequest) (*ssov1.ListOrgMembersResponse, error) {
+	validationRules := map[string]string{"OrgId": "required,gt=0"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	members, err := s.service.ListMembers(ctx, req.GetOrgId())
+	if err != nil {
+		return nil, orgErrorToStatus(err, "failed to list org members")
+	}
+	mappedMembers := make([]*ssov1.OrgMember, len(members))
+	for i := range members {
+		mappedMembers[i] = memberToProto(&members[i])
+	}
+	return &ssov1.ListOrgMembersResponse{Members: mappedMembers}, nil
+}
@@ -0,0 +1,33 @@
+package orgs
+
+import (
+	"context"
+	"log/slog"
+
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/controller/grpc/v1/authn"
+	"sso.service/internal/entity"
+)
+
+type OrgsService interface {
+	CreateOrg(ctx context.Context, org *entity.Org) (*entity.Org, error)
+	GetOrg(ctx context.Context, orgID int64) (*entity.Org, error)
+	ListUserOrgs(ctx context.Context, userID int64) ([]entity.Org, error)
+	DeleteOrg(ctx context.Context, orgID int64) error
+	AddMember(ctx context.Context, orgID int64, userID int64, role string) (*entity.OrgMember, error)
+	GetMember(ctx context.Context, orgID int64, userID int64) (*entity.OrgMember, error)
+	UpdateMemberRole(ctx context.Context, orgID int64, userID int64, role string) (*entity.OrgMember, error)
+	RemoveMember(ctx context.Context, orgID int64, userID int64) error
+	ListMembers(ctx context.Context, orgID int64) ([]entity.OrgMember, error)
+}
+
+type OrgsServer struct {
+	ssov1.UnimplementedOrgsServer
+	service       OrgsService
+	authenticator authn.Authenticator
+	log           *slog.Logger
+}
+
+func New(service OrgsService, authenticator authn.Authenticator, log *slog.Logger) *OrgsServer {
+	return &OrgsServer{service: service, authenticator: authenticator, log: log}
+}
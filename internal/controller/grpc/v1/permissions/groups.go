@@ -0,0 +1,232 @@
+package permissions
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/controller/grpc/v1/authn"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/permissions"
+	"sso.service/pkg/validator"
+)
+
+func groupToProto(group *entity.Group) *ssov1.Group {
+	return &ssov1.Group{
+		Id:          group.ID,
+		OrgId:       group.OrgID,
+		Name:        group.Name,
+		Description: group.Description,
+		CreatedAt:   group.CreatedAt.Unix(),
+	}
+}
+
+func groupMembersToProto(members *entity.GroupMembers) *ssov1.GroupMembers {
+	return &ssov1.GroupMembers{UserIds: members.UserIDs, GroupIds: members.GroupIDs}
+}
+
+func permissionsToProto(perms []entity.Permission) []*ssov1.Permission {
+	mappedPermissions := make([]*ssov1.Permission, len(perms))
+	for i, perm := range perms {
+		mappedPermissions[i] = &ssov1.Permission{
+			Id:    perm.ID,
+			Code:  perm.Code,
+			AppId: perm.AppID,
+		}
+	}
+	return mappedPermissions
+}
+
+func groupErrorToStatus(err error, fallbackMsg string) error {
+	switch {
+	case errors.Is(err, permissions.ErrGroupNotFound) ||
+		errors.Is(err, permissions.ErrGroupMemberNotFound) ||
+		errors.Is(err, permissions.ErrAppNotFound) ||
+		errors.Is(err, permissions.ErrOrgNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, permissions.ErrGroupAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, permissions.ErrGroupCycle) || errors.Is(err, permissions.ErrGroupOrgMismatch):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, permissions.ErrInvalidPermissionCode):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, fallbackMsg)
+	}
+}
+
+func (s *PermissionsServer) CreateGroup(ctx context.Context, req *ssov1.CreateGroupRequest) (*ssov1.CreateGroupResponse, error) {
+	if _, err := authn.RequireAdmin(ctx, s.authenticator); err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{"Name": "required,max=64", "Description": "max=300", "OrgId": "gte=0"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	group, err := s.service.CreateGroup(ctx, &entity.Group{OrgID: req.GetOrgId(), Name: req.GetName(), Description: req.GetDescription()})
+	if err != nil {
+		return nil, groupErrorToStatus(err, "failed to create group")
+	}
+	return &ssov1.CreateGroupResponse{Group: groupToProto(group)}, nil
+}
+
+func (s *PermissionsServer) GetGroup(ctx context.Context, req *ssov1.GetGroupRequest) (*ssov1.GetGroupResponse, error) {
+	validationRules := map[string]string{"Id": "required,gt=0"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	group, err := s.service.GetGroup(ctx, req.GetId())
+	if err != nil {
+		return nil, groupErrorToStatus(err, "failed to get group")
+	}
+	return &ssov1.GetGroupResponse{Group: groupToProto(group)}, nil
+}
+
+func (s *PermissionsServer) DeleteGroup(ctx context.Context, req *ssov1.DeleteGroupRequest) (*ssov1.DeleteGroupResponse, error) {
+	if _, err := authn.RequireAdmin(ctx, s.authenticator); err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{"Id": "required,gt=0"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	if err := s.service.DeleteGroup(ctx, req.GetId()); err != nil {
+		return nil, groupErrorToStatus(err, "failed to delete group")
+	}
+	return &ssov1.DeleteGroupResponse{}, nil
+}
+
+func (s *PermissionsServer) AddGroupMembers(ctx context.Context, req *ssov1.AddGroupMembersRequest) (*ssov1.AddGroupMembersResponse, error) {
+	if _, err := authn.RequireAdmin(ctx, s.authenticator); err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{
+		"GroupId":  "required,gt=0",
+		"UserIds":  "max=100,dive,gt=0",
+		"GroupIds": "max=100,dive,gt=0",
+	}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	if len(req.GetUserIds()) == 0 && len(req.GetGroupIds()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "either user_ids or group_ids must be provided")
+	}
+	members, err := s.service.AddGroupMembers(ctx, req.GetGroupId(), entity.GroupMembers{
+		UserIDs:  req.GetUserIds(),
+		GroupIDs: req.GetGroupIds(),
+	})
+	if err != nil {
+		return nil, groupErrorToStatus(err, "failed to add group members")
+	}
+	return &ssov1.AddGroupMembersResponse{Members: groupMembersToProto(members)}, nil
+}
+
+func (s *PermissionsServer) RemoveGroupMembers(ctx context.Context, req *ssov1.RemoveGroupMembersRequest) (*ssov1.RemoveGroupMembersResponse, error) {
+	if _, err := authn.RequireAdmin(ctx, s.authenticator); err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{
+		"GroupId":  "required,gt=0",
+		"UserIds":  "max=100,dive,gt=0",
+		"GroupIds": "max=100,dive,gt=0",
+	}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	if len(req.GetUserIds()) == 0 && len(req.GetGroupIds()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "either user_ids or group_ids must be provided")
+	}
+	members, err := s.service.RemoveGroupMembers(ctx, req.GetGroupId(), entity.GroupMembers{
+		UserIDs:  req.GetUserIds(),
+		GroupIDs: req.GetGroupIds(),
+	})
+	if err != nil {
+		return nil, groupErrorToStatus(err, "failed to remove group members")
+	}
+	return &ssov1.RemoveGroupMembersResponse{Members: groupMembersToProto(members)}, nil
+}
+
+func (s *PermissionsServer) ListGroupMembers(ctx context.Context, req *ssov1.ListGroupMembersRequest) (*ssov1.ListGroupMembersResponse, error) {
+	validationRules := map[string]string{"GroupId": "required,gt=0"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	members, err := s.service.ListGroupMembers(ctx, req.GetGroupId())
+	if err != nil {
+		return nil, groupErrorToStatus(err, "failed to list group members")
+	}
+	return &ssov1.ListGroupMembersResponse{Members: groupMembersToProto(members)}, nil
+}
+
+func (s *PermissionsServer) GrantGroupPermissions(ctx context.Context, req *ssov1.GrantGroupPermissionsRequest) (*ssov1.GrantGroupPermissionsResponse, error) {
+	if _, err := authn.RequireAdmin(ctx, s.authenticator); err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{
+		"GroupId":         "required,gt=0",
+		"AppId":           "gte=0",
+		"PermissionCodes": "required,min=1,dive,permpattern",
+	}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	perms, err := s.service.GrantGroupPermissions(ctx, req.GetGroupId(), req.GetAppId(), req.GetPermissionCodes())
+	if err != nil {
+		return nil, groupErrorToStatus(err, "failed to grant group permissions")
+	}
+	return &ssov1.GrantGroupPermissionsResponse{Permissions: permissionsToProto(perms)}, nil
+}
+
+func (s *PermissionsServer) RevokeGroupPermissions(ctx context.Context, req *ssov1.RevokeGroupPermissionsRequest) (*ssov1.RevokeGroupPermissionsResponse, error) {
+	if _, err := authn.RequireAdmin(ctx, s.authenticator); err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{
+		"GroupId":         "required,gt=0",
+		"AppId":           "gte=0",
+		"PermissionCodes": "required,min=1,dive,permpattern",
+	}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	perms, err := s.service.RevokeGroupPermissions(ctx, req.GetGroupId(), req.GetAppId(), req.GetPermissionCodes())
+	if err != nil {
+		return nil, groupErrorToStatus(err, "failed to revoke group permissions")
+	}
+	return &ssov1.RevokeGroupPermissionsResponse{Permissions: permissionsToProto(perms)}, nil
+}
+
+func (s *PermissionsServer) ListUserPermissions(ctx context.Context, req *ssov1.ListUserPermissionsRequest) (*ssov1.ListUserPermissionsResponse, error) {
+	validationRules := map[string]string{"UserId": "required,gt=0", "AppId": "gte=0"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	perms, err := s.service.ListUserPermissions(ctx, req.GetUserId(), req.GetAppId())
+	if err != nil {
+		if errors.Is(err, permissions.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to list user permissions")
+	}
+	mappedPermissions := make([]*ssov1.EffectivePermission, len(perms))
+	for i, perm := range perms {
+		mapped := &ssov1.EffectivePermission{
+			Permission: &ssov1.Permission{
+				Id:    perm.Permission.ID,
+				Code:  perm.Permission.Code,
+				AppId: perm.Permission.AppID,
+			},
+			OrgId:      perm.OrgID,
+			Source:     perm.Source,
+			SourceId:   perm.SourceID,
+			SourceName: perm.SourceName,
+		}
+		if perm.ExpiresAt != nil {
+			mapped.ExpiresAt = perm.ExpiresAt.Unix()
+		}
+		mappedPermissions[i] = mapped
+	}
+	return &ssov1.ListUserPermissionsResponse{Permissions: mappedPermissions}, nil
+}
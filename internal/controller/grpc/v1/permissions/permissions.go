@@ -3,52 +3,160 @@ package permissions
 import (
 	"context"
 	"errors"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/controller/grpc/v1/authn"
+	"sso.service/internal/services/dtos"
 	"sso.service/internal/services/permissions"
 	"sso.service/pkg/validator"
 )
 
 func (s *PermissionsServer) CheckPermission(ctx context.Context, req *ssov1.CheckPermissionRequest) (*ssov1.CheckPermissionResponse, error) {
-	validationRules := map[string]string{"PermissionCode": "required,min=6,max=32", "UserId": "required,gt=0"}
+	validationRules := map[string]string{"PermissionCode": "required,permcode", "UserId": "required,gt=0", "AppId": "gte=0"}
 	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
 		return nil, status.Error(codes.InvalidArgument, errs)
 	}
-	hasPermission, err := s.service.CheckPermission(ctx, req.GetUserId(), req.GetPermissionCode())
+	hasPermission, err := s.service.CheckPermission(ctx, req.GetUserId(), req.GetAppId(), req.GetPermissionCode())
 	if err != nil {
 		switch {
 		case errors.Is(err, permissions.ErrPermissionNotFound) || errors.Is(err, permissions.ErrUserNotFound):
 			return nil, status.Error(codes.NotFound, err.Error())
+		case errors.Is(err, permissions.ErrInvalidPermissionCode):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
 		return nil, status.Error(codes.Internal, "failed to check permission")
 	}
 	return &ssov1.CheckPermissionResponse{HasPermission: hasPermission}, nil
 }
 
+func (s *PermissionsServer) CheckPermissions(ctx context.Context, req *ssov1.CheckPermissionsRequest) (*ssov1.CheckPermissionsResponse, error) {
+	validationRules := map[string]string{
+		"UserId":          "required,gt=0",
+		"AppId":           "gte=0",
+		"PermissionCodes": "required,min=1,max=100,dive,permcode",
+	}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	results, err := s.service.CheckPermissions(ctx, req.GetUserId(), req.GetAppId(), req.GetPermissionCodes())
+	if err != nil {
+		switch {
+		case errors.Is(err, permissions.ErrUserNotFound):
+			return nil, status.Error(codes.NotFound, err.Error())
+		case errors.Is(err, permissions.ErrInvalidPermissionCode):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to check permissions")
+	}
+	return &ssov1.CheckPermissionsResponse{Results: results}, nil
+}
+
+func (s *PermissionsServer) CheckPermissionsForUsers(ctx context.Context, req *ssov1.CheckPermissionsForUsersRequest) (*ssov1.CheckPermissionsForUsersResponse, error) {
+	validationRules := map[string]string{
+		"UserIds":        "required,min=1,max=100,dive,gt=0",
+		"AppId":          "gte=0",
+		"PermissionCode": "required,permcode",
+	}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	results, err := s.service.CheckPermissionsForUsers(ctx, req.GetUserIds(), req.GetAppId(), req.GetPermissionCode())
+	if err != nil {
+		if errors.Is(err, permissions.ErrInvalidPermissionCode) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to check permissions")
+	}
+	mappedResults := make([]*ssov1.UserPermissionCheck, len(results))
+	for i, result := range results {
+		mappedResults[i] = &ssov1.UserPermissionCheck{
+			UserId:        result.UserID,
+			UserFound:     result.UserFound,
+			HasPermission: result.HasPermission,
+		}
+	}
+	return &ssov1.CheckPermissionsForUsersResponse{Results: mappedResults}, nil
+}
+
 func (s *PermissionsServer) GrantPermissions(ctx context.Context, req *ssov1.GrantPermissionsRequest) (*ssov1.GrantPermissionsResponse, error) {
-	// TODO: only admin user can grant permissions to others
-	validationRules := map[string]string{"UserId": "required,gt=0", "PermissionCodes": "required"}
+	if _, err := authn.RequireAdmin(ctx, s.authenticator); err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{
+		"UserId":          "required,gt=0",
+		"AppId":           "gte=0",
+		"OrgId":           "gte=0",
+		"PermissionCodes": "dive,permpattern",
+	}
 	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
 		return nil, status.Error(codes.InvalidArgument, errs)
 	}
-	if len(req.GetPermissionCodes()) == 0 {
+	if len(req.GetPermissionCodes()) == 0 && len(req.GetGrants()) == 0 {
 		return nil, status.Error(codes.InvalidArgument, "Permissions codes can't be empty")
 	}
-	grantedPermissions, err := s.service.GrantPermissions(ctx, req.GetUserId(), req.GetPermissionCodes())
+	grants := make([]dtos.PermissionGrantDTO, 0, len(req.GetPermissionCodes())+len(req.GetGrants()))
+	for _, code := range req.GetPermissionCodes() {
+		grants = append(grants, dtos.PermissionGrantDTO{Code: code})
+	}
+	now := time.Now()
+	for _, grant := range req.GetGrants() {
+		grantRules := map[string]string{
+			"Code":       "required,permpattern",
+			"ExpiresAt":  "omitempty,gt=0",
+			"TtlSeconds": "omitempty,gt=0",
+		}
+		if errs := validator.Validate(grant, grantRules); errs != validator.EmptyErrors {
+			return nil, status.Error(codes.InvalidArgument, errs)
+		}
+		if grant.GetExpiresAt() != 0 && grant.GetTtlSeconds() != 0 {
+			return nil, status.Error(codes.InvalidArgument, "either expires_at or ttl_seconds can be provided, not both")
+		}
+		dto := dtos.PermissionGrantDTO{Code: grant.GetCode()}
+		switch {
+		case grant.GetExpiresAt() != 0:
+			expiresAt := time.Unix(grant.GetExpiresAt(), 0)
+			dto.ExpiresAt = &expiresAt
+		case grant.GetTtlSeconds() != 0:
+			expiresAt := now.Add(time.Duration(grant.GetTtlSeconds()) * time.Second)
+			dto.ExpiresAt = &expiresAt
+		}
+		grants = append(grants, dto)
+	}
+	grantedPermissions, err := s.service.GrantPermissions(ctx, req.GetUserId(), req.GetAppId(), req.GetOrgId(), grants)
 	if err != nil {
-		if errors.Is(err, permissions.ErrUserNotFound) {
+		switch {
+		case errors.Is(err, permissions.ErrUserNotFound) || errors.Is(err, permissions.ErrAppNotFound):
 			return nil, status.Error(codes.NotFound, err.Error())
+		case errors.Is(err, permissions.ErrInvalidPermissionCode) || errors.Is(err, permissions.ErrInvalidGrantExpiry):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, permissions.ErrNotOrgMember):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
 		}
 		return nil, status.Error(codes.Internal, "failed to grant permission")
 	}
 	mappedPermissions := make([]*ssov1.Permission, len(grantedPermissions))
 	for i, perm := range grantedPermissions {
 		mappedPermissions[i] = &ssov1.Permission{
-			Id:   perm.ID,
-			Code: perm.Code,
+			Id:    perm.ID,
+			Code:  perm.Code,
+			AppId: perm.AppID,
 		}
 	}
 	return &ssov1.GrantPermissionsResponse{GrantedPermissions: mappedPermissions}, nil
 }
+
+func (s *PermissionsServer) GetCacheStats(ctx context.Context, _ *ssov1.GetCacheStatsRequest) (*ssov1.GetCacheStatsResponse, error) {
+	if _, err := authn.RequireAdmin(ctx, s.authenticator); err != nil {
+		return nil, err
+	}
+	stats := s.service.CacheStats()
+	return &ssov1.GetCacheStatsResponse{
+		Hits:      stats.Hits,
+		Misses:    stats.Misses,
+		Evictions: stats.Evictions,
+		Size:      int64(stats.Size),
+	}, nil
+}
@@ -0,0 +1,232 @@
+package permissions
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/controller/grpc/v1/authn"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+	"sso.service/internal/services/permissions"
+	"sso.service/pkg/validator"
+)
+
+var policyValidationRules = map[string]string{
+	"AppId":       "gte=0",
+	"OrgId":       "gte=0",
+	"Name":        "required,max=100",
+	"Description": "max=300",
+	"Effect":      "required,oneof=allow deny",
+	"Actions":     "required,min=1,max=50,dive,permpattern",
+	"Condition":   "max=4096",
+}
+
+var authorizeValidationRules = map[string]string{
+	"SubjectId": "required,gt=0",
+	"AppId":     "gte=0",
+	"Action":    "required,permcode",
+}
+
+func policyToProto(policy *entity.Policy) *ssov1.Policy {
+	if policy == nil {
+		return nil
+	}
+	return &ssov1.Policy{
+		Id:          policy.ID,
+		AppId:       policy.AppID,
+		OrgId:       policy.OrgID,
+		Name:        policy.Name,
+		Description: policy.Description,
+		Effect:      policy.Effect,
+		Actions:     policy.Actions,
+		Condition:   policy.Condition,
+		Priority:    policy.Priority,
+		IsEnabled:   policy.IsEnabled,
+		CreatedAt:   policy.CreatedAt.Unix(),
+		UpdatedAt:   policy.UpdatedAt.Unix(),
+	}
+}
+
+func policyFromProto(policy *ssov1.Policy) entity.Policy {
+	return entity.Policy{
+		ID:          policy.GetId(),
+		AppID:       policy.GetAppId(),
+		OrgID:       policy.GetOrgId(),
+		Name:        policy.GetName(),
+		Description: policy.GetDescription(),
+		Effect:      policy.GetEffect(),
+		Actions:     policy.GetActions(),
+		Condition:   policy.GetCondition(),
+		Priority:    policy.GetPriority(),
+		IsEnabled:   policy.GetIsEnabled(),
+	}
+}
+
+func authorizeRequestToDTO(req *ssov1.AuthorizeRequest) dtos.AuthorizeDTO {
+	return dtos.AuthorizeDTO{
+		SubjectID:          req.GetSubjectId(),
+		AppID:              req.GetAppId(),
+		Action:             req.GetAction(),
+		ResourceType:       req.GetResource().GetType(),
+		ResourceID:         req.GetResource().GetId(),
+		ResourceAttributes: req.GetResource().GetAttributes().AsMap(),
+		SubjectAttributes:  req.GetSubjectAttributes().AsMap(),
+		Environment:        req.GetEnvironment().AsMap(),
+	}
+}
+
+func policyErrorToStatus(err error, fallbackMsg string) error {
+	switch {
+	case errors.Is(err, permissions.ErrPolicyNotFound) || errors.Is(err, permissions.ErrAppNotFound) ||
+		errors.Is(err, permissions.ErrUserNotFound) || errors.Is(err, permissions.ErrOrgNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, permissions.ErrPolicyAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, permissions.ErrInvalidPolicy) || errors.Is(err, permissions.ErrInvalidPermissionCode):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, fallbackMsg)
+	}
+}
+
+func (s *PermissionsServer) CreatePolicy(ctx context.Context, req *ssov1.CreatePolicyRequest) (*ssov1.CreatePolicyResponse, error) {
+	if _, err := authn.RequireAdmin(ctx, s.authenticator); err != nil {
+		return nil, err
+	}
+	if errs := validator.Validate(req, policyValidationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	policy, err := s.service.CreatePolicy(ctx, &entity.Policy{
+		AppID:       req.GetAppId(),
+		OrgID:       req.GetOrgId(),
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+		Effect:      req.GetEffect(),
+		Actions:     req.GetActions(),
+		Condition:   req.GetCondition(),
+		Priority:    req.GetPriority(),
+		IsEnabled:   !req.GetDisabled(),
+	})
+	if err != nil {
+		return nil, policyErrorToStatus(err, "failed to create policy")
+	}
+	return &ssov1.CreatePolicyResponse{Policy: policyToProto(policy)}, nil
+}
+
+func (s *PermissionsServer) GetPolicy(ctx context.Context, req *ssov1.GetPolicyRequest) (*ssov1.GetPolicyResponse, error) {
+	validationRules := map[string]string{"Id": "required,gt=0"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	policy, err := s.service.GetPolicy(ctx, req.GetId())
+	if err != nil {
+		return nil, policyErrorToStatus(err, "failed to get policy")
+	}
+	return &ssov1.GetPolicyResponse{Policy: policyToProto(policy)}, nil
+}
+
+func (s *PermissionsServer) ListPolicies(ctx context.Context, req *ssov1.ListPoliciesRequest) (*ssov1.ListPoliciesResponse, error) {
+	validationRules := map[string]string{"AppId": "gte=0", "OrgId": "gte=0"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	policies, err := s.service.ListPolicies(ctx, req.GetAppId(), req.GetOrgId())
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to list policies")
+	}
+	mappedPolicies := make([]*ssov1.Policy, len(policies))
+	for i := range policies {
+		mappedPolicies[i] = policyToProto(&policies[i])
+	}
+	return &ssov1.ListPoliciesResponse{Policies: mappedPolicies}, nil
+}
+
+func (s *PermissionsServer) UpdatePolicy(ctx context.Context, req *ssov1.UpdatePolicyRequest) (*ssov1.UpdatePolicyResponse, error) {
+	if _, err := authn.RequireAdmin(ctx, s.authenticator); err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{"Id": "required,gt=0"}
+	for field, rule := range policyValidationRules {
+		if field != "AppId" && field != "OrgId" {
+			validationRules[field] = rule
+		}
+	}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	policy, err := s.service.UpdatePolicy(ctx, &entity.Policy{
+		ID:          req.GetId(),
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+		Effect:      req.GetEffect(),
+		Actions:     req.GetActions(),
+		Condition:   req.GetCondition(),
+		Priority:    req.GetPriority(),
+		IsEnabled:   req.GetIsEnabled(),
+	})
+	if err != nil {
+		return nil, policyErrorToStatus(err, "failed to update policy")
+	}
+	return &ssov1.UpdatePolicyResponse{Policy: policyToProto(policy)}, nil
+}
+
+func (s *PermissionsServer) DeletePolicy(ctx context.Context, req *ssov1.DeletePolicyRequest) (*ssov1.DeletePolicyResponse, error) {
+	if _, err := authn.RequireAdmin(ctx, s.authenticator); err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{"Id": "required,gt=0"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	if err := s.service.DeletePolicy(ctx, req.GetId()); err != nil {
+		return nil, policyErrorToStatus(err, "failed to delete policy")
+	}
+	return &ssov1.DeletePolicyResponse{}, nil
+}
+
+func (s *PermissionsServer) Authorize(ctx context.Context, req *ssov1.AuthorizeRequest) (*ssov1.AuthorizeResponse, error) {
+	if errs := validator.Validate(req, authorizeValidationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	decision, err := s.service.Authorize(ctx, authorizeRequestToDTO(req))
+	if err != nil {
+		return nil, policyErrorToStatus(err, "failed to authorize")
+	}
+	return &ssov1.AuthorizeResponse{Allowed: decision.Allowed, Policy: policyToProto(decision.Policy)}, nil
+}
+
+func (s *PermissionsServer) DryRunAuthorize(ctx context.Context, req *ssov1.DryRunAuthorizeRequest) (*ssov1.DryRunAuthorizeResponse, error) {
+	if req.GetRequest() == nil {
+		return nil, status.Error(codes.InvalidArgument, "request is required")
+	}
+	if errs := validator.Validate(req.GetRequest(), authorizeValidationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	drafts := make([]entity.Policy, len(req.GetDrafts()))
+	for i, draft := range req.GetDrafts() {
+		if errs := validator.Validate(draft, policyValidationRules); errs != validator.EmptyErrors {
+			return nil, status.Error(codes.InvalidArgument, errs)
+		}
+		drafts[i] = policyFromProto(draft)
+	}
+	decision, err := s.service.DryRunAuthorize(ctx, authorizeRequestToDTO(req.GetRequest()), drafts, req.GetOnlyDrafts())
+	if err != nil {
+		return nil, policyErrorToStatus(err, "failed to evaluate policies")
+	}
+	evaluations := make([]*ssov1.PolicyEvaluation, len(decision.Evaluations))
+	for i := range decision.Evaluations {
+		evaluations[i] = &ssov1.PolicyEvaluation{
+			Policy:  policyToProto(&decision.Evaluations[i].Policy),
+			Matched: decision.Evaluations[i].Matched,
+			Error:   decision.Evaluations[i].Error,
+		}
+	}
+	return &ssov1.DryRunAuthorizeResponse{
+		Allowed:     decision.Allowed,
+		Policy:      policyToProto(decision.Policy),
+		Evaluations: evaluations,
+	}, nil
+}
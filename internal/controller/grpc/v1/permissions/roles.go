@@ -0,0 +1,157 @@
+package permissions
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/controller/grpc/v1/authn"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+	"sso.service/internal/services/permissions"
+	"sso.service/pkg/validator"
+)
+
+func roleToProto(role *entity.RoleDetails) *ssov1.Role {
+	mappedPermissions := make([]*ssov1.Permission, len(role.Permissions))
+	for i, perm := range role.Permissions {
+		mappedPermissions[i] = &ssov1.Permission{
+			Id:    perm.ID,
+			Code:  perm.Code,
+			AppId: perm.AppID,
+		}
+	}
+	return &ssov1.Role{
+		Id:          role.ID,
+		OrgId:       role.OrgID,
+		Name:        role.Name,
+		Description: role.Description,
+		IsBuiltin:   role.IsBuiltin,
+		Permissions: mappedPermissions,
+	}
+}
+
+func roleErrorToStatus(err error, fallbackMsg string) error {
+	switch {
+	case errors.Is(err, permissions.ErrRoleNotFound) || errors.Is(err, permissions.ErrAppNotFound) ||
+		errors.Is(err, permissions.ErrOrgNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, permissions.ErrRoleAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, permissions.ErrRoleInUse) || errors.Is(err, permissions.ErrBuiltinRole):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, permissions.ErrInvalidPermissionCode):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, fallbackMsg)
+	}
+}
+
+func (s *PermissionsServer) CreateRole(ctx context.Context, req *ssov1.CreateRoleRequest) (*ssov1.CreateRoleResponse, error) {
+	if _, err := authn.RequireAdmin(ctx, s.authenticator); err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{
+		"Name":            "required,max=64",
+		"Description":     "max=300",
+		"OrgId":           "gte=0",
+		"PermissionCodes": "dive,permpattern",
+	}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	role, err := s.service.CreateRole(ctx, &entity.RoleDetails{
+		OrgID:       req.GetOrgId(),
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+	}, req.GetPermissionCodes())
+	if err != nil {
+		return nil, roleErrorToStatus(err, "failed to create role")
+	}
+	return &ssov1.CreateRoleResponse{Role: roleToProto(role)}, nil
+}
+
+func (s *PermissionsServer) GetRole(ctx context.Context, req *ssov1.GetRoleRequest) (*ssov1.GetRoleResponse, error) {
+	validationRules := map[string]string{"Id": "omitempty,gt=0", "Name": "omitempty,max=64"}
+	if req.GetId() == 0 && req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "either id or name must be provided")
+	}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	role, err := s.service.GetRole(ctx, dtos.GetRoleOptionsDTO{ID: req.GetId(), Name: req.GetName()})
+	if err != nil {
+		return nil, roleErrorToStatus(err, "failed to get role")
+	}
+	return &ssov1.GetRoleResponse{Role: roleToProto(role)}, nil
+}
+
+func (s *PermissionsServer) ListRoles(ctx context.Context, req *ssov1.ListRolesRequest) (*ssov1.ListRolesResponse, error) {
+	validationRules := map[string]string{"OrgId": "gte=0"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	roles, err := s.service.ListRoles(ctx, req.GetOrgId())
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to list roles")
+	}
+	mappedRoles := make([]*ssov1.Role, len(roles))
+	for i := range roles {
+		mappedRoles[i] = roleToProto(&roles[i])
+	}
+	return &ssov1.ListRolesResponse{Roles: mappedRoles}, nil
+}
+
+func (s *PermissionsServer) UpdateRole(ctx context.Context, req *ssov1.UpdateRoleRequest) (*ssov1.UpdateRoleResponse, error) {
+	if _, err := authn.RequireAdmin(ctx, s.authenticator); err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{
+		"Id":          "required,gt=0",
+		"Name":        "omitempty,min=1,max=64",
+		"Description": "omitempty,max=300",
+	}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	role, err := s.service.UpdateRole(ctx, dtos.UpdateRoleDTO{
+		ID:          req.GetId(),
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		return nil, roleErrorToStatus(err, "failed to update role")
+	}
+	return &ssov1.UpdateRoleResponse{Role: roleToProto(role)}, nil
+}
+
+func (s *PermissionsServer) DeleteRole(ctx context.Context, req *ssov1.DeleteRoleRequest) (*ssov1.DeleteRoleResponse, error) {
+	if _, err := authn.RequireAdmin(ctx, s.authenticator); err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{"Id": "required,gt=0"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	if err := s.service.DeleteRole(ctx, req.GetId()); err != nil {
+		return nil, roleErrorToStatus(err, "failed to delete role")
+	}
+	return &ssov1.DeleteRoleResponse{}, nil
+}
+
+func (s *PermissionsServer) SetRolePermissions(ctx context.Context, req *ssov1.SetRolePermissionsRequest) (*ssov1.SetRolePermissionsResponse, error) {
+	if _, err := authn.RequireAdmin(ctx, s.authenticator); err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{"RoleId": "required,gt=0", "AppId": "gte=0", "PermissionCodes": "dive,permpattern"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	role, err := s.service.SetRolePermissions(ctx, req.GetRoleId(), req.GetAppId(), req.GetPermissionCodes())
+	if err != nil {
+		return nil, roleErrorToStatus(err, "failed to set role permissions")
+	}
+	return &ssov1.SetRolePermissionsResponse{Role: roleToProto(role)}, nil
+}
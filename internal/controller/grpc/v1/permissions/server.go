@@ -5,20 +5,49 @@ import (
 	"log/slog"
 
 	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/controller/grpc/v1/authn"
 	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+	"sso.service/pkg/ttlcache"
 )
 
 type PermissionsService interface {
-	CheckPermission(ctx context.Context, userID int64, permission string) (bool, error)
-	GrantPermissions(ctx context.Context, userID int64, permissionCodes []string) ([]entity.Permission, error)
+	CheckPermission(ctx context.Context, userID int64, appID int32, permission string) (bool, error)
+	CheckPermissions(ctx context.Context, userID int64, appID int32, permCodes []string) (map[string]bool, error)
+	CheckPermissionsForUsers(ctx context.Context, userIDs []int64, appID int32, permCode string) ([]dtos.UserPermissionCheckDTO, error)
+	GrantPermissions(ctx context.Context, userID int64, appID int32, orgID int64, grants []dtos.PermissionGrantDTO) ([]entity.Permission, error)
+	CreateRole(ctx context.Context, role *entity.RoleDetails, permissionCodes []string) (*entity.RoleDetails, error)
+	GetRole(ctx context.Context, params dtos.GetRoleOptionsDTO) (*entity.RoleDetails, error)
+	ListRoles(ctx context.Context, orgID int64) ([]entity.RoleDetails, error)
+	UpdateRole(ctx context.Context, params dtos.UpdateRoleDTO) (*entity.RoleDetails, error)
+	DeleteRole(ctx context.Context, roleID int64) error
+	SetRolePermissions(ctx context.Context, roleID int64, appID int32, permissionCodes []string) (*entity.RoleDetails, error)
+	CreatePolicy(ctx context.Context, policy *entity.Policy) (*entity.Policy, error)
+	GetPolicy(ctx context.Context, policyID int64) (*entity.Policy, error)
+	ListPolicies(ctx context.Context, appID int32, orgID int64) ([]entity.Policy, error)
+	UpdatePolicy(ctx context.Context, policy *entity.Policy) (*entity.Policy, error)
+	DeletePolicy(ctx context.Context, policyID int64) error
+	Authorize(ctx context.Context, params dtos.AuthorizeDTO) (*dtos.AuthorizationDecisionDTO, error)
+	DryRunAuthorize(ctx context.Context, params dtos.AuthorizeDTO, drafts []entity.Policy, onlyDrafts bool) (*dtos.AuthorizationDecisionDTO, error)
+	CreateGroup(ctx context.Context, group *entity.Group) (*entity.Group, error)
+	GetGroup(ctx context.Context, groupID int64) (*entity.Group, error)
+	DeleteGroup(ctx context.Context, groupID int64) error
+	AddGroupMembers(ctx context.Context, groupID int64, members entity.GroupMembers) (*entity.GroupMembers, error)
+	RemoveGroupMembers(ctx context.Context, groupID int64, members entity.GroupMembers) (*entity.GroupMembers, error)
+	ListGroupMembers(ctx context.Context, groupID int64) (*entity.GroupMembers, error)
+	GrantGroupPermissions(ctx context.Context, groupID int64, appID int32, permissionCodes []string) ([]entity.Permission, error)
+	RevokeGroupPermissions(ctx context.Context, groupID int64, appID int32, permissionCodes []string) ([]entity.Permission, error)
+	ListUserPermissions(ctx context.Context, userID int64, appID int32) ([]entity.EffectivePermission, error)
+	CacheStats() ttlcache.Stats
 }
 
 type PermissionsServer struct {
 	ssov1.UnimplementedPermissionsServer
-	service PermissionsService
-	log     *slog.Logger
+	service       PermissionsService
+	authenticator authn.Authenticator
+	log           *slog.Logger
 }
 
-func New(service PermissionsService, log *slog.Logger) *PermissionsServer {
-	return &PermissionsServer{service: service, log: log}
+func New(service PermissionsService, authenticator authn.Authenticator, log *slog.Logger) *PermissionsServer {
+	return &PermissionsServer{service: service, authenticator: authenticator, log: log}
 }
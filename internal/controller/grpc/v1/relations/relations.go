@@ -0,0 +1,226 @@
+package relations
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/controller/grpc/v1/authn"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+	"sso.service/internal/services/relations"
+	"sso.service/pkg/validator"
+)
+
+const (
+	defaultPageSize   = 100
+	maxTuplesPerWrite = 100
+)
+
+var usersetValidationRules = map[string]string{
+	"Namespace": "required,max=64",
+	"ObjectId":  "required,max=128",
+	"Relation":  "required,max=64",
+}
+
+func relationErrorToStatus(err error, fallbackMsg string) error {
+	switch {
+	case errors.Is(err, relations.ErrUnknownNamespace) || errors.Is(err, relations.ErrUnknownRelation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, relations.ErrUserNotFound) || errors.Is(err, relations.ErrOrgNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, relations.ErrDepthExceeded):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, fallbackMsg)
+	}
+}
+
+func tupleFromProto(tuple *ssov1.RelationTuple) (entity.RelationTuple, error) {
+	rules := map[string]string{"OrgId": "gte=0"}
+	for field, rule := range usersetValidationRules {
+		rules[field] = rule
+	}
+	if errs := validator.Validate(tuple, rules); errs != validator.EmptyErrors {
+		return entity.RelationTuple{}, status.Error(codes.InvalidArgument, errs)
+	}
+	mapped := entity.RelationTuple{
+		OrgID:     tuple.GetOrgId(),
+		Namespace: tuple.GetNamespace(),
+		ObjectID:  tuple.GetObjectId(),
+		Relation:  tuple.GetRelation(),
+	}
+	switch {
+	case tuple.GetSubjectSet() != nil && tuple.GetSubjectUserId() != 0:
+		return mapped, status.Error(codes.InvalidArgument, "subject must be either user or userset")
+	case tuple.GetSubjectSet() != nil:
+		rules := map[string]string{"Namespace": "required,max=64", "ObjectId": "required,max=128", "Relation": "max=64"}
+		if errs := validator.Validate(tuple.GetSubjectSet(), rules); errs != validator.EmptyErrors {
+			return mapped, status.Error(codes.InvalidArgument, errs)
+		}
+		mapped.SubjectSet = &entity.Userset{
+			Namespace: tuple.GetSubjectSet().GetNamespace(),
+			ObjectID:  tuple.GetSubjectSet().GetObjectId(),
+			Relation:  tuple.GetSubjectSet().GetRelation(),
+		}
+	case tuple.GetSubjectUserId() > 0:
+		mapped.SubjectUserID = tuple.GetSubjectUserId()
+	default:
+		return mapped, status.Error(codes.InvalidArgument, "subject is required")
+	}
+	return mapped, nil
+}
+
+func tupleToProto(tuple entity.RelationTuple) *ssov1.RelationTuple {
+	mapped := &ssov1.RelationTuple{
+		OrgId:         tuple.OrgID,
+		Namespace:     tuple.Namespace,
+		ObjectId:      tuple.ObjectID,
+		Relation:      tuple.Relation,
+		SubjectUserId: tuple.SubjectUserID,
+	}
+	if tuple.SubjectSet != nil {
+		mapped.SubjectSet = usersetToProto(*tuple.SubjectSet)
+	}
+	return mapped
+}
+
+func usersetToProto(userset entity.Userset) *ssov1.Userset {
+	return &ssov1.Userset{Namespace: userset.Namespace, ObjectId: userset.ObjectID, Relation: userset.Relation}
+}
+
+func usersetTreeToProto(tree *entity.UsersetTree) *ssov1.UsersetTree {
+	children := make([]*ssov1.UsersetTree, len(tree.Children))
+	for i := range tree.Children {
+		children[i] = usersetTreeToProto(&tree.Children[i])
+	}
+	return &ssov1.UsersetTree{
+		Userset:  usersetToProto(tree.Userset),
+		UserIds:  tree.UserIDs,
+		Children: children,
+	}
+}
+
+func (s *RelationsServer) Check(ctx context.Context, req *ssov1.CheckRequest) (*ssov1.CheckResponse, error) {
+	validationRules := map[string]string{"UserId": "required,gt=0", "OrgId": "gte=0"}
+	for field, rule := range usersetValidationRules {
+		validationRules[field] = rule
+	}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	allowed, err := s.service.Check(ctx, req.GetOrgId(), entity.Userset{
+		Namespace: req.GetNamespace(),
+		ObjectID:  req.GetObjectId(),
+		Relation:  req.GetRelation(),
+	}, req.GetUserId())
+	if err != nil {
+		return nil, relationErrorToStatus(err, "failed to check relation")
+	}
+	return &ssov1.CheckResponse{Allowed: allowed}, nil
+}
+
+func (s *RelationsServer) WriteTuples(ctx context.Context, req *ssov1.WriteTuplesRequest) (*ssov1.WriteTuplesResponse, error) {
+	if _, err := authn.RequireAdmin(ctx, s.authenticator); err != nil {
+		return nil, err
+	}
+	if len(req.GetWrites())+len(req.GetDeletes()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "nothing to write")
+	}
+	if len(req.GetWrites())+len(req.GetDeletes()) > maxTuplesPerWrite {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d tuples may be written at once", maxTuplesPerWrite)
+	}
+	inserts := make([]entity.RelationTuple, len(req.GetWrites()))
+	for i, tuple := range req.GetWrites() {
+		mapped, err := tupleFromProto(tuple)
+		if err != nil {
+			return nil, err
+		}
+		inserts[i] = mapped
+	}
+	deletes := make([]entity.RelationTuple, len(req.GetDeletes()))
+	for i, tuple := range req.GetDeletes() {
+		mapped, err := tupleFromProto(tuple)
+		if err != nil {
+			return nil, err
+		}
+		deletes[i] = mapped
+	}
+	if err := s.service.WriteTuples(ctx, inserts, deletes); err != nil {
+		return nil, relationErrorToStatus(err, "failed to write tuples")
+	}
+	return &ssov1.WriteTuplesResponse{}, nil
+}
+
+func (s *RelationsServer) ReadTuples(ctx context.Context, req *ssov1.ReadTuplesRequest) (*ssov1.ReadTuplesResponse, error) {
+	validationRules := map[string]string{
+		"Namespace":     "required,max=64",
+		"ObjectId":      "max=128",
+		"Relation":      "max=64",
+		"SubjectUserId": "gte=0",
+		"OrgId":         "gte=0",
+		"PageSize":      "gte=0,lte=1000",
+	}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	filter := dtos.RelationTuplesFilterDTO{
+		OrgID:         req.GetOrgId(),
+		Namespace:     req.GetNamespace(),
+		ObjectID:      req.GetObjectId(),
+		Relation:      req.GetRelation(),
+		SubjectUserID: req.GetSubjectUserId(),
+		Limit:         int(req.GetPageSize()),
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageSize
+	}
+	if req.GetSubjectSet() != nil {
+		filter.SubjectSet = &dtos.UsersetFilterDTO{
+			Namespace: req.GetSubjectSet().GetNamespace(),
+			ObjectID:  req.GetSubjectSet().GetObjectId(),
+			Relation:  req.GetSubjectSet().GetRelation(),
+		}
+	}
+	if req.GetPageToken() != "" {
+		afterID, err := strconv.ParseInt(req.GetPageToken(), 10, 64)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid page token")
+		}
+		filter.AfterID = afterID
+	}
+	tuples, err := s.service.ReadTuples(ctx, filter)
+	if err != nil {
+		return nil, relationErrorToStatus(err, "failed to read tuples")
+	}
+	resp := &ssov1.ReadTuplesResponse{Tuples: make([]*ssov1.RelationTuple, len(tuples))}
+	for i, tuple := range tuples {
+		resp.Tuples[i] = tupleToProto(tuple)
+	}
+	if len(tuples) == filter.Limit {
+		resp.NextPageToken = strconv.FormatInt(tuples[len(tuples)-1].ID, 10)
+	}
+	return resp, nil
+}
+
+func (s *RelationsServer) Expand(ctx context.Context, req *ssov1.ExpandRequest) (*ssov1.ExpandResponse, error) {
+	validationRules := map[string]string{"OrgId": "gte=0"}
+	for field, rule := range usersetValidationRules {
+		validationRules[field] = rule
+	}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	tree, err := s.service.Expand(ctx, req.GetOrgId(), entity.Userset{
+		Namespace: req.GetNamespace(),
+		ObjectID:  req.GetObjectId(),
+		Relation:  req.GetRelation(),
+	})
+	if err != nil {
+		return nil, relationErrorToStatus(err, "failed to expand userset")
+	}
+	return &ssov1.ExpandResponse{Tree: usersetTreeToProto(tree)}, nil
+}
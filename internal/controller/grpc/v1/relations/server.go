@@ -0,0 +1,29 @@
+package relations
+
+import (
+	"context"
+	"log/slog"
+
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/controller/grpc/v1/authn"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+)
+
+type RelationsService interface {
+	Check(ctx context.Context, orgID int64, object entity.Userset, userID int64) (bool, error)
+	WriteTuples(ctx context.Context, inserts []entity.RelationTuple, deletes []entity.RelationTuple) error
+	ReadTuples(ctx context.Context, filter dtos.RelationTuplesFilterDTO) ([]entity.RelationTuple, error)
+	Expand(ctx context.Context, orgID int64, object entity.Userset) (*entity.UsersetTree, error)
+}
+
+type RelationsServer struct {
+	ssov1.UnimplementedRelationsServer
+	service       RelationsService
+	authenticator authn.Authenticator
+	log           *slog.Logger
+}
+
+func New(service RelationsService, authenticator authn.Authenticator, log *slog.Logger) *RelationsServer {
+	return &RelationsServer{service: service, authenticator: authenticator, log: log}
+}
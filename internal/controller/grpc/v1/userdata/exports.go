@@ -0,0 +1,76 @@
+package userdata
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/controller/grpc/v1/authn"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/userdata"
+	"sso.service/pkg/validator"
+)
+
+func exportToProto(export *entity.DataExport) *ssov1.DataExport {
+	mapped := &ssov1.DataExport{
+		Id:        export.ID,
+		UserId:    export.UserID,
+		Status:    string(export.Status),
+		Error:     export.Error,
+		Archive:   export.Archive,
+		CreatedAt: export.CreatedAt.Unix(),
+	}
+	if export.CompletedAt != nil {
+		mapped.CompletedAt = export.CompletedAt.Unix()
+	}
+	return mapped
+}
+
+// ExportUserData schedules an export of the user's data, users may export their own data only.
+// The archive is available through GetDataExport once the export is completed.
+func (s *UserDataServer) ExportUserData(ctx context.Context, req *ssov1.ExportUserDataRequest) (*ssov1.ExportUserDataResponse, error) {
+	caller, err := authn.Caller(ctx, s.authenticator)
+	if err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{"UserId": "required,gt=0"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	if caller.ID != req.GetUserId() && caller.Role != entity.RoleAdmin {
+		return nil, status.Error(codes.PermissionDenied, "only admins can export data of other users")
+	}
+	export, err := s.service.RequestExport(ctx, caller.ID, req.GetUserId())
+	if err != nil {
+		if errors.Is(err, userdata.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to request data export")
+	}
+	return &ssov1.ExportUserDataResponse{Export: exportToProto(export)}, nil
+}
+
+func (s *UserDataServer) GetDataExport(ctx context.Context, req *ssov1.GetDataExportRequest) (*ssov1.GetDataExportResponse, error) {
+	caller, err := authn.Caller(ctx, s.authenticator)
+	if err != nil {
+		return nil, err
+	}
+	validationRules := map[string]string{"Id": "required,gt=0"}
+	if errs := validator.Validate(req, validationRules); errs != validator.EmptyErrors {
+		return nil, status.Error(codes.InvalidArgument, errs)
+	}
+	export, err := s.service.GetExport(ctx, req.GetId())
+	if err != nil {
+		if errors.Is(err, userdata.ErrExportNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to get data export")
+	}
+	// exports of others are reported as missing, not to reveal their existence
+	if caller.ID != export.UserID && caller.ID != export.RequestedBy && caller.Role != entity.RoleAdmin {
+		return nil, status.Error(codes.NotFound, userdata.ErrExportNotFound.Error())
+	}
+	return &ssov1.GetDataExportResponse{Export: exportToProto(export)}, nil
+}
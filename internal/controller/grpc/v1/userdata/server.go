@@ -0,0 +1,26 @@
+package userdata
+
+import (
+	"context"
+	"log/slog"
+
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/controller/grpc/v1/authn"
+	"sso.service/internal/entity"
+)
+
+type UserDataService interface {
+	RequestExport(ctx context.Context, actorID int64, userID int64) (*entity.DataExport, error)
+	GetExport(ctx context.Context, exportID int64) (*entity.DataExport, error)
+}
+
+type UserDataServer struct {
+	ssov1.UnimplementedUserDataServer
+	service       UserDataService
+	authenticator authn.Authenticator
+	log           *slog.Logger
+}
+
+func New(service UserDataService, authenticator authn.Authenticator, log *slog.Logger) *UserDataServer {
+	return &UserDataServer{service: service, authenticator: authenticator, log: log}
+}
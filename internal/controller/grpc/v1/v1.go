@@ -4,17 +4,33 @@ import (
 	"log/slog"
 
 	"sso.service/internal/controller/grpc/v1/auth"
+	"sso.service/internal/controller/grpc/v1/orgs"
 	"sso.service/internal/controller/grpc/v1/permissions"
+	"sso.service/internal/controller/grpc/v1/relations"
+	"sso.service/internal/controller/grpc/v1/userdata"
 )
 
 type GRPCServers struct {
 	AuthServer        *auth.AuthServer
 	PermissionsServer *permissions.PermissionsServer
+	RelationsServer   *relations.RelationsServer
+	OrgsServer        *orgs.OrgsServer
+	UserDataServer    *userdata.UserDataServer
 }
 
-func New(authService auth.AuthService, permissionsService permissions.PermissionsService, log *slog.Logger) *GRPCServers {
+func New(
+	authService auth.AuthService,
+	permissionsService permissions.PermissionsService,
+	relationsService relations.RelationsService,
+	orgsService orgs.OrgsService,
+	userDataService userdata.UserDataService,
+	log *slog.Logger,
+) *GRPCServers {
 	return &GRPCServers{
 		AuthServer:        auth.New(authService, log),
-		PermissionsServer: permissions.New(permissionsService, log),
+		PermissionsServer: permissions.New(permissionsService, authService, log),
+		RelationsServer:   relations.New(relationsService, authService, log),
+		OrgsServer:        orgs.New(orgsService, authService, log),
+		UserDataServer:    userdata.New(userDataService, authService, log),
 	}
 }
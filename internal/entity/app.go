@@ -1,8 +1,55 @@
 package entity
 
+import (
+	"slices"
+	"time"
+)
+
 type App struct {
 	ID          int64  `db:"id"`
 	Name        string `db:"name"`
 	Description string `db:"description"`
 	Secret      string `db:"secret"`
+	// Whether role and permissions of the user are embedded in access tokens issued for the app
+	EmbedPermissions bool `db:"embed_permissions"`
+	// Org owning the app, NoOrgID for apps shared by all of the orgs
+	OrgID int64 `db:"org_id"`
+	// JSON Schema app-private metadata of users must conform to, empty if there is none
+	MetadataSchema string `db:"metadata_schema"`
+	// Secret replaced by rotation, still accepted when verifying tokens until it expires.
+	// Empty if there is none or it has expired.
+	PreviousSecret          string     `db:"previous_secret"`
+	PreviousSecretExpiresAt *time.Time `db:"previous_secret_expires_at"`
+	AppSettings
+}
+
+const LoginMethodPassword = "password"
+
+// LoginMethods lists methods users can log in with
+var LoginMethods = []string{LoginMethodPassword}
+
+// AppSettings overrides global auth settings for the app, nil fields fall back to the config.
+type AppSettings struct {
+	AccessTokenTTL     *time.Duration `db:"access_token_ttl"`
+	RefreshTokenTTL    *time.Duration `db:"refresh_token_ttl"`
+	ActivationTokenTTL *time.Duration `db:"activation_token_ttl"`
+	// Methods users may log in to the app with, nil allows all of them
+	LoginMethods []string `db:"login_methods"`
+	// Whether users may register through the app themselves
+	SelfRegistration *bool `db:"self_registration"`
+	// Whether users registered through the app have to activate their accounts before logging in
+	RequireActivation *bool `db:"require_activation"`
+}
+
+// AllowsLoginMethod reports whether users may log in to the app with the method.
+func (s *AppSettings) AllowsLoginMethod(method string) bool {
+	return s.LoginMethods == nil || slices.Contains(s.LoginMethods, method)
+}
+
+// VerificationSecrets returns secrets tokens of the app may be signed with other than the current one.
+func (a *App) VerificationSecrets() []string {
+	if a.PreviousSecret == "" {
+		return nil
+	}
+	return []string{a.PreviousSecret}
 }
@@ -0,0 +1,39 @@
+package entity
+
+import "time"
+
+type AuditEventType = string
+
+const (
+	AuditPermissionGrantExpired AuditEventType = "permission_grant.expired"
+	AuditUserUpdated            AuditEventType = "user.updated"
+	AuditUserDeactivated        AuditEventType = "user.deactivated"
+	AuditUserReactivated        AuditEventType = "user.reactivated"
+	AuditUserDeleted            AuditEventType = "user.deleted"
+	AuditUserDeletionRequested  AuditEventType = "user.deletion_requested"
+	AuditUserRestored           AuditEventType = "user.restored"
+	AuditUserErased             AuditEventType = "user.erased"
+	AuditDataExportRequested    AuditEventType = "user.data_export_requested"
+	AuditUserMetadataUpdated    AuditEventType = "user.metadata_updated"
+	AuditUserPasswordChanged    AuditEventType = "user.password_changed"
+	AuditPasswordResetIssued    AuditEventType = "user.password_reset_issued"
+	AuditUserPasswordReset      AuditEventType = "user.password_reset"
+	AuditUserImported           AuditEventType = "user.imported"
+	AuditUsersExported          AuditEventType = "users.exported"
+	AuditAppMetadataSchemaSet   AuditEventType = "app.metadata_schema_set"
+	AuditAppCreated             AuditEventType = "app.created"
+	AuditAppUpdated             AuditEventType = "app.updated"
+	AuditAppDeleted             AuditEventType = "app.deleted"
+	AuditAppSecretRotated       AuditEventType = "app.secret_rotated"
+)
+
+// AuditEvent records a security relevant change. UserID is the affected user,
+// ActorID is the user who made the change or nil if it was made by the system.
+type AuditEvent struct {
+	ID        int64          `db:"id" json:"id"`
+	Type      AuditEventType `db:"type" json:"type"`
+	ActorID   *int64         `db:"actor_id" json:"actor_id"`
+	UserID    *int64         `db:"user_id" json:"user_id"`
+	Payload   map[string]any `db:"payload" json:"payload"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// Group grants its permissions to its members. Permissions of a group of an org are effective in the org only,
+// groups of entity.NoOrgID are shared by all of the orgs.
+type Group struct {
+	ID          int64     `db:"id"`
+	OrgID       int64     `db:"org_id"`
+	Name        string    `db:"name"`
+	Description string    `db:"description"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// GroupMembers are direct members of a group, members of nested groups are not included.
+type GroupMembers struct {
+	UserIDs  []int64
+	GroupIDs []int64
+}
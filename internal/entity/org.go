@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+// NoOrgID stands for the absence of org: apps shared by all of the orgs, grants effective in every org
+// and tokens issued without an active org.
+const NoOrgID int64 = 0
+
+// Org is a tenant of the deployment. Apps, permission grants, roles, groups, policies and relation tuples
+// belong either to an org or (with NoOrgID) to all of the orgs, users join orgs as members.
+type Org struct {
+	ID        int64     `db:"id"`
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// OrgMember is a membership of the user in the org. Role applies within the org only,
+// in addition to the global role of the user.
+type OrgMember struct {
+	OrgID    int64     `db:"org_id" json:"org_id"`
+	UserID   int64     `db:"user_id" json:"user_id"`
+	Role     string    `db:"role" json:"role"`
+	JoinedAt time.Time `db:"joined_at" json:"joined_at"`
+}
@@ -1,8 +1,14 @@
 package entity
 
+import "time"
+
+// GlobalAppID is the app id of permissions shared by all apps.
+const GlobalAppID int32 = 0
+
 type Permission struct {
-	ID   int64
-	Code string
+	ID    int64  `json:"id"`
+	Code  string `json:"code"`
+	AppID int32  `db:"app_id" json:"app_id"` // GlobalAppID for cross-app permissions
 }
 
 type Permissions []*Permission
@@ -16,3 +22,34 @@ func (self Permissions) Includes(code string) (includes bool) {
 	}
 	return
 }
+
+// PermissionGrant is a permission granted directly to the user.
+// Grants with nil ExpiresAt never expire, grants with NoOrgID are effective in every org.
+type PermissionGrant struct {
+	UserID     int64      `json:"user_id"`
+	OrgID      int64      `json:"org_id"`
+	Permission Permission `json:"permission"`
+	GrantedAt  time.Time  `json:"granted_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+type PermissionSource = string
+
+const (
+	PermissionSourceDirect PermissionSource = "direct"
+	PermissionSourceRole   PermissionSource = "role"
+	PermissionSourceGroup  PermissionSource = "group"
+)
+
+// EffectivePermission is a permission of the user along with the way it was obtained:
+// granted directly, through the role or through one of (possibly nested) groups of the user.
+// SourceID and SourceName identify the role or the group, ExpiresAt is set for temporary direct grants only.
+// OrgID is the org the grant (or the role membership) is limited to, NoOrgID if it's effective in every org.
+type EffectivePermission struct {
+	Permission Permission
+	OrgID      int64
+	Source     PermissionSource
+	SourceID   int64
+	SourceName string
+	ExpiresAt  *time.Time
+}
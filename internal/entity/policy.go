@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+type PolicyEffect = string
+
+const (
+	PolicyAllow PolicyEffect = "allow"
+	PolicyDeny  PolicyEffect = "deny"
+)
+
+// Policy allows or denies actions when its condition holds.
+// Condition is a policyexpr expression over subject, resource, action and env variables,
+// empty condition always holds. Actions are permission code patterns.
+// Policies of an org apply to apps of the org only, policies of entity.NoOrgID apply in every org.
+type Policy struct {
+	ID          int64        `db:"id"`
+	OrgID       int64        `db:"org_id"`
+	AppID       int32        `db:"app_id"`
+	Name        string       `db:"name"`
+	Description string       `db:"description"`
+	Effect      PolicyEffect `db:"effect"`
+	Actions     []string     `db:"actions"`
+	Condition   string       `db:"condition"`
+	Priority    int32        `db:"priority"`
+	IsEnabled   bool         `db:"is_enabled"`
+	CreatedAt   time.Time    `db:"created_at"`
+	UpdatedAt   time.Time    `db:"updated_at"`
+}
@@ -0,0 +1,41 @@
+package entity
+
+// Userset is a set of subjects having relation to the object, written as "namespace:object_id#relation".
+// Empty relation stands for the object itself, such usersets are subjects of tupleset relations (e.g. parent folder).
+type Userset struct {
+	Namespace string
+	ObjectID  string
+	Relation  string
+}
+
+func (u Userset) String() string {
+	if u.Relation == "" {
+		return u.Namespace + ":" + u.ObjectID
+	}
+	return u.Namespace + ":" + u.ObjectID + "#" + u.Relation
+}
+
+// RelationTuple states that the subject has relation to the object.
+// Subject is either the user (SubjectUserID) or every member of SubjectSet.
+// Tuples of an org are effective in the org only, tuples of entity.NoOrgID are effective in every org.
+type RelationTuple struct {
+	ID            int64
+	OrgID         int64
+	Namespace     string
+	ObjectID      string
+	Relation      string
+	SubjectUserID int64
+	SubjectSet    *Userset
+}
+
+func (t RelationTuple) Object() Userset {
+	return Userset{Namespace: t.Namespace, ObjectID: t.ObjectID, Relation: t.Relation}
+}
+
+// UsersetTree shows how members of the userset are derived: users are members directly,
+// members of children usersets are members via tuples or schema rewrites.
+type UsersetTree struct {
+	Userset  Userset
+	UserIDs  []int64
+	Children []UsersetTree
+}
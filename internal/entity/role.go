@@ -0,0 +1,36 @@
+package entity
+
+// RoleDetails is a stored role together with the permissions granted to everyone having it.
+// Users reference roles by name, see User.Role. Roles of an org can only be assigned within the org
+// (see OrgMember.Role), roles of entity.NoOrgID are global.
+type RoleDetails struct {
+	ID          int64  `db:"id"`
+	OrgID       int64  `db:"org_id"`
+	Name        Role   `db:"name"`
+	Description string `db:"description"`
+	IsBuiltin   bool   `db:"is_builtin"`
+	Permissions []Permission
+}
+
+func IsBuiltinRole(name Role) bool {
+	switch name {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// builtinRoleRanks orders builtin roles by privileges.
+var builtinRoleRanks = map[Role]int{RoleUser: 1, RoleModerator: 2, RoleAdmin: 3}
+
+// IsRolePromotion reports whether changing role from one to another can only extend privileges of the user:
+// becoming admin or moving up among builtin roles. Any other change (custom roles included)
+// may take some privileges away.
+func IsRolePromotion(from Role, to Role) bool {
+	if to == RoleAdmin {
+		return true
+	}
+	fromRank, ok1 := builtinRoleRanks[from]
+	toRank, ok2 := builtinRoleRanks[to]
+	return ok1 && ok2 && toRank >= fromRank
+}
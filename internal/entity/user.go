@@ -1,10 +1,10 @@
 package entity
 
 import (
-	"errors"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
+	"sso.service/pkg/passhash"
 )
 
 type Role = string
@@ -19,15 +19,22 @@ const (
 
 type (
 	User struct {
-		ID          int64     `db:"id" json:"id"`
-		Username    string    `db:"username" json:"username"`
-		Email       string    `db:"email" json:"email"`
-		Password    password  `db:"password" json:"-"`
-		Role        Role      `db:"role" json:"role"`
-		IsActive    bool      `db:"is_active" json:"is_active"`
-		CreatedAt   time.Time `db:"created_at" json:"created_at"`
-		UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
-		Permissions []Permission
+		ID           int64     `db:"id" json:"id"`
+		Username     string    `db:"username" json:"username"`
+		Email        string    `db:"email" json:"email"`
+		Password     password  `db:"password" json:"-"`
+		Role         Role      `db:"role" json:"role"`
+		IsActive     bool      `db:"is_active" json:"is_active"`
+		CreatedAt    time.Time `db:"created_at" json:"created_at"`
+		UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+		TokenVersion int64     `db:"token_version" json:"-"`
+		// Set while the user is deactivated by an admin, unlike users not activated yet
+		DeactivatedAt *time.Time `db:"deactivated_at" json:"deactivated_at,omitempty"`
+		// Set for soft deleted users only
+		DeletedAt   *time.Time   `db:"deleted_at" json:"deleted_at,omitempty"`
+		Permissions []Permission `json:"-"`
+		// Loaded by Get only
+		PublicMetadata map[string]any `db:"public_metadata" json:"public_metadata"`
 	}
 	password struct {
 		Plaintext string
@@ -35,6 +42,34 @@ type (
 	}
 )
 
+// UserMetadata holds custom profile attributes of the user.
+// Public attributes are visible to anyone who can see the user and editable by the user,
+// app-private ones are kept per app and admin ones are visible to admins only.
+type UserMetadata struct {
+	Public map[string]any `json:"public"`
+	App    map[string]any `json:"app"`
+	Admin  map[string]any `json:"admin,omitempty"`
+}
+
+// ExportedUser is the user as written by exports. Direct permission grants and roles of the user in orgs
+// are loaded on request only, the password hash is set only if explicitly requested.
+type ExportedUser struct {
+	User
+	PasswordHash string            `json:"password_hash,omitempty"`
+	Grants       []PermissionGrant `json:"permissions,omitempty"`
+	OrgRoles     []OrgMember       `json:"org_roles,omitempty"`
+}
+
+// ErasureReport proves that personal data of the deleted user has been erased.
+// Details list anonymized fields and numbers of deleted records by kind.
+type ErasureReport struct {
+	ID        int64          `db:"id" json:"id"`
+	UserID    int64          `db:"user_id" json:"user_id"`
+	DeletedAt time.Time      `db:"deleted_at" json:"deleted_at"`
+	ErasedAt  time.Time      `db:"erased_at" json:"erased_at"`
+	Details   map[string]any `db:"details" json:"details"`
+}
+
 func (u *User) HasPermission(permCode string) bool {
 	for _, perm := range u.Permissions {
 		if perm.Code == permCode {
@@ -44,23 +79,34 @@ func (u *User) HasPermission(permCode string) bool {
 	return false
 }
 
+// PasswordHasher hashes passwords and verifies their hashes, it's configured on startup.
+var PasswordHasher = passhash.New(passhash.Bcrypt{Cost: bcrypt.DefaultCost}, nil)
+
 func (p *password) Set(plain string) error {
-	hash, err := bcrypt.GenerateFromPassword([]byte(plain), bcrypt.DefaultCost)
+	hash, err := PasswordHasher.Hash(plain)
 	if err != nil {
 		return err
 	}
 	p.Plaintext = plain
-	p.Hash = hash
+	p.Hash = []byte(hash)
 	return nil
 }
 
-func (p *password) Matches(plain string) (bool, error) {
-	err := bcrypt.CompareHashAndPassword(p.Hash, []byte(plain))
-	if err != nil {
-		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
-			return false, nil
-		}
-		return false, err
+// SetHash sets the hash made elsewhere (e.g. imported from another system), it must be of a known algorithm.
+func (p *password) SetHash(encoded string) error {
+	if err := PasswordHasher.Validate(encoded); err != nil {
+		return err
 	}
-	return true, nil
+	p.Plaintext = ""
+	p.Hash = []byte(encoded)
+	return nil
+}
+
+func (p *password) Matches(plain string) (bool, error) {
+	return PasswordHasher.Verify(plain, string(p.Hash))
+}
+
+// NeedsRehash reports whether the hash was made with an outdated algorithm, parameters or pepper.
+func (p *password) NeedsRehash() bool {
+	return PasswordHasher.NeedsRehash(string(p.Hash))
 }
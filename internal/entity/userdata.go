@@ -0,0 +1,53 @@
+package entity
+
+import "time"
+
+type DataExportStatus = string
+
+const (
+	DataExportPending   DataExportStatus = "pending"
+	DataExportRunning   DataExportStatus = "running"
+	DataExportCompleted DataExportStatus = "completed"
+	DataExportFailed    DataExportStatus = "failed"
+)
+
+// DataExport is an asynchronous export of the user's data. Archive (JSON encoded UserDataArchive)
+// is set once the export is completed, Error once it failed.
+type DataExport struct {
+	ID          int64            `db:"id"`
+	UserID      int64            `db:"user_id"`
+	RequestedBy int64            `db:"requested_by"`
+	Status      DataExportStatus `db:"status"`
+	Error       string           `db:"error"`
+	Archive     []byte           `db:"archive"`
+	CreatedAt   time.Time        `db:"created_at"`
+	CompletedAt *time.Time       `db:"completed_at"`
+}
+
+// UserDataArchive is a machine readable copy of the data stored about the user.
+// App-private metadata of the user is included in Apps.
+type UserDataArchive struct {
+	GeneratedAt      time.Time         `json:"generated_at"`
+	Profile          *User             `json:"profile"`
+	PublicMetadata   map[string]any    `json:"public_metadata"`
+	AdminMetadata    map[string]any    `json:"admin_metadata"`
+	PermissionGrants []PermissionGrant `json:"permission_grants"`
+	Sessions         []Session         `json:"sessions"`
+	AuditEvents      []AuditEvent      `json:"audit_events"`
+	Apps             []UserApp         `json:"apps"`
+}
+
+// Session is a token issued to the user (e.g. an activation one), its value is never stored.
+type Session struct {
+	Scope     string    `db:"scope" json:"scope"`
+	ExpiresAt time.Time `db:"expiry" json:"expires_at"`
+}
+
+// UserApp is an app the user has registered with or logged in to, along with app-private metadata of the user.
+type UserApp struct {
+	AppID       int32          `db:"app_id" json:"app_id"`
+	AppName     string         `db:"app_name" json:"app_name"`
+	JoinedAt    time.Time      `db:"joined_at" json:"joined_at"`
+	LastLoginAt *time.Time     `db:"last_login_at" json:"last_login_at"`
+	Metadata    map[string]any `db:"metadata" json:"metadata"`
+}
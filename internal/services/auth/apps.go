@@ -2,7 +2,11 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"errors"
+	"time"
 
 	"sso.service/internal/entity"
 	"sso.service/internal/services/dtos"
@@ -11,29 +15,227 @@ import (
 
 type appsRepo interface {
 	Get(ctx context.Context, params dtos.GetAppOptionsDTO) (*entity.App, error)
+	List(ctx context.Context) ([]entity.App, error)
 	Create(ctx context.Context, app *entity.App) (int64, error)
+	Update(ctx context.Context, params dtos.UpdateAppDTO) (*entity.App, error)
+	Delete(ctx context.Context, appID int32) error
+	SetMetadataSchema(ctx context.Context, appID int32, schema string) error
+	RotateSecret(ctx context.Context, appID int32, secret string, previousExpiresAt *time.Time) (*entity.App, error)
+	DeleteExpiredSecrets(ctx context.Context) (int64, error)
 }
 
+// GetOrCreateApp creates the app on behalf of the actor (an admin, 0 for the system). An existing app with the same name
+// is returned only if its secret matches the supplied one, ErrAppAlreadyExists is returned otherwise.
 func (a *AuthService) GetOrCreateApp(
 	ctx context.Context,
+	actorID int64,
 	app *entity.App,
 ) (*dtos.GetOrCreateAppDTO, error) {
 	const op = "auth.GetOrCreateApp"
-	log := a.log.With("operation", op)
+	log := a.log.With("operation", op, "actor_id", actorID)
 	id, err := a.appsRepo.Create(ctx, app)
 	if err != nil {
 		if errors.Is(err, storage.ErrRecordAlreadyExists) {
 			log.Warn("App already exists", "name", app.Name)
-			app, err := a.appsRepo.Get(ctx, dtos.GetAppOptionsDTO{AppName: app.Name})
+			existing, err := a.appsRepo.Get(ctx, dtos.GetAppOptionsDTO{AppName: app.Name})
 			if err != nil {
 				log.Error("Error getting app", "msg", err.Error())
 				return nil, err
 			}
-			return &dtos.GetOrCreateAppDTO{AppID: app.ID, IsCreated: false}, nil
+			if subtle.ConstantTimeCompare([]byte(existing.Secret), []byte(app.Secret)) != 1 {
+				log.Warn("Secret of the existing app doesn't match", "app_id", existing.ID)
+				return nil, ErrAppAlreadyExists
+			}
+			return &dtos.GetOrCreateAppDTO{AppID: existing.ID, IsCreated: false}, nil
+		}
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("Org not found", "org_id", app.OrgID)
+			return nil, ErrOrgNotFound
 		}
 		log.Error("Error creating app", "msg", err.Error())
 		return nil, err
 	}
 	log.Info("App saved", "id", id)
+	if err := a.auditApp(ctx, entity.AuditAppCreated, actorID, id, map[string]any{"name": app.Name}); err != nil {
+		log.Error("Failed to save audit event", "msg", err.Error())
+		return nil, err
+	}
 	return &dtos.GetOrCreateAppDTO{AppID: id, IsCreated: true}, nil
 }
+
+func (a *AuthService) GetApp(ctx context.Context, appID int32) (*entity.App, error) {
+	const op = "auth.GetApp"
+	log := a.log.With("operation", op, "app_id", appID)
+	app, err := a.appsRepo.Get(ctx, dtos.GetAppOptionsDTO{AppID: appID})
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("App not found")
+			return nil, ErrAppNotFound
+		}
+		log.Error("Failed to get app", "msg", err.Error())
+		return nil, err
+	}
+	return app, nil
+}
+
+func (a *AuthService) ListApps(ctx context.Context) ([]entity.App, error) {
+	const op = "auth.ListApps"
+	log := a.log.With("operation", op)
+	apps, err := a.appsRepo.List(ctx)
+	if err != nil {
+		log.Error("Failed to list apps", "msg", err.Error())
+		return nil, err
+	}
+	return apps, nil
+}
+
+// UpdateApp changes the set fields and the listed auth settings of the app on behalf of the actor (an admin).
+func (a *AuthService) UpdateApp(ctx context.Context, actorID int64, params dtos.UpdateAppDTO) (*entity.App, error) {
+	const op = "auth.UpdateApp"
+	log := a.log.With("operation", op, "actor_id", actorID, "app_id", params.ID)
+	if err := validateAppSettings(&params.Settings, params.SettingsFields); err != nil {
+		log.Warn("Invalid app settings", "msg", err.Error())
+		return nil, err
+	}
+	app, err := a.appsRepo.Update(ctx, params)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrRecordNotFound):
+			log.Warn("App not found")
+			return nil, ErrAppNotFound
+		case errors.Is(err, storage.ErrRecordAlreadyExists):
+			log.Warn("App with this name already exists")
+			return nil, ErrAppAlreadyExists
+		}
+		log.Error("Failed to update app", "msg", err.Error())
+		return nil, err
+	}
+	var changedFields []any
+	if params.Name != nil {
+		changedFields = append(changedFields, "name")
+	}
+	if params.Description != nil {
+		changedFields = append(changedFields, "description")
+	}
+	if params.EmbedPermissions != nil {
+		changedFields = append(changedFields, "embed_permissions")
+	}
+	for _, field := range params.SettingsFields {
+		changedFields = append(changedFields, field)
+	}
+	log.Info("App updated", "fields", changedFields)
+	if err := a.auditApp(ctx, entity.AuditAppUpdated, actorID, app.ID, map[string]any{"fields": changedFields}); err != nil {
+		log.Error("Failed to save audit event", "msg", err.Error())
+		return nil, err
+	}
+	return app, nil
+}
+
+// DeleteApp deletes the app with its permissions and policies on behalf of the actor (an admin).
+// Tokens issued for the app can't be verified anymore.
+func (a *AuthService) DeleteApp(ctx context.Context, actorID int64, appID int32) error {
+	const op = "auth.DeleteApp"
+	log := a.log.With("operation", op, "actor_id", actorID, "app_id", appID)
+	if err := a.appsRepo.Delete(ctx, appID); err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("App not found")
+			return ErrAppNotFound
+		}
+		log.Error("Failed to delete app", "msg", err.Error())
+		return err
+	}
+	log.Info("App deleted")
+	if err := a.auditApp(ctx, entity.AuditAppDeleted, actorID, int64(appID), nil); err != nil {
+		log.Error("Failed to save audit event", "msg", err.Error())
+		return err
+	}
+	return nil
+}
+
+// RotateAppSecret replaces the secret of the app with a generated one on behalf of the actor (an admin).
+// Tokens signed with the old secret are accepted for gracePeriod, zero grace period invalidates them at once.
+// The old secret of a rotation whose grace period isn't over yet is dropped.
+func (a *AuthService) RotateAppSecret(ctx context.Context, actorID int64, appID int32, gracePeriod time.Duration) (*entity.App, error) {
+	const op = "auth.RotateAppSecret"
+	log := a.log.With("operation", op, "actor_id", actorID, "app_id", appID, "grace_period", gracePeriod)
+	if gracePeriod < 0 || gracePeriod > a.cfg.AppSecrets.MaxGracePeriod {
+		log.Warn("Invalid grace period")
+		return nil, ErrInvalidGracePeriod
+	}
+	secret, err := generateAppSecret()
+	if err != nil {
+		log.Error("Failed to generate secret", "msg", err.Error())
+		return nil, err
+	}
+	var previousExpiresAt *time.Time
+	if gracePeriod > 0 {
+		expiresAt := time.Now().Add(gracePeriod)
+		previousExpiresAt = &expiresAt
+	}
+	app, err := a.appsRepo.RotateSecret(ctx, appID, secret, previousExpiresAt)
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("App not found")
+			return nil, ErrAppNotFound
+		}
+		log.Error("Failed to rotate secret", "msg", err.Error())
+		return nil, err
+	}
+	log.Info("App secret rotated")
+	payload := map[string]any{"grace_period_seconds": int64(gracePeriod.Seconds())}
+	if err := a.auditApp(ctx, entity.AuditAppSecretRotated, actorID, app.ID, payload); err != nil {
+		log.Error("Failed to save audit event", "msg", err.Error())
+		return nil, err
+	}
+	return app, nil
+}
+
+func generateAppSecret() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(key), nil
+}
+
+// DeleteExpiredSecrets drops previous secrets of apps whose grace period is over.
+func (a *AuthService) DeleteExpiredSecrets(ctx context.Context) {
+	const op = "auth.DeleteExpiredSecrets"
+	log := a.log.With("operation", op)
+	deleted, err := a.appsRepo.DeleteExpiredSecrets(ctx)
+	if err != nil {
+		log.Error("Failed to delete expired secrets", "msg", err.Error())
+		return
+	}
+	if deleted > 0 {
+		log.Info("Expired app secrets deleted", "count", deleted)
+	}
+}
+
+// RunSecretCleanupJob calls DeleteExpiredSecrets every interval until ctx is done.
+func (a *AuthService) RunSecretCleanupJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.DeleteExpiredSecrets(ctx)
+		}
+	}
+}
+
+// auditApp records a change of the app, the payload is extended with its id.
+// Zero actorID stands for changes made by the system (e.g. by ssoctl).
+func (a *AuthService) auditApp(ctx context.Context, eventType entity.AuditEventType, actorID int64, appID int64, payload map[string]any) error {
+	if payload == nil {
+		payload = map[string]any{}
+	}
+	payload["app_id"] = appID
+	event := entity.AuditEvent{Type: eventType, Payload: payload}
+	if actorID != 0 {
+		event.ActorID = &actorID
+	}
+	return a.auditRepo.Create(ctx, event)
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"sso.service/internal/entity"
+	jwtLib "sso.service/pkg/jwt"
+)
+
+type permissionsRepo interface {
+	ListForUser(ctx context.Context, userID int64, appID int32) ([]entity.Permission, *time.Time, error)
+	ListGrantsForUsers(ctx context.Context, userIDs []int64) ([]entity.PermissionGrant, error)
+}
+
+// newAccessToken issues access token of the user for the app within the active org (nil for none).
+// If the app embeds permissions, role (the one in the active org if any) and permission codes
+// of the user are read from storage on every call, so changes take effect with the next issued token.
+func (a *AuthService) newAccessToken(
+	ctx context.Context,
+	tokenProvider *jwtLib.TokenProvider,
+	user *entity.User,
+	app *entity.App,
+	org *entity.OrgMember,
+) (string, error) {
+	claims := tokenClaims(user, app, org, jwtLib.TokenTypeAccess)
+	ttl := a.accessTokenTTL(app)
+	if app.EmbedPermissions {
+		permissions, validUntil, err := a.permissionsRepo.ListForUser(ctx, user.ID, int32(app.ID))
+		if err != nil {
+			return "", err
+		}
+		scope := make(jwtLib.Scope, len(permissions))
+		for i, perm := range permissions {
+			scope[i] = perm.Code
+		}
+		claims[jwtLib.RoleClaim] = user.Role
+		if org != nil {
+			claims[jwtLib.RoleClaim] = org.Role
+		}
+		if encoded := scope.String(); len(encoded) <= a.cfg.MaxEmbeddedScopeLen {
+			claims[jwtLib.ScopeClaim] = encoded
+		} else {
+			a.log.Warn("Scope is too large to be embedded in access token", "user_id", user.ID, "app_id", app.ID, "len", len(encoded))
+			claims[jwtLib.ScopeOmittedClaim] = true
+		}
+		// token must not outlive temporary grants embedded in it
+		if validUntil != nil {
+			ttl = max(min(ttl, time.Until(*validUntil)), time.Second)
+		}
+	}
+	return tokenProvider.NewToken(ttl, claims)
+}
+
+// tokenClaims returns claims identifying the user, the app and the active org shared by access and refresh tokens.
+func tokenClaims(user *entity.User, app *entity.App, org *entity.OrgMember, tokenType string) map[string]any {
+	claims := map[string]any{
+		"uid":                    user.ID,
+		"app_id":                 app.ID,
+		jwtLib.TokenTypeClaim:    tokenType,
+		jwtLib.TokenVersionClaim: user.TokenVersion,
+	}
+	if org != nil {
+		claims[jwtLib.OrgIDClaim] = org.OrgID
+	}
+	return claims
+}
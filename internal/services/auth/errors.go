@@ -19,12 +19,32 @@ import (
 // }
 
 var (
-	ErrInvalidCredentials   = errors.New("invalid credentials")
-	ErrUserNotFound         = errors.New("user not found")
-	ErrAppNotFound          = errors.New("app not found")
-	ErrUserAlreadyExists    = errors.New("user with this email already exists")
-	ErrUserAlreadyActivated = errors.New("user already activated")
-	ErrInvalidToken         = errors.New("invalid or expired token")
-	ErrAppIdsMismatch       = errors.New("app ids mismatch")
+	ErrInvalidCredentials    = errors.New("invalid credentials")
+	ErrUserNotFound          = errors.New("user not found")
+	ErrAppNotFound           = errors.New("app not found")
+	ErrUserAlreadyExists     = errors.New("user with this email already exists")
+	ErrUserAlreadyActivated  = errors.New("user already activated")
+	ErrUserDeactivated       = errors.New("user has been deactivated by an admin")
+	ErrInvalidToken          = errors.New("invalid or expired token")
+	ErrAppIdsMismatch        = errors.New("app ids mismatch")
+	ErrOrgNotFound           = errors.New("org not found")
+	ErrOrgMismatch           = errors.New("app is owned by another org")
+	ErrNotOrgMember          = errors.New("user is not a member of the org")
+	ErrRoleNotFound          = errors.New("role not found")
+	ErrLastAdmin             = errors.New("the only active admin can't be demoted, deactivated or deleted")
+	ErrEditConflict          = errors.New("user has been changed concurrently, reload it and try again")
+	ErrDeletedUserNotFound   = errors.New("deleted user not found or its grace period is over")
+	ErrErasureReportNotFound = errors.New("erasure report not found")
+	ErrInvalidMetadata       = errors.New("invalid metadata")
+	ErrInvalidMetadataSchema = errors.New("invalid metadata schema")
+	ErrAppAlreadyExists      = errors.New("app with this name already exists")
+	ErrInvalidGracePeriod    = errors.New("grace period is negative or exceeds the allowed maximum")
+	ErrInvalidAppSettings    = errors.New("invalid app settings")
+	ErrLoginMethodNotAllowed = errors.New("login method is not allowed for the app")
+	ErrRegistrationClosed    = errors.New("self-registration is closed for the app")
+	ErrWeakPassword          = errors.New("password violates the password policy")
+	ErrInvalidPermissionCode = errors.New("invalid permission code")
+	ErrInvalidImportRecord   = errors.New("invalid import record")
+	ErrActorRequired         = errors.New("the admin performing the action must be specified")
 )
 
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+)
+
+const defaultExportBatchSize = 500
+
+// ExportUsers calls fn with every user matching the filter on behalf of the actor (an admin, 0 for the system),
+// reading users in batches in the sort order of the filter. Errors returned by fn stop the export.
+// Exports of password hashes allow offline attacks on the passwords, so they require an actor
+// and are audited before any hash is handed out, whether the export completes or not.
+func (a *AuthService) ExportUsers(ctx context.Context, actorID int64, options dtos.ExportUsersOptionsDTO, fn func(*entity.ExportedUser) error) error {
+	const op = "auth.ExportUsers"
+	log := a.log.With(
+		"operation", op,
+		"actor_id", actorID,
+		"with_permissions", options.WithPermissions,
+		"with_org_roles", options.WithOrgRoles,
+		"with_password_hashes", options.WithPasswordHashes,
+	)
+	if options.WithPasswordHashes {
+		if actorID == 0 {
+			log.Warn("Password hashes exported without an actor")
+			return ErrActorRequired
+		}
+		event := entity.AuditEvent{
+			Type:    entity.AuditUsersExported,
+			ActorID: &actorID,
+			Payload: map[string]any{
+				"password_hashes": true,
+				"permissions":     options.WithPermissions,
+				"org_roles":       options.WithOrgRoles,
+			},
+		}
+		if err := a.auditRepo.Create(ctx, event); err != nil {
+			log.Error("Failed to save audit event", "msg", err.Error())
+			return err
+		}
+	}
+	filter := options.Filter
+	filter.Limit = options.BatchSize
+	if filter.Limit <= 0 {
+		filter.Limit = defaultExportBatchSize
+	}
+	if filter.SortBy == "" {
+		filter.SortBy = dtos.UsersSortByCreatedAt
+	}
+	filter.After = nil
+	filter.WithPasswordHash = options.WithPasswordHashes
+	exported := 0
+	for {
+		users, err := a.ListUsers(ctx, filter)
+		if err != nil {
+			return err
+		}
+		batch, err := a.exportedUsers(ctx, users, options)
+		if err != nil {
+			log.Error("Failed to load exported users", "msg", err.Error())
+			return err
+		}
+		for _, user := range batch {
+			if err := fn(user); err != nil {
+				log.Warn("Export stopped", "exported", exported, "msg", err.Error())
+				return err
+			}
+			exported++
+		}
+		if len(users) < filter.Limit {
+			break
+		}
+		last := users[len(users)-1]
+		sortValue := last.CreatedAt
+		if filter.SortBy == dtos.UsersSortByUpdatedAt {
+			sortValue = last.UpdatedAt
+		}
+		filter.After = &dtos.UsersCursorDTO{SortValue: sortValue, ID: last.ID}
+	}
+	log.Info("Users exported", "exported", exported)
+	return nil
+}
+
+// exportedUsers attaches grants and org roles to the users, if requested, with one query per kind for the batch.
+func (a *AuthService) exportedUsers(ctx context.Context, users []entity.User, options dtos.ExportUsersOptionsDTO) ([]*entity.ExportedUser, error) {
+	exported := make([]*entity.ExportedUser, len(users))
+	byID := make(map[int64]*entity.ExportedUser, len(users))
+	userIDs := make([]int64, len(users))
+	for i := range users {
+		exported[i] = &entity.ExportedUser{User: users[i]}
+		if options.WithPasswordHashes {
+			exported[i].PasswordHash = string(users[i].Password.Hash)
+		}
+		byID[users[i].ID] = exported[i]
+		userIDs[i] = users[i].ID
+	}
+	if len(users) == 0 {
+		return exported, nil
+	}
+	if options.WithPermissions {
+		grants, err := a.permissionsRepo.ListGrantsForUsers(ctx, userIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, grant := range grants {
+			user := byID[grant.UserID]
+			user.Grants = append(user.Grants, grant)
+		}
+	}
+	if options.WithOrgRoles {
+		members, err := a.orgsRepo.ListMembershipsForUsers(ctx, userIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			user := byID[member.UserID]
+			user.OrgRoles = append(user.OrgRoles, member)
+		}
+	}
+	return exported, nil
+}
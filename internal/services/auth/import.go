@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strings"
+
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+	"sso.service/internal/storage"
+	"sso.service/pkg/permcode"
+)
+
+// ImportUser creates the user migrated from another system on behalf of the actor (an admin, 0 for the system),
+// keeping its password hash, so that the user logs in with the old password and the hash is upgraded then.
+// On dry run the record is checked against the database without saving anything.
+func (a *AuthService) ImportUser(ctx context.Context, actorID int64, record dtos.ImportUserDTO, dryRun bool) (int64, error) {
+	const op = "auth.ImportUser"
+	log := a.log.With("operation", op, "actor_id", actorID, "email", record.Email, "app_id", record.AppID, "dry_run", dryRun)
+	user, permissionCodes, err := importedUser(record)
+	if err != nil {
+		log.Warn("Invalid import record", "msg", err.Error())
+		return 0, err
+	}
+	if record.AppID != 0 {
+		if _, err := a.appsRepo.Get(ctx, dtos.GetAppOptionsDTO{AppID: record.AppID}); err != nil {
+			if errors.Is(err, storage.ErrRecordNotFound) {
+				log.Warn("App not found")
+				return 0, ErrAppNotFound
+			}
+			log.Error("Failed to get app", "msg", err.Error())
+			return 0, err
+		}
+	}
+	userID, err := a.usersRepo.Import(ctx, user, record.AppID, permissionCodes, dryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrRecordAlreadyExists):
+			log.Warn("User already exists")
+			return 0, ErrUserAlreadyExists
+		case errors.Is(err, storage.ErrInvalidReference):
+			// the app has been checked above, so it's the role unless the app is deleted in the meantime
+			log.Warn("Role not found")
+			return 0, ErrRoleNotFound
+		}
+		log.Error("Failed to import user", "msg", err.Error())
+		return 0, err
+	}
+	if dryRun {
+		return 0, nil
+	}
+	log.Info("User imported", "user_id", userID)
+	event := entity.AuditEvent{
+		Type:   entity.AuditUserImported,
+		UserID: &userID,
+		Payload: map[string]any{
+			"role":        user.Role,
+			"is_active":   user.IsActive,
+			"app_id":      record.AppID,
+			"permissions": permissionCodes,
+		},
+	}
+	if actorID != 0 {
+		event.ActorID = &actorID
+	}
+	if err := a.auditRepo.Create(ctx, event); err != nil {
+		log.Error("Failed to save audit event", "msg", err.Error())
+		return 0, err
+	}
+	return userID, nil
+}
+
+// importedUser validates the record and returns the user to create with deduplicated permission codes.
+func importedUser(record dtos.ImportUserDTO) (*entity.User, []string, error) {
+	user := &entity.User{
+		Username: strings.TrimSpace(record.Username),
+		Email:    strings.TrimSpace(record.Email),
+		Role:     record.Role,
+		IsActive: record.IsActive,
+	}
+	if user.Username == "" {
+		return nil, nil, fmt.Errorf("%w: username is required", ErrInvalidImportRecord)
+	}
+	if address, err := mail.ParseAddress(user.Email); err != nil || address.Address != user.Email {
+		return nil, nil, fmt.Errorf("%w: invalid email %q", ErrInvalidImportRecord, user.Email)
+	}
+	if user.Role == "" {
+		user.Role = entity.DefaultUserRole
+	}
+	if record.AppID < 0 {
+		return nil, nil, fmt.Errorf("%w: invalid app id %d", ErrInvalidImportRecord, record.AppID)
+	}
+	if record.PasswordHash == "" {
+		return nil, nil, fmt.Errorf("%w: password hash is required", ErrInvalidImportRecord)
+	}
+	if err := user.Password.SetHash(record.PasswordHash); err != nil {
+		return nil, nil, fmt.Errorf("%w: password hash: %w", ErrInvalidImportRecord, err)
+	}
+	permissionCodes := slices.Clone(record.Permissions)
+	slices.Sort(permissionCodes)
+	permissionCodes = slices.Compact(permissionCodes)
+	for _, code := range permissionCodes {
+		if err := permcode.ValidatePattern(code); err != nil {
+			return nil, nil, fmt.Errorf("%w: %s: %w", ErrInvalidPermissionCode, code, err)
+		}
+	}
+	return user, permissionCodes, nil
+}
+
+// ImportBatch tracks emails of the rows imported together, so that rows duplicating the email of a previous one
+// are reported the same way on dry run, when nothing is saved to conflict with. Usernames aren't unique.
+type ImportBatch struct {
+	emails map[string]int64
+}
+
+func NewImportBatch() *ImportBatch {
+	return &ImportBatch{emails: map[string]int64{}}
+}
+
+// Check returns an error if the email of the record is taken by a row added before.
+func (b *ImportBatch) Check(record dtos.ImportUserDTO) error {
+	// emails are case-insensitive in the database
+	if row, ok := b.emails[strings.ToLower(strings.TrimSpace(record.Email))]; ok {
+		return fmt.Errorf("%w: email duplicates row %d", ErrUserAlreadyExists, row)
+	}
+	return nil
+}
+
+// Add records the row imported (or checked on dry run) successfully.
+func (b *ImportBatch) Add(row int64, record dtos.ImportUserDTO) {
+	b.emails[strings.ToLower(strings.TrimSpace(record.Email))] = row
+}
+
+// IsImportRowError reports whether the error of ImportUser is caused by the imported row
+// rather than by the server, so that the rest of the rows may still be imported.
+func IsImportRowError(err error) bool {
+	for _, rowErr := range []error{
+		ErrInvalidImportRecord,
+		ErrInvalidPermissionCode,
+		ErrUserAlreadyExists,
+		ErrRoleNotFound,
+		ErrAppNotFound,
+	} {
+		if errors.Is(err, rowErr) {
+			return true
+		}
+	}
+	return false
+}
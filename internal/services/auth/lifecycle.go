@@ -0,0 +1,291 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+	"sso.service/internal/storage"
+)
+
+type auditRepo interface {
+	Create(ctx context.Context, events ...entity.AuditEvent) error
+}
+
+// UpdateUser changes the set fields of the user on behalf of the actor (an admin).
+// Tokens of the user are revoked when it's demoted or deactivated, the only active admin can't be either.
+func (a *AuthService) UpdateUser(ctx context.Context, actorID int64, params dtos.UpdateUserDTO) (*entity.User, error) {
+	const op = "auth.UpdateUser"
+	log := a.log.With("operation", op, "actor_id", actorID, "user_id", params.ID)
+	var event *entity.AuditEvent
+	_, updated, err := a.usersRepo.Patch(ctx, params, func(previous *entity.User, updated *entity.User) *entity.AuditEvent {
+		event = userUpdatedEvent(actorID, previous, updated)
+		return event
+	})
+	if err != nil {
+		return nil, userChangeError(log, err)
+	}
+	if event != nil {
+		log.Info("User updated", "fields", event.Payload["fields"], "tokens_revoked", event.Payload["tokens_revoked"])
+	}
+	return updated, nil
+}
+
+// userUpdatedEvent describes the change of the user, nil if nothing has changed.
+func userUpdatedEvent(actorID int64, previous *entity.User, updated *entity.User) *entity.AuditEvent {
+	var changedFields []string
+	if previous.Username != updated.Username {
+		changedFields = append(changedFields, "username")
+	}
+	if previous.Email != updated.Email {
+		changedFields = append(changedFields, "email")
+	}
+	if previous.Role != updated.Role {
+		changedFields = append(changedFields, "role")
+	}
+	if activityChanged(previous, updated) {
+		changedFields = append(changedFields, "is_active")
+	}
+	if len(changedFields) == 0 {
+		return nil
+	}
+	payload := map[string]any{"fields": changedFields, "tokens_revoked": updated.TokenVersion != previous.TokenVersion}
+	if previous.Role != updated.Role {
+		payload["previous_role"] = previous.Role
+		payload["role"] = updated.Role
+	}
+	event := auditEvent(entity.AuditUserUpdated, actorID, updated.ID, payload)
+	return &event
+}
+
+// SetUserRole changes the global role of the user on behalf of the actor (an admin), see UpdateUser.
+func (a *AuthService) SetUserRole(ctx context.Context, actorID int64, userID int64, role entity.Role) (*entity.User, error) {
+	return a.UpdateUser(ctx, actorID, dtos.UpdateUserDTO{ID: userID, Role: &role})
+}
+
+// DeactivateUser blocks login of the user and revokes all of its tokens.
+// Unlike users not activated yet, deactivated users can't activate themselves, see ActivateUser.
+func (a *AuthService) DeactivateUser(ctx context.Context, actorID int64, userID int64, expectedUpdatedAt *time.Time) (*entity.User, error) {
+	return a.setUserActive(ctx, actorID, userID, false, expectedUpdatedAt)
+}
+
+// ReactivateUser allows the deactivated user to log in again.
+func (a *AuthService) ReactivateUser(ctx context.Context, actorID int64, userID int64, expectedUpdatedAt *time.Time) (*entity.User, error) {
+	return a.setUserActive(ctx, actorID, userID, true, expectedUpdatedAt)
+}
+
+func (a *AuthService) setUserActive(ctx context.Context, actorID int64, userID int64, isActive bool, expectedUpdatedAt *time.Time) (*entity.User, error) {
+	const op = "auth.setUserActive"
+	log := a.log.With("operation", op, "actor_id", actorID, "user_id", userID, "is_active", isActive)
+	eventType := entity.AuditUserDeactivated
+	if isActive {
+		eventType = entity.AuditUserReactivated
+	}
+	changed := false
+	params := dtos.UpdateUserDTO{ID: userID, IsActive: &isActive, ExpectedUpdatedAt: expectedUpdatedAt}
+	_, updated, err := a.usersRepo.Patch(ctx, params, func(previous *entity.User, updated *entity.User) *entity.AuditEvent {
+		if changed = activityChanged(previous, updated); !changed {
+			return nil
+		}
+		event := auditEvent(eventType, actorID, userID, map[string]any{})
+		return &event
+	})
+	if err != nil {
+		return nil, userChangeError(log, err)
+	}
+	if changed {
+		log.Info("User activity changed")
+	}
+	return updated, nil
+}
+
+// activityChanged reports whether the user has been activated, deactivated or reactivated,
+// deactivating a user not activated yet changes only its DeactivatedAt.
+func activityChanged(previous *entity.User, updated *entity.User) bool {
+	return previous.IsActive != updated.IsActive || (previous.DeactivatedAt == nil) != (updated.DeactivatedAt == nil)
+}
+
+// DeleteUser soft deletes the user and revokes all of its tokens, see deleteUser.
+// Returns the time after which personal data of the user is going to be erased.
+func (a *AuthService) DeleteUser(ctx context.Context, actorID int64, userID int64, expectedUpdatedAt *time.Time) (time.Time, error) {
+	const op = "auth.DeleteUser"
+	log := a.log.With("operation", op, "actor_id", actorID, "user_id", userID)
+	return a.deleteUser(ctx, log, entity.AuditUserDeleted, actorID, userID, expectedUpdatedAt)
+}
+
+// RequestAccountDeletion soft deletes the account of the user on its own request, the password must be confirmed.
+// Returns the time after which personal data of the user is going to be erased.
+func (a *AuthService) RequestAccountDeletion(ctx context.Context, userID int64, password string) (time.Time, error) {
+	const op = "auth.RequestAccountDeletion"
+	log := a.log.With("operation", op, "user_id", userID)
+	user, err := a.usersRepo.Get(ctx, dtos.GetUserOptionsDTO{ID: userID})
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("User not found")
+			return time.Time{}, ErrUserNotFound
+		}
+		log.Error("Error getting user", "msg", err.Error())
+		return time.Time{}, err
+	}
+	matches, err := user.Password.Matches(password)
+	switch {
+	case err != nil:
+		log.Error("Error comparing password", "msg", err.Error())
+		return time.Time{}, err
+	case !matches:
+		log.Warn("Wrong password")
+		return time.Time{}, ErrInvalidCredentials
+	}
+	return a.deleteUser(ctx, log, entity.AuditUserDeletionRequested, userID, userID, nil)
+}
+
+// deleteUser soft deletes the user, it can be restored during the grace period (see RestoreUser),
+// after that its personal data is erased (see EraseDeletedUsers). The only active admin can't be deleted.
+func (a *AuthService) deleteUser(
+	ctx context.Context,
+	log *slog.Logger,
+	eventType entity.AuditEventType,
+	actorID int64,
+	userID int64,
+	expectedUpdatedAt *time.Time,
+) (time.Time, error) {
+	var erasureAfter time.Time
+	_, err := a.usersRepo.Delete(ctx, userID, expectedUpdatedAt, func(deleted *entity.User) *entity.AuditEvent {
+		erasureAfter = deleted.DeletedAt.Add(a.cfg.UserDeletion.GracePeriod)
+		event := auditEvent(eventType, actorID, userID, map[string]any{"erasure_after": erasureAfter})
+		return &event
+	})
+	if err != nil {
+		return time.Time{}, userChangeError(log, err)
+	}
+	log.Info("User deleted", "erasure_after", erasureAfter)
+	return erasureAfter, nil
+}
+
+// RestoreUser undeletes the user if its grace period isn't over yet. Tokens issued before the deletion stay revoked.
+func (a *AuthService) RestoreUser(ctx context.Context, actorID int64, userID int64) (*entity.User, error) {
+	const op = "auth.RestoreUser"
+	log := a.log.With("operation", op, "actor_id", actorID, "user_id", userID)
+	user, err := a.usersRepo.Restore(ctx, userID, time.Now().Add(-a.cfg.UserDeletion.GracePeriod))
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("Restorable user not found")
+			return nil, ErrDeletedUserNotFound
+		}
+		log.Error("Failed to restore user", "msg", err.Error())
+		return nil, err
+	}
+	log.Info("User restored")
+	if err := a.audit(ctx, entity.AuditUserRestored, actorID, userID, map[string]any{}); err != nil {
+		log.Error("Failed to save audit event", "msg", err.Error())
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetErasureReport returns the report of erasure of personal data of the deleted user.
+func (a *AuthService) GetErasureReport(ctx context.Context, userID int64) (*entity.ErasureReport, error) {
+	const op = "auth.GetErasureReport"
+	log := a.log.With("operation", op, "user_id", userID)
+	report, err := a.usersRepo.GetErasureReport(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("Erasure report not found")
+			return nil, ErrErasureReportNotFound
+		}
+		log.Error("Failed to get erasure report", "msg", err.Error())
+		return nil, err
+	}
+	return report, nil
+}
+
+const erasureBatchSize = 100
+
+// EraseDeletedUsers erases personal data of users whose grace period is over, producing an erasure report for each of them.
+func (a *AuthService) EraseDeletedUsers(ctx context.Context) (int, error) {
+	const op = "auth.EraseDeletedUsers"
+	log := a.log.With("operation", op)
+	deletedBefore := time.Now().Add(-a.cfg.UserDeletion.GracePeriod)
+	erased := 0
+	for {
+		userIDs, err := a.usersRepo.ListErasable(ctx, deletedBefore, erasureBatchSize)
+		if err != nil {
+			log.Error("Failed to list users to erase", "msg", err.Error())
+			return erased, err
+		}
+		for _, userID := range userIDs {
+			report, err := a.usersRepo.Erase(ctx, userID, deletedBefore)
+			if err != nil {
+				// restored in the meantime
+				if errors.Is(err, storage.ErrRecordNotFound) {
+					continue
+				}
+				log.Error("Failed to erase user", "user_id", userID, "msg", err.Error())
+				return erased, err
+			}
+			erased++
+			log.Info("User erased", "user_id", userID, "report_id", report.ID)
+			if err := a.audit(ctx, entity.AuditUserErased, 0, userID, map[string]any{"report_id": report.ID}); err != nil {
+				log.Error("Failed to save audit event", "msg", err.Error())
+				return erased, err
+			}
+		}
+		if len(userIDs) < erasureBatchSize {
+			return erased, nil
+		}
+	}
+}
+
+// RunErasureJob calls EraseDeletedUsers every interval until ctx is done.
+func (a *AuthService) RunErasureJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.EraseDeletedUsers(ctx)
+		}
+	}
+}
+
+// audit records the event, zero actorID stands for changes made by the system.
+func (a *AuthService) audit(ctx context.Context, eventType entity.AuditEventType, actorID int64, userID int64, payload map[string]any) error {
+	return a.auditRepo.Create(ctx, auditEvent(eventType, actorID, userID, payload))
+}
+
+// auditEvent builds the event affecting the user, zero actorID stands for changes made by the system.
+func auditEvent(eventType entity.AuditEventType, actorID int64, userID int64, payload map[string]any) entity.AuditEvent {
+	event := entity.AuditEvent{Type: eventType, UserID: &userID, Payload: payload}
+	if actorID != 0 {
+		event.ActorID = &actorID
+	}
+	return event
+}
+
+// userChangeError maps storage errors of changing or deleting the user to the service ones.
+func userChangeError(log *slog.Logger, err error) error {
+	switch {
+	case errors.Is(err, storage.ErrRecordNotFound):
+		log.Warn("User not found")
+		return ErrUserNotFound
+	case errors.Is(err, storage.ErrRecordAlreadyExists):
+		log.Warn("User with this email already exists")
+		return ErrUserAlreadyExists
+	case errors.Is(err, storage.ErrInvalidReference):
+		log.Warn("Role not found")
+		return ErrRoleNotFound
+	case errors.Is(err, storage.ErrLastAdmin):
+		log.Warn("Attempt to demote, deactivate or delete the last active admin")
+		return ErrLastAdmin
+	case errors.Is(err, storage.ErrEditConflict):
+		log.Warn("User has been changed concurrently")
+		return ErrEditConflict
+	}
+	log.Error("Failed to change user", "msg", err.Error())
+	return err
+}
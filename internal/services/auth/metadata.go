@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+	"sso.service/internal/storage"
+	"sso.service/pkg/mergepatch"
+)
+
+// Max size of a JSON encoded metadata area
+const maxMetadataSize = 16 << 10
+
+// compileMetadataSchema compiles JSON Schema of app-private metadata.
+// References to external documents are not resolved, schemas must be self-contained.
+func compileMetadataSchema(schema string) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("external reference %s is not allowed", url)
+	}
+	const url = "mem:///metadata_schema.json"
+	if err := compiler.AddResource(url, strings.NewReader(schema)); err != nil {
+		return nil, err
+	}
+	return compiler.Compile(url)
+}
+
+func (a *AuthService) GetUserMetadata(ctx context.Context, userID int64, appID int32) (*entity.UserMetadata, error) {
+	const op = "auth.GetUserMetadata"
+	log := a.log.With("operation", op, "user_id", userID, "app_id", appID)
+	metadata, err := a.usersRepo.GetMetadata(ctx, userID, appID)
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("User not found")
+			return nil, ErrUserNotFound
+		}
+		log.Error("Failed to get user metadata", "msg", err.Error())
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// PatchUserMetadata merges the patches into metadata of the user on behalf of the actor.
+// App-private metadata must conform to JSON Schema of the app, if it has one.
+func (a *AuthService) PatchUserMetadata(ctx context.Context, actorID int64, params dtos.PatchUserMetadataDTO) (*entity.UserMetadata, error) {
+	const op = "auth.PatchUserMetadata"
+	log := a.log.With("operation", op, "actor_id", actorID, "user_id", params.UserID, "app_id", params.AppID)
+	var appID int32
+	var schema *jsonschema.Schema
+	if params.App != nil {
+		appID = params.AppID
+		app, err := a.appsRepo.Get(ctx, dtos.GetAppOptionsDTO{AppID: appID})
+		if err != nil {
+			if errors.Is(err, storage.ErrRecordNotFound) {
+				log.Warn("App not found")
+				return nil, ErrAppNotFound
+			}
+			log.Error("Failed to get app", "msg", err.Error())
+			return nil, err
+		}
+		if app.MetadataSchema != "" {
+			if schema, err = compileMetadataSchema(app.MetadataSchema); err != nil {
+				log.Error("Failed to compile metadata schema of the app", "msg", err.Error())
+				return nil, err
+			}
+		}
+	}
+	var changedAreas []any
+	metadata, err := a.usersRepo.UpdateMetadata(ctx, params.UserID, appID, func(metadata *entity.UserMetadata) error {
+		if params.Public != nil {
+			metadata.Public = mergepatch.Apply(metadata.Public, params.Public)
+			changedAreas = append(changedAreas, "public")
+		}
+		if params.App != nil {
+			metadata.App = mergepatch.Apply(metadata.App, params.App)
+			changedAreas = append(changedAreas, "app")
+			if schema != nil {
+				if err := schema.Validate(metadata.App); err != nil {
+					return fmt.Errorf("%w: app: %w", ErrInvalidMetadata, err)
+				}
+			}
+		}
+		if params.Admin != nil {
+			metadata.Admin = mergepatch.Apply(metadata.Admin, params.Admin)
+			changedAreas = append(changedAreas, "admin")
+		}
+		return checkMetadataSize(metadata)
+	})
+	if err != nil {
+		return nil, metadataChangeError(log, err)
+	}
+	log.Info("User metadata updated", "areas", changedAreas)
+	payload := map[string]any{"areas": changedAreas}
+	if appID != 0 {
+		payload["app_id"] = appID
+	}
+	if err := a.audit(ctx, entity.AuditUserMetadataUpdated, actorID, params.UserID, payload); err != nil {
+		log.Error("Failed to save audit event", "msg", err.Error())
+		return nil, err
+	}
+	return metadata, nil
+}
+
+func checkMetadataSize(metadata *entity.UserMetadata) error {
+	areas := []struct {
+		name  string
+		value map[string]any
+	}{{"public", metadata.Public}, {"app", metadata.App}, {"admin", metadata.Admin}}
+	for _, area := range areas {
+		encoded, err := json.Marshal(area.value)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalidMetadata, area.name, err)
+		}
+		if len(encoded) > maxMetadataSize {
+			return fmt.Errorf("%w: %s metadata exceeds %d bytes", ErrInvalidMetadata, area.name, maxMetadataSize)
+		}
+	}
+	return nil
+}
+
+func metadataChangeError(log *slog.Logger, err error) error {
+	switch {
+	case errors.Is(err, ErrInvalidMetadata):
+		log.Warn("Invalid metadata", "msg", err.Error())
+		return err
+	case errors.Is(err, storage.ErrRecordNotFound):
+		log.Warn("User not found")
+		return ErrUserNotFound
+	case errors.Is(err, storage.ErrInvalidReference):
+		log.Warn("App not found")
+		return ErrAppNotFound
+	}
+	log.Error("Failed to update user metadata", "msg", err.Error())
+	return err
+}
+
+// SetAppMetadataSchema sets JSON Schema app-private metadata of users must conform to, empty schema removes it.
+// Metadata stored before is not checked against the new schema.
+func (a *AuthService) SetAppMetadataSchema(ctx context.Context, actorID int64, appID int32, schema string) error {
+	const op = "auth.SetAppMetadataSchema"
+	log := a.log.With("operation", op, "actor_id", actorID, "app_id", appID)
+	if schema != "" {
+		if _, err := compileMetadataSchema(schema); err != nil {
+			log.Warn("Invalid metadata schema", "msg", err.Error())
+			return fmt.Errorf("%w: %w", ErrInvalidMetadataSchema, err)
+		}
+	}
+	if err := a.appsRepo.SetMetadataSchema(ctx, appID, schema); err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("App not found")
+			return ErrAppNotFound
+		}
+		log.Error("Failed to set metadata schema", "msg", err.Error())
+		return err
+	}
+	log.Info("App metadata schema set")
+	payload := map[string]any{"removed": schema == ""}
+	if err := a.auditApp(ctx, entity.AuditAppMetadataSchemaSet, actorID, int64(appID), payload); err != nil {
+		log.Error("Failed to save audit event", "msg", err.Error())
+		return err
+	}
+	return nil
+}
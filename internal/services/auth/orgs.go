@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"sso.service/internal/entity"
+	"sso.service/internal/storage"
+)
+
+type orgsRepo interface {
+	GetMember(ctx context.Context, orgID int64, userID int64) (*entity.OrgMember, error)
+	AddMember(ctx context.Context, member *entity.OrgMember) error
+	ListMembershipsForUsers(ctx context.Context, userIDs []int64) ([]entity.OrgMember, error)
+}
+
+// activeOrg resolves the org tokens of the user for the app are issued for.
+// Apps owned by an org are always used within it, for shared apps the requested org (if any) is used.
+// The user must be a member of the resulting org, nil membership means no active org.
+func (a *AuthService) activeOrg(ctx context.Context, user *entity.User, app *entity.App, requestedOrgID int64) (*entity.OrgMember, error) {
+	orgID := requestedOrgID
+	if app.OrgID != entity.NoOrgID {
+		if requestedOrgID != entity.NoOrgID && requestedOrgID != app.OrgID {
+			a.log.Warn("Requested org doesn't own the app", "app_id", app.ID, "org_id", requestedOrgID)
+			return nil, ErrOrgMismatch
+		}
+		orgID = app.OrgID
+	}
+	if orgID == entity.NoOrgID {
+		return nil, nil
+	}
+	member, err := a.orgsRepo.GetMember(ctx, orgID, user.ID)
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			a.log.Warn("User is not a member of the org", "user_id", user.ID, "org_id", orgID)
+			return nil, ErrNotOrgMember
+		}
+		return nil, err
+	}
+	return member, nil
+}
@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+	"sso.service/internal/storage"
+	jwtLib "sso.service/pkg/jwt"
+	"sso.service/pkg/passwordpolicy"
+)
+
+// Value of the token type claim of password reset tokens
+const passwordResetTokenType = "password_reset"
+
+// PasswordPolicyError lists rules of the password policy the password violates, it matches ErrWeakPassword.
+type PasswordPolicyError struct {
+	Violations []passwordpolicy.Violation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	codes := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		codes[i] = violation.Code
+	}
+	return ErrWeakPassword.Error() + ": " + strings.Join(codes, ", ")
+}
+
+func (e *PasswordPolicyError) Unwrap() error {
+	return ErrWeakPassword
+}
+
+// checkPassword returns PasswordPolicyError if the password of the user violates the password policy.
+func (a *AuthService) checkPassword(password string, username string, email string) error {
+	if violations := a.passwordPolicy.Check(password, username, email); len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// setPassword checks the new password of the user against the policy and saves it, revoking tokens of the user.
+func (a *AuthService) setPassword(ctx context.Context, user *entity.User, newPassword string) error {
+	if err := a.checkPassword(newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+	if err := user.Password.Set(newPassword); err != nil {
+		return err
+	}
+	return a.usersRepo.SetPassword(ctx, user.ID, user.Password.Hash, user.TokenVersion)
+}
+
+// rehashPassword upgrades the outdated hash of the verified password of the user. Failures are only logged,
+// the hash is upgraded on one of the next logins then.
+func (a *AuthService) rehashPassword(ctx context.Context, user *entity.User, password string) {
+	const op = "auth.rehashPassword"
+	log := a.log.With("operation", op, "user_id", user.ID)
+	oldHash := user.Password.Hash
+	if err := user.Password.Set(password); err != nil {
+		log.Error("Error hashing password", "msg", err.Error())
+		return
+	}
+	if err := a.usersRepo.UpdatePasswordHash(ctx, user.ID, oldHash, user.Password.Hash); err != nil {
+		log.Error("Error saving upgraded password hash", "msg", err.Error())
+		return
+	}
+	log.Info("Password hash upgraded")
+}
+
+// ChangePassword replaces the password of the user on its own request, the current password must be confirmed.
+// All of the tokens of the user are revoked, the user has to log in again.
+func (a *AuthService) ChangePassword(ctx context.Context, userID int64, currentPassword string, newPassword string) error {
+	const op = "auth.ChangePassword"
+	log := a.log.With("operation", op, "user_id", userID)
+	user, err := a.usersRepo.Get(ctx, dtos.GetUserOptionsDTO{ID: userID})
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("User not found")
+			return ErrUserNotFound
+		}
+		log.Error("Error getting user", "msg", err.Error())
+		return err
+	}
+	matches, err := user.Password.Matches(currentPassword)
+	switch {
+	case err != nil:
+		log.Error("Error comparing password", "msg", err.Error())
+		return err
+	case !matches:
+		log.Warn("Wrong password")
+		return ErrInvalidCredentials
+	}
+	if err := a.setPassword(ctx, user, newPassword); err != nil {
+		return passwordChangeError(log, err)
+	}
+	log.Info("Password changed")
+	if err := a.audit(ctx, entity.AuditUserPasswordChanged, userID, userID, nil); err != nil {
+		log.Error("Failed to save audit event", "msg", err.Error())
+		return err
+	}
+	return nil
+}
+
+// NewPasswordResetToken issues a token to reset the password of the active user with the email through the app.
+// It's issued on behalf of the actor (an admin), who delivers it to the user. The token can be used only once
+// and is invalidated by any other password change or revocation of tokens of the user.
+func (a *AuthService) NewPasswordResetToken(ctx context.Context, actorID int64, email string, appID int32) (string, error) {
+	const op = "auth.NewPasswordResetToken"
+	log := a.log.With("operation", op, "actor_id", actorID, "app_id", appID)
+	isActive := true
+	user, err := a.usersRepo.Get(ctx, dtos.GetUserOptionsDTO{Email: strings.TrimSpace(email), IsActive: &isActive})
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("Active user not found", "email", email)
+			return "", ErrUserNotFound
+		}
+		log.Error("Error getting user", "msg", err.Error())
+		return "", err
+	}
+	app, err := a.appsRepo.Get(ctx, dtos.GetAppOptionsDTO{AppID: appID})
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("App not found")
+			return "", ErrAppNotFound
+		}
+		log.Error("Error getting app", "msg", err.Error())
+		return "", err
+	}
+	token, err := a.tokenProvider(app).NewToken(a.cfg.PasswordReset.TokenTTL, map[string]any{
+		"uid":                    user.ID,
+		"app_id":                 app.ID,
+		jwtLib.TokenTypeClaim:    passwordResetTokenType,
+		jwtLib.TokenVersionClaim: user.TokenVersion,
+	})
+	if err != nil {
+		log.Error("Error creating password reset token", "msg", err.Error())
+		return "", err
+	}
+	if err := a.audit(ctx, entity.AuditPasswordResetIssued, actorID, user.ID, map[string]any{"app_id": app.ID}); err != nil {
+		log.Error("Failed to save audit event", "msg", err.Error())
+		return "", err
+	}
+	return token, nil
+}
+
+// ResetPassword sets the new password of the user the reset token was issued for, revoking all of its tokens.
+func (a *AuthService) ResetPassword(ctx context.Context, token string, appID int32, newPassword string) error {
+	const op = "auth.ResetPassword"
+	log := a.log.With("operation", op, "app_id", appID)
+	app, err := a.appsRepo.Get(ctx, dtos.GetAppOptionsDTO{AppID: appID})
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("App not found")
+			return ErrAppNotFound
+		}
+		log.Error("Error getting app", "msg", err.Error())
+		return err
+	}
+	claims, err := a.tokenProvider(app).ParseClaimsFromToken(token)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) || errors.Is(err, jwt.ErrTokenMalformed) || errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			log.Warn(err.Error())
+			return ErrInvalidToken
+		}
+		log.Error("Error parsing token", "msg", err.Error())
+		return err
+	}
+	userID, _ := claims["uid"].(float64)
+	appIDFromToken, _ := claims["app_id"].(float64)
+	tokenVersion, hasVersion := claims[jwtLib.TokenVersionClaim].(float64)
+	if claims[jwtLib.TokenTypeClaim] != passwordResetTokenType || userID == 0 || !hasVersion {
+		log.Warn("Not a password reset token")
+		return ErrInvalidToken
+	}
+	if int32(appIDFromToken) != appID {
+		log.Warn("app_id mismatch", "appIDFromToken", appIDFromToken)
+		return ErrAppIdsMismatch
+	}
+	isActive := true
+	user, err := a.usersRepo.Get(ctx, dtos.GetUserOptionsDTO{ID: int64(userID), IsActive: &isActive})
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("Password reset token of unknown or inactive user", "user_id", int64(userID))
+			return ErrInvalidToken
+		}
+		log.Error("Error getting user", "msg", err.Error())
+		return err
+	}
+	if user.TokenVersion != int64(tokenVersion) {
+		log.Warn("Password reset token has been used or revoked", "user_id", user.ID)
+		return ErrInvalidToken
+	}
+	if err := a.setPassword(ctx, user, newPassword); err != nil {
+		if errors.Is(err, storage.ErrEditConflict) {
+			log.Warn("Password reset token has been used concurrently", "user_id", user.ID)
+			return ErrInvalidToken
+		}
+		return passwordChangeError(log, err)
+	}
+	log.Info("Password reset", "user_id", user.ID)
+	if err := a.audit(ctx, entity.AuditUserPasswordReset, user.ID, user.ID, map[string]any{"app_id": app.ID}); err != nil {
+		log.Error("Failed to save audit event", "msg", err.Error())
+		return err
+	}
+	return nil
+}
+
+// passwordChangeError maps errors of setting the password to the service ones.
+func passwordChangeError(log *slog.Logger, err error) error {
+	switch {
+	case errors.Is(err, ErrWeakPassword):
+		log.Warn("Password violates the policy", "msg", err.Error())
+		return err
+	case errors.Is(err, storage.ErrEditConflict):
+		log.Warn("User has been changed concurrently")
+		return ErrEditConflict
+	}
+	log.Error("Error setting password", "msg", err.Error())
+	return err
+}
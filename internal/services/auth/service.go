@@ -3,25 +3,38 @@ package auth
 import (
 	"log/slog"
 	"sso.service/internal/config"
+	"sso.service/pkg/passwordpolicy"
 )
 
 type AuthService struct {
-	log       *slog.Logger
-	usersRepo usersRepo
-	appsRepo  appsRepo
-	cfg       *config.Config
+	log             *slog.Logger
+	usersRepo       usersRepo
+	appsRepo        appsRepo
+	permissionsRepo permissionsRepo
+	orgsRepo        orgsRepo
+	auditRepo       auditRepo
+	passwordPolicy  *passwordpolicy.Policy
+	cfg             *config.Config
 }
 
 func New(
 	log *slog.Logger,
 	usersRepo usersRepo,
 	appsRepo appsRepo,
+	permissionsRepo permissionsRepo,
+	orgsRepo orgsRepo,
+	auditRepo auditRepo,
+	passwordPolicy *passwordpolicy.Policy,
 	cfg *config.Config,
 ) *AuthService {
 	return &AuthService{
 		log,
 		usersRepo,
 		appsRepo,
+		permissionsRepo,
+		orgsRepo,
+		auditRepo,
+		passwordPolicy,
 		cfg,
 	}
 }
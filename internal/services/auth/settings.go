@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"sso.service/internal/entity"
+)
+
+// orDefault returns the value set for the app or the global default if there is none.
+func orDefault[T any](value *T, fallback T) T {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
+
+func (a *AuthService) accessTokenTTL(app *entity.App) time.Duration {
+	return orDefault(app.AccessTokenTTL, a.cfg.AccessTokenTTL)
+}
+
+func (a *AuthService) refreshTokenTTL(app *entity.App) time.Duration {
+	return orDefault(app.RefreshTokenTTL, a.cfg.RefreshTokenTTL)
+}
+
+func (a *AuthService) activationTokenTTL(app *entity.App) time.Duration {
+	return orDefault(app.ActivationTokenTTL, a.cfg.ActivationTokenTTL)
+}
+
+func (a *AuthService) selfRegistration(app *entity.App) bool {
+	return orDefault(app.SelfRegistration, a.cfg.Registration.SelfRegistration)
+}
+
+func (a *AuthService) requireActivation(app *entity.App) bool {
+	return orDefault(app.RequireActivation, a.cfg.Registration.RequireActivation)
+}
+
+// validateAppSettings checks the settings listed in fields.
+func validateAppSettings(settings *entity.AppSettings, fields []string) error {
+	ttls := map[string]*time.Duration{
+		"access_token_ttl":     settings.AccessTokenTTL,
+		"refresh_token_ttl":    settings.RefreshTokenTTL,
+		"activation_token_ttl": settings.ActivationTokenTTL,
+	}
+	for _, field := range fields {
+		switch field {
+		case "access_token_ttl", "refresh_token_ttl", "activation_token_ttl":
+			if ttl := ttls[field]; ttl != nil && *ttl < time.Second {
+				return fmt.Errorf("%w: %s must be at least 1s", ErrInvalidAppSettings, field)
+			}
+		case "login_methods":
+			for _, method := range settings.LoginMethods {
+				if !slices.Contains(entity.LoginMethods, method) {
+					return fmt.Errorf("%w: unknown login method %q", ErrInvalidAppSettings, method)
+				}
+			}
+		case "self_registration", "require_activation":
+		default:
+			return fmt.Errorf("%w: unknown setting %q", ErrInvalidAppSettings, field)
+		}
+	}
+	return nil
+}
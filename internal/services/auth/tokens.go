@@ -6,11 +6,20 @@ import (
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
+	"sso.service/internal/entity"
 	"sso.service/internal/services/dtos"
 	"sso.service/internal/storage"
 	jwtLib "sso.service/pkg/jwt"
 )
 
+// Type of tokens activating users, they're never accepted in place of access or refresh tokens
+const activationTokenType = "activation"
+
+// tokenProvider signs tokens with the current secret of the app and verifies them with any of its secrets.
+func (a *AuthService) tokenProvider(app *entity.App) *jwtLib.TokenProvider {
+	return jwtLib.NewTokenProvider(app.Secret, a.cfg.TokenSigningAlg, app.VerificationSecrets()...)
+}
+
 func (a *AuthService) RenewAccessToken(ctx context.Context, refreshToken string, appId int32) (string, error) {
 	const op = "auth.GetAccessToken"
 	log := a.log.With("operation", op)
@@ -23,12 +32,17 @@ func (a *AuthService) RenewAccessToken(ctx context.Context, refreshToken string,
 		log.Error("Error getting app", "msg", err.Error())
 		return "", err
 	}
-	tokenProvider := jwtLib.NewTokenProvider(app.Secret, a.cfg.TokenSigningAlg)
+	tokenProvider := a.tokenProvider(app)
 	claims, err := tokenProvider.ParseClaimsFromToken(refreshToken)
 	if err != nil {
 		log.Error("Error parsing refresh token", "msg", err.Error())
 		return "", err
 	}
+	// untyped tokens are rejected too, activation tokens used to carry no type
+	if tokenType := claims[jwtLib.TokenTypeClaim]; tokenType != jwtLib.TokenTypeRefresh {
+		log.Warn("Not a refresh token", "type", tokenType)
+		return "", ErrInvalidToken
+	}
 	userID := int64(claims["uid"].(float64))
 	user, err := a.usersRepo.Get(ctx, dtos.GetUserOptionsDTO{ID: userID})
 	if err != nil {
@@ -39,7 +53,29 @@ func (a *AuthService) RenewAccessToken(ctx context.Context, refreshToken string,
 		log.Error("Error getting user", "msg", err.Error())
 		return "", err
 	}
-	return tokenProvider.NewToken(a.cfg.AccessTokenTTL, map[string]any{"uid": user.ID, "app_id": app.ID})
+	if !user.IsActive {
+		log.Warn("User is inactive", "user_id", userID)
+		return "", ErrInvalidToken
+	}
+	if jwtLib.TokenVersionFromClaims(claims) != user.TokenVersion {
+		log.Warn("Refresh token was revoked", "user_id", userID)
+		return "", ErrInvalidToken
+	}
+	// membership is checked again, users removed from the org can't renew tokens anymore
+	orgID, _ := jwtLib.OrgIDFromClaims(claims)
+	org, err := a.activeOrg(ctx, user, app, orgID)
+	if err != nil {
+		if !errors.Is(err, ErrOrgMismatch) && !errors.Is(err, ErrNotOrgMember) {
+			log.Error("Error getting org membership", "msg", err.Error())
+		}
+		return "", err
+	}
+	accessToken, err := a.newAccessToken(ctx, tokenProvider, user, app, org)
+	if err != nil {
+		log.Error("Error creating access token", "msg", err.Error())
+		return "", err
+	}
+	return accessToken, nil
 }
 
 func (a *AuthService) NewActivationToken(ctx context.Context, email string, appID int32) (string, error) {
@@ -54,6 +90,10 @@ func (a *AuthService) NewActivationToken(ctx context.Context, email string, appI
 		}
 		return "", err
 	}
+	if user.DeactivatedAt != nil {
+		log.Warn("User is deactivated", "email", email)
+		return "", ErrUserDeactivated
+	}
 	if user.IsActive {
 		log.Warn("User already active", "email", email)
 		return "", ErrUserAlreadyActivated
@@ -67,8 +107,8 @@ func (a *AuthService) NewActivationToken(ctx context.Context, email string, appI
 		log.Error("Error getting app", "msg", err.Error())
 		return "", err
 	}
-	tokenProvider := jwtLib.NewTokenProvider(app.Secret, a.cfg.TokenSigningAlg)
-	token, err := tokenProvider.NewToken(a.cfg.ActivationTokenTTL, map[string]any{"uid": user.ID, "app_id": app.ID})
+	tokenProvider := a.tokenProvider(app)
+	token, err := tokenProvider.NewToken(a.activationTokenTTL(app), activationTokenClaims(user.ID, app.ID))
 	if err != nil {
 		log.Error("Error creating activation token", "msg", err.Error())
 		return "", err
@@ -76,6 +116,10 @@ func (a *AuthService) NewActivationToken(ctx context.Context, email string, appI
 	return token, nil
 }
 
+func activationTokenClaims(userID int64, appID int64) map[string]any {
+	return map[string]any{"uid": userID, "app_id": appID, jwtLib.TokenTypeClaim: activationTokenType}
+}
+
 func (a *AuthService) VerifyToken(ctx context.Context, appID int32, token string) error {
 	const op = "auth.VerifyToken"
 	log := a.log.With("operation", op)
@@ -88,8 +132,8 @@ func (a *AuthService) VerifyToken(ctx context.Context, appID int32, token string
 		log.Error("Error getting app", "msg", err.Error())
 		return err
 	}
-	tokenProvider := jwtLib.NewTokenProvider(app.Secret, a.cfg.TokenSigningAlg)
-	_, err = tokenProvider.ParseClaimsFromToken(token)
+	tokenProvider := a.tokenProvider(app)
+	claims, err := tokenProvider.ParseClaimsFromToken(token)
 	if err != nil {
 		switch {
 		case errors.Is(err, jwt.ErrTokenExpired) || errors.Is(err, jwt.ErrTokenMalformed):
@@ -100,6 +144,76 @@ func (a *AuthService) VerifyToken(ctx context.Context, appID int32, token string
 			return err
 		}
 	}
+	if userID, ok := claims["uid"].(float64); ok && userID > 0 {
+		user, err := a.usersRepo.Get(ctx, dtos.GetUserOptionsDTO{ID: int64(userID)})
+		if err != nil {
+			if errors.Is(err, storage.ErrRecordNotFound) {
+				log.Warn("Token of unknown user", "user_id", userID)
+				return ErrInvalidToken
+			}
+			log.Error("Error getting user", "msg", err.Error())
+			return err
+		}
+		if jwtLib.TokenVersionFromClaims(claims) != user.TokenVersion {
+			log.Warn("Token was revoked", "user_id", userID)
+			return ErrInvalidToken
+		}
+	}
 	return nil
 }
 
+// AuthenticateAccessToken returns the active user the access token was issued to.
+// The token must be signed by the app it was issued for and must not be revoked.
+func (a *AuthService) AuthenticateAccessToken(ctx context.Context, token string) (*entity.User, error) {
+	const op = "auth.AuthenticateAccessToken"
+	log := a.log.With("operation", op)
+	unverified, err := jwtLib.UnverifiedClaims(token)
+	if err != nil {
+		log.Warn("Malformed access token", "msg", err.Error())
+		return nil, ErrInvalidToken
+	}
+	appID, _ := unverified["app_id"].(float64)
+	if appID <= 0 {
+		log.Warn("Access token without app")
+		return nil, ErrInvalidToken
+	}
+	app, err := a.appsRepo.Get(ctx, dtos.GetAppOptionsDTO{AppID: int32(appID)})
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("Access token of unknown app", "app_id", appID)
+			return nil, ErrInvalidToken
+		}
+		log.Error("Error getting app", "msg", err.Error())
+		return nil, err
+	}
+	claims, err := a.tokenProvider(app).ParseClaimsFromToken(token)
+	if err != nil {
+		log.Warn("Invalid access token", "msg", err.Error())
+		return nil, ErrInvalidToken
+	}
+	if claims[jwtLib.TokenTypeClaim] != jwtLib.TokenTypeAccess {
+		log.Warn("Not an access token", "type", claims[jwtLib.TokenTypeClaim])
+		return nil, ErrInvalidToken
+	}
+	userID, _ := claims["uid"].(float64)
+	if userID <= 0 {
+		log.Warn("Access token without user")
+		return nil, ErrInvalidToken
+	}
+	isActive := true
+	user, err := a.usersRepo.Get(ctx, dtos.GetUserOptionsDTO{ID: int64(userID), IsActive: &isActive})
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("Access token of unknown or inactive user", "user_id", userID)
+			return nil, ErrInvalidToken
+		}
+		log.Error("Error getting user", "msg", err.Error())
+		return nil, err
+	}
+	if jwtLib.TokenVersionFromClaims(claims) != user.TokenVersion {
+		log.Warn("Access token was revoked", "user_id", user.ID)
+		return nil, ErrInvalidToken
+	}
+	return user, nil
+}
+
@@ -3,12 +3,15 @@ package auth
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"sso.service/internal/entity"
 	"sso.service/internal/services/dtos"
 	"sso.service/internal/storage"
 	jwtLib "sso.service/pkg/jwt"
+	"sso.service/pkg/permcode"
 )
 
 type usersRepo interface {
@@ -16,7 +19,29 @@ type usersRepo interface {
 	GetForToken(ctx context.Context, tokenScope string, plainToken string) (*entity.User, error)
 	IsAdmin(ctx context.Context, userID int64) (bool, error)
 	Create(ctx context.Context, user *entity.User) (int64, error)
-	Update(ctx context.Context, user *entity.User) (*entity.User, error)
+	SetPassword(ctx context.Context, userID int64, hash []byte, tokenVersion int64) error
+	UpdatePasswordHash(ctx context.Context, userID int64, oldHash []byte, newHash []byte) error
+	AddApp(ctx context.Context, userID int64, appID int32) error
+	RecordAppLogin(ctx context.Context, userID int64, appID int32) error
+	Patch(
+		ctx context.Context,
+		params dtos.UpdateUserDTO,
+		audit func(previous *entity.User, updated *entity.User) *entity.AuditEvent,
+	) (*entity.User, *entity.User, error)
+	List(ctx context.Context, options dtos.ListUsersOptionsDTO) ([]entity.User, error)
+	Import(ctx context.Context, user *entity.User, appID int32, permissionCodes []string, dryRun bool) (int64, error)
+	Delete(
+		ctx context.Context,
+		userID int64,
+		expectedUpdatedAt *time.Time,
+		audit func(deleted *entity.User) *entity.AuditEvent,
+	) (*entity.User, error)
+	Restore(ctx context.Context, userID int64, deletedAfter time.Time) (*entity.User, error)
+	ListErasable(ctx context.Context, deletedBefore time.Time, limit int) ([]int64, error)
+	Erase(ctx context.Context, userID int64, deletedBefore time.Time) (*entity.ErasureReport, error)
+	GetErasureReport(ctx context.Context, userID int64) (*entity.ErasureReport, error)
+	GetMetadata(ctx context.Context, userID int64, appID int32) (*entity.UserMetadata, error)
+	UpdateMetadata(ctx context.Context, userID int64, appID int32, update func(metadata *entity.UserMetadata) error) (*entity.UserMetadata, error)
 }
 
 func (a *AuthService) Login(
@@ -24,6 +49,7 @@ func (a *AuthService) Login(
 	email string,
 	password string,
 	appId int32,
+	orgID int64,
 ) (*dtos.AuthTokens, error) {
 	const op = "auth.Login"
 	log := a.log.With("operation", op)
@@ -47,6 +73,9 @@ func (a *AuthService) Login(
 		log.Warn("Wrong password", "email", email)
 		return nil, ErrInvalidCredentials
 	}
+	if user.Password.NeedsRehash() {
+		a.rehashPassword(ctx, user, password)
+	}
 	app, err := a.appsRepo.Get(ctx, dtos.GetAppOptionsDTO{AppID: appId})
 	if err != nil {
 		if errors.Is(err, storage.ErrRecordNotFound) {
@@ -56,14 +85,28 @@ func (a *AuthService) Login(
 		log.Error("Error getting app", "msg", err.Error())
 		return nil, err
 	}
-	tokenProvider := jwtLib.NewTokenProvider(app.Secret, a.cfg.TokenSigningAlg)
-	tokenPayload := map[string]any{"uid": user.ID, "app_id": app.ID}
-	accessToken, err := tokenProvider.NewToken(a.cfg.AccessTokenTTL, tokenPayload)
+	if !app.AllowsLoginMethod(entity.LoginMethodPassword) {
+		log.Warn("Login method is not allowed for the app", "app_id", appId, "method", entity.LoginMethodPassword)
+		return nil, ErrLoginMethodNotAllowed
+	}
+	org, err := a.activeOrg(ctx, user, app, orgID)
+	if err != nil {
+		if !errors.Is(err, ErrOrgMismatch) && !errors.Is(err, ErrNotOrgMember) {
+			log.Error("Error getting org membership", "msg", err.Error())
+		}
+		return nil, err
+	}
+	if err := a.usersRepo.RecordAppLogin(ctx, user.ID, appId); err != nil {
+		log.Error("Error recording login to the app", "msg", err.Error())
+		return nil, err
+	}
+	tokenProvider := a.tokenProvider(app)
+	accessToken, err := a.newAccessToken(ctx, tokenProvider, user, app, org)
 	if err != nil {
 		log.Error("Error creating access token", "msg", err.Error())
 		return nil, err
 	}
-	refreshToken, err := tokenProvider.NewToken(a.cfg.RefreshTokenTTL, tokenPayload)
+	refreshToken, err := tokenProvider.NewToken(a.refreshTokenTTL(app), tokenClaims(user, app, org, jwtLib.TokenTypeRefresh))
 	if err != nil {
 		log.Error("Error creating refresh token", "msg", err.Error())
 		return nil, err
@@ -72,14 +115,35 @@ func (a *AuthService) Login(
 
 }
 
+// Register creates the user within the app. Users of apps requiring activation are created inactive
+// and the activation token is returned, the token is empty otherwise.
 func (a *AuthService) Register(ctx context.Context, username string, plainPassword string, email string, appID int32) (*dtos.UserIDAndToken, error) {
 	const op = "auth.Register"
 	log := a.log.With("operation", op)
+	app, err := a.appsRepo.Get(ctx, dtos.GetAppOptionsDTO{AppID: appID})
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("App not found", "app_id", appID)
+			return nil, ErrAppNotFound
+		}
+		log.Error("Error getting app", "msg", err.Error())
+		return nil, err
+	}
+	if !a.selfRegistration(app) {
+		log.Warn("Self-registration is closed for the app", "app_id", appID)
+		return nil, ErrRegistrationClosed
+	}
+	if err := a.checkPassword(plainPassword, username, email); err != nil {
+		log.Warn("Password violates the policy", "msg", err.Error())
+		return nil, err
+	}
+	requireActivation := a.requireActivation(app)
 	user := entity.User{
 		Username: username,
 		Email:    email,
+		IsActive: !requireActivation,
 	}
-	err := user.Password.Set(plainPassword)
+	err = user.Password.Set(plainPassword)
 	if err != nil {
 		log.Error("Error setting password", "msg", err.Error())
 		return nil, err
@@ -94,19 +158,24 @@ func (a *AuthService) Register(ctx context.Context, username string, plainPasswo
 		return nil, err
 	}
 	log.Info("User saved", "id", userID)
-	app, err := a.appsRepo.Get(ctx, dtos.GetAppOptionsDTO{AppID: appID})
-	if err != nil {
-		if errors.Is(err, storage.ErrRecordNotFound) {
-			log.Warn("App not found", "app_id", appID)
-			return nil, ErrAppNotFound
-		}
-		log.Error("Error getting app", "msg", err.Error())
+	if err := a.usersRepo.AddApp(ctx, userID, appID); err != nil {
+		log.Error("Error adding app to the user's ones", "msg", err.Error())
 		return nil, err
 	}
+	// users registered through an app of the org join the org
+	if app.OrgID != entity.NoOrgID {
+		member := entity.OrgMember{OrgID: app.OrgID, UserID: userID, Role: entity.DefaultUserRole}
+		if err := a.orgsRepo.AddMember(ctx, &member); err != nil {
+			log.Error("Error adding user to the org", "org_id", app.OrgID, "msg", err.Error())
+			return nil, err
+		}
+	}
+	if !requireActivation {
+		return &dtos.UserIDAndToken{UserID: userID}, nil
+	}
 	log.Info("Creating activation token", "userID", userID)
-	tokenProvider := jwtLib.NewTokenProvider(app.Secret, a.cfg.TokenSigningAlg)
-	claims := map[string]any{"uid": userID, "app_id": appID}
-	token, err := tokenProvider.NewToken(a.cfg.ActivationTokenTTL, claims)
+	tokenProvider := a.tokenProvider(app)
+	token, err := tokenProvider.NewToken(a.activationTokenTTL(app), activationTokenClaims(userID, int64(appID)))
 	if err != nil {
 		log.Error("Error creating activation token", "msg", err.Error())
 		return nil, err
@@ -147,6 +216,27 @@ func (a *AuthService) GetUser(ctx context.Context, params dtos.GetUserOptionsDTO
 	return user, nil
 }
 
+// ListUsers returns a page of users matching the options.
+func (a *AuthService) ListUsers(ctx context.Context, options dtos.ListUsersOptionsDTO) ([]entity.User, error) {
+	const op = "auth.ListUsers"
+	log := a.log.With("operation", op)
+	if options.Permission != nil {
+		if err := permcode.Validate(options.Permission.Code); err != nil {
+			log.Warn("Invalid permission code", "msg", err.Error())
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidPermissionCode, options.Permission.Code, err)
+		}
+		permission := *options.Permission
+		permission.Candidates = permcode.Candidates(permission.Code)
+		options.Permission = &permission
+	}
+	users, err := a.usersRepo.List(ctx, options)
+	if err != nil {
+		log.Error("Failed to list users", "msg", err.Error())
+		return nil, err
+	}
+	return users, nil
+}
+
 func (a *AuthService) ActivateUser(ctx context.Context, token string, appID int32) (*entity.User, error) {
 	const op = "auth.ActivateUser"
 	log := a.log.With("operation", op, "token", token, "appID", appID)
@@ -159,7 +249,7 @@ func (a *AuthService) ActivateUser(ctx context.Context, token string, appID int3
 		log.Error("Error getting app", "msg", err.Error())
 		return nil, err
 	}
-	tokenProvider := jwtLib.NewTokenProvider(app.Secret, a.cfg.TokenSigningAlg)
+	tokenProvider := a.tokenProvider(app)
 	claims, err := tokenProvider.ParseClaimsFromToken(token)
 	if err != nil {
 		switch {
@@ -171,6 +261,11 @@ func (a *AuthService) ActivateUser(ctx context.Context, token string, appID int3
 			return nil, err
 		}
 	}
+	// activation tokens issued before token types were introduced carry no type
+	if tokenType, ok := claims[jwtLib.TokenTypeClaim]; ok && tokenType != activationTokenType {
+		log.Warn("Not an activation token", "type", tokenType)
+		return nil, ErrInvalidToken
+	}
 	userID := int64(claims["uid"].(float64))
 	appIDFromToken := int32(claims["app_id"].(float64))
 	if userID == 0 || appIDFromToken == 0 {
@@ -190,15 +285,24 @@ func (a *AuthService) ActivateUser(ctx context.Context, token string, appID int3
 		log.Error("Error getting user for token", "msg", err.Error())
 		return nil, err
 	}
+	if user.DeactivatedAt != nil {
+		log.Warn("User is deactivated", "email", user.Email)
+		return nil, ErrUserDeactivated
+	}
 	if user.IsActive {
 		log.Warn("User already active", "email", user.Email)
 		return nil, ErrUserAlreadyActivated
 	}
-	user.IsActive = true
-	user, err = a.usersRepo.Update(ctx, user)
+	// the version check fails the activation if the user is deactivated in the meantime
+	isActive := true
+	_, updated, err := a.usersRepo.Patch(ctx, dtos.UpdateUserDTO{
+		ID:                user.ID,
+		IsActive:          &isActive,
+		ExpectedUpdatedAt: &user.UpdatedAt,
+	}, nil)
 	if err != nil {
-		log.Error("Error updating user", "msg", err.Error())
-		return nil, err
+		return nil, userChangeError(log, err)
 	}
-	return user, nil
+	log.Info("User activated", "user_id", user.ID)
+	return updated, nil
 }
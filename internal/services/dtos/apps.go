@@ -1,5 +1,7 @@
 package dtos
 
+import "sso.service/internal/entity"
+
 type GetOrCreateAppDTO struct {
 	AppID     int64
 	IsCreated bool
@@ -9,3 +11,15 @@ type GetAppOptionsDTO struct {
 	AppID   int32
 	AppName string
 }
+
+// UpdateAppDTO holds new values of the app fields, nil fields are left unchanged.
+type UpdateAppDTO struct {
+	ID          int32
+	Name        *string
+	Description *string
+	// Whether role and permissions of users are embedded in access tokens issued for the app
+	EmbedPermissions *bool
+	// Auth settings replacing those of the app listed in SettingsFields (by column name), nil ones fall back to the config
+	Settings       entity.AppSettings
+	SettingsFields []string
+}
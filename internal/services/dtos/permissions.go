@@ -1,11 +1,25 @@
 package dtos
 
+import "time"
+
 type GetPermissionOptionsDTO struct {
-	Code string
-	ID   int64
+	Code  string
+	ID    int64
+	AppID int32
 }
 
 type FetchManyPermissionsOptionsDTO struct {
 	Ids   []int
 	Codes []string
 }
+
+type PermissionGrantDTO struct {
+	Code      string
+	ExpiresAt *time.Time // nil means permanent grant
+}
+
+type UserPermissionCheckDTO struct {
+	UserID        int64
+	UserFound     bool
+	HasPermission bool
+}
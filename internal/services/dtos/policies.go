@@ -0,0 +1,29 @@
+package dtos
+
+import "sso.service/internal/entity"
+
+type AuthorizeDTO struct {
+	SubjectID          int64
+	AppID              int32
+	Action             string
+	ResourceType       string
+	ResourceID         string
+	ResourceAttributes map[string]any
+	// Attributes of the subject known to the caller, stored ones (id, role, ...) take precedence
+	SubjectAttributes map[string]any
+	Environment       map[string]any
+}
+
+type PolicyEvaluationDTO struct {
+	Policy  entity.Policy
+	Matched bool
+	// Reason why condition of the policy couldn't be evaluated, such policy doesn't match
+	Error string
+}
+
+type AuthorizationDecisionDTO struct {
+	Allowed bool
+	// Policy which made the decision, nil if no policy matched and access is denied by default
+	Policy      *entity.Policy
+	Evaluations []PolicyEvaluationDTO
+}
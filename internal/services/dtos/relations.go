@@ -0,0 +1,21 @@
+package dtos
+
+// RelationTuplesFilterDTO selects tuples of the namespace, empty fields match any value.
+type RelationTuplesFilterDTO struct {
+	// Tuples of the org and the ones effective in every org are selected, the latter only for entity.NoOrgID
+	OrgID         int64
+	Namespace     string
+	ObjectID      string
+	Relation      string
+	SubjectUserID int64
+	// Non nil value selects tuples with userset subjects only, its empty fields match any value
+	SubjectSet *UsersetFilterDTO
+	AfterID    int64
+	Limit      int
+}
+
+type UsersetFilterDTO struct {
+	Namespace string
+	ObjectID  string
+	Relation  string
+}
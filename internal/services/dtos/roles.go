@@ -0,0 +1,12 @@
+package dtos
+
+type GetRoleOptionsDTO struct {
+	ID   int64
+	Name string
+}
+
+type UpdateRoleDTO struct {
+	ID          int64
+	Name        *string // nil means "leave unchanged"
+	Description *string
+}
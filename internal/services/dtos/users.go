@@ -0,0 +1,95 @@
+package dtos
+
+import (
+	"time"
+
+	"sso.service/internal/entity"
+)
+
+type UsersSortField string
+
+const (
+	UsersSortByCreatedAt UsersSortField = "created_at"
+	UsersSortByUpdatedAt UsersSortField = "updated_at"
+)
+
+// ListUsersOptionsDTO filters users, zero fields match any user.
+type ListUsersOptionsDTO struct {
+	// Fuzzy matched against username and email
+	Search        string
+	Role          entity.Role
+	IsActive      *bool
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// Users who registered with or logged in to the app
+	AppID int32
+	// Users granted the permission, see UserPermissionFilterDTO
+	Permission *UserPermissionFilterDTO
+	SortBy     UsersSortField
+	Descending bool
+	// Users following the cursor in the sort order
+	After *UsersCursorDTO
+	Limit int
+	// Whether password hashes are loaded, for exports only
+	WithPasswordHash bool
+}
+
+// UserPermissionFilterDTO selects users the permission is granted to in the namespace of the app
+// or in the global one (entity.GlobalAppID), within the org owning the app.
+type UserPermissionFilterDTO struct {
+	AppID int32
+	Code  string
+	// Patterns covering the code, filled in by the service
+	Candidates []string
+}
+
+// UsersCursorDTO points to the last user of a page: its value of the sort field and id.
+type UsersCursorDTO struct {
+	SortValue time.Time
+	ID        int64
+}
+
+// UpdateUserDTO changes the user, nil fields are left unchanged.
+type UpdateUserDTO struct {
+	ID       int64
+	Username *string
+	Email    *string
+	Role     *entity.Role
+	IsActive *bool
+	// Version of the user (its updated_at) the change is based on, nil skips the check
+	ExpectedUpdatedAt *time.Time
+}
+
+// PatchUserMetadataDTO holds JSON merge patches (RFC 7396) of metadata areas of the user, nil patches are skipped.
+type PatchUserMetadataDTO struct {
+	UserID int64
+	// App whose private metadata is patched, required if App is set
+	AppID  int32
+	Public map[string]any
+	App    map[string]any
+	Admin  map[string]any
+}
+
+// ImportUserDTO is a user migrated from another system along with its password hash,
+// which must be in one of the formats known to the password hasher.
+type ImportUserDTO struct {
+	Username string      `json:"username"`
+	Email    string      `json:"email"`
+	Role     entity.Role `json:"role"`
+	IsActive bool        `json:"is_active"`
+	// App the user is registered with and permissions are granted in, 0 for global permissions only
+	AppID        int32    `json:"app_id"`
+	Permissions  []string `json:"permissions"`
+	PasswordHash string   `json:"password_hash"`
+}
+
+// ExportUsersOptionsDTO selects users to export and what's exported along with them.
+// Limit and After of the filter are ignored, users are read in batches of BatchSize.
+type ExportUsersOptionsDTO struct {
+	Filter          ListUsersOptionsDTO
+	WithPermissions bool
+	WithOrgRoles    bool
+	// Password hashes are exported to admins only, for backups
+	WithPasswordHashes bool
+	BatchSize          int
+}
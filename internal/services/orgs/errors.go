@@ -0,0 +1,13 @@
+package orgs
+
+import "errors"
+
+var (
+	ErrOrgNotFound      = errors.New("org not found")
+	ErrOrgAlreadyExists = errors.New("org with this name already exists")
+	ErrOrgInUse         = errors.New("org still owns apps")
+	ErrUserNotFound     = errors.New("Related user not found")
+	ErrRoleNotFound     = errors.New("role not found")
+	ErrMemberNotFound   = errors.New("user is not a member of the org")
+	ErrAlreadyOrgMember = errors.New("user is already a member of the org")
+)
@@ -0,0 +1,191 @@
+package orgs
+
+import (
+	"context"
+	"errors"
+
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+	"sso.service/internal/storage"
+)
+
+func (o *OrgsService) CreateOrg(ctx context.Context, org *entity.Org) (*entity.Org, error) {
+	const op = "orgs.CreateOrg"
+	log := o.log.With("operation", op, "name", org.Name)
+	orgID, err := o.orgsRepo.Create(ctx, org)
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordAlreadyExists) {
+			log.Warn("Org already exists")
+			return nil, ErrOrgAlreadyExists
+		}
+		log.Error("Failed to create org", "msg", err.Error())
+		return nil, err
+	}
+	log.Info("Org created", "id", orgID)
+	return o.GetOrg(ctx, orgID)
+}
+
+func (o *OrgsService) GetOrg(ctx context.Context, orgID int64) (*entity.Org, error) {
+	const op = "orgs.GetOrg"
+	log := o.log.With("operation", op, "id", orgID)
+	org, err := o.orgsRepo.Get(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("Org not found")
+			return nil, ErrOrgNotFound
+		}
+		log.Error("Failed to get org", "msg", err.Error())
+		return nil, err
+	}
+	return org, nil
+}
+
+// ListUserOrgs returns orgs the user is a member of.
+func (o *OrgsService) ListUserOrgs(ctx context.Context, userID int64) ([]entity.Org, error) {
+	const op = "orgs.ListUserOrgs"
+	log := o.log.With("operation", op, "user_id", userID)
+	userOrgs, err := o.orgsRepo.ListForUser(ctx, userID)
+	if err != nil {
+		log.Error("Failed to list orgs", "msg", err.Error())
+		return nil, err
+	}
+	return userOrgs, nil
+}
+
+func (o *OrgsService) DeleteOrg(ctx context.Context, orgID int64) error {
+	const op = "orgs.DeleteOrg"
+	log := o.log.With("operation", op, "id", orgID)
+	if err := o.orgsRepo.Delete(ctx, orgID); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrRecordNotFound):
+			log.Warn("Org not found")
+			return ErrOrgNotFound
+		case errors.Is(err, storage.ErrRecordInUse):
+			log.Warn("Org still owns apps")
+			return ErrOrgInUse
+		}
+		log.Error("Failed to delete org", "msg", err.Error())
+		return err
+	}
+	log.Info("Org deleted")
+	return nil
+}
+
+// AddMember adds the user to the org with the role, entity.DefaultUserRole if role is empty.
+func (o *OrgsService) AddMember(ctx context.Context, orgID int64, userID int64, role string) (*entity.OrgMember, error) {
+	const op = "orgs.AddMember"
+	if role == "" {
+		role = entity.DefaultUserRole
+	}
+	log := o.log.With("operation", op, "id", orgID, "user_id", userID, "role", role)
+	if _, err := o.GetOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	if err := o.checkRole(ctx, orgID, role); err != nil {
+		return nil, err
+	}
+	err := o.orgsRepo.AddMember(ctx, &entity.OrgMember{OrgID: orgID, UserID: userID, Role: role})
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrRecordAlreadyExists):
+			log.Warn("User is already a member of the org")
+			return nil, ErrAlreadyOrgMember
+		case errors.Is(err, storage.ErrRecordNotFound):
+			log.Warn("User not found")
+			return nil, ErrUserNotFound
+		case errors.Is(err, storage.ErrInvalidReference):
+			log.Warn("Role belongs to another org")
+			return nil, ErrRoleNotFound
+		}
+		log.Error("Failed to add org member", "msg", err.Error())
+		return nil, err
+	}
+	log.Info("Org member added")
+	return o.GetMember(ctx, orgID, userID)
+}
+
+func (o *OrgsService) GetMember(ctx context.Context, orgID int64, userID int64) (*entity.OrgMember, error) {
+	const op = "orgs.GetMember"
+	log := o.log.With("operation", op, "id", orgID, "user_id", userID)
+	member, err := o.orgsRepo.GetMember(ctx, orgID, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("Org member not found")
+			return nil, ErrMemberNotFound
+		}
+		log.Error("Failed to get org member", "msg", err.Error())
+		return nil, err
+	}
+	return member, nil
+}
+
+func (o *OrgsService) UpdateMemberRole(ctx context.Context, orgID int64, userID int64, role string) (*entity.OrgMember, error) {
+	const op = "orgs.UpdateMemberRole"
+	log := o.log.With("operation", op, "id", orgID, "user_id", userID, "role", role)
+	if err := o.checkRole(ctx, orgID, role); err != nil {
+		return nil, err
+	}
+	member, err := o.orgsRepo.UpdateMemberRole(ctx, orgID, userID, role)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrRecordNotFound):
+			log.Warn("Org member not found")
+			return nil, ErrMemberNotFound
+		case errors.Is(err, storage.ErrInvalidReference):
+			log.Warn("Role belongs to another org")
+			return nil, ErrRoleNotFound
+		}
+		log.Error("Failed to update org member role", "msg", err.Error())
+		return nil, err
+	}
+	log.Info("Org member role updated")
+	return member, nil
+}
+
+// RemoveMember removes the user from the org, permissions granted to the user within the org are revoked.
+func (o *OrgsService) RemoveMember(ctx context.Context, orgID int64, userID int64) error {
+	const op = "orgs.RemoveMember"
+	log := o.log.With("operation", op, "id", orgID, "user_id", userID)
+	if err := o.orgsRepo.RemoveMember(ctx, orgID, userID); err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("Org member not found")
+			return ErrMemberNotFound
+		}
+		log.Error("Failed to remove org member", "msg", err.Error())
+		return err
+	}
+	log.Info("Org member removed")
+	return nil
+}
+
+func (o *OrgsService) ListMembers(ctx context.Context, orgID int64) ([]entity.OrgMember, error) {
+	const op = "orgs.ListMembers"
+	log := o.log.With("operation", op, "id", orgID)
+	if _, err := o.GetOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	members, err := o.orgsRepo.ListMembers(ctx, orgID)
+	if err != nil {
+		log.Error("Failed to list org members", "msg", err.Error())
+		return nil, err
+	}
+	return members, nil
+}
+
+// checkRole makes sure the role exists and can be assigned within the org: it's global or belongs to the org.
+func (o *OrgsService) checkRole(ctx context.Context, orgID int64, role string) error {
+	details, err := o.rolesRepo.Get(ctx, dtos.GetRoleOptionsDTO{Name: role})
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			o.log.Warn("Role not found", "role", role)
+			return ErrRoleNotFound
+		}
+		o.log.Error("Failed to get role", "role", role, "msg", err.Error())
+		return err
+	}
+	if details.OrgID != entity.NoOrgID && details.OrgID != orgID {
+		o.log.Warn("Role belongs to another org", "role", role, "role_org_id", details.OrgID)
+		return ErrRoleNotFound
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package orgs
+
+import (
+	"context"
+	"log/slog"
+
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+)
+
+type orgsRepo interface {
+	Create(ctx context.Context, org *entity.Org) (int64, error)
+	Get(ctx context.Context, orgID int64) (*entity.Org, error)
+	ListForUser(ctx context.Context, userID int64) ([]entity.Org, error)
+	Delete(ctx context.Context, orgID int64) error
+	AddMember(ctx context.Context, member *entity.OrgMember) error
+	GetMember(ctx context.Context, orgID int64, userID int64) (*entity.OrgMember, error)
+	UpdateMemberRole(ctx context.Context, orgID int64, userID int64, role string) (*entity.OrgMember, error)
+	RemoveMember(ctx context.Context, orgID int64, userID int64) error
+	ListMembers(ctx context.Context, orgID int64) ([]entity.OrgMember, error)
+}
+
+type rolesRepo interface {
+	Get(ctx context.Context, params dtos.GetRoleOptionsDTO) (*entity.RoleDetails, error)
+}
+
+type OrgsService struct {
+	log       *slog.Logger
+	orgsRepo  orgsRepo
+	rolesRepo rolesRepo
+}
+
+func New(log *slog.Logger, orgsRepo orgsRepo, rolesRepo rolesRepo) *OrgsService {
+	return &OrgsService{
+		log:       log,
+		orgsRepo:  orgsRepo,
+		rolesRepo: rolesRepo,
+	}
+}
@@ -0,0 +1,146 @@
+package permissions
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"sso.service/internal/entity"
+	"sso.service/pkg/ttlcache"
+)
+
+const (
+	// Channel notified by database triggers whenever permissions of a user change.
+	// Payload is id of the user or invalidateAllPayload when permissions of many users changed.
+	invalidationChannel  = "permissions_invalidation"
+	invalidateAllPayload = "*"
+
+	listenerRetryDelay = 5 * time.Second
+)
+
+type cacheKey struct {
+	userID int64
+	appID  int32
+}
+
+type invalidationListener interface {
+	Listen(ctx context.Context, channel string, listening func(), handle func(payload string)) error
+}
+
+// userPermissions returns permissions granted to the user in the namespace of the app and the global one
+// within the org owning the app, served from cache when it's enabled.
+func (a *PermissionsService) userPermissions(ctx context.Context, userID int64, appID int32) ([]entity.Permission, error) {
+	key := cacheKey{userID: userID, appID: appID}
+	if a.cache != nil {
+		if perms, ok := a.cache.Get(key); ok {
+			return perms, nil
+		}
+	}
+	generation := a.cacheGeneration.Load()
+	perms, validUntil, err := a.permissionsRepo.ListForUser(ctx, userID, appID)
+	if err != nil {
+		return nil, err
+	}
+	// Entries are cached only while invalidations are delivered,
+	// and only if there was no invalidation while they were loaded.
+	if a.cache != nil && a.cacheListening.Load() && a.cacheGeneration.Load() == generation {
+		ttl := a.cacheTTL
+		if validUntil != nil {
+			ttl = min(ttl, time.Until(*validUntil))
+		}
+		a.cache.SetWithTTL(key, perms, ttl)
+	}
+	return perms, nil
+}
+
+func (a *PermissionsService) invalidateUser(userID int64) {
+	if a.cache == nil {
+		return
+	}
+	a.cacheGeneration.Add(1)
+	a.cache.DeleteFunc(func(key cacheKey) bool { return key.userID == userID })
+}
+
+func (a *PermissionsService) invalidateAll() {
+	if a.cache == nil {
+		return
+	}
+	a.cacheGeneration.Add(1)
+	a.cache.Clear()
+}
+
+func (a *PermissionsService) handleInvalidation(payload string) {
+	if payload == invalidateAllPayload {
+		a.invalidateAll()
+		return
+	}
+	userID, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		a.log.Warn("Invalid permissions invalidation payload", "payload", payload)
+		a.invalidateAll()
+		return
+	}
+	a.invalidateUser(userID)
+}
+
+// RunCacheInvalidationListener keeps the cache in sync with changes made by any of SSO replicas
+// (or directly in database) until ctx is done. Nothing is cached while the listener is not connected.
+func (a *PermissionsService) RunCacheInvalidationListener(ctx context.Context, listener invalidationListener) {
+	const op = "permissions.RunCacheInvalidationListener"
+	log := a.log.With("operation", op)
+	if a.cache == nil {
+		return
+	}
+	listening := func() {
+		// notifications might have been missed while listener was disconnected
+		a.invalidateAll()
+		a.cacheListening.Store(true)
+	}
+	for {
+		err := listener.Listen(ctx, invalidationChannel, listening, a.handleInvalidation)
+		// entries can't be trusted until the listener is connected again
+		a.cacheListening.Store(false)
+		a.invalidateAll()
+		if ctx.Err() != nil {
+			return
+		}
+		log.Error("Permissions invalidation listener failed", "msg", err.Error())
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenerRetryDelay):
+		}
+	}
+}
+
+// CacheStats returns hit/miss counters of the permissions cache, zero stats if it's disabled.
+func (a *PermissionsService) CacheStats() ttlcache.Stats {
+	if a.cache == nil {
+		return ttlcache.Stats{}
+	}
+	return a.cache.Stats()
+}
+
+// RunCacheStatsLogger logs CacheStats every interval until ctx is done.
+func (a *PermissionsService) RunCacheStatsLogger(ctx context.Context, interval time.Duration) {
+	if a.cache == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stats := a.CacheStats()
+			a.log.Info(
+				"Permissions cache stats",
+				"hits", stats.Hits,
+				"misses", stats.Misses,
+				"evictions", stats.Evictions,
+				"size", stats.Size,
+			)
+		}
+	}
+}
@@ -5,5 +5,22 @@ import "errors"
 var (
 	ErrPermissionAlreadyExists = errors.New("permission with this code already exists")
 	ErrPermissionNotFound      = errors.New("permission not found")
+	ErrInvalidPermissionCode   = errors.New("invalid permission code")
+	ErrInvalidGrantExpiry      = errors.New("grant expiry must be in the future")
 	ErrUserNotFound            = errors.New("Related user not found")
+	ErrAppNotFound             = errors.New("Related app not found")
+	ErrRoleNotFound            = errors.New("role not found")
+	ErrRoleAlreadyExists       = errors.New("role with this name already exists")
+	ErrRoleInUse               = errors.New("role is assigned to users")
+	ErrBuiltinRole             = errors.New("builtin role can't be renamed or deleted")
+	ErrPolicyNotFound          = errors.New("policy not found")
+	ErrPolicyAlreadyExists     = errors.New("policy with this name already exists")
+	ErrInvalidPolicy           = errors.New("invalid policy")
+	ErrGroupNotFound           = errors.New("group not found")
+	ErrGroupAlreadyExists      = errors.New("group with this name already exists")
+	ErrGroupMemberNotFound     = errors.New("group member (user or group) not found")
+	ErrGroupCycle              = errors.New("group can't be nested into its own member")
+	ErrNotOrgMember            = errors.New("user is not a member of the org")
+	ErrOrgNotFound             = errors.New("org not found")
+	ErrGroupOrgMismatch        = errors.New("group of another org can't be nested into the group")
 )
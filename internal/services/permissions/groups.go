@@ -0,0 +1,205 @@
+package permissions
+
+import (
+	"context"
+	"errors"
+
+	"sso.service/internal/entity"
+	"sso.service/internal/storage"
+)
+
+type groupsRepo interface {
+	Create(ctx context.Context, group *entity.Group) (int64, error)
+	Get(ctx context.Context, groupID int64) (*entity.Group, error)
+	Delete(ctx context.Context, groupID int64) error
+	AddMembers(ctx context.Context, groupID int64, members entity.GroupMembers) error
+	RemoveMembers(ctx context.Context, groupID int64, members entity.GroupMembers) error
+	ListMembers(ctx context.Context, groupID int64) (*entity.GroupMembers, error)
+	GrantPermissions(ctx context.Context, groupID int64, appID int32, codes []string) error
+	RevokePermissions(ctx context.Context, groupID int64, appID int32, codes []string) error
+	ListPermissions(ctx context.Context, groupID int64) ([]entity.Permission, error)
+}
+
+func (a *PermissionsService) CreateGroup(ctx context.Context, group *entity.Group) (*entity.Group, error) {
+	const op = "permissions.CreateGroup"
+	log := a.log.With("operation", op, "name", group.Name, "org_id", group.OrgID)
+	groupID, err := a.groupsRepo.Create(ctx, group)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrRecordAlreadyExists):
+			log.Warn("Group already exists")
+			return nil, ErrGroupAlreadyExists
+		case errors.Is(err, storage.ErrInvalidReference):
+			log.Warn("Org not found")
+			return nil, ErrOrgNotFound
+		}
+		log.Error("Failed to create group", "msg", err.Error())
+		return nil, err
+	}
+	log.Info("Group created", "id", groupID)
+	return a.GetGroup(ctx, groupID)
+}
+
+func (a *PermissionsService) GetGroup(ctx context.Context, groupID int64) (*entity.Group, error) {
+	const op = "permissions.GetGroup"
+	log := a.log.With("operation", op, "id", groupID)
+	group, err := a.groupsRepo.Get(ctx, groupID)
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("Group not found")
+			return nil, ErrGroupNotFound
+		}
+		log.Error("Failed to get group", "msg", err.Error())
+		return nil, err
+	}
+	return group, nil
+}
+
+func (a *PermissionsService) DeleteGroup(ctx context.Context, groupID int64) error {
+	const op = "permissions.DeleteGroup"
+	log := a.log.With("operation", op, "id", groupID)
+	if err := a.groupsRepo.Delete(ctx, groupID); err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("Group not found")
+			return ErrGroupNotFound
+		}
+		log.Error("Failed to delete group", "msg", err.Error())
+		return err
+	}
+	a.invalidateAll()
+	log.Info("Group deleted")
+	return nil
+}
+
+// AddGroupMembers adds users and other groups to the group.
+// Members of nested groups obtain permissions of every group up the hierarchy,
+// so only groups of the same org or shared ones can be nested.
+func (a *PermissionsService) AddGroupMembers(ctx context.Context, groupID int64, members entity.GroupMembers) (*entity.GroupMembers, error) {
+	const op = "permissions.AddGroupMembers"
+	log := a.log.With("operation", op, "id", groupID, "user_ids", members.UserIDs, "group_ids", members.GroupIDs)
+	if _, err := a.GetGroup(ctx, groupID); err != nil {
+		return nil, err
+	}
+	if err := a.groupsRepo.AddMembers(ctx, groupID, members); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrRecordNotFound):
+			log.Warn("Group member not found")
+			return nil, ErrGroupMemberNotFound
+		case errors.Is(err, storage.ErrReferenceCycle):
+			log.Warn("Group nesting would create a cycle")
+			return nil, ErrGroupCycle
+		case errors.Is(err, storage.ErrInvalidReference):
+			log.Warn("Group of another org")
+			return nil, ErrGroupOrgMismatch
+		}
+		log.Error("Failed to add group members", "msg", err.Error())
+		return nil, err
+	}
+	a.invalidateAll()
+	log.Info("Group members added")
+	return a.ListGroupMembers(ctx, groupID)
+}
+
+func (a *PermissionsService) RemoveGroupMembers(ctx context.Context, groupID int64, members entity.GroupMembers) (*entity.GroupMembers, error) {
+	const op = "permissions.RemoveGroupMembers"
+	log := a.log.With("operation", op, "id", groupID, "user_ids", members.UserIDs, "group_ids", members.GroupIDs)
+	if _, err := a.GetGroup(ctx, groupID); err != nil {
+		return nil, err
+	}
+	if err := a.groupsRepo.RemoveMembers(ctx, groupID, members); err != nil {
+		log.Error("Failed to remove group members", "msg", err.Error())
+		return nil, err
+	}
+	a.invalidateAll()
+	log.Info("Group members removed")
+	return a.ListGroupMembers(ctx, groupID)
+}
+
+func (a *PermissionsService) ListGroupMembers(ctx context.Context, groupID int64) (*entity.GroupMembers, error) {
+	const op = "permissions.ListGroupMembers"
+	log := a.log.With("operation", op, "id", groupID)
+	if _, err := a.GetGroup(ctx, groupID); err != nil {
+		return nil, err
+	}
+	members, err := a.groupsRepo.ListMembers(ctx, groupID)
+	if err != nil {
+		log.Error("Failed to list group members", "msg", err.Error())
+		return nil, err
+	}
+	return members, nil
+}
+
+// GrantGroupPermissions grants permissions of the app namespace to the group, creating unknown ones on the fly,
+// and returns all of the group's permissions.
+func (a *PermissionsService) GrantGroupPermissions(ctx context.Context, groupID int64, appID int32, permissionCodes []string) ([]entity.Permission, error) {
+	const op = "permissions.GrantGroupPermissions"
+	log := a.log.With("operation", op, "id", groupID, "app_id", appID, "permissionCodes", permissionCodes)
+	if err := validatePatterns(permissionCodes); err != nil {
+		log.Warn("Invalid permission code", "msg", err.Error())
+		return nil, err
+	}
+	if _, err := a.GetGroup(ctx, groupID); err != nil {
+		return nil, err
+	}
+	if err := a.permissionsRepo.CreateManyIgnoreConflict(ctx, appID, permissionCodes); err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("App not found")
+			return nil, ErrAppNotFound
+		}
+		log.Error("Failed to create permissions", "msg", err.Error())
+		return nil, err
+	}
+	if err := a.groupsRepo.GrantPermissions(ctx, groupID, appID, permissionCodes); err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("Group not found")
+			return nil, ErrGroupNotFound
+		}
+		log.Error("Failed to grant group permissions", "msg", err.Error())
+		return nil, err
+	}
+	a.invalidateAll()
+	log.Info("Group permissions granted")
+	return a.listGroupPermissions(ctx, groupID)
+}
+
+func (a *PermissionsService) RevokeGroupPermissions(ctx context.Context, groupID int64, appID int32, permissionCodes []string) ([]entity.Permission, error) {
+	const op = "permissions.RevokeGroupPermissions"
+	log := a.log.With("operation", op, "id", groupID, "app_id", appID, "permissionCodes", permissionCodes)
+	if _, err := a.GetGroup(ctx, groupID); err != nil {
+		return nil, err
+	}
+	if err := a.groupsRepo.RevokePermissions(ctx, groupID, appID, permissionCodes); err != nil {
+		log.Error("Failed to revoke group permissions", "msg", err.Error())
+		return nil, err
+	}
+	a.invalidateAll()
+	log.Info("Group permissions revoked")
+	return a.listGroupPermissions(ctx, groupID)
+}
+
+func (a *PermissionsService) listGroupPermissions(ctx context.Context, groupID int64) ([]entity.Permission, error) {
+	permissions, err := a.groupsRepo.ListPermissions(ctx, groupID)
+	if err != nil {
+		a.log.Error("Failed to list group permissions", "id", groupID, "msg", err.Error())
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// ListUserPermissions returns permissions of the user in the namespace of the app (and the global one)
+// effective in the org owning the app, along with the source of each of them.
+// A permission obtained in several ways is listed once per source.
+func (a *PermissionsService) ListUserPermissions(ctx context.Context, userID int64, appID int32) ([]entity.EffectivePermission, error) {
+	const op = "permissions.ListUserPermissions"
+	log := a.log.With("operation", op, "user_id", userID, "app_id", appID)
+	permissions, err := a.permissionsRepo.ListEffectiveForUser(ctx, userID, appID)
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("User not found")
+			return nil, ErrUserNotFound
+		}
+		log.Error("Failed to list user permissions", "msg", err.Error())
+		return nil, err
+	}
+	return permissions, nil
+}
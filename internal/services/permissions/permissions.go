@@ -4,53 +4,162 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"sso.service/internal/entity"
 	"sso.service/internal/services/dtos"
 	"sso.service/internal/storage"
+	"sso.service/pkg/permcode"
 )
 
 type usersRepo interface {
 	Get(ctx context.Context, params dtos.GetUserOptionsDTO) (*entity.User, error)
 }
 
+type auditRepo interface {
+	Create(ctx context.Context, events ...entity.AuditEvent) error
+}
+
+type orgsRepo interface {
+	GetMember(ctx context.Context, orgID int64, userID int64) (*entity.OrgMember, error)
+	AppOrgID(ctx context.Context, appID int32) (int64, error)
+}
+
 type permissionsRepo interface {
-	ExistsForUser(ctx context.Context, userID int64, code string) (bool, error)
+	ListForUser(ctx context.Context, userID int64, appID int32) ([]entity.Permission, *time.Time, error)
+	ListEffectiveForUser(ctx context.Context, userID int64, appID int32) ([]entity.EffectivePermission, error)
+	FindUsersGranted(ctx context.Context, userIDs []int64, appID int32, candidates []string) ([]dtos.UserPermissionCheckDTO, error)
 	Get(ctx context.Context, params dtos.GetPermissionOptionsDTO) (*entity.Permission, error)
-	AddForUserIgnoreConflict(ctx context.Context, userID int64, codes []string) ([]int, error)
+	GrantForUser(ctx context.Context, userID int64, appID int32, orgID int64, grants []dtos.PermissionGrantDTO) ([]int, error)
+	DeleteExpiredGrants(ctx context.Context) ([]entity.PermissionGrant, error)
 	FetchMany(ctx context.Context, options dtos.FetchManyPermissionsOptionsDTO) ([]entity.Permission, error)
-	CreateManyIgnoreConflict(ctx context.Context, codes []string) error
+	CreateManyIgnoreConflict(ctx context.Context, appID int32, codes []string) error
+}
+
+// CheckPermission reports whether the user has permission in the namespace of the app.
+// Permissions of the global namespace are taken into account for every app.
+// If the app is owned by an org, grants limited to that org and the user's role in it count as well.
+func (a *PermissionsService) CheckPermission(ctx context.Context, userID int64, appID int32, permCode string) (bool, error) {
+	results, err := a.CheckPermissions(ctx, userID, appID, []string{permCode})
+	if err != nil {
+		return false, err
+	}
+	return results[permCode], nil
 }
 
-func (a *PermissionsService) CheckPermission(ctx context.Context, userID int64, permCode string) (bool, error) {
-	const op = "permissions.CheckPermission"
-	log := a.log.With("operation", op, "user_id", userID, "permission", permCode)
-	_, err := a.usersRepo.Get(ctx, dtos.GetUserOptionsDTO{ID: userID})
+// CheckPermissions checks several permissions of the user at once, see CheckPermission.
+func (a *PermissionsService) CheckPermissions(ctx context.Context, userID int64, appID int32, permCodes []string) (map[string]bool, error) {
+	const op = "permissions.CheckPermissions"
+	log := a.log.With("operation", op, "user_id", userID, "app_id", appID, "permissions", permCodes)
+	for _, code := range permCodes {
+		if err := permcode.Validate(code); err != nil {
+			log.Warn("Invalid permission code", "msg", err.Error())
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidPermissionCode, code, err)
+		}
+	}
+	granted, err := a.userPermissions(ctx, userID, appID)
 	if err != nil {
 		if errors.Is(err, storage.ErrRecordNotFound) {
 			log.Warn("User not found", "user_id", userID)
-			return false, ErrUserNotFound
+			return nil, ErrUserNotFound
 		}
-		log.Error("Failed to get user", "msg", err.Error())
-		return false, err
+		log.Error("Failed to check permissions", "msg", err.Error())
+		return nil, err
+	}
+	grantedCodes := make([]string, len(granted))
+	for i, perm := range granted {
+		grantedCodes[i] = perm.Code
+	}
+	results := make(map[string]bool, len(permCodes))
+	for _, code := range permCodes {
+		matched, ok := permcode.BestMatch(grantedCodes, code)
+		if ok {
+			log.Debug("Permission granted", "permission", code, "matched_by", matched)
+		}
+		results[code] = ok
 	}
-	exists, err := a.permissionsRepo.ExistsForUser(ctx, userID, permCode)
+	return results, nil
+}
+
+// CheckPermissionsForUsers checks the same permission for several users at once.
+// Unknown users are reported in the results instead of failing the whole check.
+// Unlike CheckPermissions it always goes to the database.
+func (a *PermissionsService) CheckPermissionsForUsers(ctx context.Context, userIDs []int64, appID int32, permCode string) ([]dtos.UserPermissionCheckDTO, error) {
+	const op = "permissions.CheckPermissionsForUsers"
+	log := a.log.With("operation", op, "user_ids", userIDs, "app_id", appID, "permission", permCode)
+	candidates, err := collectCandidates([]string{permCode})
 	if err != nil {
-		log.Error("Failed to check if permission exists", "msg", err.Error())
-		return false, err
+		log.Warn("Invalid permission code", "msg", err.Error())
+		return nil, err
+	}
+	results, err := a.permissionsRepo.FindUsersGranted(ctx, userIDs, appID, candidates)
+	if err != nil {
+		log.Error("Failed to check permissions", "msg", err.Error())
+		return nil, err
+	}
+	return results, nil
+}
+
+// collectCandidates validates codes and returns all of the patterns which may cover them.
+func collectCandidates(permCodes []string) ([]string, error) {
+	var candidates []string
+	seen := make(map[string]bool)
+	for _, code := range permCodes {
+		if err := permcode.Validate(code); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidPermissionCode, code, err)
+		}
+		for _, candidate := range permcode.Candidates(code) {
+			if !seen[candidate] {
+				seen[candidate] = true
+				candidates = append(candidates, candidate)
+			}
+		}
 	}
-	return exists, nil
+	return candidates, nil
 }
 
-func (a *PermissionsService) GrantPermissions(ctx context.Context, userID int64, permissionCodes []string) ([]entity.Permission, error) {
+// GrantPermissions grants permissions of the app namespace (or the global one for entity.GlobalAppID) to the user.
+// Grants limited to the org (entity.NoOrgID for every org) are effective only in apps owned by the org
+// and require the user to be a member of it. Permissions which don't exist yet are created.
+func (a *PermissionsService) GrantPermissions(ctx context.Context, userID int64, appID int32, orgID int64, grants []dtos.PermissionGrantDTO) ([]entity.Permission, error) {
 	const op = "permissions.GrantPermission"
-	log := a.log.With("operation", op, "user_id", userID, "permissionCodes", permissionCodes)
+	grants = mergeGrants(grants)
+	permissionCodes := make([]string, len(grants))
+	for i, grant := range grants {
+		permissionCodes[i] = grant.Code
+	}
+	log := a.log.With("operation", op, "user_id", userID, "app_id", appID, "org_id", orgID, "permissionCodes", permissionCodes)
 	var grantedPermissions []entity.Permission
-	if err := a.permissionsRepo.CreateManyIgnoreConflict(ctx, permissionCodes); err != nil {
+	if err := validatePatterns(permissionCodes); err != nil {
+		log.Warn("Invalid permission code", "msg", err.Error())
+		return grantedPermissions, err
+	}
+	now := time.Now()
+	for _, grant := range grants {
+		if grant.ExpiresAt != nil && !grant.ExpiresAt.After(now) {
+			log.Warn("Grant expiry is in the past", "code", grant.Code, "expires_at", grant.ExpiresAt)
+			return grantedPermissions, fmt.Errorf("%w: %s", ErrInvalidGrantExpiry, grant.Code)
+		}
+	}
+	if orgID != entity.NoOrgID {
+		if _, err := a.orgsRepo.GetMember(ctx, orgID, userID); err != nil {
+			if errors.Is(err, storage.ErrRecordNotFound) {
+				log.Warn("User is not a member of the org")
+				return grantedPermissions, ErrNotOrgMember
+			}
+			log.Error("Failed to get org membership", "msg", err.Error())
+			return grantedPermissions, err
+		}
+	}
+	if err := a.permissionsRepo.CreateManyIgnoreConflict(ctx, appID, permissionCodes); err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("App not found")
+			return grantedPermissions, ErrAppNotFound
+		}
 		log.Error("Failed to create permissions", "msg", err.Error())
 		return grantedPermissions, err
 	}
-	grantedPermissionIds, err := a.permissionsRepo.AddForUserIgnoreConflict(ctx, userID, permissionCodes)
+	grantedPermissionIds, err := a.permissionsRepo.GrantForUser(ctx, userID, appID, orgID, grants)
 	if err != nil {
 		if errors.Is(err, storage.ErrRecordNotFound) {
 			log.Warn("User not found", "user_id", userID)
@@ -59,6 +168,7 @@ func (a *PermissionsService) GrantPermissions(ctx context.Context, userID int64,
 		log.Error("Failed to grant permission", "msg", err.Error())
 		return grantedPermissions, err
 	}
+	a.invalidateUser(userID)
 	grantedPermissions, err = a.permissionsRepo.FetchMany(ctx, dtos.FetchManyPermissionsOptionsDTO{Ids: grantedPermissionIds})
 	if err != nil {
 		log.Error("Failed to fetch granted permissions", "msg", err.Error())
@@ -69,3 +179,81 @@ func (a *PermissionsService) GrantPermissions(ctx context.Context, userID int64,
 	}
 	return grantedPermissions, nil
 }
+
+// mergeGrants collapses grants of the same code into one, keeping the longest lasting.
+func mergeGrants(grants []dtos.PermissionGrantDTO) []dtos.PermissionGrantDTO {
+	merged := make([]dtos.PermissionGrantDTO, 0, len(grants))
+	indexByCode := make(map[string]int, len(grants))
+	for _, grant := range grants {
+		i, seen := indexByCode[grant.Code]
+		if !seen {
+			indexByCode[grant.Code] = len(merged)
+			merged = append(merged, grant)
+			continue
+		}
+		existing := merged[i].ExpiresAt
+		if existing != nil && (grant.ExpiresAt == nil || grant.ExpiresAt.After(*existing)) {
+			merged[i].ExpiresAt = grant.ExpiresAt
+		}
+	}
+	return merged
+}
+
+// SweepExpiredGrants deletes expired permission grants and records an audit event for each of them.
+func (a *PermissionsService) SweepExpiredGrants(ctx context.Context) (int, error) {
+	const op = "permissions.SweepExpiredGrants"
+	log := a.log.With("operation", op)
+	expiredGrants, err := a.permissionsRepo.DeleteExpiredGrants(ctx)
+	if err != nil {
+		log.Error("Failed to delete expired grants", "msg", err.Error())
+		return 0, err
+	}
+	if len(expiredGrants) == 0 {
+		return 0, nil
+	}
+	events := make([]entity.AuditEvent, len(expiredGrants))
+	for i, grant := range expiredGrants {
+		userID := grant.UserID
+		events[i] = entity.AuditEvent{
+			Type:   entity.AuditPermissionGrantExpired,
+			UserID: &userID,
+			Payload: map[string]any{
+				"permission": grant.Permission.Code,
+				"app_id":     grant.Permission.AppID,
+				"org_id":     grant.OrgID,
+				"granted_at": grant.GrantedAt,
+				"expires_at": grant.ExpiresAt,
+			},
+		}
+		log.Info("Permission grant expired", "user_id", grant.UserID, "permission", grant.Permission.Code)
+		a.invalidateUser(grant.UserID)
+	}
+	if err := a.auditRepo.Create(ctx, events...); err != nil {
+		log.Error("Failed to save audit events", "msg", err.Error())
+		return len(expiredGrants), err
+	}
+	return len(expiredGrants), nil
+}
+
+// RunExpiredGrantsSweeper calls SweepExpiredGrants every interval until ctx is done.
+func (a *PermissionsService) RunExpiredGrantsSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.SweepExpiredGrants(ctx)
+		}
+	}
+}
+
+func validatePatterns(permissionCodes []string) error {
+	for _, code := range permissionCodes {
+		if err := permcode.ValidatePattern(code); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalidPermissionCode, code, err)
+		}
+	}
+	return nil
+}
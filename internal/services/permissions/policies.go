@@ -0,0 +1,293 @@
+package permissions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+	"sso.service/internal/storage"
+	"sso.service/pkg/permcode"
+	"sso.service/pkg/policyexpr"
+)
+
+type policiesRepo interface {
+	Create(ctx context.Context, policy *entity.Policy) (int64, error)
+	Get(ctx context.Context, policyID int64) (*entity.Policy, error)
+	List(ctx context.Context, appID int32, orgID int64) ([]entity.Policy, error)
+	Update(ctx context.Context, policy *entity.Policy) (*entity.Policy, error)
+	Delete(ctx context.Context, policyID int64) error
+	FindApplicable(ctx context.Context, appID int32, candidates []string) ([]entity.Policy, error)
+}
+
+func validatePolicy(policy *entity.Policy) error {
+	if policy.Effect != entity.PolicyAllow && policy.Effect != entity.PolicyDeny {
+		return fmt.Errorf("%w: unknown effect %q", ErrInvalidPolicy, policy.Effect)
+	}
+	if len(policy.Actions) == 0 {
+		return fmt.Errorf("%w: no actions", ErrInvalidPolicy)
+	}
+	if err := validatePatterns(policy.Actions); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPolicy, err)
+	}
+	if policy.Condition != "" {
+		if _, err := policyexpr.Compile(policy.Condition); err != nil {
+			return fmt.Errorf("%w: condition: %w", ErrInvalidPolicy, err)
+		}
+	}
+	return nil
+}
+
+func (a *PermissionsService) CreatePolicy(ctx context.Context, policy *entity.Policy) (*entity.Policy, error) {
+	const op = "permissions.CreatePolicy"
+	log := a.log.With("operation", op, "app_id", policy.AppID, "org_id", policy.OrgID, "name", policy.Name)
+	if err := validatePolicy(policy); err != nil {
+		log.Warn("Invalid policy", "msg", err.Error())
+		return nil, err
+	}
+	policyID, err := a.policiesRepo.Create(ctx, policy)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrRecordAlreadyExists):
+			log.Warn("Policy already exists")
+			return nil, ErrPolicyAlreadyExists
+		case errors.Is(err, storage.ErrRecordNotFound):
+			log.Warn("App not found")
+			return nil, ErrAppNotFound
+		case errors.Is(err, storage.ErrInvalidReference):
+			log.Warn("Org not found")
+			return nil, ErrOrgNotFound
+		}
+		log.Error("Failed to create policy", "msg", err.Error())
+		return nil, err
+	}
+	log.Info("Policy created", "id", policyID)
+	return a.GetPolicy(ctx, policyID)
+}
+
+func (a *PermissionsService) GetPolicy(ctx context.Context, policyID int64) (*entity.Policy, error) {
+	const op = "permissions.GetPolicy"
+	log := a.log.With("operation", op, "id", policyID)
+	policy, err := a.policiesRepo.Get(ctx, policyID)
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("Policy not found")
+			return nil, ErrPolicyNotFound
+		}
+		log.Error("Failed to get policy", "msg", err.Error())
+		return nil, err
+	}
+	return policy, nil
+}
+
+// ListPolicies returns policies of the app namespace belonging to the org and the ones shared by all of the orgs.
+func (a *PermissionsService) ListPolicies(ctx context.Context, appID int32, orgID int64) ([]entity.Policy, error) {
+	const op = "permissions.ListPolicies"
+	log := a.log.With("operation", op, "app_id", appID, "org_id", orgID)
+	policies, err := a.policiesRepo.List(ctx, appID, orgID)
+	if err != nil {
+		log.Error("Failed to list policies", "msg", err.Error())
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (a *PermissionsService) UpdatePolicy(ctx context.Context, policy *entity.Policy) (*entity.Policy, error) {
+	const op = "permissions.UpdatePolicy"
+	log := a.log.With("operation", op, "id", policy.ID)
+	if err := validatePolicy(policy); err != nil {
+		log.Warn("Invalid policy", "msg", err.Error())
+		return nil, err
+	}
+	updated, err := a.policiesRepo.Update(ctx, policy)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrRecordNotFound):
+			log.Warn("Policy not found")
+			return nil, ErrPolicyNotFound
+		case errors.Is(err, storage.ErrRecordAlreadyExists):
+			log.Warn("Policy with this name already exists", "name", policy.Name)
+			return nil, ErrPolicyAlreadyExists
+		}
+		log.Error("Failed to update policy", "msg", err.Error())
+		return nil, err
+	}
+	log.Info("Policy updated")
+	return updated, nil
+}
+
+func (a *PermissionsService) DeletePolicy(ctx context.Context, policyID int64) error {
+	const op = "permissions.DeletePolicy"
+	log := a.log.With("operation", op, "id", policyID)
+	if err := a.policiesRepo.Delete(ctx, policyID); err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("Policy not found")
+			return ErrPolicyNotFound
+		}
+		log.Error("Failed to delete policy", "msg", err.Error())
+		return err
+	}
+	log.Info("Policy deleted")
+	return nil
+}
+
+// Authorize evaluates enabled policies of the app and global namespaces covering the action,
+// which belong to the org owning the app or are shared by all of the orgs.
+// Deny overrides allow: any matching deny policy denies access, otherwise the first matching allow policy
+// (by priority) allows it. When no policy matches, access is denied.
+func (a *PermissionsService) Authorize(ctx context.Context, params dtos.AuthorizeDTO) (*dtos.AuthorizationDecisionDTO, error) {
+	const op = "permissions.Authorize"
+	log := a.log.With("operation", op, "subject_id", params.SubjectID, "app_id", params.AppID, "action", params.Action)
+	vars, err := a.authorizationVars(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := a.policiesRepo.FindApplicable(ctx, params.AppID, permcode.Candidates(params.Action))
+	if err != nil {
+		log.Error("Failed to find policies", "msg", err.Error())
+		return nil, err
+	}
+	decision := a.evaluatePolicies(policies, vars)
+	log.Info("Authorization decided", "allowed", decision.Allowed, "policy", policyName(decision.Policy))
+	return decision, nil
+}
+
+// DryRunAuthorize makes the same decision as Authorize would if draft policies were stored.
+// Drafts with ID of stored policies replace them. If onlyDrafts is set, stored policies are ignored.
+// Nothing is saved, decision contains result of every evaluated policy.
+func (a *PermissionsService) DryRunAuthorize(
+	ctx context.Context,
+	params dtos.AuthorizeDTO,
+	drafts []entity.Policy,
+	onlyDrafts bool,
+) (*dtos.AuthorizationDecisionDTO, error) {
+	const op = "permissions.DryRunAuthorize"
+	log := a.log.With("operation", op, "subject_id", params.SubjectID, "app_id", params.AppID, "action", params.Action)
+	for i := range drafts {
+		if err := validatePolicy(&drafts[i]); err != nil {
+			log.Warn("Invalid draft policy", "msg", err.Error())
+			return nil, fmt.Errorf("draft %d: %w", i, err)
+		}
+	}
+	vars, err := a.authorizationVars(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	appOrgID, err := a.orgsRepo.AppOrgID(ctx, params.AppID)
+	if err != nil && !errors.Is(err, storage.ErrRecordNotFound) {
+		log.Error("Failed to get org of the app", "msg", err.Error())
+		return nil, err
+	}
+	policies := []entity.Policy{}
+	if !onlyDrafts {
+		policies, err = a.policiesRepo.FindApplicable(ctx, params.AppID, permcode.Candidates(params.Action))
+		if err != nil {
+			log.Error("Failed to find policies", "msg", err.Error())
+			return nil, err
+		}
+	}
+	for _, draft := range drafts {
+		policies = slices.DeleteFunc(policies, func(p entity.Policy) bool { return draft.ID != 0 && p.ID == draft.ID })
+		_, covers := permcode.BestMatch(draft.Actions, params.Action)
+		inNamespace := draft.AppID == params.AppID || draft.AppID == entity.GlobalAppID
+		inOrg := draft.OrgID == entity.NoOrgID || draft.OrgID == appOrgID
+		if draft.IsEnabled && covers && inNamespace && inOrg {
+			policies = append(policies, draft)
+		}
+	}
+	slices.SortStableFunc(policies, func(p1, p2 entity.Policy) int { return int(p2.Priority) - int(p1.Priority) })
+	return a.evaluatePolicies(policies, vars), nil
+}
+
+// authorizationVars builds variables policy conditions are evaluated against.
+func (a *PermissionsService) authorizationVars(ctx context.Context, params dtos.AuthorizeDTO) (map[string]any, error) {
+	const op = "permissions.authorizationVars"
+	log := a.log.With("operation", op, "subject_id", params.SubjectID)
+	if err := permcode.Validate(params.Action); err != nil {
+		log.Warn("Invalid action", "msg", err.Error())
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidPermissionCode, params.Action, err)
+	}
+	user, err := a.usersRepo.Get(ctx, dtos.GetUserOptionsDTO{ID: params.SubjectID})
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("User not found")
+			return nil, ErrUserNotFound
+		}
+		log.Error("Failed to get user", "msg", err.Error())
+		return nil, err
+	}
+	subject := maps.Clone(params.SubjectAttributes)
+	if subject == nil {
+		subject = map[string]any{}
+	}
+	subject["id"] = user.ID
+	subject["username"] = user.Username
+	subject["role"] = user.Role
+	subject["is_active"] = user.IsActive
+	resource := maps.Clone(params.ResourceAttributes)
+	if resource == nil {
+		resource = map[string]any{}
+	}
+	resource["type"] = params.ResourceType
+	resource["id"] = params.ResourceID
+	env := maps.Clone(params.Environment)
+	if env == nil {
+		env = map[string]any{}
+	}
+	env["time"] = time.Now().Unix()
+	env["app_id"] = params.AppID
+	return map[string]any{
+		"subject":  subject,
+		"resource": resource,
+		"action":   params.Action,
+		"env":      env,
+	}, nil
+}
+
+// evaluatePolicies expects policies to be ordered by priority.
+// Policy which condition fails to evaluate (e.g. an attribute is missing) doesn't match if it allows,
+// but matches if it denies, so that a broken condition never grants access.
+func (a *PermissionsService) evaluatePolicies(policies []entity.Policy, vars map[string]any) *dtos.AuthorizationDecisionDTO {
+	decision := &dtos.AuthorizationDecisionDTO{Evaluations: make([]dtos.PolicyEvaluationDTO, len(policies))}
+	var allowedBy, deniedBy *entity.Policy
+	for i := range policies {
+		policy := &policies[i]
+		evaluation := dtos.PolicyEvaluationDTO{Policy: *policy, Matched: true}
+		if policy.Condition != "" {
+			matched, err := policyexpr.Eval(policy.Condition, vars)
+			if err != nil {
+				a.log.Warn("Failed to evaluate policy condition", "policy_id", policy.ID, "msg", err.Error())
+				evaluation.Error = err.Error()
+				matched = policy.Effect == entity.PolicyDeny
+			}
+			evaluation.Matched = matched
+		}
+		decision.Evaluations[i] = evaluation
+		switch {
+		case !evaluation.Matched:
+		case policy.Effect == entity.PolicyDeny && deniedBy == nil:
+			deniedBy = policy
+		case policy.Effect == entity.PolicyAllow && allowedBy == nil:
+			allowedBy = policy
+		}
+	}
+	switch {
+	case deniedBy != nil:
+		decision.Policy = deniedBy
+	case allowedBy != nil:
+		decision.Allowed = true
+		decision.Policy = allowedBy
+	}
+	return decision
+}
+
+func policyName(policy *entity.Policy) string {
+	if policy == nil {
+		return ""
+	}
+	return policy.Name
+}
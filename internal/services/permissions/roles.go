@@ -0,0 +1,153 @@
+package permissions
+
+import (
+	"context"
+	"errors"
+
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+	"sso.service/internal/storage"
+)
+
+type rolesRepo interface {
+	Create(ctx context.Context, role *entity.RoleDetails) (int64, error)
+	Get(ctx context.Context, params dtos.GetRoleOptionsDTO) (*entity.RoleDetails, error)
+	List(ctx context.Context, orgID int64) ([]entity.RoleDetails, error)
+	Update(ctx context.Context, params dtos.UpdateRoleDTO) (*entity.RoleDetails, error)
+	Delete(ctx context.Context, roleID int64) error
+	SetPermissions(ctx context.Context, roleID int64, appID int32, codes []string) error
+}
+
+func (a *PermissionsService) CreateRole(ctx context.Context, role *entity.RoleDetails, permissionCodes []string) (*entity.RoleDetails, error) {
+	const op = "permissions.CreateRole"
+	log := a.log.With("operation", op, "name", role.Name)
+	roleID, err := a.rolesRepo.Create(ctx, role)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrRecordAlreadyExists):
+			log.Warn("Role already exists")
+			return nil, ErrRoleAlreadyExists
+		case errors.Is(err, storage.ErrInvalidReference):
+			log.Warn("Org not found", "org_id", role.OrgID)
+			return nil, ErrOrgNotFound
+		}
+		log.Error("Failed to create role", "msg", err.Error())
+		return nil, err
+	}
+	log.Info("Role created", "id", roleID)
+	if len(permissionCodes) > 0 {
+		return a.SetRolePermissions(ctx, roleID, entity.GlobalAppID, permissionCodes)
+	}
+	return a.GetRole(ctx, dtos.GetRoleOptionsDTO{ID: roleID})
+}
+
+func (a *PermissionsService) GetRole(ctx context.Context, params dtos.GetRoleOptionsDTO) (*entity.RoleDetails, error) {
+	const op = "permissions.GetRole"
+	log := a.log.With("operation", op)
+	role, err := a.rolesRepo.Get(ctx, params)
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("Role not found", "params", params)
+			return nil, ErrRoleNotFound
+		}
+		log.Error("Failed to get role", "msg", err.Error())
+		return nil, err
+	}
+	return role, nil
+}
+
+// ListRoles returns the roles which can be assigned within the org, global ones included.
+func (a *PermissionsService) ListRoles(ctx context.Context, orgID int64) ([]entity.RoleDetails, error) {
+	const op = "permissions.ListRoles"
+	log := a.log.With("operation", op, "org_id", orgID)
+	roles, err := a.rolesRepo.List(ctx, orgID)
+	if err != nil {
+		log.Error("Failed to list roles", "msg", err.Error())
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (a *PermissionsService) UpdateRole(ctx context.Context, params dtos.UpdateRoleDTO) (*entity.RoleDetails, error) {
+	const op = "permissions.UpdateRole"
+	log := a.log.With("operation", op, "id", params.ID)
+	if params.Name != nil {
+		role, err := a.GetRole(ctx, dtos.GetRoleOptionsDTO{ID: params.ID})
+		if err != nil {
+			return nil, err
+		}
+		if role.IsBuiltin && role.Name != *params.Name {
+			log.Warn("Attempt to rename builtin role", "name", role.Name)
+			return nil, ErrBuiltinRole
+		}
+	}
+	role, err := a.rolesRepo.Update(ctx, params)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrRecordNotFound):
+			log.Warn("Role not found")
+			return nil, ErrRoleNotFound
+		case errors.Is(err, storage.ErrRecordAlreadyExists):
+			log.Warn("Role with this name already exists")
+			return nil, ErrRoleAlreadyExists
+		}
+		log.Error("Failed to update role", "msg", err.Error())
+		return nil, err
+	}
+	return role, nil
+}
+
+func (a *PermissionsService) DeleteRole(ctx context.Context, roleID int64) error {
+	const op = "permissions.DeleteRole"
+	log := a.log.With("operation", op, "id", roleID)
+	role, err := a.GetRole(ctx, dtos.GetRoleOptionsDTO{ID: roleID})
+	if err != nil {
+		return err
+	}
+	if role.IsBuiltin {
+		log.Warn("Attempt to delete builtin role", "name", role.Name)
+		return ErrBuiltinRole
+	}
+	if err := a.rolesRepo.Delete(ctx, roleID); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrRecordNotFound):
+			return ErrRoleNotFound
+		case errors.Is(err, storage.ErrRecordInUse):
+			log.Warn("Role is still assigned to users")
+			return ErrRoleInUse
+		}
+		log.Error("Failed to delete role", "msg", err.Error())
+		return err
+	}
+	log.Info("Role deleted")
+	return nil
+}
+
+// SetRolePermissions replaces the permission set of the role within the app namespace,
+// creating unknown permissions on the fly.
+func (a *PermissionsService) SetRolePermissions(ctx context.Context, roleID int64, appID int32, permissionCodes []string) (*entity.RoleDetails, error) {
+	const op = "permissions.SetRolePermissions"
+	log := a.log.With("operation", op, "id", roleID, "app_id", appID, "permissionCodes", permissionCodes)
+	if err := validatePatterns(permissionCodes); err != nil {
+		log.Warn("Invalid permission code", "msg", err.Error())
+		return nil, err
+	}
+	if err := a.permissionsRepo.CreateManyIgnoreConflict(ctx, appID, permissionCodes); err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("App not found")
+			return nil, ErrAppNotFound
+		}
+		log.Error("Failed to create permissions", "msg", err.Error())
+		return nil, err
+	}
+	if err := a.rolesRepo.SetPermissions(ctx, roleID, appID, permissionCodes); err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("Role not found")
+			return nil, ErrRoleNotFound
+		}
+		log.Error("Failed to set role permissions", "msg", err.Error())
+		return nil, err
+	}
+	a.invalidateAll()
+	return a.GetRole(ctx, dtos.GetRoleOptionsDTO{ID: roleID})
+}
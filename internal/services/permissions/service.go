@@ -2,22 +2,54 @@ package permissions
 
 import (
 	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"sso.service/internal/config"
+	"sso.service/internal/entity"
+	"sso.service/pkg/ttlcache"
 )
 
 type PermissionsService struct {
 	log             *slog.Logger
 	permissionsRepo permissionsRepo
 	usersRepo       usersRepo
+	rolesRepo       rolesRepo
+	auditRepo       auditRepo
+	policiesRepo    policiesRepo
+	groupsRepo      groupsRepo
+	orgsRepo        orgsRepo
+	// permissions of the user in the app (see userPermissions), nil if caching is disabled
+	cache           *ttlcache.Cache[cacheKey, []entity.Permission]
+	cacheTTL        time.Duration
+	cacheGeneration atomic.Uint64
+	cacheListening  atomic.Bool
 }
 
 func New(
 	log *slog.Logger,
 	permissionsRepo permissionsRepo,
 	usersRepo usersRepo,
+	rolesRepo rolesRepo,
+	auditRepo auditRepo,
+	policiesRepo policiesRepo,
+	groupsRepo groupsRepo,
+	orgsRepo orgsRepo,
+	cacheCfg config.PermissionsCache,
 ) *PermissionsService {
-	return &PermissionsService{
-		log,
-		permissionsRepo,
-		usersRepo,
+	service := &PermissionsService{
+		log:             log,
+		permissionsRepo: permissionsRepo,
+		usersRepo:       usersRepo,
+		rolesRepo:       rolesRepo,
+		auditRepo:       auditRepo,
+		policiesRepo:    policiesRepo,
+		groupsRepo:      groupsRepo,
+		orgsRepo:        orgsRepo,
+		cacheTTL:        cacheCfg.TTL,
+	}
+	if cacheCfg.Enabled {
+		service.cache = ttlcache.New[cacheKey, []entity.Permission](cacheCfg.MaxEntries, cacheCfg.TTL)
 	}
+	return service
 }
@@ -0,0 +1,207 @@
+package relations
+
+import (
+	"context"
+	"errors"
+
+	"sso.service/internal/config"
+	"sso.service/internal/entity"
+)
+
+// Check reports whether the user is a member of the userset within the org, following schema rewrites
+// and nested usersets up to the configured depth. Only tuples effective in the org are considered.
+func (a *RelationsService) Check(ctx context.Context, orgID int64, object entity.Userset, userID int64) (bool, error) {
+	const op = "relations.Check"
+	log := a.log.With("operation", op, "org_id", orgID, "userset", object.String(), "user_id", userID)
+	c := &checker{
+		service:  a,
+		orgID:    orgID,
+		userID:   userID,
+		results:  map[entity.Userset]bool{},
+		visiting: map[entity.Userset]bool{},
+	}
+	allowed, err := c.check(ctx, object, 0)
+	if err != nil {
+		if errors.Is(err, ErrUnknownNamespace) || errors.Is(err, ErrUnknownRelation) || errors.Is(err, ErrDepthExceeded) {
+			log.Warn("Failed to check relation", "msg", err.Error())
+		} else {
+			log.Error("Failed to check relation", "msg", err.Error())
+		}
+		return false, err
+	}
+	return allowed, nil
+}
+
+type checker struct {
+	service *RelationsService
+	orgID   int64
+	userID  int64
+	results map[entity.Userset]bool
+	// usersets being checked, reaching one of them again means a cycle
+	visiting  map[entity.Userset]bool
+	cycleHits int
+}
+
+func (c *checker) check(ctx context.Context, userset entity.Userset, depth int) (bool, error) {
+	if depth > c.service.maxDepth {
+		return false, ErrDepthExceeded
+	}
+	if result, ok := c.results[userset]; ok {
+		return result, nil
+	}
+	if c.visiting[userset] {
+		c.cycleHits++
+		return false, nil
+	}
+	rewrite, err := c.service.rewrite(userset.Namespace, userset.Relation)
+	if err != nil {
+		return false, err
+	}
+	c.visiting[userset] = true
+	cycleHits := c.cycleHits
+	result, err := c.checkRewrite(ctx, userset, rewrite, depth)
+	delete(c.visiting, userset)
+	if err != nil {
+		return false, err
+	}
+	// negative result reached through a cycle may change once the cycle is resolved
+	if result || c.cycleHits == cycleHits {
+		c.results[userset] = result
+	}
+	return result, nil
+}
+
+func (c *checker) checkRewrite(ctx context.Context, userset entity.Userset, rewrite *config.RelationRewrite, depth int) (bool, error) {
+	switch {
+	case rewrite == nil || rewrite.This != nil:
+		found, err := c.service.tuplesRepo.HasUser(ctx, c.orgID, userset, c.userID)
+		if err != nil || found {
+			return found, err
+		}
+		subjectSets, err := c.service.tuplesRepo.ListSubjectSets(ctx, c.orgID, userset)
+		if err != nil {
+			return false, err
+		}
+		for _, subjectSet := range subjectSets {
+			// object itself is not a set of users
+			if subjectSet.Relation == "" {
+				continue
+			}
+			if found, err := c.check(ctx, subjectSet, depth+1); err != nil || found {
+				return found, err
+			}
+		}
+		return false, nil
+	case rewrite.ComputedUserset != "":
+		computed := entity.Userset{Namespace: userset.Namespace, ObjectID: userset.ObjectID, Relation: rewrite.ComputedUserset}
+		return c.check(ctx, computed, depth+1)
+	case rewrite.TupleToUserset != nil:
+		tupleset := entity.Userset{Namespace: userset.Namespace, ObjectID: userset.ObjectID, Relation: rewrite.TupleToUserset.Tupleset}
+		related, err := c.service.tuplesRepo.ListSubjectSets(ctx, c.orgID, tupleset)
+		if err != nil {
+			return false, err
+		}
+		for _, object := range related {
+			computed := entity.Userset{Namespace: object.Namespace, ObjectID: object.ObjectID, Relation: rewrite.TupleToUserset.ComputedUserset}
+			if found, err := c.check(ctx, computed, depth+1); err != nil || found {
+				return found, err
+			}
+		}
+		return false, nil
+	default:
+		for i := range rewrite.Union {
+			if found, err := c.checkRewrite(ctx, userset, &rewrite.Union[i], depth); err != nil || found {
+				return found, err
+			}
+		}
+		return false, nil
+	}
+}
+
+// Expand returns the tree of usersets the members of the userset within the org are derived from.
+// Usersets which are already expanded higher in the tree are included without children.
+func (a *RelationsService) Expand(ctx context.Context, orgID int64, object entity.Userset) (*entity.UsersetTree, error) {
+	const op = "relations.Expand"
+	log := a.log.With("operation", op, "org_id", orgID, "userset", object.String())
+	tree, err := a.expand(ctx, orgID, object, map[entity.Userset]bool{}, 0)
+	if err != nil {
+		log.Warn("Failed to expand userset", "msg", err.Error())
+		return nil, err
+	}
+	return tree, nil
+}
+
+func (a *RelationsService) expand(ctx context.Context, orgID int64, userset entity.Userset, path map[entity.Userset]bool, depth int) (*entity.UsersetTree, error) {
+	if depth > a.maxDepth {
+		return nil, ErrDepthExceeded
+	}
+	tree := &entity.UsersetTree{Userset: userset, UserIDs: []int64{}, Children: []entity.UsersetTree{}}
+	if path[userset] {
+		return tree, nil
+	}
+	path[userset] = true
+	defer delete(path, userset)
+	rewrite, err := a.rewrite(userset.Namespace, userset.Relation)
+	if err != nil {
+		return nil, err
+	}
+	children, err := a.derivedUsersets(ctx, orgID, tree, rewrite)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		subtree, err := a.expand(ctx, orgID, child, path, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		tree.Children = append(tree.Children, *subtree)
+	}
+	return tree, nil
+}
+
+// derivedUsersets collects direct members of the rewrite into tree and returns usersets the rest of members come from.
+func (a *RelationsService) derivedUsersets(ctx context.Context, orgID int64, tree *entity.UsersetTree, rewrite *config.RelationRewrite) ([]entity.Userset, error) {
+	userset := tree.Userset
+	switch {
+	case rewrite == nil || rewrite.This != nil:
+		users, err := a.tuplesRepo.ListUsers(ctx, orgID, userset)
+		if err != nil {
+			return nil, err
+		}
+		tree.UserIDs = append(tree.UserIDs, users...)
+		subjectSets, err := a.tuplesRepo.ListSubjectSets(ctx, orgID, userset)
+		if err != nil {
+			return nil, err
+		}
+		derived := []entity.Userset{}
+		for _, subjectSet := range subjectSets {
+			if subjectSet.Relation != "" {
+				derived = append(derived, subjectSet)
+			}
+		}
+		return derived, nil
+	case rewrite.ComputedUserset != "":
+		return []entity.Userset{{Namespace: userset.Namespace, ObjectID: userset.ObjectID, Relation: rewrite.ComputedUserset}}, nil
+	case rewrite.TupleToUserset != nil:
+		tupleset := entity.Userset{Namespace: userset.Namespace, ObjectID: userset.ObjectID, Relation: rewrite.TupleToUserset.Tupleset}
+		related, err := a.tuplesRepo.ListSubjectSets(ctx, orgID, tupleset)
+		if err != nil {
+			return nil, err
+		}
+		derived := make([]entity.Userset, len(related))
+		for i, object := range related {
+			derived[i] = entity.Userset{Namespace: object.Namespace, ObjectID: object.ObjectID, Relation: rewrite.TupleToUserset.ComputedUserset}
+		}
+		return derived, nil
+	default:
+		derived := []entity.Userset{}
+		for i := range rewrite.Union {
+			usersets, err := a.derivedUsersets(ctx, orgID, tree, &rewrite.Union[i])
+			if err != nil {
+				return nil, err
+			}
+			derived = append(derived, usersets...)
+		}
+		return derived, nil
+	}
+}
@@ -0,0 +1,11 @@
+package relations
+
+import "errors"
+
+var (
+	ErrUnknownNamespace = errors.New("unknown namespace")
+	ErrUnknownRelation  = errors.New("unknown relation")
+	ErrUserNotFound     = errors.New("Related user not found")
+	ErrOrgNotFound      = errors.New("Org not found")
+	ErrDepthExceeded    = errors.New("max depth of nested usersets exceeded")
+)
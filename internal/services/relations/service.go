@@ -0,0 +1,34 @@
+package relations
+
+import (
+	"context"
+	"log/slog"
+
+	"sso.service/internal/config"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+)
+
+type tuplesRepo interface {
+	Write(ctx context.Context, inserts []entity.RelationTuple, deletes []entity.RelationTuple) error
+	Read(ctx context.Context, filter dtos.RelationTuplesFilterDTO) ([]entity.RelationTuple, error)
+	HasUser(ctx context.Context, orgID int64, object entity.Userset, userID int64) (bool, error)
+	ListUsers(ctx context.Context, orgID int64, object entity.Userset) ([]int64, error)
+	ListSubjectSets(ctx context.Context, orgID int64, object entity.Userset) ([]entity.Userset, error)
+}
+
+type RelationsService struct {
+	log        *slog.Logger
+	tuplesRepo tuplesRepo
+	schema     *config.RelationsSchema
+	maxDepth   int
+}
+
+func New(log *slog.Logger, tuplesRepo tuplesRepo, schema *config.RelationsSchema, maxDepth int) *RelationsService {
+	return &RelationsService{
+		log:        log,
+		tuplesRepo: tuplesRepo,
+		schema:     schema,
+		maxDepth:   maxDepth,
+	}
+}
@@ -0,0 +1,83 @@
+package relations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sso.service/internal/config"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+	"sso.service/internal/storage"
+)
+
+// rewrite returns rewrite rule of the relation, nil rule stands for "this".
+func (a *RelationsService) rewrite(namespace string, relation string) (*config.RelationRewrite, error) {
+	ns, ok := a.schema.Namespaces[namespace]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownNamespace, namespace)
+	}
+	rewrite, ok := ns.Relations[relation]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s#%s", ErrUnknownRelation, namespace, relation)
+	}
+	return rewrite, nil
+}
+
+func (a *RelationsService) validateTuple(tuple entity.RelationTuple) error {
+	if _, err := a.rewrite(tuple.Namespace, tuple.Relation); err != nil {
+		return err
+	}
+	if tuple.SubjectSet == nil {
+		return nil
+	}
+	if tuple.SubjectSet.Relation == "" {
+		if _, ok := a.schema.Namespaces[tuple.SubjectSet.Namespace]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownNamespace, tuple.SubjectSet.Namespace)
+		}
+		return nil
+	}
+	_, err := a.rewrite(tuple.SubjectSet.Namespace, tuple.SubjectSet.Relation)
+	return err
+}
+
+// WriteTuples atomically inserts and deletes tuples.
+func (a *RelationsService) WriteTuples(ctx context.Context, inserts []entity.RelationTuple, deletes []entity.RelationTuple) error {
+	const op = "relations.WriteTuples"
+	log := a.log.With("operation", op)
+	for _, tuple := range inserts {
+		if err := a.validateTuple(tuple); err != nil {
+			log.Warn("Invalid tuple", "msg", err.Error())
+			return err
+		}
+	}
+	if err := a.tuplesRepo.Write(ctx, inserts, deletes); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrRecordNotFound):
+			log.Warn("User not found")
+			return ErrUserNotFound
+		case errors.Is(err, storage.ErrInvalidReference):
+			log.Warn("Org not found")
+			return ErrOrgNotFound
+		}
+		log.Error("Failed to write tuples", "msg", err.Error())
+		return err
+	}
+	log.Info("Tuples written", "inserted", len(inserts), "deleted", len(deletes))
+	return nil
+}
+
+func (a *RelationsService) ReadTuples(ctx context.Context, filter dtos.RelationTuplesFilterDTO) ([]entity.RelationTuple, error) {
+	const op = "relations.ReadTuples"
+	log := a.log.With("operation", op, "namespace", filter.Namespace)
+	if _, ok := a.schema.Namespaces[filter.Namespace]; !ok {
+		log.Warn("Unknown namespace")
+		return nil, fmt.Errorf("%w: %s", ErrUnknownNamespace, filter.Namespace)
+	}
+	tuples, err := a.tuplesRepo.Read(ctx, filter)
+	if err != nil {
+		log.Error("Failed to read tuples", "msg", err.Error())
+		return nil, err
+	}
+	return tuples, nil
+}
@@ -0,0 +1,8 @@
+package userdata
+
+import "errors"
+
+var (
+	ErrUserNotFound   = errors.New("user not found")
+	ErrExportNotFound = errors.New("data export not found")
+)
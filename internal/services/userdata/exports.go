@@ -0,0 +1,151 @@
+package userdata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+	"sso.service/internal/storage"
+)
+
+// Exports running for longer are considered abandoned and processed again.
+const staleExportAfter = 15 * time.Minute
+
+// BuildArchive collects the data stored about the user: profile, metadata, permission grants, sessions,
+// audit events and apps the user has used.
+func (a *UserDataService) BuildArchive(ctx context.Context, userID int64) (*entity.UserDataArchive, error) {
+	const op = "userdata.BuildArchive"
+	log := a.log.With("operation", op, "user_id", userID)
+	user, err := a.usersRepo.Get(ctx, dtos.GetUserOptionsDTO{ID: userID})
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("User not found")
+			return nil, ErrUserNotFound
+		}
+		log.Error("Failed to get user", "msg", err.Error())
+		return nil, err
+	}
+	archive := entity.UserDataArchive{GeneratedAt: time.Now().UTC(), Profile: user}
+	metadata, err := a.usersRepo.GetMetadata(ctx, userID, entity.GlobalAppID)
+	if err != nil {
+		log.Error("Failed to get metadata", "msg", err.Error())
+		return nil, err
+	}
+	archive.PublicMetadata, archive.AdminMetadata = metadata.Public, metadata.Admin
+	if archive.PermissionGrants, err = a.permissionsRepo.ListGrantsForUser(ctx, userID); err != nil {
+		log.Error("Failed to list permission grants", "msg", err.Error())
+		return nil, err
+	}
+	if archive.Sessions, err = a.usersRepo.ListSessions(ctx, userID); err != nil {
+		log.Error("Failed to list sessions", "msg", err.Error())
+		return nil, err
+	}
+	if archive.AuditEvents, err = a.auditRepo.ListForUser(ctx, userID); err != nil {
+		log.Error("Failed to list audit events", "msg", err.Error())
+		return nil, err
+	}
+	if archive.Apps, err = a.usersRepo.ListApps(ctx, userID); err != nil {
+		log.Error("Failed to list apps", "msg", err.Error())
+		return nil, err
+	}
+	return &archive, nil
+}
+
+// RequestExport schedules an export of the user's data on behalf of the actor (the user or an admin).
+// The export is processed in background, see RunExportWorker.
+func (a *UserDataService) RequestExport(ctx context.Context, actorID int64, userID int64) (*entity.DataExport, error) {
+	const op = "userdata.RequestExport"
+	log := a.log.With("operation", op, "actor_id", actorID, "user_id", userID)
+	export, err := a.exportsRepo.Create(ctx, userID, actorID)
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("User not found")
+			return nil, ErrUserNotFound
+		}
+		log.Error("Failed to create data export", "msg", err.Error())
+		return nil, err
+	}
+	log.Info("Data export requested", "export_id", export.ID)
+	event := entity.AuditEvent{
+		Type:    entity.AuditDataExportRequested,
+		ActorID: &actorID,
+		UserID:  &userID,
+		Payload: map[string]any{"export_id": export.ID},
+	}
+	if err := a.auditRepo.Create(ctx, event); err != nil {
+		log.Error("Failed to save audit event", "msg", err.Error())
+		return nil, err
+	}
+	return export, nil
+}
+
+func (a *UserDataService) GetExport(ctx context.Context, exportID int64) (*entity.DataExport, error) {
+	const op = "userdata.GetExport"
+	log := a.log.With("operation", op, "export_id", exportID)
+	export, err := a.exportsRepo.Get(ctx, exportID)
+	if err != nil {
+		if errors.Is(err, storage.ErrRecordNotFound) {
+			log.Warn("Data export not found")
+			return nil, ErrExportNotFound
+		}
+		log.Error("Failed to get data export", "msg", err.Error())
+		return nil, err
+	}
+	return export, nil
+}
+
+// ProcessNextExport builds the archive of the oldest pending export, reports false if there was none.
+func (a *UserDataService) ProcessNextExport(ctx context.Context) (bool, error) {
+	const op = "userdata.ProcessNextExport"
+	log := a.log.With("operation", op)
+	export, err := a.exportsRepo.ClaimNext(ctx, staleExportAfter)
+	if err != nil {
+		log.Error("Failed to claim data export", "msg", err.Error())
+		return false, err
+	}
+	if export == nil {
+		return false, nil
+	}
+	log = log.With("export_id", export.ID, "user_id", export.UserID)
+	archive, err := a.BuildArchive(ctx, export.UserID)
+	var encoded []byte
+	if err == nil {
+		encoded, err = json.Marshal(archive)
+	}
+	if err != nil {
+		if failErr := a.exportsRepo.Fail(ctx, export.ID, err.Error()); failErr != nil {
+			log.Error("Failed to mark data export as failed", "msg", failErr.Error())
+			return true, failErr
+		}
+		log.Warn("Data export failed", "msg", err.Error())
+		return true, nil
+	}
+	if err := a.exportsRepo.Complete(ctx, export.ID, encoded); err != nil {
+		log.Error("Failed to save data export", "msg", err.Error())
+		return true, err
+	}
+	log.Info("Data export completed", "size", len(encoded))
+	return true, nil
+}
+
+// RunExportWorker processes pending exports every interval until ctx is done.
+func (a *UserDataService) RunExportWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				processed, err := a.ProcessNextExport(ctx)
+				if err != nil || !processed {
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package userdata
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+)
+
+type usersRepo interface {
+	Get(ctx context.Context, params dtos.GetUserOptionsDTO) (*entity.User, error)
+	ListApps(ctx context.Context, userID int64) ([]entity.UserApp, error)
+	ListSessions(ctx context.Context, userID int64) ([]entity.Session, error)
+	GetMetadata(ctx context.Context, userID int64, appID int32) (*entity.UserMetadata, error)
+}
+
+type permissionsRepo interface {
+	ListGrantsForUser(ctx context.Context, userID int64) ([]entity.PermissionGrant, error)
+}
+
+type auditRepo interface {
+	Create(ctx context.Context, events ...entity.AuditEvent) error
+	ListForUser(ctx context.Context, userID int64) ([]entity.AuditEvent, error)
+}
+
+type exportsRepo interface {
+	Create(ctx context.Context, userID int64, requestedBy int64) (*entity.DataExport, error)
+	Get(ctx context.Context, exportID int64) (*entity.DataExport, error)
+	ClaimNext(ctx context.Context, staleAfter time.Duration) (*entity.DataExport, error)
+	Complete(ctx context.Context, exportID int64, archive []byte) error
+	Fail(ctx context.Context, exportID int64, reason string) error
+}
+
+type UserDataService struct {
+	log             *slog.Logger
+	usersRepo       usersRepo
+	permissionsRepo permissionsRepo
+	auditRepo       auditRepo
+	exportsRepo     exportsRepo
+}
+
+func New(
+	log *slog.Logger,
+	usersRepo usersRepo,
+	permissionsRepo permissionsRepo,
+	auditRepo auditRepo,
+	exportsRepo exportsRepo,
+) *UserDataService {
+	return &UserDataService{
+		log:             log,
+		usersRepo:       usersRepo,
+		permissionsRepo: permissionsRepo,
+		auditRepo:       auditRepo,
+		exportsRepo:     exportsRepo,
+	}
+}
@@ -5,5 +5,9 @@ import "errors"
 var (
 	ErrRecordNotFound      = errors.New("record not found")
 	ErrRecordAlreadyExists = errors.New("record already exists")
+	ErrRecordInUse         = errors.New("record is referenced by other records")
+	ErrReferenceCycle      = errors.New("record references itself")
+	ErrInvalidReference    = errors.New("record references a missing record")
+	ErrLastAdmin           = errors.New("the only active admin can't be demoted, deactivated or deleted")
+	ErrEditConflict        = errors.New("record has been changed concurrently")
 )
-
@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Listen subscribes to notifications of the channel on a dedicated connection, calls listening once
+// the subscription is in effect and then handle with payload of each of them until ctx is done or connection fails.
+func (s *Storage) Listen(ctx context.Context, channel string, listening func(), handle func(payload string)) error {
+	conn, err := s.DB.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// connection is in LISTEN state, so it must not be returned to the pool
+	pgConn := conn.Hijack()
+	defer pgConn.Close(context.Background())
+	if _, err := pgConn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+	listening()
+	for {
+		notification, err := pgConn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		handle(notification.Payload)
+	}
+}
@@ -3,6 +3,8 @@ package models
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -15,40 +17,279 @@ import (
 
 type AppModel struct {
 	DB *pgxpool.Pool
+	// Encrypts secrets at rest, secrets are stored in plaintext if it's nil
+	Cipher SecretCipher
+}
+
+type SecretCipher interface {
+	Encrypt(plaintext string) (string, error)
+	// Decrypt returns legacy plaintext values as is
+	Decrypt(value string) (string, error)
+	NeedsReencryption(value string) bool
+}
+
+func (a *AppModel) encryptSecret(secret string) (string, error) {
+	if a.Cipher == nil {
+		return secret, nil
+	}
+	return a.Cipher.Encrypt(secret)
+}
+
+func (a *AppModel) decryptSecrets(app *entity.App) (err error) {
+	if a.Cipher == nil {
+		return nil
+	}
+	if app.Secret, err = a.Cipher.Decrypt(app.Secret); err != nil {
+		return fmt.Errorf("app %d: secret: %w", app.ID, err)
+	}
+	if app.PreviousSecret, err = a.Cipher.Decrypt(app.PreviousSecret); err != nil {
+		return fmt.Errorf("app %d: previous secret: %w", app.ID, err)
+	}
+	return nil
+}
+
+// collectApps reads apps from rows and decrypts their secrets.
+func (a *AppModel) collectApps(rows pgx.Rows) ([]entity.App, error) {
+	apps, err := pgx.CollectRows(rows, pgx.RowToStructByName[entity.App])
+	if err != nil {
+		return nil, err
+	}
+	for i := range apps {
+		if err := a.decryptSecrets(&apps[i]); err != nil {
+			return nil, err
+		}
+	}
+	return apps, nil
+}
+
+// collectApp reads the only app from rows and decrypts its secrets.
+func (a *AppModel) collectApp(rows pgx.Rows) (*entity.App, error) {
+	app, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.App])
+	if err != nil {
+		return nil, err
+	}
+	return &app, a.decryptSecrets(&app)
 }
 
 func (a *AppModel) Create(ctx context.Context, app *entity.App) (int64, error) {
+	secret, err := a.encryptSecret(app.Secret)
+	if err != nil {
+		return 0, err
+	}
 	var appID int64
-	err := a.DB.QueryRow(
+	err = a.DB.QueryRow(
 		ctx,
-		"INSERT INTO apps (name, description, secret) VALUES ($1, $2, $3) RETURNING id",
+		"INSERT INTO apps (name, description, secret, embed_permissions, org_id) VALUES ($1, $2, $3, $4, nullif($5, 0)) RETURNING id",
 		app.Name,
 		app.Description,
-		app.Secret,
+		secret,
+		app.EmbedPermissions,
+		app.OrgID,
 	).Scan(&appID)
 	if err != nil {
 		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == postgres.UniqueViolationErrCode {
-			return 0, storage.ErrRecordAlreadyExists
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case postgres.UniqueViolationErrCode:
+				return 0, storage.ErrRecordAlreadyExists
+			case postgres.ForeignKeyViolationErrCode:
+				return 0, storage.ErrRecordNotFound
+			}
 		}
 		return 0, err
 	}
 	return appID, nil
 }
 
+// Columns of apps as expected by entity.App
+const appColumns = `id, name, coalesce(description, '') AS description, secret, embed_permissions,
+	coalesce(org_id, 0) AS org_id, coalesce(metadata_schema::text, '') AS metadata_schema,
+	CASE WHEN previous_secret_expires_at > now() THEN previous_secret ELSE '' END AS previous_secret,
+	CASE WHEN previous_secret_expires_at > now() THEN previous_secret_expires_at END AS previous_secret_expires_at,
+	(extract(epoch FROM access_token_ttl) * 1000000)::bigint * 1000 AS access_token_ttl,
+	(extract(epoch FROM refresh_token_ttl) * 1000000)::bigint * 1000 AS refresh_token_ttl,
+	(extract(epoch FROM activation_token_ttl) * 1000000)::bigint * 1000 AS activation_token_ttl,
+	login_methods, self_registration, require_activation`
+
 func (a *AppModel) Get(ctx context.Context, params dtos.GetAppOptionsDTO) (*entity.App, error) {
 	args := []any{params.AppID, params.AppName}
 	row, _ := a.DB.Query(
 		ctx,
-		`SELECT id, name, coalesce(description, '') AS description, secret FROM apps WHERE (id = $1 OR $1 = 0) AND (name = $2 OR $2 = '')`,
+		"SELECT "+appColumns+" FROM apps WHERE (id = $1 OR $1 = 0) AND (name = $2 OR $2 = '')",
 		args...,
 	)
-	app, err := pgx.CollectOneRow(row, pgx.RowToStructByName[entity.App])
+	app, err := a.collectApp(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return app, nil
+}
+
+func (a *AppModel) List(ctx context.Context) ([]entity.App, error) {
+	rows, _ := a.DB.Query(ctx, "SELECT "+appColumns+" FROM apps ORDER BY id")
+	return a.collectApps(rows)
+}
+
+// Update changes the set fields of the app and replaces its auth settings listed in params.SettingsFields.
+func (a *AppModel) Update(ctx context.Context, params dtos.UpdateAppDTO) (*entity.App, error) {
+	const query = `
+		UPDATE apps SET name = coalesce($2, name), description = coalesce($3, description),
+		embed_permissions = coalesce($4, embed_permissions),
+		access_token_ttl = CASE WHEN 'access_token_ttl' = ANY($5) THEN $6::bigint * interval '1 microsecond' ELSE access_token_ttl END,
+		refresh_token_ttl = CASE WHEN 'refresh_token_ttl' = ANY($5) THEN $7::bigint * interval '1 microsecond' ELSE refresh_token_ttl END,
+		activation_token_ttl = CASE WHEN 'activation_token_ttl' = ANY($5) THEN $8::bigint * interval '1 microsecond' ELSE activation_token_ttl END,
+		login_methods = CASE WHEN 'login_methods' = ANY($5) THEN $9 ELSE login_methods END,
+		self_registration = CASE WHEN 'self_registration' = ANY($5) THEN $10 ELSE self_registration END,
+		require_activation = CASE WHEN 'require_activation' = ANY($5) THEN $11 ELSE require_activation END
+		WHERE id = $1 RETURNING ` + appColumns
+	settings := params.Settings
+	rows, _ := a.DB.Query(
+		ctx,
+		query,
+		params.ID,
+		params.Name,
+		params.Description,
+		params.EmbedPermissions,
+		params.SettingsFields,
+		microseconds(settings.AccessTokenTTL),
+		microseconds(settings.RefreshTokenTTL),
+		microseconds(settings.ActivationTokenTTL),
+		settings.LoginMethods,
+		settings.SelfRegistration,
+		settings.RequireActivation,
+	)
+	app, err := a.collectApp(rows)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, storage.ErrRecordNotFound
+		case errors.As(err, &pgErr) && pgErr.Code == postgres.UniqueViolationErrCode:
+			return nil, storage.ErrRecordAlreadyExists
+		}
+		return nil, err
+	}
+	return app, nil
+}
+
+// microseconds converts the duration to be multiplied by interval '1 microsecond', nil stays NULL.
+func microseconds(d *time.Duration) *int64 {
+	if d == nil {
+		return nil
+	}
+	us := d.Microseconds()
+	return &us
+}
+
+// Delete deletes the app along with its permissions, policies and memberships of users.
+func (a *AppModel) Delete(ctx context.Context, appID int32) error {
+	tag, err := a.DB.Exec(ctx, "DELETE FROM apps WHERE id = $1", appID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SetMetadataSchema sets JSON Schema of app-private metadata of users, empty schema removes it.
+func (a *AppModel) SetMetadataSchema(ctx context.Context, appID int32, schema string) error {
+	tag, err := a.DB.Exec(ctx, "UPDATE apps SET metadata_schema = nullif($2, '')::jsonb WHERE id = $1", appID, schema)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RotateSecret replaces the secret of the app. The current secret is kept as the previous one until
+// previousExpiresAt, nil drops it at once. A previous secret left from an earlier rotation is dropped either way.
+func (a *AppModel) RotateSecret(ctx context.Context, appID int32, secret string, previousExpiresAt *time.Time) (*entity.App, error) {
+	const query = `
+		UPDATE apps SET
+		previous_secret = CASE WHEN $3::timestamptz IS NOT NULL THEN secret END,
+		previous_secret_expires_at = $3, secret = $2
+		WHERE id = $1 RETURNING ` + appColumns
+	encrypted, err := a.encryptSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	rows, _ := a.DB.Query(ctx, query, appID, encrypted, previousExpiresAt)
+	app, err := a.collectApp(rows)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, storage.ErrRecordNotFound
 		}
 		return nil, err
 	}
-	return &app, nil
+	return app, nil
+}
+
+// DeleteExpiredSecrets drops previous secrets whose grace period is over, returns the number of affected apps.
+func (a *AppModel) DeleteExpiredSecrets(ctx context.Context) (int64, error) {
+	const query = `
+		UPDATE apps SET previous_secret = NULL, previous_secret_expires_at = NULL
+		WHERE previous_secret_expires_at <= now()`
+	tag, err := a.DB.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ReencryptSecrets encrypts secrets stored in plaintext or with an old master key with the current one,
+// returns the number of updated apps. Secrets changed concurrently are left as they are.
+func (a *AppModel) ReencryptSecrets(ctx context.Context) (int, error) {
+	if a.Cipher == nil {
+		return 0, errors.New("no cipher to encrypt secrets with")
+	}
+	type storedSecrets struct {
+		ID             int64  `db:"id"`
+		Secret         string `db:"secret"`
+		PreviousSecret string `db:"previous_secret"`
+	}
+	rows, _ := a.DB.Query(ctx, "SELECT id, secret, coalesce(previous_secret, '') AS previous_secret FROM apps ORDER BY id")
+	stored, err := pgx.CollectRows(rows, pgx.RowToStructByName[storedSecrets])
+	if err != nil {
+		return 0, err
+	}
+	reencrypt := func(value string) (string, error) {
+		if value == "" || !a.Cipher.NeedsReencryption(value) {
+			return value, nil
+		}
+		plaintext, err := a.Cipher.Decrypt(value)
+		if err != nil {
+			return "", err
+		}
+		return a.Cipher.Encrypt(plaintext)
+	}
+	updated := 0
+	for _, app := range stored {
+		secret, err := reencrypt(app.Secret)
+		if err != nil {
+			return updated, fmt.Errorf("app %d: secret: %w", app.ID, err)
+		}
+		previousSecret, err := reencrypt(app.PreviousSecret)
+		if err != nil {
+			return updated, fmt.Errorf("app %d: previous secret: %w", app.ID, err)
+		}
+		if secret == app.Secret && previousSecret == app.PreviousSecret {
+			continue
+		}
+		const query = `
+			UPDATE apps SET secret = $2, previous_secret = nullif($3, '')
+			WHERE id = $1 AND secret = $4 AND coalesce(previous_secret, '') = $5`
+		tag, err := a.DB.Exec(ctx, query, app.ID, secret, previousSecret, app.Secret, app.PreviousSecret)
+		if err != nil {
+			return updated, err
+		}
+		updated += int(tag.RowsAffected())
+	}
+	return updated, nil
 }
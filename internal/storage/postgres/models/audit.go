@@ -0,0 +1,56 @@
+package models
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sso.service/internal/entity"
+)
+
+type AuditModel struct {
+	DB *pgxpool.Pool
+}
+
+func (a *AuditModel) Create(ctx context.Context, events ...entity.AuditEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return a.DB.SendBatch(ctx, auditBatch(events)).Close()
+}
+
+// createAuditEvents records the events within the transaction making the change they describe.
+func createAuditEvents(ctx context.Context, tx pgx.Tx, events ...entity.AuditEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return tx.SendBatch(ctx, auditBatch(events)).Close()
+}
+
+func auditBatch(events []entity.AuditEvent) *pgx.Batch {
+	batch := &pgx.Batch{}
+	for _, event := range events {
+		payload := event.Payload
+		if payload == nil {
+			payload = map[string]any{}
+		}
+		batch.Queue(
+			"INSERT INTO audit_events (type, actor_id, user_id, payload) VALUES ($1, $2, $3, $4)",
+			event.Type,
+			event.ActorID,
+			event.UserID,
+			payload,
+		)
+	}
+	return batch
+}
+
+// ListForUser returns events affecting the user or made by the user, oldest first.
+func (a *AuditModel) ListForUser(ctx context.Context, userID int64) ([]entity.AuditEvent, error) {
+	const query = `
+		SELECT id, type, actor_id, user_id, payload, created_at FROM audit_events
+		WHERE user_id = $1 OR actor_id = $1
+		ORDER BY id`
+	rows, _ := a.DB.Query(ctx, query, userID)
+	return pgx.CollectRows(rows, pgx.RowToStructByName[entity.AuditEvent])
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sso.service/internal/entity"
+	"sso.service/internal/storage"
+	"sso.service/internal/storage/postgres"
+)
+
+const dataExportColumns = "id, user_id, requested_by, status, error, archive, created_at, completed_at"
+
+type DataExportModel struct {
+	DB *pgxpool.Pool
+}
+
+func (d *DataExportModel) Create(ctx context.Context, userID int64, requestedBy int64) (*entity.DataExport, error) {
+	query := "INSERT INTO data_exports (user_id, requested_by) VALUES ($1, $2) RETURNING " + dataExportColumns
+	rows, _ := d.DB.Query(ctx, query, userID, requestedBy)
+	export, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.DataExport])
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == postgres.ForeignKeyViolationErrCode {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (d *DataExportModel) Get(ctx context.Context, exportID int64) (*entity.DataExport, error) {
+	rows, _ := d.DB.Query(ctx, "SELECT "+dataExportColumns+" FROM data_exports WHERE id = $1", exportID)
+	export, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.DataExport])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+// ClaimNext marks the oldest pending export as running and returns it, nil if there is nothing to do.
+// Exports running for longer than staleAfter are considered abandoned (e.g. by a crashed replica) and claimed again.
+// Concurrent callers never claim the same export.
+func (d *DataExportModel) ClaimNext(ctx context.Context, staleAfter time.Duration) (*entity.DataExport, error) {
+	query := `
+		UPDATE data_exports SET status = 'running', started_at = now()
+		WHERE id = (
+			SELECT id FROM data_exports
+			WHERE status = 'pending' OR status = 'running' AND started_at < now() - $1::interval
+			ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED
+		) RETURNING ` + dataExportColumns
+	rows, _ := d.DB.Query(ctx, query, staleAfter)
+	export, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.DataExport])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (d *DataExportModel) Complete(ctx context.Context, exportID int64, archive []byte) error {
+	const query = "UPDATE data_exports SET status = 'completed', archive = $2, completed_at = now() WHERE id = $1"
+	_, err := d.DB.Exec(ctx, query, exportID, archive)
+	return err
+}
+
+func (d *DataExportModel) Fail(ctx context.Context, exportID int64, reason string) error {
+	const query = "UPDATE data_exports SET status = 'failed', error = $2, completed_at = now() WHERE id = $1"
+	_, err := d.DB.Exec(ctx, query, exportID, reason)
+	return err
+}
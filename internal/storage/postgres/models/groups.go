@@ -0,0 +1,191 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sso.service/internal/entity"
+	"sso.service/internal/storage"
+	"sso.service/internal/storage/postgres"
+)
+
+type GroupModel struct {
+	DB *pgxpool.Pool
+}
+
+func (g *GroupModel) Create(ctx context.Context, group *entity.Group) (int64, error) {
+	var groupID int64
+	err := g.DB.QueryRow(
+		ctx,
+		"INSERT INTO groups (org_id, name, description) VALUES (nullif($1, 0), $2, $3) RETURNING id",
+		group.OrgID,
+		group.Name,
+		group.Description,
+	).Scan(&groupID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case postgres.UniqueViolationErrCode:
+				return 0, storage.ErrRecordAlreadyExists
+			case postgres.ForeignKeyViolationErrCode:
+				return 0, storage.ErrInvalidReference
+			}
+		}
+		return 0, err
+	}
+	return groupID, nil
+}
+
+func (g *GroupModel) Get(ctx context.Context, groupID int64) (*entity.Group, error) {
+	rows, _ := g.DB.Query(ctx, "SELECT id, coalesce(org_id, 0) AS org_id, name, description, created_at FROM groups WHERE id = $1", groupID)
+	group, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.Group])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (g *GroupModel) Delete(ctx context.Context, groupID int64) error {
+	res, err := g.DB.Exec(ctx, "DELETE FROM groups WHERE id = $1", groupID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return storage.ErrRecordNotFound
+	}
+	return nil
+}
+
+// AddMembers adds users and groups to the group, existing members are skipped.
+// Adding a group which already contains the group (directly or through nested groups) fails with storage.ErrReferenceCycle,
+// adding a group of another org (only shared groups can be nested into any group) fails with storage.ErrInvalidReference.
+func (g *GroupModel) AddMembers(ctx context.Context, groupID int64, members entity.GroupMembers) error {
+	tx, err := g.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if len(members.GroupIDs) > 0 {
+		// concurrent nesting of groups into each other must not be able to create a cycle
+		if _, err := tx.Exec(ctx, "LOCK TABLE group_members IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+			return err
+		}
+		const cycleQuery = `
+			WITH RECURSIVE nested(id) AS (
+				SELECT unnest($2::bigint[])
+				UNION
+				SELECT gm.member_group_id FROM group_members gm JOIN nested n ON gm.group_id = n.id
+				WHERE gm.member_group_id IS NOT NULL
+			) SELECT EXISTS (SELECT 1 FROM nested WHERE id = $1)`
+		var cycle bool
+		if err := tx.QueryRow(ctx, cycleQuery, groupID, members.GroupIDs).Scan(&cycle); err != nil {
+			return err
+		}
+		if cycle {
+			return storage.ErrReferenceCycle
+		}
+		const orgQuery = `
+			SELECT EXISTS (
+				SELECT 1 FROM groups g JOIN groups m ON m.id = ANY($2)
+				WHERE g.id = $1 AND m.org_id IS NOT NULL AND m.org_id IS DISTINCT FROM g.org_id
+			)`
+		var otherOrg bool
+		if err := tx.QueryRow(ctx, orgQuery, groupID, members.GroupIDs).Scan(&otherOrg); err != nil {
+			return err
+		}
+		if otherOrg {
+			return storage.ErrInvalidReference
+		}
+	}
+	const query = `
+		INSERT INTO group_members (group_id, user_id, member_group_id)
+		SELECT $1, user_id, NULL FROM unnest($2::bigint[]) AS user_id
+		UNION ALL
+		SELECT $1, NULL, member_group_id FROM unnest($3::bigint[]) AS member_group_id
+		ON CONFLICT DO NOTHING`
+	if _, err := tx.Exec(ctx, query, groupID, members.UserIDs, members.GroupIDs); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == postgres.ForeignKeyViolationErrCode {
+			return storage.ErrRecordNotFound
+		}
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (g *GroupModel) RemoveMembers(ctx context.Context, groupID int64, members entity.GroupMembers) error {
+	const query = `
+		DELETE FROM group_members
+		WHERE group_id = $1 AND (user_id = ANY($2) OR member_group_id = ANY($3))`
+	_, err := g.DB.Exec(ctx, query, groupID, members.UserIDs, members.GroupIDs)
+	return err
+}
+
+func (g *GroupModel) ListMembers(ctx context.Context, groupID int64) (*entity.GroupMembers, error) {
+	const query = `
+		SELECT user_id, member_group_id FROM group_members WHERE group_id = $1
+		ORDER BY user_id NULLS LAST, member_group_id`
+	rows, err := g.DB.Query(ctx, query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	members := &entity.GroupMembers{UserIDs: []int64{}, GroupIDs: []int64{}}
+	for rows.Next() {
+		var userID, memberGroupID *int64
+		if err := rows.Scan(&userID, &memberGroupID); err != nil {
+			return nil, err
+		}
+		if userID != nil {
+			members.UserIDs = append(members.UserIDs, *userID)
+		} else {
+			members.GroupIDs = append(members.GroupIDs, *memberGroupID)
+		}
+	}
+	return members, rows.Err()
+}
+
+// GrantPermissions grants existing permissions of the app namespace to the group.
+func (g *GroupModel) GrantPermissions(ctx context.Context, groupID int64, appID int32, codes []string) error {
+	const query = `
+		INSERT INTO group_permissions (group_id, permission_id)
+		SELECT $1, p.id FROM permissions p
+		WHERE p.code = ANY($2) AND p.app_id IS NOT DISTINCT FROM nullif($3, 0)
+		ON CONFLICT DO NOTHING`
+	if _, err := g.DB.Exec(ctx, query, groupID, codes, appID); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == postgres.ForeignKeyViolationErrCode {
+			return storage.ErrRecordNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (g *GroupModel) RevokePermissions(ctx context.Context, groupID int64, appID int32, codes []string) error {
+	const query = `
+		DELETE FROM group_permissions gp USING permissions p
+		WHERE gp.permission_id = p.id AND gp.group_id = $1 AND p.code = ANY($2)
+		AND p.app_id IS NOT DISTINCT FROM nullif($3, 0)`
+	_, err := g.DB.Exec(ctx, query, groupID, codes, appID)
+	return err
+}
+
+func (g *GroupModel) ListPermissions(ctx context.Context, groupID int64) ([]entity.Permission, error) {
+	const query = `
+		SELECT p.id, p.code, coalesce(p.app_id, 0) AS app_id FROM permissions p
+		JOIN group_permissions gp ON gp.permission_id = p.id
+		WHERE gp.group_id = $1 ORDER BY p.app_id NULLS FIRST, p.code`
+	rows, err := g.DB.Query(ctx, query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[entity.Permission])
+}
@@ -6,6 +6,13 @@ type Models struct {
 	User *UserModel
 	App *AppModel
 	Permission *PermissionModel
+	Role *RoleModel
+	Audit *AuditModel
+	Policy *PolicyModel
+	RelationTuple *RelationTupleModel
+	Group *GroupModel
+	Org *OrgModel
+	DataExport *DataExportModel
 }
 
 func New(db *pgxpool.Pool) *Models {
@@ -13,5 +20,12 @@ func New(db *pgxpool.Pool) *Models {
 		User: &UserModel{DB: db},
 		App: &AppModel{DB: db},
 		Permission: &PermissionModel{DB: db},
+		Role: &RoleModel{DB: db},
+		Audit: &AuditModel{DB: db},
+		Policy: &PolicyModel{DB: db},
+		RelationTuple: &RelationTupleModel{DB: db},
+		Group: &GroupModel{DB: db},
+		Org: &OrgModel{DB: db},
+		DataExport: &DataExportModel{DB: db},
 	}
 }
\ No newline at end of file
@@ -0,0 +1,203 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sso.service/internal/entity"
+	"sso.service/internal/storage"
+	"sso.service/internal/storage/postgres"
+)
+
+// roleOrgConstraint is reported by the trigger refusing to assign a role of another org.
+const roleOrgConstraint = "role_org"
+
+type OrgModel struct {
+	DB *pgxpool.Pool
+}
+
+func (o *OrgModel) Create(ctx context.Context, org *entity.Org) (int64, error) {
+	var orgID int64
+	err := o.DB.QueryRow(ctx, "INSERT INTO orgs (name) VALUES ($1) RETURNING id", org.Name).Scan(&orgID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == postgres.UniqueViolationErrCode {
+			return 0, storage.ErrRecordAlreadyExists
+		}
+		return 0, err
+	}
+	return orgID, nil
+}
+
+func (o *OrgModel) Get(ctx context.Context, orgID int64) (*entity.Org, error) {
+	rows, _ := o.DB.Query(ctx, "SELECT id, name, created_at FROM orgs WHERE id = $1", orgID)
+	org, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.Org])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &org, nil
+}
+
+// ListForUser returns orgs the user is a member of.
+func (o *OrgModel) ListForUser(ctx context.Context, userID int64) ([]entity.Org, error) {
+	const query = `
+		SELECT o.id, o.name, o.created_at FROM orgs o
+		JOIN org_members om ON om.org_id = o.id
+		WHERE om.user_id = $1 ORDER BY o.id`
+	rows, err := o.DB.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[entity.Org])
+}
+
+// Delete deletes the org along with memberships and grants limited to it.
+// Orgs still owning apps can't be deleted.
+func (o *OrgModel) Delete(ctx context.Context, orgID int64) error {
+	res, err := o.DB.Exec(ctx, "DELETE FROM orgs WHERE id = $1", orgID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == postgres.ForeignKeyViolationErrCode {
+			return storage.ErrRecordInUse
+		}
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return storage.ErrRecordNotFound
+	}
+	return nil
+}
+
+// AddMember adds the user to the org with the role. Unknown org or user result in storage.ErrRecordNotFound.
+func (o *OrgModel) AddMember(ctx context.Context, member *entity.OrgMember) error {
+	_, err := o.DB.Exec(
+		ctx,
+		"INSERT INTO org_members (org_id, user_id, role) VALUES ($1, $2, $3)",
+		member.OrgID,
+		member.UserID,
+		member.Role,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case postgres.UniqueViolationErrCode:
+				return storage.ErrRecordAlreadyExists
+			case postgres.ForeignKeyViolationErrCode:
+				if pgErr.ConstraintName == roleOrgConstraint {
+					return storage.ErrInvalidReference
+				}
+				return storage.ErrRecordNotFound
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+func (o *OrgModel) GetMember(ctx context.Context, orgID int64, userID int64) (*entity.OrgMember, error) {
+	rows, _ := o.DB.Query(
+		ctx,
+		"SELECT org_id, user_id, role, joined_at FROM org_members WHERE org_id = $1 AND user_id = $2",
+		orgID,
+		userID,
+	)
+	member, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.OrgMember])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (o *OrgModel) UpdateMemberRole(ctx context.Context, orgID int64, userID int64, role string) (*entity.OrgMember, error) {
+	rows, _ := o.DB.Query(
+		ctx,
+		"UPDATE org_members SET role = $3 WHERE org_id = $1 AND user_id = $2 RETURNING org_id, user_id, role, joined_at",
+		orgID,
+		userID,
+		role,
+	)
+	member, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.OrgMember])
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, storage.ErrRecordNotFound
+		case errors.As(err, &pgErr) && pgErr.Code == postgres.ForeignKeyViolationErrCode:
+			return nil, storage.ErrInvalidReference
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// RemoveMember removes the user from the org along with permissions granted to the user within the org.
+func (o *OrgModel) RemoveMember(ctx context.Context, orgID int64, userID int64) error {
+	tx, err := o.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	res, err := tx.Exec(ctx, "DELETE FROM org_members WHERE org_id = $1 AND user_id = $2", orgID, userID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return storage.ErrRecordNotFound
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM users_permissions WHERE org_id = $1 AND user_id = $2", orgID, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ListMembershipsForUsers returns memberships of any of the users in orgs.
+func (o *OrgModel) ListMembershipsForUsers(ctx context.Context, userIDs []int64) ([]entity.OrgMember, error) {
+	rows, err := o.DB.Query(
+		ctx,
+		"SELECT org_id, user_id, role, joined_at FROM org_members WHERE user_id = ANY($1) ORDER BY user_id, joined_at, org_id",
+		userIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[entity.OrgMember])
+}
+
+func (o *OrgModel) ListMembers(ctx context.Context, orgID int64) ([]entity.OrgMember, error) {
+	rows, err := o.DB.Query(
+		ctx,
+		"SELECT org_id, user_id, role, joined_at FROM org_members WHERE org_id = $1 ORDER BY joined_at, user_id",
+		orgID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[entity.OrgMember])
+}
+
+// AppOrgID returns id of the org owning the app, entity.NoOrgID for apps shared by all of the orgs
+// and for the global namespace.
+func (o *OrgModel) AppOrgID(ctx context.Context, appID int32) (int64, error) {
+	if appID == entity.GlobalAppID {
+		return entity.NoOrgID, nil
+	}
+	var orgID int64
+	err := o.DB.QueryRow(ctx, "SELECT coalesce(org_id, 0) FROM apps WHERE id = $1", appID).Scan(&orgID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, storage.ErrRecordNotFound
+		}
+		return 0, err
+	}
+	return orgID, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -14,17 +15,87 @@ import (
 	"sso.service/internal/storage/postgres"
 )
 
+// userGrantsQuery builds a query selecting ids (and expiry) of all permissions of the user identified by userIDExpr
+// (a query param or a column of the outer query), either granted directly (and not expired yet),
+// through the user's global role, through the user's role in an org or through any of the user's groups, nested ones included
+// (permissions of a group of an org are limited to the org).
+// Each row also contains the source of the grant (see entity.PermissionSource), id and name of the role or group
+// and the org the grant is limited to (NULL if it's effective in every org), see orgGrantsFilter.
+// The same permission may be returned several times, once per source.
+func userGrantsQuery(userIDExpr string) string {
+	return fmt.Sprintf(`
+		SELECT up.permission_id, up.expires_at, 'direct' AS source, NULL::bigint AS source_id, NULL::text AS source_name, up.org_id
+		FROM users_permissions up
+		WHERE up.user_id = %[1]s AND (up.expires_at IS NULL OR up.expires_at > now())
+		UNION ALL
+		SELECT rp.permission_id, NULL, 'role', r.id, r.name, NULL FROM role_permissions rp
+		JOIN roles r ON r.id = rp.role_id
+		JOIN users u ON u.role = r.name
+		WHERE u.id = %[1]s
+		UNION ALL
+		SELECT rp.permission_id, NULL, 'role', r.id, r.name, om.org_id FROM role_permissions rp
+		JOIN roles r ON r.id = rp.role_id
+		JOIN org_members om ON om.role = r.name
+		WHERE om.user_id = %[1]s
+		UNION ALL
+		SELECT gp.permission_id, NULL, 'group', g.id, g.name, g.org_id FROM group_permissions gp
+		JOIN groups g ON g.id = gp.group_id
+		WHERE gp.group_id IN (%[2]s)`, userIDExpr, userGroupsQuery(userIDExpr))
+}
+
+// orgGrantsFilter builds a condition keeping only grants (rows of userGrantsQuery aliased as grantsAlias)
+// effective in the org owning the app identified by appIDExpr. Apps shared by all of the orgs
+// and the global namespace see only grants which are not limited to an org.
+func orgGrantsFilter(grantsAlias string, appIDExpr string) string {
+	return fmt.Sprintf(
+		"(%[1]s.org_id IS NULL OR %[1]s.org_id = (SELECT a.org_id FROM apps a WHERE a.id = %[2]s))",
+		grantsAlias,
+		appIDExpr,
+	)
+}
+
+// userGroupsQuery builds a query selecting ids of groups the user is a member of, directly or through nested groups.
+// UNION stops the recursion even if groups happen to form a cycle.
+func userGroupsQuery(userIDExpr string) string {
+	return fmt.Sprintf(`
+		WITH RECURSIVE user_groups(id) AS (
+			SELECT gm.group_id FROM group_members gm WHERE gm.user_id = %[1]s
+			UNION
+			SELECT gm.group_id FROM group_members gm JOIN user_groups ug ON gm.member_group_id = ug.id
+		) SELECT id FROM user_groups`, userIDExpr)
+}
+
 type PermissionModel struct {
 	DB *pgxpool.Pool
 }
 
-func (p *PermissionModel) AddForUserIgnoreConflict(ctx context.Context, userID int64, codes []string) ([]int, error) {
-	const query = `INSERT INTO users_permissions AS up (user_id, permission_id)
-		SELECT $1, p.id FROM permissions p WHERE p.code = ANY($2)
-		ON CONFLICT DO NOTHING
+func (p *PermissionModel) AddForUserIgnoreConflict(ctx context.Context, userID int64, appID int32, codes []string) ([]int, error) {
+	grants := make([]dtos.PermissionGrantDTO, len(codes))
+	for i, code := range codes {
+		grants[i] = dtos.PermissionGrantDTO{Code: code}
+	}
+	return p.GrantForUser(ctx, userID, appID, entity.NoOrgID, grants)
+}
+
+// GrantForUser grants existing permissions of the app namespace to the user within the org
+// (entity.NoOrgID for every org) and returns ids of the granted ones.
+// Already granted temporary permissions are extended if the new grant lasts longer,
+// permanent grants are left as is.
+func (p *PermissionModel) GrantForUser(ctx context.Context, userID int64, appID int32, orgID int64, grants []dtos.PermissionGrantDTO) ([]int, error) {
+	const query = `INSERT INTO users_permissions AS up (user_id, permission_id, expires_at, org_id)
+		SELECT $1, p.id, g.expires_at, nullif($5, 0) FROM unnest($2::text[], $3::timestamptz[]) AS g(code, expires_at)
+		JOIN permissions p ON p.code = g.code AND p.app_id IS NOT DISTINCT FROM nullif($4, 0)
+		ON CONFLICT (user_id, permission_id, org_id) DO UPDATE SET expires_at = EXCLUDED.expires_at
+		WHERE up.expires_at IS NOT NULL AND (EXCLUDED.expires_at IS NULL OR EXCLUDED.expires_at > up.expires_at)
 		RETURNING up.permission_id
 	`
-	args := []any{userID, codes}
+	codes := make([]string, len(grants))
+	expiries := make([]*time.Time, len(grants))
+	for i, grant := range grants {
+		codes[i] = grant.Code
+		expiries[i] = grant.ExpiresAt
+	}
+	args := []any{userID, codes, expiries, appID, orgID}
 	var permissionIds []int
 	rows, err := p.DB.Query(ctx, query, args...)
 	if err != nil {
@@ -45,29 +116,46 @@ func (p *PermissionModel) AddForUserIgnoreConflict(ctx context.Context, userID i
 	return permissionIds, nil
 }
 
-func (p *PermissionModel) CreateManyIgnoreConflict(ctx context.Context, codes []string) error {
-	codes_len := len(codes)
-	if codes_len == 0 {
+// DeleteExpiredGrants removes grants which expired by now and returns them.
+func (p *PermissionModel) DeleteExpiredGrants(ctx context.Context) ([]entity.PermissionGrant, error) {
+	const query = `
+		DELETE FROM users_permissions up USING permissions p
+		WHERE up.permission_id = p.id AND up.expires_at <= now()
+		RETURNING up.user_id, coalesce(up.org_id, 0), p.id, p.code, coalesce(p.app_id, 0), up.granted_at, up.expires_at`
+	rows, err := p.DB.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.PermissionGrant, error) {
+		var grant entity.PermissionGrant
+		err := row.Scan(&grant.UserID, &grant.OrgID, &grant.Permission.ID, &grant.Permission.Code, &grant.Permission.AppID, &grant.GrantedAt, &grant.ExpiresAt)
+		return grant, err
+	})
+}
+
+// CreateManyIgnoreConflict creates permissions in the namespace of the app (or the global one for entity.GlobalAppID).
+func (p *PermissionModel) CreateManyIgnoreConflict(ctx context.Context, appID int32, codes []string) error {
+	if len(codes) == 0 {
 		return nil
 	}
-	args := make([]any, codes_len)
-	query := `INSERT INTO permissions (code) VALUES `
-	for i, code := range codes {
-		format := "($%d)"
-		if i != codes_len-1 {
-			format += ", "
+	const query = `
+		INSERT INTO permissions (app_id, code)
+		SELECT nullif($1, 0), code FROM unnest($2::text[]) AS code
+		ON CONFLICT DO NOTHING`
+	_, err := p.DB.Exec(ctx, query, appID, codes)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == postgres.ForeignKeyViolationErrCode {
+			return storage.ErrRecordNotFound
 		}
-		query += fmt.Sprintf(format, i+1)
-		args[i] = code
+		return err
 	}
-	query += " ON CONFLICT DO NOTHING"
-	_, err := p.DB.Exec(ctx, query, args...)
-	return err
+	return nil
 }
 
 func (p *PermissionModel) FetchMany(ctx context.Context, options dtos.FetchManyPermissionsOptionsDTO) ([]entity.Permission, error) {
 	const query = `
-		SELECT * FROM permissions 
+		SELECT id, code, coalesce(app_id, 0) AS app_id FROM permissions
 		WHERE (id = ANY ($1) OR $1 IS NULL) AND 
 		(code = ANY ($2) OR $2 IS NULL)`
 	rows, err := p.DB.Query(ctx, query, options.Ids, options.Codes)
@@ -85,9 +173,10 @@ func (p *PermissionModel) FetchMany(ctx context.Context, options dtos.FetchManyP
 func (p *PermissionModel) Get(ctx context.Context, params dtos.GetPermissionOptionsDTO) (*entity.Permission, error) {
 	var permission entity.Permission
 	const query = `
-		SELECT * FROM permissions WHERE (id = $1 OR $1 = 0) AND (code = $2 OR $2 = '')`
-	args := []any{params.ID, params.Code}
-	err := p.DB.QueryRow(ctx, query, args...).Scan(&permission.ID, &permission.Code)
+		SELECT id, code, coalesce(app_id, 0) FROM permissions
+		WHERE (id = $1 OR $1 = 0) AND (code = $2 OR $2 = '') AND app_id IS NOT DISTINCT FROM nullif($3, 0)`
+	args := []any{params.ID, params.Code, params.AppID}
+	err := p.DB.QueryRow(ctx, query, args...).Scan(&permission.ID, &permission.Code, &permission.AppID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, storage.ErrRecordNotFound
@@ -97,19 +186,123 @@ func (p *PermissionModel) Get(ctx context.Context, params dtos.GetPermissionOpti
 	return &permission, nil
 }
 
-func (p *PermissionModel) ExistsForUser(ctx context.Context, userID int64, code string) (bool, error) {
-	var exists bool
+// ListForUser returns permissions of the user in the namespace of the app and the global one
+// effective in the org owning the app, and the moment when the earliest of temporary grants expires (nil if there are none).
+func (p *PermissionModel) ListForUser(ctx context.Context, userID int64, appID int32) ([]entity.Permission, *time.Time, error) {
+	grants, err := p.ListEffectiveForUser(ctx, userID, appID)
+	if err != nil {
+		return nil, nil, err
+	}
+	granted := []entity.Permission{}
+	seen := make(map[int64]bool, len(grants))
+	var validUntil *time.Time
+	for _, grant := range grants {
+		if !seen[grant.Permission.ID] {
+			seen[grant.Permission.ID] = true
+			granted = append(granted, grant.Permission)
+		}
+		if grant.ExpiresAt != nil && (validUntil == nil || grant.ExpiresAt.Before(*validUntil)) {
+			validUntil = grant.ExpiresAt
+		}
+	}
+	return granted, validUntil, nil
+}
+
+// ListEffectiveForUser returns permissions of the user in the namespace of the app and the global one
+// effective in the org owning the app, with the source of each of them.
+func (p *PermissionModel) ListEffectiveForUser(ctx context.Context, userID int64, appID int32) ([]entity.EffectivePermission, error) {
+	query := `
+		SELECT u.id IS NOT NULL, p.id, p.code, coalesce(p.app_id, 0), coalesce(g.org_id, 0), g.expires_at, g.source, g.source_id, g.source_name
+		FROM (SELECT $1::bigint AS id) q
+		LEFT JOIN users u ON u.id = q.id
+		LEFT JOIN LATERAL (` + userGrantsQuery("q.id") + `) g ON ` + orgGrantsFilter("g", "$2") + `
+		LEFT JOIN permissions p ON p.id = g.permission_id AND (p.app_id IS NULL OR p.app_id = $2)
+		ORDER BY p.app_id NULLS FIRST, p.code, g.source, g.source_id`
+	rows, err := p.DB.Query(ctx, query, userID, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	grants := []entity.EffectivePermission{}
+	userFound := false
+	for rows.Next() {
+		var permID *int64
+		var permCode, source, sourceName *string
+		var permAppID *int32
+		var orgID int64
+		var sourceID *int64
+		var expiresAt *time.Time
+		err := rows.Scan(&userFound, &permID, &permCode, &permAppID, &orgID, &expiresAt, &source, &sourceID, &sourceName)
+		if err != nil {
+			return nil, err
+		}
+		if permID == nil {
+			continue
+		}
+		grant := entity.EffectivePermission{
+			Permission: entity.Permission{ID: *permID, Code: *permCode, AppID: *permAppID},
+			OrgID:      orgID,
+			Source:     *source,
+			ExpiresAt:  expiresAt,
+		}
+		if sourceID != nil {
+			grant.SourceID = *sourceID
+			grant.SourceName = *sourceName
+		}
+		grants = append(grants, grant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if !userFound {
+		return nil, storage.ErrRecordNotFound
+	}
+	return grants, nil
+}
+
+// FindUsersGranted checks for each of the users whether any of candidate codes is granted to them
+// either in the namespace of the app or in the global one, within the org owning the app.
+// Results are in the order of userIDs.
+func (p *PermissionModel) FindUsersGranted(ctx context.Context, userIDs []int64, appID int32, candidates []string) ([]dtos.UserPermissionCheckDTO, error) {
+	query := `
+		SELECT q.id, u.id IS NOT NULL, EXISTS(
+			SELECT 1 FROM (` + userGrantsQuery("q.id") + `) g
+			JOIN permissions p ON p.id = g.permission_id
+			WHERE p.code = ANY($2) AND (p.app_id = $3 OR p.app_id IS NULL) AND ` + orgGrantsFilter("g", "$3") + `
+		) FROM unnest($1::bigint[]) WITH ORDINALITY AS q(id, ord)
+		LEFT JOIN users u ON u.id = q.id
+		ORDER BY q.ord`
+	args := []any{userIDs, candidates, appID}
+	rows, err := p.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (dtos.UserPermissionCheckDTO, error) {
+		var check dtos.UserPermissionCheckDTO
+		err := row.Scan(&check.UserID, &check.UserFound, &check.HasPermission)
+		return check, err
+	})
+}
+
+// ListGrantsForUser returns permissions granted directly to the user, expired ones which haven't been swept yet included.
+func (p *PermissionModel) ListGrantsForUser(ctx context.Context, userID int64) ([]entity.PermissionGrant, error) {
+	return p.ListGrantsForUsers(ctx, []int64{userID})
+}
+
+// ListGrantsForUsers returns permissions granted directly to any of the users, see ListGrantsForUser.
+func (p *PermissionModel) ListGrantsForUsers(ctx context.Context, userIDs []int64) ([]entity.PermissionGrant, error) {
 	const query = `
-		SELECT EXISTS(
-			SELECT 1 FROM users_permissions WHERE user_id = $1 
-			AND permission_id = (
-				SELECT id FROM permissions WHERE code = $2
-			)
-		)`
-	args := []any{userID, code}
-	err := p.DB.QueryRow(ctx, query, args...).Scan(&exists)
+		SELECT up.user_id, coalesce(up.org_id, 0), p.id, p.code, coalesce(p.app_id, 0), up.granted_at, up.expires_at
+		FROM users_permissions up JOIN permissions p ON p.id = up.permission_id
+		WHERE up.user_id = ANY($1)
+		ORDER BY up.user_id, up.granted_at, p.id`
+	rows, err := p.DB.Query(ctx, query, userIDs)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	return exists, nil
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.PermissionGrant, error) {
+		var grant entity.PermissionGrant
+		err := row.Scan(&grant.UserID, &grant.OrgID, &grant.Permission.ID, &grant.Permission.Code, &grant.Permission.AppID, &grant.GrantedAt, &grant.ExpiresAt)
+		return grant, err
+	})
 }
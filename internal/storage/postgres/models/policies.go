@@ -0,0 +1,137 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sso.service/internal/entity"
+	"sso.service/internal/storage"
+	"sso.service/internal/storage/postgres"
+)
+
+const policyColumns = `id, coalesce(org_id, 0) AS org_id, coalesce(app_id, 0) AS app_id, name, description, effect, actions,
+	condition, priority, is_enabled, created_at, updated_at`
+
+type PolicyModel struct {
+	DB *pgxpool.Pool
+}
+
+func policyWriteError(err error) error {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return storage.ErrRecordNotFound
+	case errors.As(err, &pgErr) && pgErr.Code == postgres.UniqueViolationErrCode:
+		return storage.ErrRecordAlreadyExists
+	case errors.As(err, &pgErr) && pgErr.Code == postgres.ForeignKeyViolationErrCode && pgErr.ConstraintName == "policies_org_id_fkey":
+		return storage.ErrInvalidReference
+	case errors.As(err, &pgErr) && pgErr.Code == postgres.ForeignKeyViolationErrCode:
+		return storage.ErrRecordNotFound
+	}
+	return err
+}
+
+func (p *PolicyModel) Create(ctx context.Context, policy *entity.Policy) (int64, error) {
+	const query = `
+		INSERT INTO policies (app_id, name, description, effect, actions, condition, priority, is_enabled, org_id)
+		VALUES (nullif($1, 0), $2, $3, $4, $5, $6, $7, $8, nullif($9, 0)) RETURNING id`
+	var policyID int64
+	err := p.DB.QueryRow(
+		ctx,
+		query,
+		policy.AppID,
+		policy.Name,
+		policy.Description,
+		policy.Effect,
+		policy.Actions,
+		policy.Condition,
+		policy.Priority,
+		policy.IsEnabled,
+		policy.OrgID,
+	).Scan(&policyID)
+	if err != nil {
+		return 0, policyWriteError(err)
+	}
+	return policyID, nil
+}
+
+func (p *PolicyModel) Get(ctx context.Context, policyID int64) (*entity.Policy, error) {
+	rows, _ := p.DB.Query(ctx, "SELECT "+policyColumns+" FROM policies WHERE id = $1", policyID)
+	policy, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.Policy])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// List returns policies of the app namespace belonging to the org or shared by all of the orgs
+// (only the latter for entity.NoOrgID).
+func (p *PolicyModel) List(ctx context.Context, appID int32, orgID int64) ([]entity.Policy, error) {
+	const query = `
+		SELECT ` + policyColumns + ` FROM policies
+		WHERE app_id IS NOT DISTINCT FROM nullif($1, 0) AND (org_id IS NULL OR org_id = $2)
+		ORDER BY priority DESC, id`
+	rows, err := p.DB.Query(ctx, query, appID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[entity.Policy])
+}
+
+// Update replaces every editable field of the policy. Namespace and org of the policy can't be changed.
+func (p *PolicyModel) Update(ctx context.Context, policy *entity.Policy) (*entity.Policy, error) {
+	const query = `
+		UPDATE policies SET name = $2, description = $3, effect = $4, actions = $5, condition = $6,
+		priority = $7, is_enabled = $8, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 RETURNING ` + policyColumns
+	rows, _ := p.DB.Query(
+		ctx,
+		query,
+		policy.ID,
+		policy.Name,
+		policy.Description,
+		policy.Effect,
+		policy.Actions,
+		policy.Condition,
+		policy.Priority,
+		policy.IsEnabled,
+	)
+	updated, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.Policy])
+	if err != nil {
+		return nil, policyWriteError(err)
+	}
+	return &updated, nil
+}
+
+func (p *PolicyModel) Delete(ctx context.Context, policyID int64) error {
+	res, err := p.DB.Exec(ctx, "DELETE FROM policies WHERE id = $1", policyID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return storage.ErrRecordNotFound
+	}
+	return nil
+}
+
+// FindApplicable returns enabled policies of the app and global namespaces
+// with any of the actions among candidates (see permcode.Candidates), which belong to the org owning the app
+// or are shared by all of the orgs.
+func (p *PolicyModel) FindApplicable(ctx context.Context, appID int32, candidates []string) ([]entity.Policy, error) {
+	const query = `
+		SELECT ` + policyColumns + ` FROM policies
+		WHERE is_enabled AND (app_id IS NULL OR app_id = $1) AND actions && $2
+		AND (org_id IS NULL OR org_id = (SELECT a.org_id FROM apps a WHERE a.id = $1))
+		ORDER BY priority DESC, id`
+	rows, err := p.DB.Query(ctx, query, appID, candidates)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[entity.Policy])
+}
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+	"sso.service/internal/storage"
+	"sso.service/internal/storage/postgres"
+)
+
+type RelationTupleModel struct {
+	DB *pgxpool.Pool
+}
+
+func subjectColumns(tuple entity.RelationTuple) (userID *int64, namespace, objectID, relation *string) {
+	if tuple.SubjectSet != nil {
+		return nil, &tuple.SubjectSet.Namespace, &tuple.SubjectSet.ObjectID, &tuple.SubjectSet.Relation
+	}
+	return &tuple.SubjectUserID, nil, nil, nil
+}
+
+// Write inserts and deletes tuples atomically. Inserting existing tuples and deleting missing ones is not an error.
+func (r *RelationTupleModel) Write(ctx context.Context, inserts []entity.RelationTuple, deletes []entity.RelationTuple) error {
+	const insertQuery = `
+		INSERT INTO relation_tuples
+		(namespace, object_id, relation, subject_user_id, subject_namespace, subject_object_id, subject_relation, org_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, nullif($8, 0)) ON CONFLICT DO NOTHING`
+	const deleteQuery = `
+		DELETE FROM relation_tuples WHERE namespace = $1 AND object_id = $2 AND relation = $3
+		AND subject_user_id IS NOT DISTINCT FROM $4 AND subject_namespace IS NOT DISTINCT FROM $5
+		AND subject_object_id IS NOT DISTINCT FROM $6 AND subject_relation IS NOT DISTINCT FROM $7
+		AND org_id IS NOT DISTINCT FROM nullif($8, 0)`
+	batch := &pgx.Batch{}
+	for _, tuples := range []struct {
+		query  string
+		tuples []entity.RelationTuple
+	}{{deleteQuery, deletes}, {insertQuery, inserts}} {
+		for _, tuple := range tuples.tuples {
+			userID, namespace, objectID, relation := subjectColumns(tuple)
+			batch.Queue(tuples.query, tuple.Namespace, tuple.ObjectID, tuple.Relation, userID, namespace, objectID, relation, tuple.OrgID)
+		}
+	}
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == postgres.ForeignKeyViolationErrCode {
+			if pgErr.ConstraintName == "relation_tuples_org_id_fkey" {
+				return storage.ErrInvalidReference
+			}
+			return storage.ErrRecordNotFound
+		}
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Read returns tuples matching the filter ordered by id.
+func (r *RelationTupleModel) Read(ctx context.Context, filter dtos.RelationTuplesFilterDTO) ([]entity.RelationTuple, error) {
+	query := `
+		SELECT id, coalesce(org_id, 0), namespace, object_id, relation,
+		subject_user_id, subject_namespace, subject_object_id, subject_relation
+		FROM relation_tuples
+		WHERE namespace = $1 AND ($2 = '' OR object_id = $2) AND ($3 = '' OR relation = $3)
+		AND ($4 = 0 OR subject_user_id = $4) AND id > $5 AND (org_id IS NULL OR org_id = $7)`
+	args := []any{
+		filter.Namespace, filter.ObjectID, filter.Relation, filter.SubjectUserID, filter.AfterID, filter.Limit, filter.OrgID,
+	}
+	if filter.SubjectSet != nil {
+		query += `
+		AND subject_namespace IS NOT NULL AND ($8 = '' OR subject_namespace = $8)
+		AND ($9 = '' OR subject_object_id = $9) AND ($10 = '' OR subject_relation = $10)`
+		args = append(args, filter.SubjectSet.Namespace, filter.SubjectSet.ObjectID, filter.SubjectSet.Relation)
+	}
+	query += " ORDER BY id LIMIT $6"
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tuples := []entity.RelationTuple{}
+	for rows.Next() {
+		var tuple entity.RelationTuple
+		var userID *int64
+		var namespace, objectID, relation *string
+		err := rows.Scan(
+			&tuple.ID, &tuple.OrgID, &tuple.Namespace, &tuple.ObjectID, &tuple.Relation, &userID, &namespace, &objectID, &relation,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if userID != nil {
+			tuple.SubjectUserID = *userID
+		} else {
+			tuple.SubjectSet = &entity.Userset{Namespace: *namespace, ObjectID: *objectID, Relation: *relation}
+		}
+		tuples = append(tuples, tuple)
+	}
+	return tuples, rows.Err()
+}
+
+// HasUser reports whether there is a tuple effective in the org relating the user to the object directly.
+func (r *RelationTupleModel) HasUser(ctx context.Context, orgID int64, object entity.Userset, userID int64) (bool, error) {
+	const query = `
+		SELECT EXISTS (SELECT 1 FROM relation_tuples
+		WHERE namespace = $1 AND object_id = $2 AND relation = $3 AND subject_user_id = $4
+		AND (org_id IS NULL OR org_id = $5))`
+	var found bool
+	err := r.DB.QueryRow(ctx, query, object.Namespace, object.ObjectID, object.Relation, userID, orgID).Scan(&found)
+	return found, err
+}
+
+// ListUsers returns users related to the object directly by tuples effective in the org.
+func (r *RelationTupleModel) ListUsers(ctx context.Context, orgID int64, object entity.Userset) ([]int64, error) {
+	const query = `
+		SELECT DISTINCT subject_user_id FROM relation_tuples
+		WHERE namespace = $1 AND object_id = $2 AND relation = $3 AND subject_user_id IS NOT NULL
+		AND (org_id IS NULL OR org_id = $4)
+		ORDER BY subject_user_id`
+	rows, err := r.DB.Query(ctx, query, object.Namespace, object.ObjectID, object.Relation, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int64])
+}
+
+// ListSubjectSets returns usersets related to the object by tuples effective in the org.
+func (r *RelationTupleModel) ListSubjectSets(ctx context.Context, orgID int64, object entity.Userset) ([]entity.Userset, error) {
+	const query = `
+		SELECT subject_namespace, subject_object_id, subject_relation FROM relation_tuples
+		WHERE namespace = $1 AND object_id = $2 AND relation = $3 AND subject_namespace IS NOT NULL
+		AND (org_id IS NULL OR org_id = $4)
+		ORDER BY id`
+	rows, err := r.DB.Query(ctx, query, object.Namespace, object.ObjectID, object.Relation, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	usersets := []entity.Userset{}
+	for rows.Next() {
+		var userset entity.Userset
+		if err := rows.Scan(&userset.Namespace, &userset.ObjectID, &userset.Relation); err != nil {
+			return nil, err
+		}
+		usersets = append(usersets, userset)
+	}
+	return usersets, rows.Err()
+}
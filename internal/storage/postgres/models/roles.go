@@ -0,0 +1,180 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+	"sso.service/internal/storage"
+	"sso.service/internal/storage/postgres"
+)
+
+type RoleModel struct {
+	DB *pgxpool.Pool
+}
+
+func (r *RoleModel) Create(ctx context.Context, role *entity.RoleDetails) (int64, error) {
+	var roleID int64
+	err := r.DB.QueryRow(
+		ctx,
+		"INSERT INTO roles (name, description, org_id) VALUES ($1, $2, nullif($3, 0)) RETURNING id",
+		role.Name,
+		role.Description,
+		role.OrgID,
+	).Scan(&roleID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case postgres.UniqueViolationErrCode:
+				return 0, storage.ErrRecordAlreadyExists
+			case postgres.ForeignKeyViolationErrCode:
+				return 0, storage.ErrInvalidReference
+			}
+		}
+		return 0, err
+	}
+	return roleID, nil
+}
+
+func (r *RoleModel) Get(ctx context.Context, params dtos.GetRoleOptionsDTO) (*entity.RoleDetails, error) {
+	const query = `
+		SELECT id, coalesce(org_id, 0), name, description, is_builtin FROM roles
+		WHERE (id = $1 OR $1 = 0) AND (name = $2 OR $2 = '')`
+	var role entity.RoleDetails
+	err := r.DB.QueryRow(ctx, query, params.ID, params.Name).
+		Scan(&role.ID, &role.OrgID, &role.Name, &role.Description, &role.IsBuiltin)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	role.Permissions, err = r.fetchPermissions(ctx, role.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// List returns the roles which can be assigned within the org: global roles and roles of the org.
+// With entity.NoOrgID only global roles are returned.
+func (r *RoleModel) List(ctx context.Context, orgID int64) ([]entity.RoleDetails, error) {
+	const query = `
+		SELECT r.id, coalesce(r.org_id, 0), r.name, r.description, r.is_builtin, p.id, p.code, coalesce(p.app_id, 0) FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		LEFT JOIN permissions p ON p.id = rp.permission_id
+		WHERE r.org_id IS NULL OR r.org_id = $1
+		ORDER BY r.id, p.app_id NULLS FIRST, p.code`
+	rows, err := r.DB.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var roles []entity.RoleDetails
+	for rows.Next() {
+		var role entity.RoleDetails
+		var permID *int64
+		var permCode *string
+		var permAppID int32
+		if err := rows.Scan(&role.ID, &role.OrgID, &role.Name, &role.Description, &role.IsBuiltin, &permID, &permCode, &permAppID); err != nil {
+			return nil, err
+		}
+		if len(roles) == 0 || roles[len(roles)-1].ID != role.ID {
+			roles = append(roles, role)
+		}
+		if permID != nil {
+			last := &roles[len(roles)-1]
+			last.Permissions = append(last.Permissions, entity.Permission{ID: *permID, Code: *permCode, AppID: permAppID})
+		}
+	}
+	return roles, rows.Err()
+}
+
+func (r *RoleModel) Update(ctx context.Context, params dtos.UpdateRoleDTO) (*entity.RoleDetails, error) {
+	const query = `
+		UPDATE roles SET name = coalesce($2, name), description = coalesce($3, description)
+		WHERE id = $1 RETURNING id, coalesce(org_id, 0), name, description, is_builtin`
+	var role entity.RoleDetails
+	err := r.DB.QueryRow(ctx, query, params.ID, params.Name, params.Description).
+		Scan(&role.ID, &role.OrgID, &role.Name, &role.Description, &role.IsBuiltin)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return nil, storage.ErrRecordNotFound
+		case errors.As(err, &pgErr) && pgErr.Code == postgres.UniqueViolationErrCode:
+			return nil, storage.ErrRecordAlreadyExists
+		}
+		return nil, err
+	}
+	role.Permissions, err = r.fetchPermissions(ctx, role.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *RoleModel) Delete(ctx context.Context, roleID int64) error {
+	res, err := r.DB.Exec(ctx, "DELETE FROM roles WHERE id = $1", roleID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == postgres.ForeignKeyViolationErrCode {
+			return storage.ErrRecordInUse
+		}
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return storage.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SetPermissions replaces permissions of the role within the app namespace with the ones identified by codes.
+// Codes which don't exist in permissions table are skipped, no codes (nil included) clear the namespace.
+func (r *RoleModel) SetPermissions(ctx context.Context, roleID int64, appID int32, codes []string) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	err = tx.QueryRow(ctx, "SELECT id FROM roles WHERE id = $1 FOR UPDATE", roleID).Scan(&roleID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.ErrRecordNotFound
+		}
+		return err
+	}
+	const deleteQuery = `
+		DELETE FROM role_permissions rp USING permissions p
+		WHERE rp.permission_id = p.id AND rp.role_id = $1 AND NOT p.code = ANY(coalesce($2::text[], '{}'))
+		AND p.app_id IS NOT DISTINCT FROM nullif($3, 0)`
+	if _, err := tx.Exec(ctx, deleteQuery, roleID, codes, appID); err != nil {
+		return err
+	}
+	const insertQuery = `
+		INSERT INTO role_permissions (role_id, permission_id)
+		SELECT $1, p.id FROM permissions p
+		WHERE p.code = ANY($2) AND p.app_id IS NOT DISTINCT FROM nullif($3, 0)
+		ON CONFLICT DO NOTHING`
+	if _, err := tx.Exec(ctx, insertQuery, roleID, codes, appID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *RoleModel) fetchPermissions(ctx context.Context, roleID int64) ([]entity.Permission, error) {
+	const query = `
+		SELECT p.id, p.code, coalesce(p.app_id, 0) AS app_id FROM permissions p
+		JOIN role_permissions rp ON rp.permission_id = p.id
+		WHERE rp.role_id = $1 ORDER BY p.app_id NULLS FIRST, p.code`
+	rows, err := r.DB.Query(ctx, query, roleID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByName[entity.Permission])
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"crypto/sha256"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -46,14 +49,14 @@ func (u *UserModel) Update(ctx context.Context, user *entity.User) (*entity.User
 	var updatedUser entity.User
 	err := u.DB.QueryRow(
 		ctx,
-		`UPDATE users SET username = $1, password = $2, email = $3, role = $4, is_active = $5 WHERE id = $6 RETURNING id, username, email, role, is_active, created_at, updated_at`,
+		`UPDATE users SET username = $1, password = $2, email = $3, role = $4, is_active = $5 WHERE id = $6 AND deleted_at IS NULL RETURNING id, username, email, role, is_active, created_at, updated_at, token_version`,
 		user.Username,
 		user.Password.Hash,
 		user.Email,
 		user.Role,
 		user.IsActive,
 		user.ID,
-	).Scan(&updatedUser.ID, &updatedUser.Username, &updatedUser.Email, &updatedUser.Role, &updatedUser.IsActive, &updatedUser.CreatedAt, &updatedUser.UpdatedAt)
+	).Scan(&updatedUser.ID, &updatedUser.Username, &updatedUser.Email, &updatedUser.Role, &updatedUser.IsActive, &updatedUser.CreatedAt, &updatedUser.UpdatedAt, &updatedUser.TokenVersion)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, storage.ErrRecordNotFound
@@ -65,8 +68,8 @@ func (u *UserModel) Update(ctx context.Context, user *entity.User) (*entity.User
 
 func (u *UserModel) Get(ctx context.Context, params dtos.GetUserOptionsDTO) (*entity.User, error) {
 	args := []any{params.Email, params.ID}
-	query := `SELECT id, username, password, email, role, is_active, created_at, updated_at FROM users
-		WHERE (email = $1 OR $1 = '') AND (id = $2 OR $2 = 0)`
+	query := `SELECT id, username, password, email, role, is_active, created_at, updated_at, token_version, deactivated_at, public_metadata FROM users
+		WHERE (email = $1 OR $1 = '') AND (id = $2 OR $2 = 0) AND deleted_at IS NULL`
 	if params.IsActive != nil {
 		args = append(args, *params.IsActive)
 		query += " AND is_active = $3"
@@ -76,7 +79,7 @@ func (u *UserModel) Get(ctx context.Context, params dtos.GetUserOptionsDTO) (*en
 		ctx,
 		query,
 		args...,
-	).Scan(&user.ID, &user.Username, &user.Password.Hash, &user.Email, &user.Role, &user.IsActive, &user.CreatedAt, &user.UpdatedAt)
+	).Scan(&user.ID, &user.Username, &user.Password.Hash, &user.Email, &user.Role, &user.IsActive, &user.CreatedAt, &user.UpdatedAt, &user.TokenVersion, &user.DeactivatedAt, &user.PublicMetadata)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, storage.ErrRecordNotFound
@@ -90,13 +93,13 @@ func (u *UserModel) GetForToken(ctx context.Context, tokenScope string, plainTok
 	var user entity.User
 	tokenHash := sha256.Sum256([]byte(plainToken))
 	query := `
-		SELECT u.id, u.username, u.password, u.email, u.role, u.is_active, u.created_at, u.updated_at FROM users u
+		SELECT u.id, u.username, u.password, u.email, u.role, u.is_active, u.created_at, u.updated_at, u.token_version FROM users u
 		JOIN tokens t ON t.user_id = u.id
-		WHERE t.hash = $1 AND t.scope = $2 AND t.expiry >= now()`
+		WHERE t.hash = $1 AND t.scope = $2 AND t.expiry >= now() AND u.deleted_at IS NULL`
 	args := []any{tokenHash[:], tokenScope}
 	err := u.DB.QueryRow(
 		ctx, query, args...,
-	).Scan(&user.ID, &user.Username, &user.Password.Hash, &user.Email, &user.Role, &user.IsActive, &user.CreatedAt, &user.UpdatedAt)
+	).Scan(&user.ID, &user.Username, &user.Password.Hash, &user.Email, &user.Role, &user.IsActive, &user.CreatedAt, &user.UpdatedAt, &user.TokenVersion)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, storage.ErrRecordNotFound
@@ -113,7 +116,7 @@ func (u *UserModel) IsAdmin(ctx context.Context, userID int64) (bool, error) {
 		return false, err
 	}
 	defer transation.Commit(ctx)
-	err = transation.QueryRow(ctx, "SELECT role FROM users WHERE id = $1 FOR UPDATE", userID).Scan(&role)
+	err = transation.QueryRow(ctx, "SELECT role FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", userID).Scan(&role)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return false, storage.ErrRecordNotFound
@@ -122,3 +125,535 @@ func (u *UserModel) IsAdmin(ctx context.Context, userID int64) (bool, error) {
 	}
 	return role == string(entity.RoleAdmin), nil
 }
+
+// SetRole changes role of the user and returns the previous one, see Patch.
+func (u *UserModel) SetRole(ctx context.Context, userID int64, role entity.Role) (entity.Role, error) {
+	previous, _, err := u.Patch(ctx, dtos.UpdateUserDTO{ID: userID, Role: &role}, nil)
+	if err != nil {
+		return "", err
+	}
+	return previous.Role, nil
+}
+
+// Patch changes the set fields of the user and returns the user before and after the change.
+// Unless the change is a promotion (see entity.IsRolePromotion) or a reactivation, all of the tokens
+// issued to the user so far are revoked by bumping the token version. Demoting or deactivating the only active admin
+// fails with storage.ErrLastAdmin, unknown role results in storage.ErrInvalidReference and a taken email
+// in storage.ErrRecordAlreadyExists. Setting is_active to false marks the user deactivated (see entity.User.DeactivatedAt),
+// setting it to true clears the mark. If params.ExpectedUpdatedAt is set and the user has been changed since then,
+// storage.ErrEditConflict is returned. Unless audit is nil, the event it returns for the change (if any)
+// is recorded in the same transaction, so that no change goes unaudited.
+func (u *UserModel) Patch(
+	ctx context.Context,
+	params dtos.UpdateUserDTO,
+	audit func(previous *entity.User, updated *entity.User) *entity.AuditEvent,
+) (*entity.User, *entity.User, error) {
+	tx, err := u.DB.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+	previous, activeAdmins, err := lockUserAndAdmins(ctx, tx, params.ID, params.ExpectedUpdatedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+	role, isActive, deactivated := previous.Role, previous.IsActive, previous.DeactivatedAt != nil
+	if params.Role != nil {
+		role = *params.Role
+	}
+	if params.IsActive != nil {
+		isActive = *params.IsActive
+		deactivated = !isActive
+	}
+	unchanged := role == previous.Role && isActive == previous.IsActive && deactivated == (previous.DeactivatedAt != nil) &&
+		(params.Username == nil || *params.Username == previous.Username) &&
+		(params.Email == nil || *params.Email == previous.Email)
+	if unchanged {
+		return previous, previous, nil
+	}
+	if isActiveAdmin(previous.Role, previous.IsActive) && !isActiveAdmin(role, isActive) && activeAdmins <= 1 {
+		return nil, nil, storage.ErrLastAdmin
+	}
+	revokeTokens := role != previous.Role && !entity.IsRolePromotion(previous.Role, role) || previous.IsActive && !isActive
+	const query = `
+		UPDATE users SET username = coalesce($2, username), email = coalesce($3, email), role = $4, is_active = $5,
+		token_version = token_version + CASE WHEN $6 THEN 1 ELSE 0 END,
+		deactivated_at = CASE WHEN $7 THEN coalesce(deactivated_at, now()) END
+		WHERE id = $1
+		RETURNING id, username, email, role, is_active, created_at, updated_at, token_version, deactivated_at`
+	var updated entity.User
+	err = tx.QueryRow(ctx, query, params.ID, params.Username, params.Email, role, isActive, revokeTokens, deactivated).Scan(
+		&updated.ID, &updated.Username, &updated.Email, &updated.Role, &updated.IsActive, &updated.CreatedAt, &updated.UpdatedAt, &updated.TokenVersion,
+		&updated.DeactivatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case postgres.UniqueViolationErrCode:
+				return nil, nil, storage.ErrRecordAlreadyExists
+			case postgres.ForeignKeyViolationErrCode:
+				return nil, nil, storage.ErrInvalidReference
+			}
+		}
+		return nil, nil, err
+	}
+	if audit != nil {
+		if event := audit(previous, &updated); event != nil {
+			if err := createAuditEvents(ctx, tx, *event); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return previous, &updated, tx.Commit(ctx)
+}
+
+// Delete soft deletes the user, revoking all of its tokens, and returns the deleted user.
+// Deleted users are invisible to the other methods until restored, see Restore and Erase.
+// Deleting the only active admin fails with storage.ErrLastAdmin. If expectedUpdatedAt is not nil
+// and the user has been changed since then, storage.ErrEditConflict is returned.
+// Unless audit is nil, the event it returns for the deleted user is recorded in the same transaction.
+func (u *UserModel) Delete(
+	ctx context.Context,
+	userID int64,
+	expectedUpdatedAt *time.Time,
+	audit func(deleted *entity.User) *entity.AuditEvent,
+) (*entity.User, error) {
+	tx, err := u.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	user, activeAdmins, err := lockUserAndAdmins(ctx, tx, userID, expectedUpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if isActiveAdmin(user.Role, user.IsActive) && activeAdmins <= 1 {
+		return nil, storage.ErrLastAdmin
+	}
+	const query = `
+		UPDATE users SET deleted_at = now(), token_version = token_version + 1 WHERE id = $1
+		RETURNING deleted_at, token_version`
+	if err := tx.QueryRow(ctx, query, userID).Scan(&user.DeletedAt, &user.TokenVersion); err != nil {
+		return nil, err
+	}
+	if audit != nil {
+		if event := audit(user); event != nil {
+			if err := createAuditEvents(ctx, tx, *event); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return user, tx.Commit(ctx)
+}
+
+// SetPassword replaces the password hash of the user and revokes all of its tokens.
+// storage.ErrEditConflict is returned if the token version of the user is no longer tokenVersion
+// (the password has been changed or tokens have been revoked since it was read).
+func (u *UserModel) SetPassword(ctx context.Context, userID int64, hash []byte, tokenVersion int64) error {
+	const query = `
+		UPDATE users SET password = $2, token_version = token_version + 1
+		WHERE id = $1 AND token_version = $3 AND deleted_at IS NULL`
+	tag, err := u.DB.Exec(ctx, query, userID, hash, tokenVersion)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrEditConflict
+	}
+	return nil
+}
+
+// UpdatePasswordHash replaces the hash of the same password made with outdated parameters, tokens are kept.
+// Nothing is changed if the hash is no longer oldHash (the password has been changed concurrently).
+func (u *UserModel) UpdatePasswordHash(ctx context.Context, userID int64, oldHash []byte, newHash []byte) error {
+	_, err := u.DB.Exec(ctx, "UPDATE users SET password = $3 WHERE id = $1 AND password = $2", userID, oldHash, newHash)
+	return err
+}
+
+// Restore undeletes the user deleted after deletedAfter whose data hasn't been erased yet.
+func (u *UserModel) Restore(ctx context.Context, userID int64, deletedAfter time.Time) (*entity.User, error) {
+	const query = `
+		UPDATE users SET deleted_at = NULL
+		WHERE id = $1 AND deleted_at > $2 AND anonymized_at IS NULL
+		RETURNING id, username, email, role, is_active, created_at, updated_at, token_version`
+	var user entity.User
+	err := u.DB.QueryRow(ctx, query, userID, deletedAfter).Scan(
+		&user.ID, &user.Username, &user.Email, &user.Role, &user.IsActive, &user.CreatedAt, &user.UpdatedAt, &user.TokenVersion,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ListErasable returns ids of up to limit users deleted before deletedBefore whose data hasn't been erased yet.
+func (u *UserModel) ListErasable(ctx context.Context, deletedBefore time.Time, limit int) ([]int64, error) {
+	const query = `
+		SELECT id FROM users WHERE deleted_at <= $1 AND anonymized_at IS NULL
+		ORDER BY deleted_at LIMIT $2`
+	rows, _ := u.DB.Query(ctx, query, deletedBefore, limit)
+	return pgx.CollectRows(rows, pgx.RowTo[int64])
+}
+
+// Erase anonymizes username and email of the user deleted before deletedBefore, deletes everything else
+// belonging to the user (including archives of their data exports) and saves the report of what was erased.
+// The user's row is kept, so that audit events keep referring to it. Users restored in the meantime result in storage.ErrRecordNotFound.
+func (u *UserModel) Erase(ctx context.Context, userID int64, deletedBefore time.Time) (*entity.ErasureReport, error) {
+	tx, err := u.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	report := entity.ErasureReport{UserID: userID}
+	const lockQuery = "SELECT deleted_at FROM users WHERE id = $1 AND deleted_at <= $2 AND anonymized_at IS NULL FOR UPDATE"
+	if err := tx.QueryRow(ctx, lockQuery, userID, deletedBefore).Scan(&report.DeletedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	// records are deleted explicitly (instead of relying on cascades) to count them for the report
+	deleted := map[string]any{}
+	for _, table := range []string{"tokens", "users_permissions", "group_members", "org_members", "users_apps", "data_exports"} {
+		tag, err := tx.Exec(ctx, "DELETE FROM "+table+" WHERE user_id = $1", userID)
+		if err != nil {
+			return nil, err
+		}
+		deleted[table] = tag.RowsAffected()
+	}
+	tag, err := tx.Exec(ctx, "DELETE FROM relation_tuples WHERE subject_user_id = $1", userID)
+	if err != nil {
+		return nil, err
+	}
+	deleted["relation_tuples"] = tag.RowsAffected()
+	const anonymizeQuery = `
+		UPDATE users SET username = 'deleted-user-' || id, email = 'deleted-user-' || id || '@erased.invalid',
+		password = '', public_metadata = '{}', admin_metadata = '{}', is_active = false, anonymized_at = now()
+		WHERE id = $1`
+	if _, err := tx.Exec(ctx, anonymizeQuery, userID); err != nil {
+		return nil, err
+	}
+	report.Details = map[string]any{
+		"anonymized_fields": []any{"username", "email", "password", "public_metadata", "admin_metadata"},
+		"deleted_records":   deleted,
+	}
+	const reportQuery = "INSERT INTO erasure_reports (user_id, deleted_at, details) VALUES ($1, $2, $3) RETURNING id, erased_at"
+	if err := tx.QueryRow(ctx, reportQuery, userID, report.DeletedAt, report.Details).Scan(&report.ID, &report.ErasedAt); err != nil {
+		return nil, err
+	}
+	return &report, tx.Commit(ctx)
+}
+
+// GetErasureReport returns the report of erasure of the user's data.
+func (u *UserModel) GetErasureReport(ctx context.Context, userID int64) (*entity.ErasureReport, error) {
+	rows, _ := u.DB.Query(ctx, "SELECT id, user_id, deleted_at, erased_at, details FROM erasure_reports WHERE user_id = $1", userID)
+	report, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[entity.ErasureReport])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+func isActiveAdmin(role entity.Role, isActive bool) bool {
+	return role == entity.RoleAdmin && isActive
+}
+
+// lockUserAndAdmins locks the user and all of the active admins, so that concurrent changes can't leave no admin at all,
+// and returns the user and the number of active admins.
+func lockUserAndAdmins(ctx context.Context, tx pgx.Tx, userID int64, expectedUpdatedAt *time.Time) (*entity.User, int, error) {
+	rows, _ := tx.Query(ctx, "SELECT id FROM users WHERE role = $1 AND is_active AND deleted_at IS NULL ORDER BY id FOR UPDATE", entity.RoleAdmin)
+	adminIDs, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return nil, 0, err
+	}
+	const query = `
+		SELECT id, username, email, role, is_active, created_at, updated_at, token_version, deactivated_at FROM users
+		WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+	var user entity.User
+	err = tx.QueryRow(ctx, query, userID).Scan(
+		&user.ID, &user.Username, &user.Email, &user.Role, &user.IsActive, &user.CreatedAt, &user.UpdatedAt, &user.TokenVersion, &user.DeactivatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, 0, storage.ErrRecordNotFound
+		}
+		return nil, 0, err
+	}
+	if expectedUpdatedAt != nil && !user.UpdatedAt.Equal(*expectedUpdatedAt) {
+		return nil, 0, storage.ErrEditConflict
+	}
+	return &user, len(adminIDs), nil
+}
+
+var usersSortColumns = map[dtos.UsersSortField]string{
+	dtos.UsersSortByCreatedAt: "u.created_at",
+	dtos.UsersSortByUpdatedAt: "u.updated_at",
+}
+
+// List returns a page of users matching the options, ordered by the sort field and id.
+func (u *UserModel) List(ctx context.Context, options dtos.ListUsersOptionsDTO) ([]entity.User, error) {
+	sortColumn, ok := usersSortColumns[options.SortBy]
+	if !ok {
+		sortColumn = usersSortColumns[dtos.UsersSortByCreatedAt]
+	}
+	direction, comparison := "ASC", ">"
+	if options.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	conditions := []string{"u.deleted_at IS NULL"}
+	args := []any{}
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if options.Search != "" {
+		search := arg(options.Search)
+		pattern := arg("%" + escapeLikePattern(options.Search) + "%")
+		conditions = append(conditions, fmt.Sprintf(
+			"(u.username %% %[1]s OR u.email::text %% %[1]s OR u.username ILIKE %[2]s OR u.email::text ILIKE %[2]s)",
+			search,
+			pattern,
+		))
+	}
+	if options.Role != "" {
+		conditions = append(conditions, "u.role = "+arg(options.Role))
+	}
+	if options.IsActive != nil {
+		conditions = append(conditions, "u.is_active = "+arg(*options.IsActive))
+	}
+	if options.CreatedAfter != nil {
+		conditions = append(conditions, "u.created_at >= "+arg(*options.CreatedAfter))
+	}
+	if options.CreatedBefore != nil {
+		conditions = append(conditions, "u.created_at < "+arg(*options.CreatedBefore))
+	}
+	if options.AppID != 0 {
+		conditions = append(conditions, "EXISTS(SELECT 1 FROM users_apps ua WHERE ua.user_id = u.id AND ua.app_id = "+arg(options.AppID)+")")
+	}
+	if options.Permission != nil {
+		candidates, appID := arg(options.Permission.Candidates), arg(options.Permission.AppID)
+		conditions = append(conditions, `EXISTS(
+			SELECT 1 FROM (`+userGrantsQuery("u.id")+`) g
+			JOIN permissions p ON p.id = g.permission_id
+			WHERE p.code = ANY(`+candidates+`) AND (p.app_id = `+appID+` OR p.app_id IS NULL)
+			AND `+orgGrantsFilter("g", appID)+`)`)
+	}
+	if options.After != nil {
+		conditions = append(conditions, fmt.Sprintf(
+			"(%s, u.id) %s (%s, %s)",
+			sortColumn,
+			comparison,
+			arg(options.After.SortValue),
+			arg(options.After.ID),
+		))
+	}
+	columns := "u.id, u.username, u.email, u.role, u.is_active, u.created_at, u.updated_at, u.token_version"
+	if options.WithPasswordHash {
+		columns += ", u.password"
+	}
+	query := "SELECT " + columns + " FROM users u"
+	query += " WHERE " + strings.Join(conditions, " AND ")
+	query += fmt.Sprintf(" ORDER BY %[1]s %[2]s, u.id %[2]s LIMIT %[3]s", sortColumn, direction, arg(options.Limit))
+	rows, err := u.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	users := []entity.User{}
+	for rows.Next() {
+		var user entity.User
+		dest := []any{&user.ID, &user.Username, &user.Email, &user.Role, &user.IsActive, &user.CreatedAt, &user.UpdatedAt, &user.TokenVersion}
+		if options.WithPasswordHash {
+			dest = append(dest, &user.Password.Hash)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// escapeLikePattern escapes characters having special meaning in LIKE patterns.
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// Import creates the user registered with the app (unless appID is 0) and grants it the permissions
+// of the app namespace (creating missing ones) in one transaction. Missing role or app results
+// in storage.ErrInvalidReference. On dry run the transaction is rolled back after all checks passed,
+// the returned id isn't valid then.
+func (u *UserModel) Import(ctx context.Context, user *entity.User, appID int32, permissionCodes []string, dryRun bool) (int64, error) {
+	if user.Role == "" {
+		user.Role = entity.DefaultUserRole
+	}
+	tx, err := u.DB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+	var userID int64
+	err = tx.QueryRow(
+		ctx,
+		"INSERT INTO users (username, password, email, is_active, role) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		user.Username,
+		user.Password.Hash,
+		user.Email,
+		user.IsActive,
+		user.Role,
+	).Scan(&userID)
+	if err != nil {
+		return 0, importError(err)
+	}
+	if appID != 0 {
+		if _, err := tx.Exec(ctx, "INSERT INTO users_apps (user_id, app_id) VALUES ($1, $2)", userID, appID); err != nil {
+			return 0, importError(err)
+		}
+	}
+	if len(permissionCodes) > 0 {
+		// permissions which don't exist yet are created, as when granted one by one
+		const createQuery = `
+			INSERT INTO permissions (app_id, code)
+			SELECT nullif($1, 0), code FROM unnest($2::text[]) AS code
+			ON CONFLICT DO NOTHING`
+		if _, err := tx.Exec(ctx, createQuery, appID, permissionCodes); err != nil {
+			return 0, importError(err)
+		}
+		const grantQuery = `
+			INSERT INTO users_permissions (user_id, permission_id)
+			SELECT $1, p.id FROM permissions p WHERE p.code = ANY($2) AND p.app_id IS NOT DISTINCT FROM nullif($3, 0)`
+		if _, err := tx.Exec(ctx, grantQuery, userID, permissionCodes, appID); err != nil {
+			return 0, err
+		}
+	}
+	if dryRun {
+		return userID, nil
+	}
+	return userID, tx.Commit(ctx)
+}
+
+func importError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case postgres.UniqueViolationErrCode:
+			return storage.ErrRecordAlreadyExists
+		case postgres.ForeignKeyViolationErrCode:
+			return storage.ErrInvalidReference
+		}
+	}
+	return err
+}
+
+// AddApp records that the user has registered with the app.
+func (u *UserModel) AddApp(ctx context.Context, userID int64, appID int32) error {
+	_, err := u.DB.Exec(
+		ctx,
+		"INSERT INTO users_apps (user_id, app_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		userID,
+		appID,
+	)
+	return err
+}
+
+// RecordAppLogin records that the user has logged in to the app, adding the app to the user's ones if needed.
+func (u *UserModel) RecordAppLogin(ctx context.Context, userID int64, appID int32) error {
+	const query = `
+		INSERT INTO users_apps (user_id, app_id, last_login_at) VALUES ($1, $2, now())
+		ON CONFLICT (user_id, app_id) DO UPDATE SET last_login_at = excluded.last_login_at`
+	_, err := u.DB.Exec(ctx, query, userID, appID)
+	return err
+}
+
+// ListApps returns apps the user has registered with or logged in to.
+func (u *UserModel) ListApps(ctx context.Context, userID int64) ([]entity.UserApp, error) {
+	const query = `
+		SELECT ua.app_id, a.name AS app_name, ua.joined_at, ua.last_login_at, coalesce(ua.metadata, '{}') AS metadata
+		FROM users_apps ua JOIN apps a ON a.id = ua.app_id
+		WHERE ua.user_id = $1
+		ORDER BY ua.joined_at`
+	rows, _ := u.DB.Query(ctx, query, userID)
+	return pgx.CollectRows(rows, pgx.RowToStructByName[entity.UserApp])
+}
+
+// ListSessions returns tokens issued to the user which haven't expired yet.
+func (u *UserModel) ListSessions(ctx context.Context, userID int64) ([]entity.Session, error) {
+	const query = "SELECT scope, expiry FROM tokens WHERE user_id = $1 AND expiry >= now() ORDER BY expiry"
+	rows, _ := u.DB.Query(ctx, query, userID)
+	return pgx.CollectRows(rows, pgx.RowToStructByName[entity.Session])
+}
+
+// GetMetadata returns metadata of the user, app-private metadata is empty if appID is zero
+// or the user has no metadata in the app.
+func (u *UserModel) GetMetadata(ctx context.Context, userID int64, appID int32) (*entity.UserMetadata, error) {
+	const query = `
+		SELECT u.public_metadata, u.admin_metadata, coalesce(ua.metadata, '{}')
+		FROM users u LEFT JOIN users_apps ua ON ua.user_id = u.id AND ua.app_id = $2
+		WHERE u.id = $1 AND u.deleted_at IS NULL`
+	var metadata entity.UserMetadata
+	err := u.DB.QueryRow(ctx, query, userID, appID).Scan(&metadata.Public, &metadata.Admin, &metadata.App)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &metadata, nil
+}
+
+// UpdateMetadata locks metadata of the user (app-private one of the app if appID isn't zero),
+// lets update change it and saves the result unless update fails.
+func (u *UserModel) UpdateMetadata(
+	ctx context.Context,
+	userID int64,
+	appID int32,
+	update func(metadata *entity.UserMetadata) error,
+) (*entity.UserMetadata, error) {
+	tx, err := u.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	metadata := entity.UserMetadata{App: map[string]any{}}
+	const lockQuery = "SELECT public_metadata, admin_metadata FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE"
+	if err := tx.QueryRow(ctx, lockQuery, userID).Scan(&metadata.Public, &metadata.Admin); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	if appID != 0 {
+		const appQuery = "SELECT metadata FROM users_apps WHERE user_id = $1 AND app_id = $2 FOR UPDATE"
+		err := tx.QueryRow(ctx, appQuery, userID, appID).Scan(&metadata.App)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+	}
+	if err := update(&metadata); err != nil {
+		return nil, err
+	}
+	const updateQuery = "UPDATE users SET public_metadata = $2, admin_metadata = $3 WHERE id = $1"
+	if _, err := tx.Exec(ctx, updateQuery, userID, metadata.Public, metadata.Admin); err != nil {
+		return nil, err
+	}
+	if appID != 0 {
+		const appUpdateQuery = `
+			INSERT INTO users_apps (user_id, app_id, metadata) VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, app_id) DO UPDATE SET metadata = excluded.metadata`
+		if _, err := tx.Exec(ctx, appUpdateQuery, userID, appID, metadata.App); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == postgres.ForeignKeyViolationErrCode {
+				return nil, storage.ErrInvalidReference
+			}
+			return nil, err
+		}
+	}
+	return &metadata, tx.Commit(ctx)
+}
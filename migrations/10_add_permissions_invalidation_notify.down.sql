@@ -0,0 +1,8 @@
+BEGIN;
+DROP TRIGGER IF EXISTS role_permissions_invalidation ON role_permissions;
+DROP TRIGGER IF EXISTS users_invalidation ON users;
+DROP TRIGGER IF EXISTS users_permissions_invalidation ON users_permissions;
+DROP FUNCTION IF EXISTS notify_all_permissions_changed();
+DROP FUNCTION IF EXISTS notify_user_changed();
+DROP FUNCTION IF EXISTS notify_user_permissions_changed();
+COMMIT;
@@ -0,0 +1,43 @@
+BEGIN;
+-- Notifies SSO replicas that cached permissions of the user (or of everyone for '*') are stale
+CREATE OR REPLACE FUNCTION notify_user_permissions_changed()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM pg_notify('permissions_invalidation', OLD.user_id::text);
+    ELSE
+        PERFORM pg_notify('permissions_invalidation', NEW.user_id::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+CREATE OR REPLACE FUNCTION notify_user_changed()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM pg_notify('permissions_invalidation', OLD.id::text);
+    ELSE
+        PERFORM pg_notify('permissions_invalidation', NEW.id::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+CREATE OR REPLACE FUNCTION notify_all_permissions_changed()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('permissions_invalidation', '*');
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+CREATE OR REPLACE TRIGGER users_permissions_invalidation AFTER INSERT OR UPDATE OR DELETE ON users_permissions
+FOR EACH ROW EXECUTE PROCEDURE notify_user_permissions_changed();
+
+CREATE OR REPLACE TRIGGER users_invalidation AFTER UPDATE OF role, is_active OR DELETE ON users
+FOR EACH ROW EXECUTE PROCEDURE notify_user_changed();
+
+CREATE OR REPLACE TRIGGER role_permissions_invalidation AFTER INSERT OR UPDATE OR DELETE ON role_permissions
+FOR EACH STATEMENT EXECUTE PROCEDURE notify_all_permissions_changed();
+COMMIT;
@@ -0,0 +1 @@
+ALTER TABLE apps ADD COLUMN IF NOT EXISTS embed_permissions boolean NOT NULL DEFAULT false;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS policies (
+    id bigint PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    -- NULL app_id stands for the global namespace, such policies apply to every app
+    app_id integer REFERENCES apps ON DELETE CASCADE,
+    name text NOT NULL,
+    description text NOT NULL DEFAULT '',
+    effect text NOT NULL CHECK (effect IN ('allow', 'deny')),
+    actions text[] NOT NULL,
+    condition text NOT NULL DEFAULT '',
+    priority integer NOT NULL DEFAULT 0,
+    is_enabled boolean NOT NULL DEFAULT true,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS policies_app_id_name_key ON policies (app_id, name) NULLS NOT DISTINCT;
+CREATE INDEX IF NOT EXISTS policies_actions_idx ON policies USING gin (actions);
@@ -0,0 +1,21 @@
+-- Tuple "object_namespace:object_id#relation@subject" where subject is either a user
+-- or a userset "subject_namespace:subject_object_id#subject_relation" (empty relation stands for the object itself)
+CREATE TABLE IF NOT EXISTS relation_tuples (
+    id bigint PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    namespace text NOT NULL,
+    object_id text NOT NULL,
+    relation text NOT NULL,
+    subject_user_id bigint REFERENCES users ON DELETE CASCADE,
+    subject_namespace text,
+    subject_object_id text,
+    subject_relation text,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (
+        (subject_user_id IS NOT NULL AND subject_namespace IS NULL AND subject_object_id IS NULL AND subject_relation IS NULL)
+        OR (subject_user_id IS NULL AND subject_namespace IS NOT NULL AND subject_object_id IS NOT NULL AND subject_relation IS NOT NULL)
+    )
+);
+CREATE UNIQUE INDEX IF NOT EXISTS relation_tuples_key ON relation_tuples
+    (namespace, object_id, relation, subject_user_id, subject_namespace, subject_object_id, subject_relation) NULLS NOT DISTINCT;
+CREATE INDEX IF NOT EXISTS relation_tuples_subject_user_id_idx ON relation_tuples (subject_user_id);
+CREATE INDEX IF NOT EXISTS relation_tuples_subject_set_idx ON relation_tuples (subject_namespace, subject_object_id, subject_relation);
@@ -0,0 +1,5 @@
+BEGIN;
+DROP TABLE IF EXISTS group_permissions;
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS groups;
+COMMIT;
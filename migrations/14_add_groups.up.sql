@@ -0,0 +1,32 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS groups (
+    id bigint PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    name text NOT NULL UNIQUE,
+    description text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- Member is either a user or another group, cycles of nested groups are rejected by the application
+CREATE TABLE IF NOT EXISTS group_members (
+    group_id bigint NOT NULL REFERENCES groups ON DELETE CASCADE,
+    user_id bigint REFERENCES users ON DELETE CASCADE,
+    member_group_id bigint REFERENCES groups ON DELETE CASCADE,
+    added_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((user_id IS NULL) <> (member_group_id IS NULL)),
+    CHECK (member_group_id <> group_id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS group_members_key ON group_members (group_id, user_id, member_group_id) NULLS NOT DISTINCT;
+CREATE INDEX IF NOT EXISTS group_members_user_id_idx ON group_members (user_id);
+CREATE INDEX IF NOT EXISTS group_members_member_group_id_idx ON group_members (member_group_id);
+CREATE TABLE IF NOT EXISTS group_permissions (
+    group_id bigint NOT NULL REFERENCES groups ON DELETE CASCADE,
+    permission_id bigint NOT NULL REFERENCES permissions ON DELETE CASCADE,
+    granted_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (group_id, permission_id)
+);
+
+CREATE OR REPLACE TRIGGER group_members_invalidation AFTER INSERT OR UPDATE OR DELETE ON group_members
+FOR EACH STATEMENT EXECUTE PROCEDURE notify_all_permissions_changed();
+
+CREATE OR REPLACE TRIGGER group_permissions_invalidation AFTER INSERT OR UPDATE OR DELETE ON group_permissions
+FOR EACH STATEMENT EXECUTE PROCEDURE notify_all_permissions_changed();
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+DELETE FROM users_permissions WHERE org_id IS NOT NULL;
+DROP INDEX IF EXISTS users_permissions_key;
+ALTER TABLE users_permissions DROP COLUMN IF EXISTS org_id;
+ALTER TABLE users_permissions ADD PRIMARY KEY (user_id, permission_id);
+ALTER TABLE apps DROP COLUMN IF EXISTS org_id;
+DROP TABLE IF EXISTS org_members;
+DROP TABLE IF EXISTS orgs;
+COMMIT;
@@ -0,0 +1,27 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS orgs (
+    id bigint PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    name text NOT NULL UNIQUE,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS org_members (
+    org_id bigint NOT NULL REFERENCES orgs ON DELETE CASCADE,
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    role text NOT NULL DEFAULT 'user' REFERENCES roles (name) ON UPDATE CASCADE,
+    joined_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (org_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS org_members_user_id_idx ON org_members (user_id);
+
+-- NULL org_id stands for an app shared by all of the orgs
+ALTER TABLE apps ADD COLUMN IF NOT EXISTS org_id bigint REFERENCES orgs ON DELETE RESTRICT;
+CREATE INDEX IF NOT EXISTS apps_org_id_idx ON apps (org_id);
+
+-- NULL org_id stands for a grant effective in every org
+ALTER TABLE users_permissions ADD COLUMN IF NOT EXISTS org_id bigint REFERENCES orgs ON DELETE CASCADE;
+ALTER TABLE users_permissions DROP CONSTRAINT IF EXISTS users_permissions_pkey;
+CREATE UNIQUE INDEX IF NOT EXISTS users_permissions_key ON users_permissions (user_id, permission_id, org_id) NULLS NOT DISTINCT;
+
+CREATE OR REPLACE TRIGGER org_members_invalidation AFTER INSERT OR UPDATE OR DELETE ON org_members
+FOR EACH ROW EXECUTE PROCEDURE notify_user_permissions_changed();
+COMMIT;
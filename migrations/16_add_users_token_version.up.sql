@@ -0,0 +1,2 @@
+-- Incremented to revoke all of the tokens issued to the user so far
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+BEGIN;
+DROP TABLE IF EXISTS users_apps;
+DROP INDEX IF EXISTS users_updated_at_idx;
+DROP INDEX IF EXISTS users_created_at_idx;
+DROP INDEX IF EXISTS users_email_trgm_idx;
+DROP INDEX IF EXISTS users_username_trgm_idx;
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+-- fuzzy search by username and email
+CREATE INDEX IF NOT EXISTS users_username_trgm_idx ON users USING gin (username gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS users_email_trgm_idx ON users USING gin ((email::text) gin_trgm_ops);
+-- keyset pagination
+CREATE INDEX IF NOT EXISTS users_created_at_idx ON users (created_at, id);
+CREATE INDEX IF NOT EXISTS users_updated_at_idx ON users (updated_at, id);
+
+-- apps the user has registered with or logged in to
+CREATE TABLE IF NOT EXISTS users_apps (
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    app_id int NOT NULL REFERENCES apps ON DELETE CASCADE,
+    joined_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_login_at timestamptz,
+    PRIMARY KEY (user_id, app_id)
+);
+CREATE INDEX IF NOT EXISTS users_apps_app_id_idx ON users_apps (app_id);
+COMMIT;
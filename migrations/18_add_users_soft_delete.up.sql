@@ -0,0 +1,16 @@
+BEGIN;
+-- deleted users can be restored until their personal data is erased (anonymized_at is set)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS anonymized_at timestamptz;
+CREATE INDEX IF NOT EXISTS users_deleted_at_idx ON users (deleted_at) WHERE deleted_at IS NOT NULL AND anonymized_at IS NULL;
+
+-- proof of erasure of the user's personal data, user_id is not a foreign key
+-- since reports must outlive users
+CREATE TABLE IF NOT EXISTS erasure_reports (
+    id bigint PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    user_id bigint NOT NULL UNIQUE,
+    deleted_at timestamptz NOT NULL,
+    erased_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    details jsonb NOT NULL DEFAULT '{}'
+);
+COMMIT;
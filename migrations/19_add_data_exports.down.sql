@@ -0,0 +1,4 @@
+BEGIN;
+DROP INDEX IF EXISTS audit_events_actor_id_idx;
+DROP TABLE IF EXISTS data_exports;
+COMMIT;
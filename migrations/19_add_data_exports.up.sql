@@ -0,0 +1,15 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS data_exports (
+    id bigint PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    requested_by bigint NOT NULL,
+    status text NOT NULL DEFAULT 'pending',
+    error text NOT NULL DEFAULT '',
+    archive bytea,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at timestamptz,
+    completed_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS data_exports_pending_idx ON data_exports (id) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS audit_events_actor_id_idx ON audit_events (actor_id);
+COMMIT;
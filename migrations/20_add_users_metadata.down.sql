@@ -0,0 +1,6 @@
+BEGIN;
+ALTER TABLE apps DROP COLUMN IF EXISTS metadata_schema;
+ALTER TABLE users_apps DROP COLUMN IF EXISTS metadata;
+ALTER TABLE users DROP COLUMN IF EXISTS admin_metadata;
+ALTER TABLE users DROP COLUMN IF EXISTS public_metadata;
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+-- custom profile attributes: public ones are visible to anyone who can see the user,
+-- admin ones to admins only and app-private ones are kept per app in users_apps
+ALTER TABLE users ADD COLUMN IF NOT EXISTS public_metadata jsonb NOT NULL DEFAULT '{}';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS admin_metadata jsonb NOT NULL DEFAULT '{}';
+ALTER TABLE users_apps ADD COLUMN IF NOT EXISTS metadata jsonb NOT NULL DEFAULT '{}';
+-- JSON Schema app-private metadata must conform to, not checked if null
+ALTER TABLE apps ADD COLUMN IF NOT EXISTS metadata_schema jsonb;
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE apps DROP COLUMN IF EXISTS previous_secret_expires_at;
+ALTER TABLE apps DROP COLUMN IF EXISTS previous_secret;
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+-- secret replaced by rotation, tokens signed with it are accepted until it expires
+ALTER TABLE apps ADD COLUMN IF NOT EXISTS previous_secret text;
+ALTER TABLE apps ADD COLUMN IF NOT EXISTS previous_secret_expires_at timestamptz;
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+ALTER TABLE apps DROP COLUMN IF EXISTS require_activation;
+ALTER TABLE apps DROP COLUMN IF EXISTS self_registration;
+ALTER TABLE apps DROP COLUMN IF EXISTS login_methods;
+ALTER TABLE apps DROP COLUMN IF EXISTS activation_token_ttl;
+ALTER TABLE apps DROP COLUMN IF EXISTS refresh_token_ttl;
+ALTER TABLE apps DROP COLUMN IF EXISTS access_token_ttl;
+COMMIT;
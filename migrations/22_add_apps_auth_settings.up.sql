@@ -0,0 +1,10 @@
+BEGIN;
+-- per-app auth settings, NULL falls back to the global config
+ALTER TABLE apps ADD COLUMN IF NOT EXISTS access_token_ttl interval CHECK (access_token_ttl > interval '0');
+ALTER TABLE apps ADD COLUMN IF NOT EXISTS refresh_token_ttl interval CHECK (refresh_token_ttl > interval '0');
+ALTER TABLE apps ADD COLUMN IF NOT EXISTS activation_token_ttl interval CHECK (activation_token_ttl > interval '0');
+-- NULL allows all of the login methods
+ALTER TABLE apps ADD COLUMN IF NOT EXISTS login_methods text[];
+ALTER TABLE apps ADD COLUMN IF NOT EXISTS self_registration boolean;
+ALTER TABLE apps ADD COLUMN IF NOT EXISTS require_activation boolean;
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+-- set while the user is deactivated by an admin, is_active alone doesn't tell deactivated users
+-- from the ones not activated yet
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deactivated_at timestamptz;
+-- activation never unsets is_active, so inactive users whose activity has been changed by an admin are deactivated
+UPDATE users SET deactivated_at = updated_at WHERE NOT is_active AND id IN (
+    SELECT user_id FROM audit_events
+    WHERE type = 'user.deactivated' OR type = 'user.updated' AND payload->'fields' ? 'is_active'
+);
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+DROP TRIGGER IF EXISTS org_members_role_org ON org_members;
+DROP TRIGGER IF EXISTS users_role_org ON users;
+DROP FUNCTION IF EXISTS check_role_org;
+ALTER TABLE roles DROP COLUMN IF EXISTS org_id;
+
+DROP INDEX IF EXISTS relation_tuples_key;
+ALTER TABLE relation_tuples DROP COLUMN IF EXISTS org_id;
+CREATE UNIQUE INDEX IF NOT EXISTS relation_tuples_key ON relation_tuples
+    (namespace, object_id, relation, subject_user_id, subject_namespace, subject_object_id, subject_relation) NULLS NOT DISTINCT;
+
+DROP INDEX IF EXISTS policies_org_id_app_id_name_key;
+ALTER TABLE policies DROP COLUMN IF EXISTS org_id;
+CREATE UNIQUE INDEX IF NOT EXISTS policies_app_id_name_key ON policies (app_id, name) NULLS NOT DISTINCT;
+
+DROP INDEX IF EXISTS groups_org_id_name_key;
+ALTER TABLE groups DROP COLUMN IF EXISTS org_id;
+ALTER TABLE groups ADD CONSTRAINT groups_name_key UNIQUE (name);
+COMMIT;
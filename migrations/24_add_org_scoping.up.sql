@@ -0,0 +1,45 @@
+BEGIN;
+-- NULL org_id stands for a group shared by all of the orgs, permissions of groups of an org are effective in the org only
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS org_id bigint REFERENCES orgs ON DELETE CASCADE;
+ALTER TABLE groups DROP CONSTRAINT IF EXISTS groups_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS groups_org_id_name_key ON groups (org_id, name) NULLS NOT DISTINCT;
+
+-- NULL org_id stands for a policy applying in every org
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS org_id bigint REFERENCES orgs ON DELETE CASCADE;
+DROP INDEX IF EXISTS policies_app_id_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS policies_org_id_app_id_name_key ON policies (org_id, app_id, name) NULLS NOT DISTINCT;
+
+-- NULL org_id stands for a tuple effective in every org
+ALTER TABLE relation_tuples ADD COLUMN IF NOT EXISTS org_id bigint REFERENCES orgs ON DELETE CASCADE;
+DROP INDEX IF EXISTS relation_tuples_key;
+CREATE UNIQUE INDEX IF NOT EXISTS relation_tuples_key ON relation_tuples
+    (org_id, namespace, object_id, relation, subject_user_id, subject_namespace, subject_object_id, subject_relation) NULLS NOT DISTINCT;
+
+-- NULL org_id stands for a global role, roles of an org can be assigned only to members of the org
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS org_id bigint REFERENCES orgs ON DELETE CASCADE;
+
+-- Roles are referenced by name, so the org of the role is checked by trigger instead of foreign key
+CREATE OR REPLACE FUNCTION check_role_org()
+RETURNS TRIGGER AS $$
+DECLARE
+    role_org_id bigint;
+    assigned_org_id bigint;
+BEGIN
+    SELECT org_id INTO role_org_id FROM roles WHERE name = NEW.role;
+    IF TG_TABLE_NAME = 'org_members' THEN
+        assigned_org_id := NEW.org_id;
+    END IF;
+    IF role_org_id IS NOT NULL AND role_org_id IS DISTINCT FROM assigned_org_id THEN
+        RAISE foreign_key_violation USING MESSAGE = format('role %s belongs to another org', NEW.role),
+            CONSTRAINT = 'role_org';
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE OR REPLACE TRIGGER users_role_org BEFORE INSERT OR UPDATE OF role ON users
+FOR EACH ROW EXECUTE PROCEDURE check_role_org();
+
+CREATE OR REPLACE TRIGGER org_members_role_org BEFORE INSERT OR UPDATE OF role ON org_members
+FOR EACH ROW EXECUTE PROCEDURE check_role_org();
+COMMIT;
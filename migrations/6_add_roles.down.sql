@@ -0,0 +1,11 @@
+BEGIN;
+CREATE TYPE role AS ENUM ('user', 'moderator', 'admin');
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_fkey;
+UPDATE users SET role = 'user' WHERE role NOT IN ('user', 'moderator', 'admin');
+ALTER TABLE users ALTER COLUMN role DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN role DROP NOT NULL;
+ALTER TABLE users ALTER COLUMN role TYPE role USING role::role;
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'user';
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
+COMMIT;
@@ -0,0 +1,26 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS roles (
+    id bigint PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    name text UNIQUE NOT NULL,
+    description text NOT NULL DEFAULT '',
+    is_builtin boolean NOT NULL DEFAULT FALSE
+);
+INSERT INTO roles (name, is_builtin)
+SELECT unnest(enum_range(NULL::role))::text, TRUE
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id bigint NOT NULL REFERENCES roles ON DELETE CASCADE,
+    permission_id bigint NOT NULL REFERENCES permissions ON DELETE CASCADE,
+    granted_at timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+ALTER TABLE users ALTER COLUMN role DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN role TYPE text USING coalesce(role::text, 'user');
+ALTER TABLE users
+ALTER COLUMN role SET DEFAULT 'user',
+ALTER COLUMN role SET NOT NULL,
+ADD CONSTRAINT users_role_fkey FOREIGN KEY (role) REFERENCES roles (name) ON UPDATE CASCADE;
+DROP TYPE IF EXISTS role;
+COMMIT;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+    id bigint PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    type text NOT NULL,
+    actor_id bigint,
+    user_id bigint,
+    payload jsonb NOT NULL DEFAULT '{}',
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS audit_events_user_id_idx ON audit_events (user_id);
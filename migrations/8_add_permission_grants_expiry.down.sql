@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS users_permissions_expires_at_idx;
+ALTER TABLE users_permissions DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,2 @@
+ALTER TABLE users_permissions ADD COLUMN IF NOT EXISTS expires_at timestamptz;
+CREATE INDEX IF NOT EXISTS users_permissions_expires_at_idx ON users_permissions (expires_at) WHERE expires_at IS NOT NULL;
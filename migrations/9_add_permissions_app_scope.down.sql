@@ -0,0 +1,6 @@
+BEGIN;
+DELETE FROM permissions WHERE app_id IS NOT NULL;
+DROP INDEX IF EXISTS permissions_app_id_code_key;
+ALTER TABLE permissions DROP COLUMN IF EXISTS app_id;
+ALTER TABLE permissions ADD CONSTRAINT permissions_code_key UNIQUE (code);
+COMMIT;
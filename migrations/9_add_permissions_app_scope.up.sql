@@ -0,0 +1,6 @@
+BEGIN;
+-- NULL app_id stands for the global namespace, all of the existing permissions are moved there
+ALTER TABLE permissions ADD COLUMN IF NOT EXISTS app_id integer REFERENCES apps ON DELETE CASCADE;
+ALTER TABLE permissions DROP CONSTRAINT IF EXISTS permissions_code_key;
+CREATE UNIQUE INDEX IF NOT EXISTS permissions_app_id_code_key ON permissions (app_id, code) NULLS NOT DISTINCT;
+COMMIT;
@@ -0,0 +1,176 @@
+// Package envelope encrypts secrets stored at rest with envelope encryption.
+//
+// Every value is encrypted with its own random data key and the data key is encrypted with a master key,
+// both with AES-256-GCM. Master keys are versioned and the version is kept with the value, so that values
+// encrypted with older keys can still be decrypted and re-encrypted with the current key after rotation.
+//
+// Encrypted values look like "enc:v1:<key version>:<encrypted data key>:<encrypted value>",
+// encrypted parts are base64 (URL alphabet, no padding) encoded and carry their nonce in front.
+// Values without the "enc:" prefix are treated as legacy plaintext.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	KeySize = 32
+
+	prefix = "enc:v1:"
+)
+
+var (
+	ErrNoKeys         = errors.New("no master keys")
+	ErrInvalidKey     = errors.New("master key must be 32 bytes long")
+	ErrUnknownVersion = errors.New("unknown master key version")
+	ErrMalformed      = errors.New("malformed encrypted value")
+)
+
+// Keyring holds versioned master keys, safe for concurrent use.
+type Keyring struct {
+	current int
+	keys    map[int]cipher.AEAD
+}
+
+// NewKeyring returns keyring encrypting with the key of the current version, the highest version if current is zero.
+func NewKeyring(keys map[int][]byte, current int) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+	keyring := Keyring{current: current, keys: make(map[int]cipher.AEAD, len(keys))}
+	for version, key := range keys {
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("%w: version %d", ErrInvalidKey, version)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		keyring.keys[version] = aead
+		if current == 0 && version > keyring.current {
+			keyring.current = version
+		}
+	}
+	if _, ok := keyring.keys[keyring.current]; !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, keyring.current)
+	}
+	return &keyring, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// CurrentVersion returns version of the master key new values are encrypted with.
+func (k *Keyring) CurrentVersion() int {
+	return k.current
+}
+
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	version := strconv.Itoa(k.current)
+	// the version is authenticated along with the data key, so that it can't be swapped
+	encryptedKey, err := seal(k.keys[k.current], dataKey, []byte(version))
+	if err != nil {
+		return "", err
+	}
+	encryptedValue, err := seal(dataAEAD, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+	return prefix + version + ":" + encryptedKey + ":" + encryptedValue, nil
+}
+
+// Decrypt returns the plaintext of the value encrypted with any of the keys, legacy plaintext values are returned as is.
+func (k *Keyring) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", ErrMalformed
+	}
+	version, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return "", ErrMalformed
+	}
+	masterAEAD, ok := k.keys[version]
+	if !ok {
+		return "", fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+	dataKey, err := open(masterAEAD, parts[1], []byte(parts[0]))
+	if err != nil {
+		return "", err
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", ErrMalformed
+	}
+	plaintext, err := open(dataAEAD, parts[2], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// NeedsReencryption reports whether the value is plaintext or encrypted with other than the current key.
+func (k *Keyring) NeedsReencryption(value string) bool {
+	version, ok := Version(value)
+	return !ok || version != k.current
+}
+
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, "enc:")
+}
+
+// Version returns version of the master key the value is encrypted with, false for plaintext or malformed values.
+func Version(value string) (int, bool) {
+	if !strings.HasPrefix(value, prefix) {
+		return 0, false
+	}
+	version, _, found := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	if !found {
+		return 0, false
+	}
+	parsed, err := strconv.Atoi(version)
+	return parsed, err == nil
+}
+
+func seal(aead cipher.AEAD, plaintext []byte, additionalData []byte) (string, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, additionalData)), nil
+}
+
+func open(aead cipher.AEAD, encoded string, additionalData []byte) ([]byte, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,95 @@
+package envelope
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	keyring, err := NewKeyring(map[int][]byte{1: testKey(1)}, 0)
+	require.NoError(t, err)
+	encrypted, err := keyring.Encrypt("app-secret")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, "enc:v1:1:"))
+	assert.NotContains(t, encrypted, "app-secret")
+	again, err := keyring.Encrypt("app-secret")
+	require.NoError(t, err)
+	assert.NotEqual(t, encrypted, again, "every value is encrypted with its own data key and nonce")
+
+	decrypted, err := keyring.Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "app-secret", decrypted)
+	assert.False(t, keyring.NeedsReencryption(encrypted))
+}
+
+func TestDecryptPlaintext(t *testing.T) {
+	keyring, err := NewKeyring(map[int][]byte{1: testKey(1)}, 0)
+	require.NoError(t, err)
+	decrypted, err := keyring.Decrypt("legacy-secret")
+	require.NoError(t, err)
+	assert.Equal(t, "legacy-secret", decrypted)
+	assert.True(t, keyring.NeedsReencryption("legacy-secret"))
+}
+
+func TestKeyRotation(t *testing.T) {
+	old, err := NewKeyring(map[int][]byte{1: testKey(1)}, 0)
+	require.NoError(t, err)
+	encrypted, err := old.Encrypt("app-secret")
+	require.NoError(t, err)
+
+	rotated, err := NewKeyring(map[int][]byte{1: testKey(1), 2: testKey(2)}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, rotated.CurrentVersion())
+	assert.True(t, rotated.NeedsReencryption(encrypted))
+	decrypted, err := rotated.Decrypt(encrypted)
+	require.NoError(t, err)
+	reencrypted, err := rotated.Encrypt(decrypted)
+	require.NoError(t, err)
+	version, ok := Version(reencrypted)
+	require.True(t, ok)
+	assert.Equal(t, 2, version)
+
+	_, err = old.Decrypt(reencrypted)
+	assert.ErrorIs(t, err, ErrUnknownVersion)
+	pinned, err := NewKeyring(map[int][]byte{1: testKey(1), 2: testKey(2)}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pinned.CurrentVersion())
+}
+
+func TestDecryptTampered(t *testing.T) {
+	keyring, err := NewKeyring(map[int][]byte{1: testKey(1), 2: testKey(2)}, 1)
+	require.NoError(t, err)
+	encrypted, err := keyring.Encrypt("app-secret")
+	require.NoError(t, err)
+	testCases := map[string]string{
+		"swapped version":    strings.Replace(encrypted, "enc:v1:1:", "enc:v1:2:", 1),
+		"truncated":          encrypted[:len(encrypted)-4],
+		"missing parts":      "enc:v1:1:abc",
+		"unsupported format": "enc:v9:1:a:b",
+	}
+	for name, value := range testCases {
+		_, err := keyring.Decrypt(value)
+		assert.ErrorIs(t, err, ErrMalformed, name)
+	}
+	wrongKey, err := NewKeyring(map[int][]byte{1: testKey(3)}, 0)
+	require.NoError(t, err)
+	_, err = wrongKey.Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestNewKeyring(t *testing.T) {
+	_, err := NewKeyring(nil, 0)
+	assert.ErrorIs(t, err, ErrNoKeys)
+	_, err = NewKeyring(map[int][]byte{1: []byte("short")}, 0)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = NewKeyring(map[int][]byte{1: testKey(1)}, 2)
+	assert.ErrorIs(t, err, ErrUnknownVersion)
+}
@@ -0,0 +1,34 @@
+package grpcserver
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recoveryUnaryInterceptor turns panics of handlers into Internal errors, so that a single bad request
+// (e.g. a malformed imported password hash) can't bring the whole server down.
+func recoveryUnaryInterceptor(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer recoverPanic(log, info.FullMethod, &err)
+		return handler(ctx, req)
+	}
+}
+
+func recoveryStreamInterceptor(log *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer recoverPanic(log, info.FullMethod, &err)
+		return handler(srv, stream)
+	}
+}
+
+func recoverPanic(log *slog.Logger, method string, err *error) {
+	if p := recover(); p != nil {
+		log.Error("Recovered from panic", "method", method, "panic", p, "stack", string(debug.Stack()))
+		*err = status.Error(codes.Internal, "internal error")
+	}
+}
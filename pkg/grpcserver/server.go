@@ -21,10 +21,25 @@ type Server struct {
 
 const fullSystemHealthServing = ""
 
-func New(log *slog.Logger, host string, port string, authServer ssov1.AuthServer, permissionsServer ssov1.PermissionsServer) *Server {
-	gRPCServer := grpc.NewServer()
+func New(
+	log *slog.Logger,
+	host string,
+	port string,
+	authServer ssov1.AuthServer,
+	permissionsServer ssov1.PermissionsServer,
+	relationsServer ssov1.RelationsServer,
+	orgsServer ssov1.OrgsServer,
+	userDataServer ssov1.UserDataServer,
+) *Server {
+	gRPCServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(recoveryUnaryInterceptor(log)),
+		grpc.ChainStreamInterceptor(recoveryStreamInterceptor(log)),
+	)
 	ssov1.RegisterAuthServer(gRPCServer, authServer)
 	ssov1.RegisterPermissionsServer(gRPCServer, permissionsServer)
+	ssov1.RegisterRelationsServer(gRPCServer, relationsServer)
+	ssov1.RegisterOrgsServer(gRPCServer, orgsServer)
+	ssov1.RegisterUserDataServer(gRPCServer, userDataServer)
 	healthcheckServer := health.NewServer()
 	healthgrpc.RegisterHealthServer(gRPCServer, healthcheckServer)
 	return &Server{log, gRPCServer, make(chan error, 1), healthcheckServer, host, port}
@@ -0,0 +1,92 @@
+package jwt
+
+import (
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"sso.service/pkg/permcode"
+)
+
+const (
+	RoleClaim  = "role"
+	ScopeClaim = "scope"
+	// Set instead of ScopeClaim when the scope was too large to be embedded,
+	// permissions must be checked online in this case.
+	ScopeOmittedClaim = "scope_omitted"
+	// Org the token was issued for, absent for tokens issued without an active org
+	OrgIDClaim = "org_id"
+	// Version of the user's tokens at the moment of issue, tokens of older versions are revoked
+	TokenVersionClaim = "tv"
+	// Distinguishes access tokens from refresh (and other) tokens signed with the same key
+	TokenTypeClaim   = "typ"
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// Scope is a set of permission codes (wildcard patterns included) embedded in an access token.
+type Scope []string
+
+// String encodes scope as space separated codes, the way it is stored in ScopeClaim.
+func (s Scope) String() string {
+	return strings.Join(s, " ")
+}
+
+// Has reports whether any of the granted codes covers the code.
+func (s Scope) Has(code string) bool {
+	_, ok := permcode.BestMatch(s, code)
+	return ok
+}
+
+// HasAll reports whether every one of the codes is covered by the scope.
+func (s Scope) HasAll(codes ...string) bool {
+	for _, code := range codes {
+		if !s.Has(code) {
+			return false
+		}
+	}
+	return true
+}
+
+// ScopeFromClaims extracts scope embedded in token claims.
+// ok is false when the token carries no scope, i.e. the app doesn't embed permissions
+// or the scope was omitted because of its size.
+func ScopeFromClaims(claims map[string]any) (scope Scope, ok bool) {
+	if omitted, _ := claims[ScopeOmittedClaim].(bool); omitted {
+		return nil, false
+	}
+	raw, ok := claims[ScopeClaim].(string)
+	if !ok {
+		return nil, false
+	}
+	return Scope(strings.Fields(raw)), true
+}
+
+// RoleFromClaims extracts role of the user embedded in token claims.
+func RoleFromClaims(claims map[string]any) (string, bool) {
+	role, ok := claims[RoleClaim].(string)
+	return role, ok && role != ""
+}
+
+// OrgIDFromClaims extracts id of the active org from token claims.
+func OrgIDFromClaims(claims map[string]any) (int64, bool) {
+	// numbers are decoded from JSON as float64
+	orgID, ok := claims[OrgIDClaim].(float64)
+	return int64(orgID), ok && orgID > 0
+}
+
+// TokenVersionFromClaims extracts version of the user's tokens, tokens issued without it have version 0.
+func TokenVersionFromClaims(claims map[string]any) int64 {
+	version, _ := claims[TokenVersionClaim].(float64)
+	return int64(version)
+}
+
+// UnverifiedClaims decodes claims of the token without verifying its signature,
+// e.g. to find out which app the token was issued for and hence which key verifies it.
+// The claims must not be trusted until the token is verified.
+func UnverifiedClaims(token string) (map[string]any, error) {
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any(parsed.Claims.(jwt.MapClaims)), nil
+}
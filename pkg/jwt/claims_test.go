@@ -0,0 +1,72 @@
+package jwt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopeFromClaims(t *testing.T) {
+	tokenProvider := NewTokenProvider(testSecret, testSigningAlg)
+	scope := Scope{"billing.*", "users:read"}
+	token, err := tokenProvider.NewToken(testTokenExp, map[string]any{RoleClaim: "admin", ScopeClaim: scope.String()})
+	require.NoError(t, err)
+	claims, err := tokenProvider.ParseClaimsFromToken(token)
+	require.NoError(t, err)
+	parsed, ok := ScopeFromClaims(claims)
+	require.True(t, ok)
+	assert.Equal(t, scope, parsed)
+	role, ok := RoleFromClaims(claims)
+	assert.True(t, ok)
+	assert.Equal(t, "admin", role)
+}
+
+func TestScopeFromClaimsMissing(t *testing.T) {
+	_, ok := ScopeFromClaims(map[string]any{})
+	assert.False(t, ok)
+	_, ok = ScopeFromClaims(map[string]any{ScopeClaim: "", ScopeOmittedClaim: true})
+	assert.False(t, ok)
+	scope, ok := ScopeFromClaims(map[string]any{ScopeClaim: ""})
+	assert.True(t, ok)
+	assert.Empty(t, scope)
+	_, ok = RoleFromClaims(map[string]any{})
+	assert.False(t, ok)
+}
+
+func TestOrgIDFromClaims(t *testing.T) {
+	tokenProvider := NewTokenProvider(testSecret, testSigningAlg)
+	token, err := tokenProvider.NewToken(testTokenExp, map[string]any{OrgIDClaim: int64(42)})
+	require.NoError(t, err)
+	claims, err := tokenProvider.ParseClaimsFromToken(token)
+	require.NoError(t, err)
+	orgID, ok := OrgIDFromClaims(claims)
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), orgID)
+	_, ok = OrgIDFromClaims(map[string]any{})
+	assert.False(t, ok)
+}
+
+func TestUnverifiedClaims(t *testing.T) {
+	tokenProvider := NewTokenProvider(testSecret, testSigningAlg)
+	token, err := tokenProvider.NewToken(testTokenExp, map[string]any{"app_id": 7, TokenVersionClaim: 3})
+	require.NoError(t, err)
+	claims, err := UnverifiedClaims(token)
+	require.NoError(t, err)
+	assert.Equal(t, float64(7), claims["app_id"])
+	assert.Equal(t, int64(3), TokenVersionFromClaims(claims))
+	assert.Equal(t, int64(0), TokenVersionFromClaims(map[string]any{}))
+	_, err = UnverifiedClaims("not a token")
+	assert.Error(t, err)
+}
+
+func TestScopeHas(t *testing.T) {
+	scope := Scope{"billing.*", "users:read"}
+	assert.True(t, scope.Has("users:read"))
+	assert.True(t, scope.Has("billing.invoices:read"))
+	assert.False(t, scope.Has("users:write"))
+	assert.True(t, scope.HasAll("users:read", "billing.refunds:create"))
+	assert.False(t, scope.HasAll("users:read", "users:delete"))
+	assert.True(t, Scope{"*"}.Has("anything.at.all"))
+	assert.False(t, Scope{}.Has("users:read"))
+}
@@ -1,6 +1,7 @@
 package jwt
 
 import (
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -9,10 +10,13 @@ import (
 type TokenProvider struct {
 	SigningKey string
 	SigningAlg string
+	// Keys replaced by SigningKey, tokens signed with them are still accepted
+	OldKeys []string
 }
 
-func NewTokenProvider(signingKey string, signingAlg string) *TokenProvider {
-	return &TokenProvider{signingKey, signingAlg}
+// oldKeys are accepted by ParseClaimsFromToken only, new tokens are signed with signingKey.
+func NewTokenProvider(signingKey string, signingAlg string, oldKeys ...string) *TokenProvider {
+	return &TokenProvider{signingKey, signingAlg, oldKeys}
 }
 
 
@@ -31,11 +35,21 @@ func (tp *TokenProvider) NewToken(expires time.Duration, _claims ...map[string]a
 }
 
 func (tp *TokenProvider) ParseClaimsFromToken(token string) (map[string]any, error) {
-	parsed, err := jwt.Parse(token, func(token *jwt.Token) (any, error) {
-		return []byte(tp.SigningKey), nil
-	}, jwt.WithValidMethods([]string{tp.SigningAlg}))
+	parsed, err := tp.parse(token, tp.SigningKey)
+	for _, key := range tp.OldKeys {
+		if !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			break
+		}
+		parsed, err = tp.parse(token, key)
+	}
 	if err != nil {
 		return nil, err
 	}
 	return map[string]any(parsed.Claims.(jwt.MapClaims)), nil
+}
+
+func (tp *TokenProvider) parse(token string, key string) (*jwt.Token, error) {
+	return jwt.Parse(token, func(token *jwt.Token) (any, error) {
+		return []byte(key), nil
+	}, jwt.WithValidMethods([]string{tp.SigningAlg}))
 }
\ No newline at end of file
@@ -40,4 +40,21 @@ func TestParseClaimsFromToken(t *testing.T) {
 	claims, err := tokenProvider.ParseClaimsFromToken(token)
 	require.NoError(t, err)
 	assert.Equal(t, tokenPayload["id"], claims["id"])
-}
\ No newline at end of file
+}
+
+func TestParseClaimsFromTokenWithOldKeys(t *testing.T) {
+	const newSecret = "new_test_secret"
+	oldToken, err := NewTokenProvider(testSecret, testSigningAlg).NewToken(testTokenExp, map[string]any{"id": float64(1)})
+	require.NoError(t, err)
+	rotated := NewTokenProvider(newSecret, testSigningAlg, "unrelated_secret", testSecret)
+	claims, err := rotated.ParseClaimsFromToken(oldToken)
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), claims["id"])
+
+	newToken, err := rotated.NewToken(testTokenExp, map[string]any{"id": float64(2)})
+	require.NoError(t, err)
+	_, err = NewTokenProvider(testSecret, testSigningAlg).ParseClaimsFromToken(newToken)
+	assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+	_, err = NewTokenProvider(newSecret, testSigningAlg).ParseClaimsFromToken(oldToken)
+	assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+}
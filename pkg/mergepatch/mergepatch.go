@@ -0,0 +1,25 @@
+// Package mergepatch applies JSON merge patches (RFC 7396) to decoded JSON objects.
+//
+// Keys of the patch replace keys of the target, null values remove them and nested objects
+// are merged recursively. Any other value, including a list, replaces the target value as a whole.
+package mergepatch
+
+// Apply returns the target with the patch merged into it. The target is not modified.
+func Apply(target map[string]any, patch map[string]any) map[string]any {
+	result := make(map[string]any, len(target)+len(patch))
+	for key, value := range target {
+		result[key] = value
+	}
+	for key, value := range patch {
+		switch v := value.(type) {
+		case nil:
+			delete(result, key)
+		case map[string]any:
+			nested, _ := result[key].(map[string]any)
+			result[key] = Apply(nested, v)
+		default:
+			result[key] = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,63 @@
+package mergepatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	testCases := []struct {
+		name     string
+		target   map[string]any
+		patch    map[string]any
+		expected map[string]any
+	}{
+		{
+			name:     "set and replace",
+			target:   map[string]any{"a": "b", "c": 1.0},
+			patch:    map[string]any{"a": "z", "d": true},
+			expected: map[string]any{"a": "z", "c": 1.0, "d": true},
+		},
+		{
+			name:     "remove with null",
+			target:   map[string]any{"a": "b", "c": "d"},
+			patch:    map[string]any{"a": nil, "missing": nil},
+			expected: map[string]any{"c": "d"},
+		},
+		{
+			name:     "merge nested objects",
+			target:   map[string]any{"prefs": map[string]any{"locale": "en", "theme": "dark"}},
+			patch:    map[string]any{"prefs": map[string]any{"locale": "de", "theme": nil}},
+			expected: map[string]any{"prefs": map[string]any{"locale": "de"}},
+		},
+		{
+			name:     "replace non object with object",
+			target:   map[string]any{"a": "b"},
+			patch:    map[string]any{"a": map[string]any{"c": nil, "d": 1.0}},
+			expected: map[string]any{"a": map[string]any{"d": 1.0}},
+		},
+		{
+			name:     "replace lists as a whole",
+			target:   map[string]any{"tags": []any{"a", "b"}},
+			patch:    map[string]any{"tags": []any{"c"}},
+			expected: map[string]any{"tags": []any{"c"}},
+		},
+		{
+			name:     "nil target",
+			patch:    map[string]any{"a": "b"},
+			expected: map[string]any{"a": "b"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Apply(tc.target, tc.patch))
+		})
+	}
+}
+
+func TestApplyDoesNotModifyTarget(t *testing.T) {
+	target := map[string]any{"a": "b", "nested": map[string]any{"c": "d"}}
+	Apply(target, map[string]any{"a": nil, "nested": map[string]any{"c": "e"}})
+	assert.Equal(t, map[string]any{"a": "b", "nested": map[string]any{"c": "d"}}, target)
+}
@@ -0,0 +1,116 @@
+package passhash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	argon2idPrefix = "$argon2id$"
+	argon2SaltLen  = 16
+	argon2KeyLen   = 32
+	// Upper bounds of the parameters of verified hashes, so that imported hashes can't make logins arbitrarily expensive
+	argon2MaxMemory     = maxHashMemory >> 10 // in KiB
+	argon2MaxIterations = 64
+)
+
+// Argon2id hashes into PHC string format: $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>.
+type Argon2id struct {
+	// Memory in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+type argon2idHash struct {
+	params Argon2id
+	salt   []byte
+	key    []byte
+}
+
+func (a Argon2id) Hash(secret []byte) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey(secret, salt, a.Iterations, a.Memory, a.Parallelism, argon2KeyLen)
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		a.Memory,
+		a.Iterations,
+		a.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (a Argon2id) Verify(secret []byte, encoded string) (bool, error) {
+	hash, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	p := hash.params
+	key := argon2.IDKey(secret, hash.salt, p.Iterations, p.Memory, p.Parallelism, uint32(len(hash.key)))
+	return subtle.ConstantTimeCompare(key, hash.key) == 1, nil
+}
+
+func (a Argon2id) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, argon2idPrefix)
+}
+
+func (a Argon2id) Outdated(encoded string) bool {
+	hash, err := decodeArgon2id(encoded)
+	return err != nil || hash.params != a
+}
+
+func (a Argon2id) Validate(encoded string) error {
+	_, err := decodeArgon2id(encoded)
+	return err
+}
+
+// CheckParams returns an error if hashes made with the parameters wouldn't be verified.
+func (a Argon2id) CheckParams() error {
+	// argon2 requires at least 8 KiB per lane
+	if a.Iterations < 1 || a.Iterations > argon2MaxIterations || a.Parallelism < 1 ||
+		a.Memory < 8*uint32(a.Parallelism) || a.Memory > argon2MaxMemory {
+		return fmt.Errorf("passhash: argon2id parameters m=%d,t=%d,p=%d out of range", a.Memory, a.Iterations, a.Parallelism)
+	}
+	return nil
+}
+
+func decodeArgon2id(encoded string) (*argon2idHash, error) {
+	parts := strings.Split(strings.TrimPrefix(encoded, argon2idPrefix), "$")
+	if len(parts) != 4 {
+		return nil, malformed("argon2id: expected 4 parts, got %d", len(parts))
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[0], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, malformed("argon2id: unsupported version %q", parts[0])
+	}
+	var hash argon2idHash
+	p := &hash.params
+	if _, err := fmt.Sscanf(parts[1], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return nil, malformed("argon2id: parameters %q: %v", parts[1], err)
+	}
+	if err := p.CheckParams(); err != nil {
+		return nil, malformed("argon2id: parameters %q out of range", parts[1])
+	}
+	var err error
+	if hash.salt, err = base64.RawStdEncoding.DecodeString(parts[2]); err != nil {
+		return nil, malformed("argon2id: salt: %v", err)
+	}
+	if hash.key, err = base64.RawStdEncoding.DecodeString(parts[3]); err != nil {
+		return nil, malformed("argon2id: key: %v", err)
+	}
+	if len(hash.key) < minKeyLen || len(hash.key) > maxKeyLen {
+		return nil, malformed("argon2id: key length %d out of range", len(hash.key))
+	}
+	return &hash, nil
+}
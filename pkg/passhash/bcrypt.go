@@ -0,0 +1,53 @@
+package passhash
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Bcrypt hashes in the modular crypt format: $2a$<cost>$<salt and hash>.
+type Bcrypt struct {
+	Cost int
+}
+
+func (b Bcrypt) Hash(secret []byte) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword(secret, b.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (b Bcrypt) Verify(secret []byte, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), secret)
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (b Bcrypt) Recognizes(encoded string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(encoded, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b Bcrypt) Outdated(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != b.Cost
+}
+
+func (b Bcrypt) Validate(encoded string) error {
+	if _, err := bcrypt.Cost([]byte(encoded)); err != nil {
+		return malformed("bcrypt: %v", err)
+	}
+	return nil
+}
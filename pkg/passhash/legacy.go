@@ -0,0 +1,235 @@
+package passhash
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Hashes of the algorithms below are only expected to be imported from other systems and verified,
+// they're hashable to make test fixtures, and are always outdated to be rehashed on the next login.
+
+const (
+	pbkdf2SHA256Prefix = "pbkdf2_sha256$"
+	scryptPrefix       = "$scrypt$"
+	saltedSHA256Prefix = "sha256$"
+	legacySaltLen      = 16
+	legacyKeyLen       = 32
+	// Django's default is well below it, the bound keeps imported hashes from making logins arbitrarily expensive
+	pbkdf2MaxIterations  = 10_000_000
+	scryptMaxBlockSize   = 32
+	scryptMaxParallelism = 16
+)
+
+// PBKDF2SHA256 hashes in the Django format: pbkdf2_sha256$<iterations>$<salt>$<base64 key>.
+type PBKDF2SHA256 struct {
+	Iterations int
+}
+
+func (p PBKDF2SHA256) Hash(secret []byte) (string, error) {
+	salt, err := legacySalt()
+	if err != nil {
+		return "", err
+	}
+	key := pbkdf2.Key(secret, []byte(salt), p.Iterations, legacyKeyLen, sha256.New)
+	return fmt.Sprintf("%s%d$%s$%s", pbkdf2SHA256Prefix, p.Iterations, salt, base64.StdEncoding.EncodeToString(key)), nil
+}
+
+func (p PBKDF2SHA256) Verify(secret []byte, encoded string) (bool, error) {
+	iterations, salt, key, err := decodePBKDF2SHA256(encoded)
+	if err != nil {
+		return false, err
+	}
+	computed := pbkdf2.Key(secret, []byte(salt), iterations, len(key), sha256.New)
+	return subtle.ConstantTimeCompare(computed, key) == 1, nil
+}
+
+func (p PBKDF2SHA256) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, pbkdf2SHA256Prefix)
+}
+
+func (p PBKDF2SHA256) Outdated(string) bool {
+	return true
+}
+
+func (p PBKDF2SHA256) Validate(encoded string) error {
+	_, _, _, err := decodePBKDF2SHA256(encoded)
+	return err
+}
+
+func decodePBKDF2SHA256(encoded string) (iterations int, salt string, key []byte, err error) {
+	parts := strings.Split(strings.TrimPrefix(encoded, pbkdf2SHA256Prefix), "$")
+	if len(parts) != 3 {
+		return 0, "", nil, malformed("pbkdf2_sha256: expected 3 parts, got %d", len(parts))
+	}
+	if iterations, err = strconv.Atoi(parts[0]); err != nil || iterations < 1 || iterations > pbkdf2MaxIterations {
+		return 0, "", nil, malformed("pbkdf2_sha256: iterations %q", parts[0])
+	}
+	if parts[1] == "" {
+		return 0, "", nil, malformed("pbkdf2_sha256: empty salt")
+	}
+	if key, err = base64.StdEncoding.DecodeString(parts[2]); err != nil {
+		return 0, "", nil, malformed("pbkdf2_sha256: key: %v", err)
+	}
+	if len(key) < minKeyLen || len(key) > maxKeyLen {
+		return 0, "", nil, malformed("pbkdf2_sha256: key length %d out of range", len(key))
+	}
+	return iterations, parts[1], key, nil
+}
+
+// Scrypt hashes in the passlib format: $scrypt$ln=<log2 N>,r=<block size>,p=<parallelism>$<salt>$<key>,
+// salt and key are base64 encoded, with or without padding, "." is accepted in place of "+".
+type Scrypt struct {
+	LogN        int
+	BlockSize   int
+	Parallelism int
+}
+
+type scryptHash struct {
+	params Scrypt
+	salt   []byte
+	key    []byte
+}
+
+func (s Scrypt) Hash(secret []byte) (string, error) {
+	salt := make([]byte, legacySaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := scrypt.Key(secret, salt, 1<<s.LogN, s.BlockSize, s.Parallelism, legacyKeyLen)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(
+		"%sln=%d,r=%d,p=%d$%s$%s",
+		scryptPrefix,
+		s.LogN,
+		s.BlockSize,
+		s.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (s Scrypt) Verify(secret []byte, encoded string) (bool, error) {
+	hash, err := decodeScrypt(encoded)
+	if err != nil {
+		return false, err
+	}
+	p := hash.params
+	key, err := scrypt.Key(secret, hash.salt, 1<<p.LogN, p.BlockSize, p.Parallelism, len(hash.key))
+	if err != nil {
+		return false, malformed("scrypt: %v", err)
+	}
+	return subtle.ConstantTimeCompare(key, hash.key) == 1, nil
+}
+
+func (s Scrypt) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, scryptPrefix)
+}
+
+func (s Scrypt) Outdated(string) bool {
+	return true
+}
+
+func (s Scrypt) Validate(encoded string) error {
+	_, err := decodeScrypt(encoded)
+	return err
+}
+
+func decodeScrypt(encoded string) (*scryptHash, error) {
+	parts := strings.Split(strings.TrimPrefix(encoded, scryptPrefix), "$")
+	if len(parts) != 3 {
+		return nil, malformed("scrypt: expected 3 parts, got %d", len(parts))
+	}
+	var hash scryptHash
+	p := &hash.params
+	if _, err := fmt.Sscanf(parts[0], "ln=%d,r=%d,p=%d", &p.LogN, &p.BlockSize, &p.Parallelism); err != nil {
+		return nil, malformed("scrypt: parameters %q: %v", parts[0], err)
+	}
+	// scrypt.Key allocates 128·r·N bytes, the cost is bounded to keep imported hashes from exhausting memory
+	if p.LogN < 1 || p.LogN > 30 || p.BlockSize < 1 || p.BlockSize > scryptMaxBlockSize ||
+		p.Parallelism < 1 || p.Parallelism > scryptMaxParallelism ||
+		128*int64(p.BlockSize)<<p.LogN > maxHashMemory {
+		return nil, malformed("scrypt: parameters %q out of range", parts[0])
+	}
+	var err error
+	if hash.salt, err = decodeLenientBase64(parts[1]); err != nil {
+		return nil, malformed("scrypt: salt: %v", err)
+	}
+	if hash.key, err = decodeLenientBase64(parts[2]); err != nil {
+		return nil, malformed("scrypt: key: %v", err)
+	}
+	if len(hash.key) < minKeyLen || len(hash.key) > maxKeyLen {
+		return nil, malformed("scrypt: key length %d out of range", len(hash.key))
+	}
+	return &hash, nil
+}
+
+func decodeLenientBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.ReplaceAll(s, ".", "+"), "=")
+	return base64.RawStdEncoding.DecodeString(s)
+}
+
+// SaltedSHA256 hashes as sha256$<salt>$<hex SHA-256 of the salt followed by the secret>.
+// It's unsuitable for passwords and exists only to verify hashes imported from legacy systems.
+type SaltedSHA256 struct{}
+
+func (SaltedSHA256) Hash(secret []byte) (string, error) {
+	salt, err := legacySalt()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(salt), secret...))
+	return saltedSHA256Prefix + salt + "$" + hex.EncodeToString(sum[:]), nil
+}
+
+func (SaltedSHA256) Verify(secret []byte, encoded string) (bool, error) {
+	salt, digest, err := decodeSaltedSHA256(encoded)
+	if err != nil {
+		return false, err
+	}
+	sum := sha256.Sum256(append([]byte(salt), secret...))
+	return subtle.ConstantTimeCompare(sum[:], digest) == 1, nil
+}
+
+func (SaltedSHA256) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, saltedSHA256Prefix)
+}
+
+func (SaltedSHA256) Outdated(string) bool {
+	return true
+}
+
+func (SaltedSHA256) Validate(encoded string) error {
+	_, _, err := decodeSaltedSHA256(encoded)
+	return err
+}
+
+func decodeSaltedSHA256(encoded string) (salt string, digest []byte, err error) {
+	salt, hexDigest, ok := strings.Cut(strings.TrimPrefix(encoded, saltedSHA256Prefix), "$")
+	if !ok {
+		return "", nil, malformed("sha256: expected salt and digest")
+	}
+	if digest, err = hex.DecodeString(hexDigest); err != nil || len(digest) != sha256.Size {
+		return "", nil, malformed("sha256: digest %q", hexDigest)
+	}
+	return salt, digest, nil
+}
+
+// legacySalt returns a random salt printable in the formats keeping it verbatim
+func legacySalt() (string, error) {
+	salt := make([]byte, legacySaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(salt), nil
+}
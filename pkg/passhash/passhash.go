@@ -0,0 +1,123 @@
+// Package passhash hashes passwords with pluggable algorithms into self-describing encoded strings,
+// so that hashes made with other algorithms or outdated parameters can be verified and upgraded.
+package passhash
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// Prefix of hashes of peppered passwords, followed by the encoding of the algorithm
+	pepperedPrefix = "$peppered"
+	// Bounds of lengths of keys of verified hashes
+	minKeyLen = 16
+	maxKeyLen = 64
+	// Upper bound of memory (in bytes) a single verification may take
+	maxHashMemory = 256 << 20
+)
+
+var (
+	ErrUnknownAlgorithm = errors.New("passhash: hash of unknown algorithm")
+	ErrMalformedHash    = errors.New("passhash: malformed hash")
+	ErrPepperMissing    = errors.New("passhash: hash is peppered, but pepper isn't configured")
+)
+
+// Algorithm hashes secrets into strings encoding the algorithm along with its parameters.
+type Algorithm interface {
+	Hash(secret []byte) (string, error)
+	Verify(secret []byte, encoded string) (bool, error)
+	// Recognizes reports whether the hash has been made by the algorithm (with any parameters)
+	Recognizes(encoded string) bool
+	// Outdated reports whether the hash made by the algorithm has parameters other than the current ones
+	Outdated(encoded string) bool
+	// Validate checks the format of the hash made by the algorithm
+	Validate(encoded string) error
+}
+
+// Hasher hashes passwords with the current algorithm and verifies hashes of any of the known ones.
+// If the pepper is set, passwords are keyed with it (HMAC-SHA256) before hashing.
+type Hasher struct {
+	current Algorithm
+	known   []Algorithm
+	pepper  []byte
+}
+
+// New returns hasher hashing with current and verifying hashes of current and of the other algorithms.
+func New(current Algorithm, pepper []byte, other ...Algorithm) *Hasher {
+	return &Hasher{current: current, known: append([]Algorithm{current}, other...), pepper: pepper}
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	if len(h.pepper) == 0 {
+		return h.current.Hash([]byte(password))
+	}
+	encoded, err := h.current.Hash(h.peppered(password))
+	if err != nil {
+		return "", err
+	}
+	return pepperedPrefix + encoded, nil
+}
+
+func (h *Hasher) Verify(password string, encoded string) (bool, error) {
+	secret := []byte(password)
+	if inner, ok := strings.CutPrefix(encoded, pepperedPrefix); ok {
+		if len(h.pepper) == 0 {
+			return false, ErrPepperMissing
+		}
+		secret, encoded = h.peppered(password), inner
+	}
+	algorithm, err := h.algorithm(encoded)
+	if err != nil {
+		return false, err
+	}
+	return algorithm.Verify(secret, encoded)
+}
+
+// Validate checks that the hash has been made by one of the known algorithms and is well-formed,
+// without verifying any password against it.
+func (h *Hasher) Validate(encoded string) error {
+	inner, peppered := strings.CutPrefix(encoded, pepperedPrefix)
+	if peppered && len(h.pepper) == 0 {
+		return ErrPepperMissing
+	}
+	algorithm, err := h.algorithm(inner)
+	if err != nil {
+		return err
+	}
+	return algorithm.Validate(inner)
+}
+
+// NeedsRehash reports whether the hash should be replaced with a new one: it's made by another algorithm
+// than the current one or with outdated parameters, or it's peppered differently than configured.
+func (h *Hasher) NeedsRehash(encoded string) bool {
+	inner, peppered := strings.CutPrefix(encoded, pepperedPrefix)
+	if peppered != (len(h.pepper) > 0) {
+		return true
+	}
+	return !h.current.Recognizes(inner) || h.current.Outdated(inner)
+}
+
+func (h *Hasher) algorithm(encoded string) (Algorithm, error) {
+	for _, algorithm := range h.known {
+		if algorithm.Recognizes(encoded) {
+			return algorithm, nil
+		}
+	}
+	return nil, ErrUnknownAlgorithm
+}
+
+// peppered keys the password with the pepper, the result is base64 encoded since bcrypt can't hash NUL bytes.
+func (h *Hasher) peppered(password string) []byte {
+	mac := hmac.New(sha256.New, h.pepper)
+	mac.Write([]byte(password))
+	return []byte(base64.RawStdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+func malformed(format string, args ...any) error {
+	return fmt.Errorf("%w: "+format, append([]any{ErrMalformedHash}, args...)...)
+}
@@ -0,0 +1,170 @@
+package passhash
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+var testArgon2id = Argon2id{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+// well-formed 32 bytes key
+var testKey = base64.RawStdEncoding.EncodeToString(make([]byte, 32))
+
+func TestHashVerify(t *testing.T) {
+	testCases := []struct {
+		name   string
+		hasher *Hasher
+		prefix string
+	}{
+		{"argon2id", New(testArgon2id, nil), "$argon2id$v=19$m=1024,t=1,p=1$"},
+		{"bcrypt", New(Bcrypt{Cost: bcrypt.MinCost}, nil), "$2a$04$"},
+		{"peppered argon2id", New(testArgon2id, []byte("pepper")), "$peppered$argon2id$"},
+		{"peppered bcrypt", New(Bcrypt{Cost: bcrypt.MinCost}, []byte("pepper")), "$peppered$2a$"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			encoded, err := tc.hasher.Hash("Correct-horse1")
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(encoded, tc.prefix), encoded)
+			again, err := tc.hasher.Hash("Correct-horse1")
+			require.NoError(t, err)
+			assert.NotEqual(t, encoded, again, "hashes are salted")
+
+			matches, err := tc.hasher.Verify("Correct-horse1", encoded)
+			require.NoError(t, err)
+			assert.True(t, matches)
+			matches, err = tc.hasher.Verify("Wrong-horse1", encoded)
+			require.NoError(t, err)
+			assert.False(t, matches)
+			assert.False(t, tc.hasher.NeedsRehash(encoded))
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	legacy := New(Bcrypt{Cost: bcrypt.MinCost}, nil)
+	legacyHash, err := legacy.Hash("Correct-horse1")
+	require.NoError(t, err)
+
+	upgraded := New(testArgon2id, nil, Bcrypt{Cost: bcrypt.MinCost})
+	assert.True(t, upgraded.NeedsRehash(legacyHash), "another algorithm")
+	matches, err := upgraded.Verify("Correct-horse1", legacyHash)
+	require.NoError(t, err)
+	assert.True(t, matches, "hashes of known algorithms are verified")
+
+	assert.True(t, New(Bcrypt{Cost: bcrypt.MinCost + 1}, nil).NeedsRehash(legacyHash), "outdated cost")
+	argonHash, err := upgraded.Hash("Correct-horse1")
+	require.NoError(t, err)
+	assert.True(t, New(Argon2id{Memory: 2048, Iterations: 1, Parallelism: 1}, nil).NeedsRehash(argonHash), "outdated parameters")
+
+	peppered := New(testArgon2id, []byte("pepper"), Bcrypt{Cost: bcrypt.MinCost})
+	assert.True(t, peppered.NeedsRehash(argonHash), "not peppered")
+	matches, err = peppered.Verify("Correct-horse1", argonHash)
+	require.NoError(t, err)
+	assert.True(t, matches, "hashes made before the pepper was set are verified")
+}
+
+func TestVerifyErrors(t *testing.T) {
+	hasher := New(testArgon2id, nil)
+	_, err := hasher.Verify("Correct-horse1", "$scrypt$whatever")
+	assert.ErrorIs(t, err, ErrUnknownAlgorithm)
+	_, err = hasher.Verify("Correct-horse1", "$argon2id$v=19$m=1024$c2FsdA$a2V5")
+	assert.ErrorIs(t, err, ErrMalformedHash)
+
+	peppered, err := New(testArgon2id, []byte("pepper")).Hash("Correct-horse1")
+	require.NoError(t, err)
+	_, err = hasher.Verify("Correct-horse1", peppered)
+	assert.ErrorIs(t, err, ErrPepperMissing)
+	matches, err := New(testArgon2id, []byte("another pepper")).Verify("Correct-horse1", peppered)
+	require.NoError(t, err)
+	assert.False(t, matches)
+}
+
+func TestLegacyAlgorithms(t *testing.T) {
+	hasher := New(testArgon2id, nil, PBKDF2SHA256{}, Scrypt{}, SaltedSHA256{})
+	testCases := []struct {
+		name    string
+		encoded string
+	}{
+		{"pbkdf2_sha256", mustHash(t, PBKDF2SHA256{Iterations: 1000})},
+		{"scrypt", mustHash(t, Scrypt{LogN: 4, BlockSize: 8, Parallelism: 1})},
+		{"salted sha256", mustHash(t, SaltedSHA256{})},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, hasher.Validate(tc.encoded))
+			matches, err := hasher.Verify("Correct-horse1", tc.encoded)
+			require.NoError(t, err)
+			assert.True(t, matches)
+			matches, err = hasher.Verify("Wrong-horse1", tc.encoded)
+			require.NoError(t, err)
+			assert.False(t, matches)
+			assert.True(t, hasher.NeedsRehash(tc.encoded), "legacy hashes are always rehashed")
+		})
+	}
+}
+
+func TestLegacyKnownHashes(t *testing.T) {
+	hasher := New(testArgon2id, nil, PBKDF2SHA256{}, Scrypt{}, SaltedSHA256{})
+	sum := sha256.Sum256([]byte("saltCorrect-horse1"))
+	salted := "sha256$salt$" + hex.EncodeToString(sum[:])
+	key := pbkdf2.Key([]byte("Correct-horse1"), []byte("salt"), 1000, 32, sha256.New)
+	django := "pbkdf2_sha256$1000$salt$" + base64.StdEncoding.EncodeToString(key)
+	for _, encoded := range []string{salted, django} {
+		matches, err := hasher.Verify("Correct-horse1", encoded)
+		require.NoError(t, err)
+		assert.True(t, matches, encoded)
+	}
+
+	scryptHash := mustHash(t, Scrypt{LogN: 4, BlockSize: 8, Parallelism: 1})
+	// passlib's adapted base64 alphabet
+	passlib := strings.ReplaceAll(scryptHash, "+", ".")
+	matches, err := hasher.Verify("Correct-horse1", passlib)
+	require.NoError(t, err)
+	assert.True(t, matches)
+}
+
+func TestValidate(t *testing.T) {
+	hasher := New(testArgon2id, nil, Bcrypt{Cost: bcrypt.MinCost}, PBKDF2SHA256{}, Scrypt{}, SaltedSHA256{})
+	for _, encoded := range []string{
+		"$argon2id$v=19$m=1024$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=0,p=1$c2FsdHNhbHRzYWx0$" + testKey,
+		"$argon2id$v=19$m=1024,t=1,p=0$c2FsdHNhbHRzYWx0$" + testKey,
+		"$argon2id$v=19$m=4,t=1,p=1$c2FsdHNhbHRzYWx0$" + testKey,
+		"$argon2id$v=19$m=4294967295,t=1,p=1$c2FsdHNhbHRzYWx0$" + testKey,
+		"$argon2id$v=19$m=4194304,t=1,p=1$c2FsdHNhbHRzYWx0$" + testKey,
+		"$argon2id$v=19$m=1024,t=1000000,p=1$c2FsdHNhbHRzYWx0$" + testKey,
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0$a2V5",
+		"$2a$04$short",
+		"pbkdf2_sha256$zero$salt$a2V5",
+		"pbkdf2_sha256$1000$salt",
+		"pbkdf2_sha256$2000000000$salt$" + base64.StdEncoding.EncodeToString(make([]byte, 32)),
+		"pbkdf2_sha256$1000$salt$" + base64.StdEncoding.EncodeToString(make([]byte, 1024)),
+		"$scrypt$ln=4,r=8,p=1$c2FsdA",
+		"$scrypt$ln=99,r=8,p=1$c2FsdA$a2V5",
+		"$scrypt$ln=30,r=8,p=1$c2FsdA$" + testKey,
+		"$scrypt$ln=19,r=8,p=1$c2FsdA$" + testKey,
+		"$scrypt$ln=4,r=1000000,p=1$c2FsdA$" + testKey,
+		"$scrypt$ln=4,r=8,p=1000$c2FsdA$" + testKey,
+		"sha256$salt$nothex",
+	} {
+		assert.ErrorIs(t, hasher.Validate(encoded), ErrMalformedHash, encoded)
+	}
+	assert.ErrorIs(t, hasher.Validate("md5$salt$whatever"), ErrUnknownAlgorithm)
+	assert.ErrorIs(t, hasher.Validate("$peppered$argon2id$whatever"), ErrPepperMissing)
+}
+
+func mustHash(t *testing.T, algorithm Algorithm) string {
+	t.Helper()
+	encoded, err := algorithm.Hash([]byte("Correct-horse1"))
+	require.NoError(t, err)
+	return encoded
+}
@@ -0,0 +1,91 @@
+package passwordpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Prefixes shorter than this would match too many passwords
+const minPrefixLen = 5
+
+// BreachedList holds hex encoded SHA-1 prefixes (up to full hashes) of breached passwords.
+// A password is considered breached if its hash starts with any of them.
+type BreachedList struct {
+	// prefixes by length
+	prefixes map[int]map[string]struct{}
+}
+
+// LoadBreachedList reads the list from the file, see ReadBreachedList.
+func LoadBreachedList(path string) (*BreachedList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	list, err := ReadBreachedList(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return list, nil
+}
+
+// ReadBreachedList reads a prefix per line, anything after a colon (such as a breach count) is ignored,
+// as are blank lines and lines starting with #.
+func ReadBreachedList(r io.Reader) (*BreachedList, error) {
+	list := &BreachedList{prefixes: map[int]map[string]struct{}{}}
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		prefix, _, _ := strings.Cut(line, ":")
+		prefix = strings.ToUpper(strings.TrimSpace(prefix))
+		if len(prefix) < minPrefixLen || len(prefix) > sha1.Size*2 || !isHex(prefix) {
+			return nil, fmt.Errorf("line %d: invalid SHA-1 prefix %q", lineNum, prefix)
+		}
+		if list.prefixes[len(prefix)] == nil {
+			list.prefixes[len(prefix)] = map[string]struct{}{}
+		}
+		list.prefixes[len(prefix)][prefix] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Contains reports whether the password is breached.
+func (l *BreachedList) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	for length, prefixes := range l.prefixes {
+		if _, ok := prefixes[hash[:length]]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of prefixes in the list.
+func (l *BreachedList) Len() int {
+	n := 0
+	for _, prefixes := range l.prefixes {
+		n += len(prefixes)
+	}
+	return n
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789ABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+// Package passwordpolicy checks passwords against configurable strength rules
+// and, optionally, a local list of breached passwords.
+package passwordpolicy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Codes of violations
+const (
+	TooShort             = "too_short"
+	TooLong              = "too_long"
+	TooFewCharClasses    = "too_few_char_classes"
+	ContainsPersonalInfo = "contains_personal_info"
+	Breached             = "breached"
+)
+
+// Parts of personal info shorter than this are too common to be looked for in passwords
+const minPersonalInfoLen = 3
+
+// Violation is a rule of the policy the password breaks.
+type Violation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type Policy struct {
+	// Lengths are counted in characters, zero disables the check
+	MinLength int
+	MaxLength int
+	// Min number of character classes (lowercase and uppercase letters, digits, other characters) used
+	MinCharClasses int
+	// Whether the password may not contain the username or the email (or its local part)
+	ForbidPersonalInfo bool
+	// Passwords known to be breached, nil disables the check
+	Breached *BreachedList
+}
+
+// Check returns violations of the policy by the password of the user with the personal info
+// (username, email), nil if the password complies with it.
+func (p *Policy) Check(password string, personalInfo ...string) []Violation {
+	var violations []Violation
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		violations = append(violations, Violation{TooShort, fmt.Sprintf("Password must be at least %d characters long", p.MinLength)})
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, Violation{TooLong, fmt.Sprintf("Password must be at most %d characters long", p.MaxLength)})
+	}
+	if charClasses(password) < p.MinCharClasses {
+		violations = append(violations, Violation{
+			TooFewCharClasses,
+			fmt.Sprintf("Password must contain at least %d of lowercase letters, uppercase letters, digits and other characters", p.MinCharClasses),
+		})
+	}
+	if p.ForbidPersonalInfo && containsPersonalInfo(password, personalInfo) {
+		violations = append(violations, Violation{ContainsPersonalInfo, "Password must not contain the username or email"})
+	}
+	if p.Breached != nil && p.Breached.Contains(password) {
+		violations = append(violations, Violation{Breached, "Password has appeared in a data breach, choose another one"})
+	}
+	return violations
+}
+
+func charClasses(password string) int {
+	var lower, upper, digit, other int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+	return lower + upper + digit + other
+}
+
+func containsPersonalInfo(password string, personalInfo []string) bool {
+	password = strings.ToLower(password)
+	for _, info := range personalInfo {
+		info = strings.ToLower(info)
+		parts := []string{info}
+		if local, _, found := strings.Cut(info, "@"); found {
+			parts = append(parts, local)
+		}
+		for _, part := range parts {
+			if utf8.RuneCountInString(part) >= minPersonalInfoLen && strings.Contains(password, part) {
+				return true
+			}
+		}
+	}
+	return false
+}
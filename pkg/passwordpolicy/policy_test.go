@@ -0,0 +1,66 @@
+package passwordpolicy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func codes(violations []Violation) []string {
+	var result []string
+	for _, v := range violations {
+		result = append(result, v.Code)
+	}
+	return result
+}
+
+func TestCheck(t *testing.T) {
+	policy := Policy{MinLength: 8, MaxLength: 16, MinCharClasses: 3, ForbidPersonalInfo: true}
+	testCases := []struct {
+		name     string
+		password string
+		expected []string
+	}{
+		{"valid", "Correct-horse1", nil},
+		{"too short", "Ab1-", []string{TooShort}},
+		{"too long", "Correct-horse-battery-staple1", []string{TooLong}},
+		{"length in characters", "Пароль-пароль1", nil},
+		{"too few char classes", "correcthorse1", []string{TooFewCharClasses}},
+		{"contains username", "xJohnDoe-1", []string{ContainsPersonalInfo}},
+		{"contains email local part", "Jdoe.work-1", []string{ContainsPersonalInfo}},
+		{"several violations", "johndoe", []string{TooShort, TooFewCharClasses, ContainsPersonalInfo}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			violations := policy.Check(tc.password, "johndoe", "jdoe.work@example.com")
+			assert.Equal(t, tc.expected, codes(violations))
+		})
+	}
+}
+
+func TestCheckShortPersonalInfoIgnored(t *testing.T) {
+	policy := Policy{ForbidPersonalInfo: true}
+	assert.Empty(t, policy.Check("Abc-12345", "ab", "ab@example.com"))
+}
+
+func TestBreachedList(t *testing.T) {
+	// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	list, err := ReadBreachedList(strings.NewReader("# breached\n\n5baa61e4c9:42\n7C4A8D09CA3762AF61E59520943DC26494F8941B\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, list.Len())
+	assert.True(t, list.Contains("password"))
+	assert.True(t, list.Contains("123456"))
+	assert.False(t, list.Contains("Correct-horse1"))
+
+	policy := Policy{Breached: list}
+	assert.Equal(t, []string{Breached}, codes(policy.Check("password")))
+}
+
+func TestReadBreachedListInvalid(t *testing.T) {
+	for _, content := range []string{"5BAA", "5BAA61E4CZ", strings.Repeat("A", 41)} {
+		_, err := ReadBreachedList(strings.NewReader(content))
+		assert.Error(t, err, content)
+	}
+}
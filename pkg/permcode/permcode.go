@@ -0,0 +1,134 @@
+// Package permcode defines syntax of permission codes and rules of matching them against granted patterns.
+//
+// A code consists of a namespace and an optional action separated by colon.
+// Namespace is a dot separated path of segments, e.g. "articles:edit", "billing.invoices.read" or "billing.invoices:read".
+// Segments and actions may contain latin letters, digits, "_" and "-".
+//
+// Granted codes (patterns) may additionally end with a wildcard:
+//
+//	"*"               covers every code
+//	"billing.*"       covers every code inside billing namespace: "billing:read", "billing.invoices.read", ...
+//	"articles:*"      covers every action of articles namespace: "articles:edit", "articles:delete", ...
+//
+// When several granted patterns cover the same code, the most specific one takes precedence:
+// exact match first, then wildcards from the deepest namespace to the top-level one, and "*" last.
+// See Candidates for the exact order.
+package permcode
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+const (
+	Wildcard        = "*"
+	ActionSeparator = ":"
+	PathSeparator   = "."
+	MaxLength       = 128
+)
+
+var (
+	ErrEmpty         = errors.New("permission code is empty")
+	ErrTooLong       = errors.New("permission code is too long")
+	ErrInvalidSyntax = errors.New("permission code must look like namespace[.subnamespace][:action]")
+	ErrWildcard      = errors.New("wildcard is not allowed in permission code")
+)
+
+var segmentRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Validate checks syntax of a concrete permission code, the one which is checked against granted patterns.
+func Validate(code string) error {
+	if code == "" {
+		return ErrEmpty
+	}
+	if len(code) > MaxLength {
+		return ErrTooLong
+	}
+	if strings.Contains(code, Wildcard) {
+		return ErrWildcard
+	}
+	namespace, action, hasAction := strings.Cut(code, ActionSeparator)
+	if hasAction && !segmentRe.MatchString(action) {
+		return ErrInvalidSyntax
+	}
+	for _, segment := range strings.Split(namespace, PathSeparator) {
+		if !segmentRe.MatchString(segment) {
+			return ErrInvalidSyntax
+		}
+	}
+	return nil
+}
+
+// ValidatePattern checks syntax of a code which is going to be granted. Unlike Validate, it accepts wildcards.
+func ValidatePattern(pattern string) error {
+	if pattern == Wildcard {
+		return nil
+	}
+	if prefix, ok := strings.CutSuffix(pattern, ActionSeparator+Wildcard); ok {
+		return validateNamespace(prefix)
+	}
+	if prefix, ok := strings.CutSuffix(pattern, PathSeparator+Wildcard); ok {
+		return validateNamespace(prefix)
+	}
+	return Validate(pattern)
+}
+
+func validateNamespace(namespace string) error {
+	if err := Validate(namespace); err != nil {
+		return err
+	}
+	if strings.Contains(namespace, ActionSeparator) {
+		return ErrInvalidSyntax
+	}
+	return nil
+}
+
+// IsWildcard reports whether pattern covers more than a single code.
+func IsWildcard(pattern string) bool {
+	return strings.HasSuffix(pattern, Wildcard)
+}
+
+// Candidates returns every pattern which covers the code, ordered by precedence (most specific first).
+// The code is expected to be valid, see Validate.
+//
+// For "billing.invoices:read" it returns
+// "billing.invoices:read", "billing.invoices:*", "billing.invoices.*", "billing.*", "*".
+func Candidates(code string) []string {
+	candidates := []string{code}
+	namespace, _, hasAction := strings.Cut(code, ActionSeparator)
+	if hasAction {
+		candidates = append(candidates, namespace+ActionSeparator+Wildcard, namespace+PathSeparator+Wildcard)
+	}
+	for {
+		i := strings.LastIndex(namespace, PathSeparator)
+		if i < 0 {
+			break
+		}
+		namespace = namespace[:i]
+		candidates = append(candidates, namespace+PathSeparator+Wildcard)
+	}
+	return append(candidates, Wildcard)
+}
+
+// Covers reports whether granted pattern covers the code.
+func Covers(pattern string, code string) bool {
+	for _, candidate := range Candidates(code) {
+		if candidate == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// BestMatch returns the most specific of granted patterns covering the code.
+func BestMatch(granted []string, code string) (string, bool) {
+	for _, candidate := range Candidates(code) {
+		for _, pattern := range granted {
+			if pattern == candidate {
+				return pattern, true
+			}
+		}
+	}
+	return "", false
+}
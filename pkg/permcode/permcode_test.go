@@ -0,0 +1,86 @@
+package permcode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		code        string
+		expectedErr error
+	}{
+		{"articles:edit", nil},
+		{"billing.invoices.read", nil},
+		{"billing.invoices:read", nil},
+		{"legacy_perm-1", nil},
+		{"", ErrEmpty},
+		{"articles:*", ErrWildcard},
+		{"*", ErrWildcard},
+		{"articles::edit", ErrInvalidSyntax},
+		{"articles:edit:own", ErrInvalidSyntax},
+		{"billing..read", ErrInvalidSyntax},
+		{".billing", ErrInvalidSyntax},
+		{"user@example.com", ErrInvalidSyntax},
+	}
+	for _, tc := range testCases {
+		assert.ErrorIs(t, Validate(tc.code), tc.expectedErr, tc.code)
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	testCases := []struct {
+		pattern     string
+		expectedErr error
+	}{
+		{"*", nil},
+		{"articles:*", nil},
+		{"billing.*", nil},
+		{"billing.invoices:*", nil},
+		{"billing.invoices.read", nil},
+		{"billing*", ErrWildcard},
+		{"*.read", ErrWildcard},
+		{"articles:edit.*", ErrInvalidSyntax},
+		{"articles:*:*", ErrWildcard},
+	}
+	for _, tc := range testCases {
+		assert.ErrorIs(t, ValidatePattern(tc.pattern), tc.expectedErr, tc.pattern)
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	assert.Equal(t,
+		[]string{"billing.invoices:read", "billing.invoices:*", "billing.invoices.*", "billing.*", "*"},
+		Candidates("billing.invoices:read"),
+	)
+	assert.Equal(t,
+		[]string{"billing.invoices.read", "billing.invoices.*", "billing.*", "*"},
+		Candidates("billing.invoices.read"),
+	)
+	assert.Equal(t, []string{"legacy", "*"}, Candidates("legacy"))
+}
+
+func TestCovers(t *testing.T) {
+	assert.True(t, Covers("articles:*", "articles:edit"))
+	assert.True(t, Covers("billing.*", "billing.invoices.read"))
+	assert.True(t, Covers("billing.*", "billing:read"))
+	assert.True(t, Covers("*", "anything:at_all"))
+	assert.True(t, Covers("articles:edit", "articles:edit"))
+	assert.False(t, Covers("articles:*", "articles.comments:edit"))
+	assert.False(t, Covers("billing.*", "billing"))
+	assert.False(t, Covers("billing.*", "billingx:read"))
+	assert.False(t, Covers("articles:edit", "articles:delete"))
+}
+
+func TestBestMatch(t *testing.T) {
+	granted := []string{"*", "billing.*", "billing.invoices:*"}
+	match, ok := BestMatch(granted, "billing.invoices:read")
+	assert.True(t, ok)
+	assert.Equal(t, "billing.invoices:*", match)
+	match, ok = BestMatch(granted, "articles:edit")
+	assert.True(t, ok)
+	assert.Equal(t, "*", match)
+	_, ok = BestMatch([]string{"articles:*"}, "billing:read")
+	assert.False(t, ok)
+}
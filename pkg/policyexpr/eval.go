@@ -0,0 +1,359 @@
+package policyexpr
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type node interface {
+	eval(vars map[string]any) (any, error)
+}
+
+type literalNode struct {
+	value any
+}
+
+func (n *literalNode) eval(map[string]any) (any, error) {
+	return n.value, nil
+}
+
+type attributeNode struct {
+	path []string
+}
+
+func (n *attributeNode) eval(vars map[string]any) (any, error) {
+	var value any = vars
+	for _, name := range n.path {
+		m, ok := normalize(value).(map[string]any)
+		if !ok {
+			return nil, nil
+		}
+		value = m[name]
+	}
+	return normalize(value), nil
+}
+
+type listNode struct {
+	items []node
+}
+
+func (n *listNode) eval(vars map[string]any) (any, error) {
+	list := make([]any, len(n.items))
+	for i, item := range n.items {
+		value, err := item.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = value
+	}
+	return list, nil
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) eval(vars map[string]any) (any, error) {
+	value, err := evalBool(n.operand, vars, "!")
+	if err != nil {
+		return nil, err
+	}
+	return !value, nil
+}
+
+type logicalNode struct {
+	op          string
+	left, right node
+}
+
+func (n *logicalNode) eval(vars map[string]any) (any, error) {
+	left, err := evalBool(n.left, vars, n.op)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "&&" && !left || n.op == "||" && left {
+		return left, nil
+	}
+	return evalBool(n.right, vars, n.op)
+}
+
+type comparisonNode struct {
+	op          string
+	left, right node
+}
+
+func (n *comparisonNode) eval(vars map[string]any) (any, error) {
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	}
+	var cmp int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return nil, typeError(n.op, left, right)
+		}
+		cmp = compare(l, r)
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return nil, typeError(n.op, left, right)
+		}
+		cmp = strings.Compare(l, r)
+	default:
+		return nil, typeError(n.op, left, right)
+	}
+	switch n.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+type inNode struct {
+	left, right node
+}
+
+func (n *inNode) eval(vars map[string]any) (any, error) {
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	return contains(right, left, "in")
+}
+
+type callNode struct {
+	name string
+	fn   func(args []any) (any, error)
+	args []node
+}
+
+func (n *callNode) eval(vars map[string]any) (any, error) {
+	args := make([]any, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+	return n.fn(args)
+}
+
+type function struct {
+	arity int
+	call  func(args []any) (any, error)
+}
+
+var functions = map[string]function{
+	"len": {1, func(args []any) (any, error) {
+		switch v := args[0].(type) {
+		case string:
+			return float64(len([]rune(v))), nil
+		case []any:
+			return float64(len(v)), nil
+		case map[string]any:
+			return float64(len(v)), nil
+		case nil:
+			return float64(0), nil
+		}
+		return nil, typeError("len", args[0])
+	}},
+	"lower":      {1, stringFunc("lower", strings.ToLower)},
+	"upper":      {1, stringFunc("upper", strings.ToUpper)},
+	"startsWith": {2, stringsPredicate("startsWith", strings.HasPrefix)},
+	"endsWith":   {2, stringsPredicate("endsWith", strings.HasSuffix)},
+	"contains": {2, func(args []any) (any, error) {
+		return contains(args[0], args[1], "contains")
+	}},
+}
+
+func stringFunc(name string, fn func(string) string) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, typeError(name, args[0])
+		}
+		return fn(s), nil
+	}
+}
+
+func stringsPredicate(name string, fn func(string, string) bool) func(args []any) (any, error) {
+	return func(args []any) (any, error) {
+		s, ok1 := args[0].(string)
+		sub, ok2 := args[1].(string)
+		if !ok1 || !ok2 {
+			return nil, typeError(name, args...)
+		}
+		return fn(s, sub), nil
+	}
+}
+
+// contains reports whether list includes the item or string includes the substring.
+// Null container includes nothing.
+func contains(container any, item any, op string) (any, error) {
+	switch c := container.(type) {
+	case []any:
+		for _, elem := range c {
+			if equal(elem, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case string:
+		s, ok := item.(string)
+		if !ok {
+			return nil, typeError(op, item, container)
+		}
+		return strings.Contains(c, s), nil
+	case nil:
+		return false, nil
+	}
+	return nil, typeError(op, item, container)
+}
+
+func evalBool(n node, vars map[string]any, op string) (bool, error) {
+	value, err := n.eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, typeError(op, value)
+	}
+	return b, nil
+}
+
+func compare(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func equal(a, b any) bool {
+	a, b = normalize(a), normalize(b)
+	la, ok1 := a.([]any)
+	lb, ok2 := b.([]any)
+	if ok1 || ok2 {
+		if !ok1 || !ok2 || len(la) != len(lb) {
+			return false
+		}
+		for i := range la {
+			if !equal(la[i], lb[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func typeError(op string, operands ...any) error {
+	types := make([]string, len(operands))
+	for i, operand := range operands {
+		types[i] = typeName(operand)
+	}
+	return fmt.Errorf("%w: %s is not applicable to %s", ErrType, op, strings.Join(types, " and "))
+}
+
+func typeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "list"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// normalize converts values supplied by the caller to the few types the evaluator works with:
+// numbers to float64, slices to []any and maps with string keys to map[string]any.
+func normalize(value any) any {
+	switch v := value.(type) {
+	case nil, bool, float64, string, []any, map[string]any:
+		return v
+	case int:
+		return float64(v)
+	case int8:
+		return float64(v)
+	case int16:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint8:
+		return float64(v)
+	case uint16:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case []string:
+		list := make([]any, len(v))
+		for i, s := range v {
+			list[i] = s
+		}
+		return list
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		list := make([]any, rv.Len())
+		for i := range list {
+			list[i] = normalize(rv.Index(i).Interface())
+		}
+		return list
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return value
+		}
+		m := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = iter.Value().Interface()
+		}
+		return m
+	case reflect.String:
+		return rv.String()
+	}
+	return value
+}
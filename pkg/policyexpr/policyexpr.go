@@ -0,0 +1,83 @@
+// Package policyexpr implements a small side effect free expression language used in policy conditions.
+//
+// An expression is evaluated against a set of variables (e.g. subject, resource, env) and must produce a boolean:
+//
+//	subject.role == "moderator" && subject.region == resource.region
+//	resource.owner_id == subject.id || "admin" in subject.groups
+//	!resource.archived && startsWith(resource.path, "/public/")
+//
+// Supported are string, number, boolean and null literals, lists ([1, 2]), attribute access (a.b.c),
+// logical (&&, ||, !), comparison (==, !=, <, <=, >, >=) and membership (in) operators
+// and a fixed set of functions: len, lower, upper, startsWith, endsWith, contains.
+//
+// Access to a missing attribute yields null instead of failing. There are no loops, assignments
+// or user defined functions, so evaluation time is bounded by the size of the expression.
+package policyexpr
+
+import (
+	"errors"
+)
+
+const (
+	MaxLength = 4096
+	MaxDepth  = 64
+)
+
+var (
+	ErrSyntax          = errors.New("syntax error")
+	ErrTooLong         = errors.New("expression is too long")
+	ErrTooDeep         = errors.New("expression is nested too deeply")
+	ErrUnknownFunction = errors.New("unknown function")
+	ErrType            = errors.New("type mismatch")
+	ErrNotBool         = errors.New("expression result is not a boolean")
+)
+
+// Expr is a compiled expression, safe for concurrent use.
+type Expr struct {
+	source string
+	root   node
+}
+
+// Compile parses the expression. Unknown functions and wrong number of their arguments are reported here,
+// type errors may only be detected during evaluation.
+func Compile(source string) (*Expr, error) {
+	if len(source) > MaxLength {
+		return nil, ErrTooLong
+	}
+	p, err := newParser(source)
+	if err != nil {
+		return nil, err
+	}
+	root, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return &Expr{source: source, root: root}, nil
+}
+
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression against vars. Nested values may be maps with string keys,
+// slices, strings, booleans, numbers of any builtin type and nil.
+func (e *Expr) Eval(vars map[string]any) (bool, error) {
+	result, err := e.root.eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := result.(bool)
+	if !ok {
+		return false, ErrNotBool
+	}
+	return b, nil
+}
+
+// Eval compiles and evaluates the expression at once.
+func Eval(source string, vars map[string]any) (bool, error) {
+	expr, err := Compile(source)
+	if err != nil {
+		return false, err
+	}
+	return expr.Eval(vars)
+}
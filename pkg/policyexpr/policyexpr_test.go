@@ -0,0 +1,124 @@
+package policyexpr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testVars = map[string]any{
+	"subject": map[string]any{
+		"id":     int64(7),
+		"role":   "moderator",
+		"region": "eu",
+		"groups": []string{"editors", "reviewers"},
+	},
+	"resource": map[string]any{
+		"type":     "post",
+		"owner_id": float64(7),
+		"region":   "eu",
+		"path":     "/public/posts/1",
+		"archived": false,
+		"rating":   4.5,
+	},
+	"env": map[string]any{"time": int64(1700000000)},
+}
+
+func TestEval(t *testing.T) {
+	testCases := []struct {
+		expr     string
+		expected bool
+	}{
+		{`true`, true},
+		{`subject.role == "moderator" && subject.region == resource.region`, true},
+		{`subject.role == 'admin' || resource.owner_id == subject.id`, true},
+		{`!resource.archived && startsWith(resource.path, "/public/")`, true},
+		{`"editors" in subject.groups`, true},
+		{`"admins" in subject.groups`, false},
+		{`subject.role in ["admin", "moderator"]`, true},
+		{`"pub" in resource.path`, true},
+		{`resource.rating >= 4 && resource.rating < 5`, true},
+		{`env.time > 1600000000`, true},
+		{`"a" < "b"`, true},
+		{`resource.missing == null`, true},
+		{`resource.missing.deeper != null`, false},
+		{`"x" in resource.missing`, false},
+		{`len(subject.groups) == 2 && len("héllo") == 5`, true},
+		{`lower("EU") == subject.region && upper(subject.region) == "EU"`, true},
+		{`endsWith(resource.path, "/1") && contains(subject.groups, "reviewers")`, true},
+		{`!(subject.id == 1 || subject.id == 2)`, true},
+		{`[1, 2] == [1, 2] && [] != [1]`, true},
+		{`subject.role == "moderator" && (resource.type == "comment" || resource.type == "post")`, true},
+		{`"it\'s" == 'it\'s'`, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			result, err := Eval(tc.expr, testVars)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestShortCircuit(t *testing.T) {
+	// right operands would fail with type error if evaluated
+	result, err := Eval(`false && subject.role > 1`, testVars)
+	require.NoError(t, err)
+	assert.False(t, result)
+	result, err = Eval(`true || subject.role > 1`, testVars)
+	require.NoError(t, err)
+	assert.True(t, result)
+}
+
+func TestCompileErrors(t *testing.T) {
+	testCases := []struct {
+		expr        string
+		expectedErr error
+	}{
+		{``, ErrSyntax},
+		{`subject.role ==`, ErrSyntax},
+		{`(true`, ErrSyntax},
+		{`"unterminated`, ErrSyntax},
+		{`a == b == c`, ErrSyntax},
+		{`subject.`, ErrSyntax},
+		{`1.2.3 == 1`, ErrSyntax},
+		{`a = b`, ErrSyntax},
+		{`true false`, ErrSyntax},
+		{`exec("rm")`, ErrUnknownFunction},
+		{`len(1, 2)`, ErrSyntax},
+		{strings.Repeat("(", MaxDepth+1) + "true" + strings.Repeat(")", MaxDepth+1), ErrTooDeep},
+		{strings.Repeat("!", MaxDepth+1) + "true", ErrTooDeep},
+		{strings.Repeat("a", MaxLength+1), ErrTooLong},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := Compile(tc.expr)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	testCases := []struct {
+		expr        string
+		expectedErr error
+	}{
+		{`subject.role`, ErrNotBool},
+		{`subject.role > 1`, ErrType},
+		{`resource.missing < 1`, ErrType},
+		{`subject.role && true`, ErrType},
+		{`!subject.id`, ErrType},
+		{`1 in subject.id`, ErrType},
+		{`lower(subject.id) == "7"`, ErrType},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			expr, err := Compile(tc.expr)
+			require.NoError(t, err)
+			_, err = expr.Eval(testVars)
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package ttlcache
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCache(maxEntries int, ttl time.Duration) (*Cache[string, int], *time.Time) {
+	cache := New[string, int](maxEntries, ttl)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	return cache, &now
+}
+
+func TestGetSet(t *testing.T) {
+	cache, _ := newTestCache(10, time.Minute)
+	_, ok := cache.Get("a")
+	assert.False(t, ok)
+	cache.Set("a", 1)
+	value, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	cache.Set("a", 2)
+	value, _ = cache.Get("a")
+	assert.Equal(t, 2, value)
+	assert.Equal(t, Stats{Hits: 2, Misses: 1, Size: 1}, cache.Stats())
+}
+
+func TestExpiration(t *testing.T) {
+	cache, now := newTestCache(10, time.Minute)
+	cache.Set("a", 1)
+	cache.SetWithTTL("b", 2, time.Hour)
+	cache.SetWithTTL("c", 3, 0)
+	*now = now.Add(time.Minute)
+	_, ok := cache.Get("a")
+	assert.False(t, ok)
+	_, ok = cache.Get("b")
+	assert.True(t, ok)
+	_, ok = cache.Get("c")
+	assert.False(t, ok)
+	assert.Equal(t, 1, cache.Stats().Size)
+}
+
+func TestEviction(t *testing.T) {
+	cache, _ := newTestCache(2, time.Minute)
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	// "a" becomes the most recently used, so "b" has to be evicted
+	cache.Get("a")
+	cache.Set("c", 3)
+	_, ok := cache.Get("b")
+	assert.False(t, ok)
+	_, ok = cache.Get("a")
+	assert.True(t, ok)
+	_, ok = cache.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), cache.Stats().Evictions)
+}
+
+func TestDeleteAndClear(t *testing.T) {
+	cache, _ := newTestCache(10, time.Minute)
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Delete("a")
+	_, ok := cache.Get("a")
+	assert.False(t, ok)
+	cache.Clear()
+	_, ok = cache.Get("b")
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.Stats().Size)
+}
+
+func TestDeleteFunc(t *testing.T) {
+	cache, _ := newTestCache(10, time.Minute)
+	cache.Set("a1", 1)
+	cache.Set("a2", 2)
+	cache.Set("b1", 3)
+	cache.DeleteFunc(func(key string) bool { return strings.HasPrefix(key, "a") })
+	_, ok := cache.Get("a1")
+	assert.False(t, ok)
+	_, ok = cache.Get("a2")
+	assert.False(t, ok)
+	_, ok = cache.Get("b1")
+	assert.True(t, ok)
+	assert.Equal(t, 1, cache.Stats().Size)
+}
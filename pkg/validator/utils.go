@@ -8,10 +8,27 @@ import (
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+	"sso.service/pkg/permcode"
 )
 
 const EmptyErrors = "{}"
 
+var indexSuffixRe = regexp.MustCompile(`\[.*\]$`)
+
+// structFieldName returns name of the field which failed validation,
+// trimming index suffix added for "dive" errors (e.g PermissionCodes[0]).
+func structFieldName(err validator.FieldError) string {
+	return indexSuffixRe.ReplaceAllString(err.StructField(), "")
+}
+
+func validatePermissionCode(fl validator.FieldLevel) bool {
+	return permcode.Validate(fl.Field().String()) == nil
+}
+
+func validatePermissionPattern(fl validator.FieldLevel) bool {
+	return permcode.ValidatePattern(fl.Field().String()) == nil
+}
+
 func camelToSnake(s string) string {
 	re := regexp.MustCompile("([a-z0-9])([A-Z])")
 	snake := re.ReplaceAllString(s, "${1}_${2}")
@@ -44,9 +61,9 @@ func GetErrorMsgForField(obj any, err validator.FieldError) (errorMsg string) {
 	if t.Kind() == reflect.Ptr {
 		t = t.Elem()
 	}
-	field, found := t.FieldByName(err.StructField())
+	field, found := t.FieldByName(structFieldName(err))
 	if !found {
-		panic(fmt.Sprintf("Field %s not found in type %s", err.StructField(), t.Name()))
+		panic(fmt.Sprintf("Field %s not found in type %s", structFieldName(err), t.Name()))
 	}
 	errorMsg = field.Tag.Get("errorMsg")
 	if errorMsg == "" {
@@ -77,6 +94,10 @@ func GetErrorMsgForField(obj any, err validator.FieldError) (errorMsg string) {
 			errorMsg = fmt.Sprintf("Length should be equal to %s", err.Param())
 		case "unique":
 			errorMsg = "Value must not contain duplicate values"
+		case "permcode":
+			errorMsg = "Invalid permission code. Expected format: namespace[.subnamespace][:action]"
+		case "permpattern":
+			errorMsg = "Invalid permission code. Expected format: namespace[.subnamespace][:action], optionally ending with wildcard (*)"
 		default:
 			errorMsg = "This field is invalid"
 		}
@@ -92,10 +113,12 @@ func Validate(obj any, rules map[string]string) string {
 	}
 	newObj := reflect.New(objType).Elem().Interface()
 	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.RegisterValidation("permcode", validatePermissionCode)
+	validate.RegisterValidation("permpattern", validatePermissionPattern)
 	validate.RegisterStructValidationMapRules(rules, newObj)
 	if err := validate.Struct(obj); err != nil {
 		for _, e := range err.(validator.ValidationErrors) {
-			fieldErrors[getJsonFieldName(obj, e.StructField())] = GetErrorMsgForField(obj, e)
+			fieldErrors[getJsonFieldName(obj, structFieldName(e))] = GetErrorMsgForField(obj, e)
 		}
 	}
 	// return strings.Join(fieldErrors, ", ")
@@ -0,0 +1,106 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/storage"
+	"sso.service/internal/storage/postgres/models"
+	"sso.service/tests/suite"
+)
+
+func TestRequestAccountDeletion(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	user := suite.CreateActiveTestUser(t, models.User)
+	ctx := context.Background()
+	loginReq := &ssov1.LoginRequest{Email: user.Email, Password: user.Password.Plaintext, AppId: suite.AppID}
+	loginResp, err := st.AuthClient.Login(ctx, loginReq)
+	require.NoError(t, err)
+	userCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+loginResp.GetAccessToken())
+
+	_, err = st.AuthClient.RequestAccountDeletion(userCtx, &ssov1.RequestAccountDeletionRequest{Password: suite.FakePassword()})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = st.AuthClient.RequestAccountDeletion(ctx, &ssov1.RequestAccountDeletionRequest{Password: user.Password.Plaintext})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	resp, err := st.AuthClient.RequestAccountDeletion(userCtx, &ssov1.RequestAccountDeletionRequest{Password: user.Password.Plaintext})
+	require.NoError(t, err)
+	assert.Greater(t, resp.GetErasureAfter(), time.Now().Unix())
+	_, err = st.AuthClient.Login(ctx, loginReq)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	// tokens issued before the deletion are revoked
+	_, err = st.AuthClient.RequestAccountDeletion(userCtx, &ssov1.RequestAccountDeletionRequest{Password: user.Password.Plaintext})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestRestoreUser(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	user := suite.CreateActiveTestUser(t, models.User)
+	ctx := context.Background()
+	loginReq := &ssov1.LoginRequest{Email: user.Email, Password: user.Password.Plaintext, AppId: suite.AppID}
+
+	_, err := st.AuthClient.RestoreUser(adminCtx, &ssov1.RestoreUserRequest{UserId: user.ID})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	_, err = st.AuthClient.DeleteUser(adminCtx, &ssov1.DeleteUserRequest{UserId: user.ID})
+	require.NoError(t, err)
+	_, err = st.AuthClient.Login(ctx, loginReq)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	resp, err := st.AuthClient.RestoreUser(adminCtx, &ssov1.RestoreUserRequest{UserId: user.ID})
+	require.NoError(t, err)
+	assert.Equal(t, user.Email, resp.GetUser().GetEmail())
+	_, err = st.AuthClient.Login(ctx, loginReq)
+	assert.NoError(t, err)
+}
+
+func TestErasureReport(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	testStorage := st.NewTestStorage()
+	models := models.New(testStorage.DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	user := suite.CreateActiveTestUser(t, models.User)
+	_, err := st.AuthClient.Login(context.Background(), &ssov1.LoginRequest{
+		Email:    user.Email,
+		Password: user.Password.Plaintext,
+		AppId:    suite.AppID,
+	})
+	require.NoError(t, err)
+	export, err := models.DataExport.Create(context.Background(), user.ID, user.ID)
+	require.NoError(t, err)
+	require.NoError(t, models.DataExport.Complete(context.Background(), export.ID, []byte("archive")))
+
+	_, err = st.AuthClient.DeleteUser(adminCtx, &ssov1.DeleteUserRequest{UserId: user.ID})
+	require.NoError(t, err)
+	_, err = st.AuthClient.GetErasureReport(adminCtx, &ssov1.GetErasureReportRequest{UserId: user.ID})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	// the grace period is over for users deleted before now
+	_, err = models.User.Erase(context.Background(), user.ID, time.Now())
+	require.NoError(t, err)
+
+	resp, err := st.AuthClient.GetErasureReport(adminCtx, &ssov1.GetErasureReportRequest{UserId: user.ID})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, resp.GetReport().GetUserId())
+	deleted := resp.GetReport().GetDetails().AsMap()["deleted_records"].(map[string]any)
+	assert.Equal(t, float64(1), deleted["users_apps"])
+	// archives of the user's data exports are erased with the rest of the data
+	assert.Equal(t, float64(1), deleted["data_exports"])
+	_, err = models.DataExport.Get(context.Background(), export.ID)
+	assert.ErrorIs(t, err, storage.ErrRecordNotFound)
+	_, err = st.AuthClient.RestoreUser(adminCtx, &ssov1.RestoreUserRequest{UserId: user.ID})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,50 @@
+package auth_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+	"sso.service/internal/storage/postgres/models"
+	"sso.service/pkg/envelope"
+	"sso.service/tests/suite"
+)
+
+func TestAppSecretEncryption(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	ctx := context.Background()
+	oldKey, newKey := bytes.Repeat([]byte{1}, envelope.KeySize), bytes.Repeat([]byte{2}, envelope.KeySize)
+	keyring, err := envelope.NewKeyring(map[int][]byte{1: oldKey}, 0)
+	require.NoError(t, err)
+	m := models.New(storage.DB)
+	m.App.Cipher = keyring
+	app := suite.CreateTestApp(t, m, &entity.App{Secret: "plaintext-secret-" + suite.FakePassword()})
+	storedSecret := func() string {
+		var secret string
+		require.NoError(t, storage.DB.QueryRow(ctx, "SELECT secret FROM apps WHERE id = $1", app.ID).Scan(&secret))
+		return secret
+	}
+
+	stored := storedSecret()
+	assert.True(t, strings.HasPrefix(stored, "enc:v1:1:"))
+	assert.NotContains(t, stored, app.Secret)
+	got, err := m.App.Get(ctx, dtos.GetAppOptionsDTO{AppID: int32(app.ID)})
+	require.NoError(t, err)
+	assert.Equal(t, app.Secret, got.Secret)
+
+	// after rotation of the master key secrets encrypted with the old one are still readable
+	rotatedKeyring, err := envelope.NewKeyring(map[int][]byte{1: oldKey, 2: newKey}, 0)
+	require.NoError(t, err)
+	m.App.Cipher = rotatedKeyring
+	assert.True(t, rotatedKeyring.NeedsReencryption(stored))
+	got, err = m.App.Get(ctx, dtos.GetAppOptionsDTO{AppID: int32(app.ID)})
+	require.NoError(t, err)
+	assert.Equal(t, app.Secret, got.Secret)
+}
@@ -0,0 +1,100 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/entity"
+	"sso.service/internal/storage/postgres/models"
+	jwtLib "sso.service/pkg/jwt"
+	"sso.service/tests/suite"
+)
+
+func TestRotateAppSecret(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	app := suite.CreateTestApp(t, models, &entity.App{})
+	user := suite.CreateActiveTestUser(t, models.User)
+	login := func() context.Context {
+		resp, err := st.AuthClient.Login(context.Background(), &ssov1.LoginRequest{
+			Email:    user.Email,
+			Password: user.Password.Plaintext,
+			AppId:    int32(app.ID),
+		})
+		require.NoError(t, err)
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+resp.GetAccessToken())
+	}
+	authenticated := func(ctx context.Context) error {
+		_, err := st.AuthClient.GetUserMetadata(ctx, &ssov1.GetUserMetadataRequest{UserId: user.ID})
+		return err
+	}
+	oldCtx := login()
+	require.NoError(t, authenticated(oldCtx))
+
+	_, err := st.AuthClient.RotateAppSecret(st.AuthorizedContext(user), &ssov1.RotateAppSecretRequest{AppId: int32(app.ID)})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = st.AuthClient.RotateAppSecret(adminCtx, &ssov1.RotateAppSecretRequest{
+		AppId:       int32(app.ID),
+		GracePeriod: durationpb.New(st.Cfg.AppSecrets.MaxGracePeriod + time.Hour),
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	resp, err := st.AuthClient.RotateAppSecret(adminCtx, &ssov1.RotateAppSecretRequest{
+		AppId:       int32(app.ID),
+		GracePeriod: durationpb.New(time.Hour),
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, app.Secret, resp.GetSecret())
+	assert.InDelta(t, time.Now().Add(time.Hour).Unix(), resp.GetPreviousSecretExpiresAt(), 60)
+	// tokens signed with the old secret are accepted during the grace period, new ones are signed with the new secret
+	assert.NoError(t, authenticated(oldCtx))
+	newCtx := login()
+	require.NoError(t, authenticated(newCtx))
+	loginResp, err := st.AuthClient.Login(context.Background(), &ssov1.LoginRequest{
+		Email:    user.Email,
+		Password: user.Password.Plaintext,
+		AppId:    int32(app.ID),
+	})
+	require.NoError(t, err)
+	_, err = jwtLib.NewTokenProvider(resp.GetSecret(), st.Cfg.TokenSigningAlg).ParseClaimsFromToken(loginResp.GetAccessToken())
+	assert.NoError(t, err)
+
+	// rotation without grace period drops both the old secrets at once
+	resp, err = st.AuthClient.RotateAppSecret(adminCtx, &ssov1.RotateAppSecretRequest{AppId: int32(app.ID)})
+	require.NoError(t, err)
+	assert.Zero(t, resp.GetPreviousSecretExpiresAt())
+	assert.Equal(t, codes.Unauthenticated, status.Code(authenticated(oldCtx)))
+	assert.Equal(t, codes.Unauthenticated, status.Code(authenticated(newCtx)))
+	assert.NoError(t, authenticated(login()))
+}
+
+func TestDeleteExpiredSecrets(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	app := suite.CreateTestApp(t, models, &entity.App{})
+	expired := time.Now().Add(-time.Minute)
+	rotated, err := models.App.RotateSecret(context.Background(), int32(app.ID), "rotated-secret-"+app.Name, &expired)
+	require.NoError(t, err)
+	// expired secrets aren't used even before they are deleted
+	assert.Empty(t, rotated.PreviousSecret)
+
+	_, err = models.App.DeleteExpiredSecrets(context.Background())
+	require.NoError(t, err)
+	var previous *string
+	err = storage.DB.QueryRow(context.Background(), "SELECT previous_secret FROM apps WHERE id = $1", app.ID).Scan(&previous)
+	require.NoError(t, err)
+	assert.Nil(t, previous)
+}
@@ -0,0 +1,126 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+	"sso.service/internal/storage/postgres/models"
+	jwtLib "sso.service/pkg/jwt"
+	"sso.service/tests/suite"
+)
+
+func TestAppSettings(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	app := suite.CreateTestApp(t, models, &entity.App{})
+	user := suite.CreateActiveTestUser(t, models.User)
+	updateApp := func(req *ssov1.UpdateAppRequest, paths ...string) (*ssov1.App, error) {
+		req.AppId = int32(app.ID)
+		req.UpdateMask = &fieldmaskpb.FieldMask{Paths: paths}
+		resp, err := st.AuthClient.UpdateApp(adminCtx, req)
+		return resp.GetApp(), err
+	}
+	login := func() (*ssov1.LoginResponse, error) {
+		return st.AuthClient.Login(context.Background(), &ssov1.LoginRequest{
+			Email:    user.Email,
+			Password: user.Password.Plaintext,
+			AppId:    int32(app.ID),
+		})
+	}
+	register := func() (*ssov1.RegisterResponse, error) {
+		registered := suite.NewTestUser(t, false)
+		return st.AuthClient.Register(context.Background(), &ssov1.RegisterRequest{
+			Username: registered.Username,
+			Email:    registered.Email,
+			Password: registered.Password.Plaintext,
+			AppId:    int32(app.ID),
+		})
+	}
+	tokenTTL := func(token string) time.Duration {
+		claims, err := jwtLib.NewTokenProvider(app.Secret, st.Cfg.TokenSigningAlg).ParseClaimsFromToken(token)
+		require.NoError(t, err)
+		return time.Until(time.Unix(int64(claims["exp"].(float64)), 0))
+	}
+
+	t.Run("TTLs fall back to the config", func(t *testing.T) {
+		resp, err := login()
+		require.NoError(t, err)
+		assert.InDelta(t, st.Cfg.AccessTokenTTL.Seconds(), tokenTTL(resp.GetAccessToken()).Seconds(), 60)
+		assert.InDelta(t, st.Cfg.RefreshTokenTTL.Seconds(), tokenTTL(resp.GetRefreshToken()).Seconds(), 60)
+	})
+	t.Run("Per-app TTLs", func(t *testing.T) {
+		updated, err := updateApp(&ssov1.UpdateAppRequest{
+			AccessTokenTtl:  durationpb.New(5 * time.Minute),
+			RefreshTokenTtl: durationpb.New(12 * time.Hour),
+		}, "access_token_ttl", "refresh_token_ttl")
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Minute, updated.GetAccessTokenTtl().AsDuration())
+		assert.Equal(t, 12*time.Hour, updated.GetRefreshTokenTtl().AsDuration())
+		assert.Nil(t, updated.GetActivationTokenTtl())
+		resp, err := login()
+		require.NoError(t, err)
+		assert.InDelta(t, (5 * time.Minute).Seconds(), tokenTTL(resp.GetAccessToken()).Seconds(), 60)
+		assert.InDelta(t, (12 * time.Hour).Seconds(), tokenTTL(resp.GetRefreshToken()).Seconds(), 60)
+
+		// unset TTL falls back to the config again
+		updated, err = updateApp(&ssov1.UpdateAppRequest{}, "access_token_ttl")
+		require.NoError(t, err)
+		assert.Nil(t, updated.GetAccessTokenTtl())
+		assert.Equal(t, 12*time.Hour, updated.GetRefreshTokenTtl().AsDuration())
+	})
+	t.Run("Invalid settings", func(t *testing.T) {
+		_, err := updateApp(&ssov1.UpdateAppRequest{AccessTokenTtl: durationpb.New(time.Millisecond)}, "access_token_ttl")
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, err = updateApp(&ssov1.UpdateAppRequest{LoginMethods: []string{"carrier_pigeon"}}, "login_methods")
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+	t.Run("Login methods", func(t *testing.T) {
+		updated, err := updateApp(&ssov1.UpdateAppRequest{LoginMethods: []string{entity.LoginMethodPassword}}, "login_methods")
+		require.NoError(t, err)
+		assert.Equal(t, []string{entity.LoginMethodPassword}, updated.GetLoginMethods())
+		_, err = login()
+		assert.NoError(t, err)
+	})
+	t.Run("Closed self-registration", func(t *testing.T) {
+		_, err := updateApp(&ssov1.UpdateAppRequest{SelfRegistration: wrapperspb.Bool(false)}, "self_registration")
+		require.NoError(t, err)
+		_, err = register()
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		updated, err := updateApp(&ssov1.UpdateAppRequest{}, "self_registration")
+		require.NoError(t, err)
+		assert.Nil(t, updated.GetSelfRegistration())
+		_, err = register()
+		assert.NoError(t, err)
+	})
+	t.Run("Registration without activation", func(t *testing.T) {
+		_, err := updateApp(&ssov1.UpdateAppRequest{RequireActivation: wrapperspb.Bool(false)}, "require_activation")
+		require.NoError(t, err)
+		resp, err := register()
+		require.NoError(t, err)
+		assert.Empty(t, resp.GetActivationToken())
+		registered, err := models.User.Get(context.Background(), dtos.GetUserOptionsDTO{ID: resp.GetUserId()})
+		require.NoError(t, err)
+		assert.True(t, registered.IsActive)
+	})
+	t.Run("Non-admin", func(t *testing.T) {
+		_, err := st.AuthClient.UpdateApp(st.AuthorizedContext(user), &ssov1.UpdateAppRequest{
+			AppId:      int32(app.ID),
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"require_activation"}},
+		})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+}
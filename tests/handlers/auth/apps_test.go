@@ -0,0 +1,107 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/entity"
+	"sso.service/internal/storage/postgres/models"
+	"sso.service/tests/suite"
+)
+
+func TestGetOrCreateApp(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	req := &ssov1.GetOrCreateAppRequest{
+		Name:        "test-app-" + gofakeit.UUID(),
+		Description: "test app",
+		Secret:      gofakeit.Password(true, true, true, false, false, 20),
+	}
+
+	_, err := st.AuthClient.GetOrCreateApp(context.Background(), req)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = st.AuthClient.GetOrCreateApp(st.AuthorizedContext(suite.CreateActiveTestUser(t, models.User)), req)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	created, err := st.AuthClient.GetOrCreateApp(adminCtx, req)
+	require.NoError(t, err)
+	assert.True(t, created.GetCreated())
+	existing, err := st.AuthClient.GetOrCreateApp(adminCtx, req)
+	require.NoError(t, err)
+	assert.False(t, existing.GetCreated())
+	assert.Equal(t, created.GetId(), existing.GetId())
+
+	req.Secret = gofakeit.Password(true, true, true, false, false, 20)
+	_, err = st.AuthClient.GetOrCreateApp(adminCtx, req)
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+}
+
+func TestManageApps(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	userCtx := st.AuthorizedContext(suite.CreateActiveTestUser(t, models.User))
+	app := suite.CreateTestApp(t, models, &entity.App{Description: "before"})
+	other := suite.CreateTestApp(t, models, &entity.App{})
+	appID := int32(app.ID)
+
+	_, err := st.AuthClient.ListApps(userCtx, &ssov1.ListAppsRequest{})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	listResp, err := st.AuthClient.ListApps(adminCtx, &ssov1.ListAppsRequest{})
+	require.NoError(t, err)
+	var names []string
+	for _, listed := range listResp.GetApps() {
+		names = append(names, listed.GetName())
+	}
+	assert.Contains(t, names, app.Name)
+	assert.Contains(t, names, other.Name)
+
+	getResp, err := st.AuthClient.GetApp(adminCtx, &ssov1.GetAppRequest{AppId: appID})
+	require.NoError(t, err)
+	assert.Equal(t, "before", getResp.GetApp().GetDescription())
+
+	newName := "test-app-" + gofakeit.UUID()
+	updateResp, err := st.AuthClient.UpdateApp(adminCtx, &ssov1.UpdateAppRequest{
+		AppId:            appID,
+		Name:             newName,
+		Description:      "ignored, not in the mask",
+		EmbedPermissions: true,
+		UpdateMask:       &fieldmaskpb.FieldMask{Paths: []string{"name", "embed_permissions"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, newName, updateResp.GetApp().GetName())
+	assert.Equal(t, "before", updateResp.GetApp().GetDescription())
+	assert.True(t, updateResp.GetApp().GetEmbedPermissions())
+	_, err = st.AuthClient.UpdateApp(adminCtx, &ssov1.UpdateAppRequest{
+		AppId:      appID,
+		Name:       other.Name,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+	})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = st.AuthClient.UpdateApp(adminCtx, &ssov1.UpdateAppRequest{
+		AppId:      appID,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"secret"}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = st.AuthClient.DeleteApp(userCtx, &ssov1.DeleteAppRequest{AppId: appID})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = st.AuthClient.DeleteApp(adminCtx, &ssov1.DeleteAppRequest{AppId: appID})
+	require.NoError(t, err)
+	_, err = st.AuthClient.GetApp(adminCtx, &ssov1.GetAppRequest{AppId: appID})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = st.AuthClient.DeleteApp(adminCtx, &ssov1.DeleteAppRequest{AppId: appID})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
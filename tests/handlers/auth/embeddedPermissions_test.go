@@ -0,0 +1,75 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/entity"
+	"sso.service/internal/storage/postgres/models"
+	jwtLib "sso.service/pkg/jwt"
+	"sso.service/tests/suite"
+)
+
+func TestLoginEmbedsPermissions(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	ctx := context.Background()
+	m := models.New(st.NewTestStorage().DB)
+	app := entity.App{Name: "embedding-" + gofakeit.UUID(), Secret: gofakeit.UUID(), EmbedPermissions: true}
+	appID, err := m.App.Create(ctx, &app)
+	require.NoError(t, err)
+	user := suite.CreateActiveTestUser(t, m.User)
+	appCode := "reports:" + gofakeit.Username()
+	require.NoError(t, m.Permission.CreateManyIgnoreConflict(ctx, int32(appID), []string{appCode}))
+	_, err = m.Permission.AddForUserIgnoreConflict(ctx, user.ID, int32(appID), []string{appCode})
+	require.NoError(t, err)
+	login := func() map[string]any {
+		resp, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{
+			Email:    user.Email,
+			Password: user.Password.Plaintext,
+			AppId:    int32(appID),
+		})
+		require.NoError(t, err)
+		claims, err := jwtLib.NewTokenProvider(app.Secret, st.Cfg.TokenSigningAlg).ParseClaimsFromToken(resp.GetAccessToken())
+		require.NoError(t, err)
+		return claims
+	}
+
+	claims := login()
+	role, ok := jwtLib.RoleFromClaims(claims)
+	require.True(t, ok)
+	assert.Equal(t, entity.DefaultUserRole, role)
+	scope, ok := jwtLib.ScopeFromClaims(claims)
+	require.True(t, ok)
+	assert.True(t, scope.Has(appCode))
+
+	globalCode := "exports." + gofakeit.Username() + ".*"
+	require.NoError(t, m.Permission.CreateManyIgnoreConflict(ctx, entity.GlobalAppID, []string{globalCode}))
+	_, err = m.Permission.AddForUserIgnoreConflict(ctx, user.ID, entity.GlobalAppID, []string{globalCode})
+	require.NoError(t, err)
+	scope, ok = jwtLib.ScopeFromClaims(login())
+	require.True(t, ok)
+	assert.ElementsMatch(t, jwtLib.Scope{appCode, globalCode}, scope)
+}
+
+func TestLoginDoesNotEmbedPermissionsByDefault(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	user := suite.CreateActiveTestUser(t, models.New(st.NewTestStorage().DB).User)
+	resp, err := st.AuthClient.Login(context.Background(), &ssov1.LoginRequest{
+		Email:    user.Email,
+		Password: user.Password.Plaintext,
+		AppId:    suite.AppID,
+	})
+	require.NoError(t, err)
+	claims, err := jwtLib.NewTokenProvider(app.Secret, st.Cfg.TokenSigningAlg).ParseClaimsFromToken(resp.GetAccessToken())
+	require.NoError(t, err)
+	_, ok := jwtLib.ScopeFromClaims(claims)
+	assert.False(t, ok)
+	_, ok = jwtLib.RoleFromClaims(claims)
+	assert.False(t, ok)
+}
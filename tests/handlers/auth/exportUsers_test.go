@@ -0,0 +1,96 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/entity"
+	"sso.service/internal/storage/postgres/models"
+	"sso.service/tests/suite"
+)
+
+func exportUsers(t *testing.T, client ssov1.AuthClient, ctx context.Context, req *ssov1.ExportUsersRequest) ([]*ssov1.ExportedUser, error) {
+	t.Helper()
+	stream, err := client.ExportUsers(ctx, req)
+	require.NoError(t, err)
+	var users []*ssov1.ExportedUser
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return users, nil
+		}
+		if err != nil {
+			return users, err
+		}
+		users = append(users, resp.GetUser())
+	}
+}
+
+func TestExportUsers(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	prefix := "export" + gofakeit.LetterN(10)
+	active := createDirectoryUsers(t, models, prefix, 3, true)
+	inactive := createDirectoryUsers(t, models, prefix, 1, false)
+	permCode := gofakeit.Username()
+	_, err := models.Permission.AddForUserIgnoreConflict(context.Background(), active[1].ID, entity.GlobalAppID, []string{permCode})
+	require.NoError(t, err)
+	admin := suite.CreateTestAdmin(t, models)
+	adminCtx := st.AuthorizedContext(admin)
+
+	users, err := exportUsers(t, st.AuthClient, adminCtx, &ssov1.ExportUsersRequest{
+		Filter: &ssov1.ListUsersRequest{Search: prefix, PageSize: 1},
+	})
+	require.NoError(t, err)
+	require.Len(t, users, 4, "paging fields of the filter are ignored")
+	for i, user := range append(active, inactive...) {
+		assert.Equal(t, user.ID, users[i].GetUser().GetId())
+		assert.Empty(t, users[i].GetPasswordHash(), "hashes are excluded unless requested")
+		assert.Empty(t, users[i].GetPermissions())
+	}
+
+	isActive := true
+	users, err = exportUsers(t, st.AuthClient, adminCtx, &ssov1.ExportUsersRequest{
+		Filter:                &ssov1.ListUsersRequest{Search: prefix, IsActive: &isActive, Permission: permCode},
+		IncludePermissions:    true,
+		IncludePasswordHashes: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, active[1].ID, users[0].GetUser().GetId())
+	assert.Equal(t, string(active[1].Password.Hash), users[0].GetPasswordHash())
+	require.Len(t, users[0].GetPermissions(), 1)
+	assert.Equal(t, permCode, users[0].GetPermissions()[0].GetPermission().GetCode())
+
+	events, err := models.Audit.ListForUser(context.Background(), admin.ID)
+	require.NoError(t, err)
+	var exports []entity.AuditEvent
+	for _, event := range events {
+		if event.Type == entity.AuditUsersExported {
+			exports = append(exports, event)
+		}
+	}
+	require.Len(t, exports, 1, "only the export of password hashes is audited")
+	require.NotNil(t, exports[0].ActorID)
+	assert.Equal(t, admin.ID, *exports[0].ActorID)
+}
+
+func TestExportUsersRequiresAdmin(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	ctx := st.AuthorizedContext(suite.CreateActiveTestUser(t, models.User))
+	_, err := exportUsers(t, st.AuthClient, ctx, &ssov1.ExportUsersRequest{IncludePasswordHashes: true})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -0,0 +1,173 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+	"sso.service/internal/storage"
+	"sso.service/internal/storage/postgres/models"
+	"sso.service/pkg/passhash"
+	"sso.service/tests/suite"
+)
+
+func importUsers(t *testing.T, client ssov1.AuthClient, ctx context.Context, dryRun bool, users ...*ssov1.ImportedUser) (*ssov1.ImportUsersResponse, error) {
+	t.Helper()
+	stream, err := client.ImportUsers(ctx)
+	require.NoError(t, err)
+	// rows are split into several messages to check they're numbered across the stream
+	for i, user := range users {
+		req := &ssov1.ImportUsersRequest{Users: []*ssov1.ImportedUser{user}}
+		if i == 0 {
+			req.DryRun = dryRun
+		}
+		// the server may fail the stream early, its status is returned by CloseAndRecv then
+		if err := stream.Send(req); err != nil {
+			break
+		}
+	}
+	return stream.CloseAndRecv()
+}
+
+func legacyUser(t *testing.T, algorithm passhash.Algorithm, password string) *ssov1.ImportedUser {
+	t.Helper()
+	hash, err := algorithm.Hash([]byte(password))
+	require.NoError(t, err)
+	return &ssov1.ImportedUser{
+		Username:     gofakeit.Username(),
+		Email:        gofakeit.Email(),
+		IsActive:     true,
+		PasswordHash: hash,
+	}
+}
+
+func TestImportUsers(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	testStorage := st.NewTestStorage()
+	models := models.New(testStorage.DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	password := suite.FakePassword()
+	scryptUser := legacyUser(t, passhash.Scrypt{LogN: 4, BlockSize: 8, Parallelism: 1}, password)
+	scryptUser.AppId = suite.AppID
+	scryptUser.Permissions = []string{"imported:" + gofakeit.LetterN(10)}
+	pbkdf2User := legacyUser(t, passhash.PBKDF2SHA256{Iterations: 1000}, password)
+	sha256User := legacyUser(t, passhash.SaltedSHA256{}, password)
+	invalidHash := legacyUser(t, passhash.SaltedSHA256{}, password)
+	invalidHash.PasswordHash = "md5$salt$" + gofakeit.LetterN(32)
+	unknownRole := legacyUser(t, passhash.SaltedSHA256{}, password)
+	unknownRole.Role = "role-" + gofakeit.LetterN(10)
+
+	resp, err := importUsers(t, st.AuthClient, adminCtx, true, scryptUser, invalidHash, pbkdf2User, unknownRole)
+	require.NoError(t, err)
+	assert.True(t, resp.GetDryRun())
+	assert.Equal(t, int64(2), resp.GetImported())
+	assert.Equal(t, int64(2), resp.GetFailed())
+	require.Len(t, resp.GetErrors(), 2)
+	assert.Equal(t, int64(2), resp.GetErrors()[0].GetRow())
+	assert.Equal(t, int64(4), resp.GetErrors()[1].GetRow())
+	_, err = models.User.Get(context.Background(), dtos.GetUserOptionsDTO{Email: scryptUser.Email})
+	assert.ErrorIs(t, err, storage.ErrRecordNotFound, "dry run saves nothing")
+
+	resp, err = importUsers(t, st.AuthClient, adminCtx, false, scryptUser, pbkdf2User, sha256User, pbkdf2User)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), resp.GetImported())
+	require.Len(t, resp.GetErrors(), 1)
+	assert.Equal(t, int64(4), resp.GetErrors()[0].GetRow(), "duplicate user")
+
+	for _, imported := range []*ssov1.ImportedUser{scryptUser, pbkdf2User, sha256User} {
+		_, err := st.AuthClient.Login(context.Background(), &ssov1.LoginRequest{
+			Email:    imported.Email,
+			Password: password,
+			AppId:    suite.AppID,
+		})
+		require.NoError(t, err, imported.PasswordHash)
+		user, err := models.User.Get(context.Background(), dtos.GetUserOptionsDTO{Email: imported.Email})
+		require.NoError(t, err)
+		assert.NotEqual(t, imported.PasswordHash, string(user.Password.Hash), "legacy hash is upgraded on login")
+	}
+	user, err := models.User.Get(context.Background(), dtos.GetUserOptionsDTO{Email: scryptUser.Email})
+	require.NoError(t, err)
+	assert.Equal(t, entity.DefaultUserRole, user.Role)
+	permissions, _, err := models.Permission.ListForUser(context.Background(), user.ID, suite.AppID)
+	require.NoError(t, err)
+	require.Len(t, permissions, 1)
+	assert.Equal(t, scryptUser.Permissions[0], permissions[0].Code)
+}
+
+func TestImportUsersDryRunReportsDuplicates(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	testStorage := st.NewTestStorage()
+	models := models.New(testStorage.DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	password := suite.FakePassword()
+	user := legacyUser(t, passhash.SaltedSHA256{}, password)
+	sameEmail := legacyUser(t, passhash.SaltedSHA256{}, password)
+	sameEmail.Email = strings.ToUpper(user.Email)
+	// usernames aren't unique
+	sameUsername := legacyUser(t, passhash.SaltedSHA256{}, password)
+	sameUsername.Username = user.Username
+	another := legacyUser(t, passhash.SaltedSHA256{}, password)
+
+	for _, dryRun := range []bool{true, false} {
+		resp, err := importUsers(t, st.AuthClient, adminCtx, dryRun, user, sameEmail, sameUsername, another)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), resp.GetImported(), "dry run %t", dryRun)
+		require.Len(t, resp.GetErrors(), 1)
+		assert.Equal(t, int64(2), resp.GetErrors()[0].GetRow())
+	}
+}
+
+func TestImportUsersRejectsHashesWithOutOfRangeParameters(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	testStorage := st.NewTestStorage()
+	models := models.New(testStorage.DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	key := base64.RawStdEncoding.EncodeToString(make([]byte, 32))
+	var users []*ssov1.ImportedUser
+	for _, hash := range []string{
+		"$argon2id$v=19$m=65536,t=0,p=1$c2FsdHNhbHRzYWx0$" + key,
+		"$argon2id$v=19$m=65536,t=1,p=0$c2FsdHNhbHRzYWx0$" + key,
+		"$argon2id$v=19$m=1,t=1,p=4$c2FsdHNhbHRzYWx0$" + key,
+		"$argon2id$v=19$m=4294967295,t=1,p=1$c2FsdHNhbHRzYWx0$" + key,
+		"$argon2id$v=19$m=65536,t=1,p=1$c2FsdHNhbHRzYWx0$a2V5",
+		"pbkdf2_sha256$2000000000$salt$" + base64.StdEncoding.EncodeToString(make([]byte, 32)),
+	} {
+		user := legacyUser(t, passhash.SaltedSHA256{}, suite.FakePassword())
+		user.PasswordHash = hash
+		users = append(users, user)
+	}
+
+	resp, err := importUsers(t, st.AuthClient, adminCtx, false, users...)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), resp.GetImported())
+	assert.Equal(t, int64(len(users)), resp.GetFailed())
+	for _, user := range users {
+		_, err = models.User.Get(context.Background(), dtos.GetUserOptionsDTO{Email: user.Email})
+		assert.ErrorIs(t, err, storage.ErrRecordNotFound, user.PasswordHash)
+	}
+}
+
+func TestImportUsersRequiresAdmin(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	testStorage := st.NewTestStorage()
+	models := models.New(testStorage.DB)
+	ctx := st.AuthorizedContext(suite.CreateActiveTestUser(t, models.User))
+	user := legacyUser(t, passhash.SaltedSHA256{}, suite.FakePassword())
+	_, err := importUsers(t, st.AuthClient, ctx, false, user)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = models.User.Get(context.Background(), dtos.GetUserOptionsDTO{Email: user.Email})
+	assert.ErrorIs(t, err, storage.ErrRecordNotFound)
+}
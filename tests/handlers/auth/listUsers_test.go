@@ -0,0 +1,155 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/entity"
+	"sso.service/internal/storage/postgres/models"
+	"sso.service/tests/suite"
+)
+
+func createDirectoryUsers(t *testing.T, m *models.Models, prefix string, count int, isActive bool) []*entity.User {
+	users := make([]*entity.User, count)
+	for i := range users {
+		user := suite.NewTestUser(t, isActive)
+		user.Username = prefix + gofakeit.LetterN(6)
+		suite.SaveTestUser(t, m.User, user)
+		users[i] = user
+	}
+	return users
+}
+
+func userIDs(users []*ssov1.User) []int64 {
+	ids := make([]int64, len(users))
+	for i, user := range users {
+		ids[i] = user.GetId()
+	}
+	return ids
+}
+
+func TestListUsers(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	prefix := "directory" + gofakeit.LetterN(10)
+	active := createDirectoryUsers(t, models, prefix, 2, true)
+	inactive := createDirectoryUsers(t, models, prefix, 1, false)
+	app := suite.CreateTestApp(t, models, &entity.App{})
+	_, err := st.AuthClient.Login(context.Background(), &ssov1.LoginRequest{
+		Email:    active[0].Email,
+		Password: active[0].Password.Plaintext,
+		AppId:    int32(app.ID),
+	})
+	require.NoError(t, err)
+	permCode := gofakeit.Username()
+	_, err = models.Permission.AddForUserIgnoreConflict(context.Background(), active[1].ID, entity.GlobalAppID, []string{permCode})
+	require.NoError(t, err)
+	isActive := false
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	testCases := []struct {
+		name         string
+		req          *ssov1.ListUsersRequest
+		expectedCode codes.Code
+		expectedIDs  []int64
+	}{
+		{
+			name:         "search",
+			req:          &ssov1.ListUsersRequest{Search: prefix},
+			expectedCode: codes.OK,
+			expectedIDs:  []int64{active[0].ID, active[1].ID, inactive[0].ID},
+		},
+		{
+			name:         "search descending",
+			req:          &ssov1.ListUsersRequest{Search: prefix, Descending: true},
+			expectedCode: codes.OK,
+			expectedIDs:  []int64{inactive[0].ID, active[1].ID, active[0].ID},
+		},
+		{
+			name:         "inactive",
+			req:          &ssov1.ListUsersRequest{Search: prefix, IsActive: &isActive},
+			expectedCode: codes.OK,
+			expectedIDs:  []int64{inactive[0].ID},
+		},
+		{
+			name:         "app members",
+			req:          &ssov1.ListUsersRequest{Search: prefix, AppId: int32(app.ID)},
+			expectedCode: codes.OK,
+			expectedIDs:  []int64{active[0].ID},
+		},
+		{
+			name:         "granted permission",
+			req:          &ssov1.ListUsersRequest{Search: prefix, Permission: permCode},
+			expectedCode: codes.OK,
+			expectedIDs:  []int64{active[1].ID},
+		},
+		{
+			name:         "invalid sort field",
+			req:          &ssov1.ListUsersRequest{SortBy: "password"},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "invalid page token",
+			req:          &ssov1.ListUsersRequest{PageToken: gofakeit.UUID()},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := st.AuthClient.ListUsers(adminCtx, tc.req)
+			respStatus := status.Code(err)
+			t.Log("Actual code", respStatus, "Expected", tc.expectedCode)
+			require.Equal(t, tc.expectedCode, respStatus)
+			if tc.expectedCode == codes.OK {
+				assert.Equal(t, tc.expectedIDs, userIDs(resp.GetUsers()))
+			}
+		})
+	}
+}
+
+func TestListUsersPagination(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	prefix := "directory" + gofakeit.LetterN(10)
+	users := createDirectoryUsers(t, models, prefix, 3, true)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+
+	req := &ssov1.ListUsersRequest{Search: prefix, SortBy: "updated_at", PageSize: 2}
+	firstPage, err := st.AuthClient.ListUsers(adminCtx, req)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{users[0].ID, users[1].ID}, userIDs(firstPage.GetUsers()))
+	require.NotEmpty(t, firstPage.GetNextPageToken())
+
+	req.PageToken = firstPage.GetNextPageToken()
+	secondPage, err := st.AuthClient.ListUsers(adminCtx, req)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{users[2].ID}, userIDs(secondPage.GetUsers()))
+	assert.Empty(t, secondPage.GetNextPageToken())
+
+	// page tokens are bound to the sort field
+	req.SortBy = "created_at"
+	_, err = st.AuthClient.ListUsers(adminCtx, req)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestListUsersRequiresAdmin(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	userCtx := st.AuthorizedContext(suite.CreateActiveTestUser(t, models.User))
+
+	_, err := st.AuthClient.ListUsers(userCtx, &ssov1.ListUsersRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = st.AuthClient.ListUsers(context.Background(), &ssov1.ListUsersRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
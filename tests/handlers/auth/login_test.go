@@ -51,15 +51,25 @@ func TestLogin(t *testing.T) {
 			expectedErrMsgContains: "email",
 		},
 		{
-			name: "short password",
+			name: "empty password",
+			req: &ssov1.LoginRequest{
+				Email: activatedUser.Email,
+				AppId: suite.AppID,
+			},
+			expectedErr:            true,
+			expectedCode:           codes.InvalidArgument,
+			expectedErrMsgContains: "password",
+		},
+		{
+			name: "Invalid credentials (short password)",
 			req: &ssov1.LoginRequest{
 				Email:    activatedUser.Email,
 				Password: "123",
 				AppId:    suite.AppID,
 			},
 			expectedErr:            true,
-			expectedCode:           codes.InvalidArgument,
-			expectedErrMsgContains: "password",
+			expectedCode:           codes.Unauthenticated,
+			expectedErrMsgContains: "invalid credentials",
 		},
 		{
 			name: "invalid app-id",
@@ -160,3 +170,21 @@ func TestLogin(t *testing.T) {
 		})
 	}
 }
+
+func TestLoginWithPasswordShorterThanPolicy(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	userModel := models.New(st.NewTestStorage().DB).User
+	// passwords set before the password policy are still accepted
+	user := suite.NewTestUser(t, true)
+	require.NoError(t, user.Password.Set("123"))
+	suite.SaveTestUser(t, userModel, user)
+
+	resp, err := st.AuthClient.Login(context.Background(), &ssov1.LoginRequest{
+		Email:    user.Email,
+		Password: "123",
+		AppId:    suite.AppID,
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.GetAccessToken())
+}
@@ -0,0 +1,52 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/metadata"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/services/dtos"
+	"sso.service/internal/storage/postgres/models"
+	"sso.service/pkg/passhash"
+	"sso.service/tests/suite"
+)
+
+func TestLoginUpgradesOutdatedPasswordHash(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	user := suite.NewTestUser(t, true)
+	// a hash no server config considers up to date
+	legacyHash, err := passhash.New(passhash.Bcrypt{Cost: bcrypt.MinCost}, nil).Hash(user.Password.Plaintext)
+	require.NoError(t, err)
+	user.Password.Hash = []byte(legacyHash)
+	suite.SaveTestUser(t, models.User, user)
+	login := func() (*ssov1.LoginResponse, error) {
+		return st.AuthClient.Login(context.Background(), &ssov1.LoginRequest{
+			Email:    user.Email,
+			Password: user.Password.Plaintext,
+			AppId:    suite.AppID,
+		})
+	}
+
+	resp, err := login()
+	require.NoError(t, err)
+	stored, err := models.User.Get(context.Background(), dtos.GetUserOptionsDTO{ID: user.ID})
+	require.NoError(t, err)
+	assert.NotEqual(t, legacyHash, string(stored.Password.Hash))
+	assert.Equal(t, user.TokenVersion, stored.TokenVersion, "upgrading the hash doesn't revoke tokens")
+
+	_, err = login()
+	require.NoError(t, err)
+	upgraded, err := models.User.Get(context.Background(), dtos.GetUserOptionsDTO{ID: user.ID})
+	require.NoError(t, err)
+	assert.Equal(t, stored.Password.Hash, upgraded.Password.Hash, "up to date hash is kept")
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+resp.GetAccessToken())
+	_, err = st.AuthClient.GetUserMetadata(ctx, &ssov1.GetUserMetadataRequest{UserId: user.ID})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,107 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/storage/postgres/models"
+	"sso.service/pkg/passwordpolicy"
+	"sso.service/tests/suite"
+)
+
+func TestRegisterPasswordPolicy(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	user := suite.NewTestUser(t, false)
+	register := func(password string) error {
+		_, err := st.AuthClient.Register(context.Background(), &ssov1.RegisterRequest{
+			Username: user.Username,
+			Email:    user.Email,
+			Password: password,
+			AppId:    suite.AppID,
+		})
+		return err
+	}
+	err := register("aaaaaaaaaaaa")
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.ErrorContains(t, err, `"password"`)
+	assert.ErrorContains(t, err, passwordpolicy.TooFewCharClasses)
+	err = register("Aa-1" + user.Username)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.ErrorContains(t, err, passwordpolicy.ContainsPersonalInfo)
+}
+
+func TestChangePassword(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	user := suite.CreateActiveTestUser(t, models.User)
+	ctx := st.AuthorizedContext(user)
+	newPassword := suite.FakePassword()
+
+	_, err := st.AuthClient.ChangePassword(ctx, &ssov1.ChangePasswordRequest{CurrentPassword: "wrong-Password1", NewPassword: newPassword})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = st.AuthClient.ChangePassword(ctx, &ssov1.ChangePasswordRequest{CurrentPassword: user.Password.Plaintext, NewPassword: "short"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.ErrorContains(t, err, `"new_password"`)
+	assert.ErrorContains(t, err, passwordpolicy.TooShort)
+
+	_, err = st.AuthClient.ChangePassword(ctx, &ssov1.ChangePasswordRequest{CurrentPassword: user.Password.Plaintext, NewPassword: newPassword})
+	require.NoError(t, err)
+	// tokens issued before the change are revoked
+	_, err = st.AuthClient.ChangePassword(ctx, &ssov1.ChangePasswordRequest{CurrentPassword: newPassword, NewPassword: suite.FakePassword()})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = st.AuthClient.Login(context.Background(), &ssov1.LoginRequest{Email: user.Email, Password: user.Password.Plaintext, AppId: suite.AppID})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = st.AuthClient.Login(context.Background(), &ssov1.LoginRequest{Email: user.Email, Password: newPassword, AppId: suite.AppID})
+	assert.NoError(t, err)
+}
+
+func TestResetPassword(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	user := suite.CreateActiveTestUser(t, models.User)
+	inactiveUser := suite.NewTestUser(t, false)
+	suite.SaveTestUser(t, models.User, inactiveUser)
+	newPassword := suite.FakePassword()
+
+	_, err := st.AuthClient.NewPasswordResetToken(st.AuthorizedContext(user), &ssov1.NewPasswordResetTokenRequest{Email: user.Email, AppId: suite.AppID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = st.AuthClient.NewPasswordResetToken(adminCtx, &ssov1.NewPasswordResetTokenRequest{Email: inactiveUser.Email, AppId: suite.AppID})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	resp, err := st.AuthClient.NewPasswordResetToken(adminCtx, &ssov1.NewPasswordResetTokenRequest{Email: user.Email, AppId: suite.AppID})
+	require.NoError(t, err)
+	token := resp.GetResetToken()
+
+	loginResp, err := st.AuthClient.Login(context.Background(), &ssov1.LoginRequest{Email: user.Email, Password: user.Password.Plaintext, AppId: suite.AppID})
+	require.NoError(t, err)
+	_, err = st.AuthClient.ResetPassword(context.Background(), &ssov1.ResetPasswordRequest{
+		ResetToken:  loginResp.GetAccessToken(),
+		AppId:       suite.AppID,
+		NewPassword: newPassword,
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "access tokens can't reset passwords")
+	_, err = st.AuthClient.ResetPassword(context.Background(), &ssov1.ResetPasswordRequest{ResetToken: token, AppId: suite.AppID, NewPassword: user.Email})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.ErrorContains(t, err, passwordpolicy.ContainsPersonalInfo)
+
+	_, err = st.AuthClient.ResetPassword(context.Background(), &ssov1.ResetPasswordRequest{ResetToken: token, AppId: suite.AppID, NewPassword: newPassword})
+	require.NoError(t, err)
+	_, err = st.AuthClient.ResetPassword(context.Background(), &ssov1.ResetPasswordRequest{ResetToken: token, AppId: suite.AppID, NewPassword: suite.FakePassword()})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "reset tokens can be used once")
+	oldCtx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+loginResp.GetAccessToken())
+	_, err = st.AuthClient.ChangePassword(oldCtx, &ssov1.ChangePasswordRequest{CurrentPassword: newPassword, NewPassword: suite.FakePassword()})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = st.AuthClient.Login(context.Background(), &ssov1.LoginRequest{Email: user.Email, Password: newPassword, AppId: suite.AppID})
+	assert.NoError(t, err)
+}
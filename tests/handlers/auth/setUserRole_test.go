@@ -0,0 +1,161 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/entity"
+	"sso.service/internal/storage/postgres/models"
+	"sso.service/tests/suite"
+)
+
+func TestSetUserRole(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	admin := suite.CreateTestAdmin(t, models)
+	user := suite.CreateActiveTestUser(t, models.User)
+	adminCtx := st.AuthorizedContext(admin)
+	userCtx := st.AuthorizedContext(suite.CreateActiveTestUser(t, models.User))
+	testCases := []struct {
+		name         string
+		ctx          context.Context
+		req          *ssov1.SetUserRoleRequest
+		expectedCode codes.Code
+	}{
+		{
+			name:         "valid",
+			ctx:          adminCtx,
+			req:          &ssov1.SetUserRoleRequest{UserId: user.ID, Role: entity.RoleModerator},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "without token",
+			ctx:          context.Background(),
+			req:          &ssov1.SetUserRoleRequest{UserId: user.ID, Role: entity.RoleModerator},
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "invalid token",
+			ctx:          metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+gofakeit.UUID()),
+			req:          &ssov1.SetUserRoleRequest{UserId: user.ID, Role: entity.RoleModerator},
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "not admin",
+			ctx:          userCtx,
+			req:          &ssov1.SetUserRoleRequest{UserId: user.ID, Role: entity.RoleModerator},
+			expectedCode: codes.PermissionDenied,
+		},
+		{
+			name:         "not found user",
+			ctx:          adminCtx,
+			req:          &ssov1.SetUserRoleRequest{UserId: suite.NotFoundUserID, Role: entity.RoleModerator},
+			expectedCode: codes.NotFound,
+		},
+		{
+			name:         "not found role",
+			ctx:          adminCtx,
+			req:          &ssov1.SetUserRoleRequest{UserId: user.ID, Role: gofakeit.UUID()},
+			expectedCode: codes.NotFound,
+		},
+		{
+			name:         "empty role",
+			ctx:          adminCtx,
+			req:          &ssov1.SetUserRoleRequest{UserId: user.ID},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := st.AuthClient.SetUserRole(tc.ctx, tc.req)
+			respStatus := status.Code(err)
+			t.Log("Actual code", respStatus, "Expected", tc.expectedCode)
+			require.Equal(t, tc.expectedCode, respStatus)
+			if tc.expectedCode == codes.OK {
+				assert.Equal(t, tc.req.GetUserId(), resp.GetUser().GetId())
+				assert.Equal(t, tc.req.GetRole(), resp.GetUser().GetRole())
+			}
+		})
+	}
+}
+
+func TestSetUserRoleRevokesTokensOnDemotion(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	admin := suite.CreateTestAdmin(t, models)
+	demoted := suite.CreateTestAdmin(t, models)
+	ctx := context.Background()
+
+	loginResp, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{
+		Email:    demoted.Email,
+		Password: demoted.Password.Plaintext,
+		AppId:    suite.AppID,
+	})
+	require.NoError(t, err)
+	demotedCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+loginResp.GetAccessToken())
+
+	_, err = st.AuthClient.SetUserRole(st.AuthorizedContext(admin), &ssov1.SetUserRoleRequest{
+		UserId: demoted.ID,
+		Role:   entity.RoleUser,
+	})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.RenewAccessToken(ctx, &ssov1.RenewAccessTokenRequest{
+		RefreshToken: loginResp.GetRefreshToken(),
+		AppId:        suite.AppID,
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = st.AuthClient.SetUserRole(demotedCtx, &ssov1.SetUserRoleRequest{
+		UserId: admin.ID,
+		Role:   entity.RoleUser,
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestSetUserRolePromotionKeepsTokens(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	admin := suite.CreateTestAdmin(t, models)
+	user := suite.CreateActiveTestUser(t, models.User)
+	ctx := context.Background()
+
+	loginResp, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{
+		Email:    user.Email,
+		Password: user.Password.Plaintext,
+		AppId:    suite.AppID,
+	})
+	require.NoError(t, err)
+	_, err = st.AuthClient.SetUserRole(st.AuthorizedContext(admin), &ssov1.SetUserRoleRequest{
+		UserId: user.ID,
+		Role:   entity.RoleModerator,
+	})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.RenewAccessToken(ctx, &ssov1.RenewAccessTokenRequest{
+		RefreshToken: loginResp.GetRefreshToken(),
+		AppId:        suite.AppID,
+	})
+	assert.NoError(t, err)
+	// role changes are audited the same way as the ones made with UpdateUser
+	events, err := models.Audit.ListForUser(ctx, user.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+	event := events[len(events)-1]
+	assert.Equal(t, entity.AuditUserUpdated, event.Type)
+	require.NotNil(t, event.ActorID)
+	assert.Equal(t, admin.ID, *event.ActorID)
+	assert.Equal(t, entity.RoleModerator, event.Payload["role"])
+}
@@ -118,3 +118,29 @@ func TestActivateUser(t *testing.T) {
 		})
 	}
 }
+
+func TestActivationTokenCantRenewAccessToken(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	userModel := models.New(storage.DB).User
+	inactiveUser := suite.NewTestUser(t, false)
+	suite.SaveTestUser(t, userModel, inactiveUser)
+	ctx := context.Background()
+	resp, err := st.AuthClient.NewActivationToken(ctx, &ssov1.NewActivationTokenRequest{Email: inactiveUser.Email, AppId: suite.AppID})
+	require.NoError(t, err)
+	// activation tokens issued before token types were introduced
+	legacyToken, err := jwt.NewTokenProvider(suite.AppSecret, st.Cfg.TokenSigningAlg).NewToken(
+		st.Cfg.ActivationTokenTTL,
+		map[string]any{"uid": inactiveUser.ID, "app_id": suite.AppID},
+	)
+	require.NoError(t, err)
+
+	for _, token := range []string{resp.GetActivationToken(), legacyToken} {
+		_, err = st.AuthClient.RenewAccessToken(ctx, &ssov1.RenewAccessTokenRequest{RefreshToken: token, AppId: suite.AppID})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+	// the typed token still activates the user
+	_, err = st.AuthClient.ActivateUser(ctx, &ssov1.ActivateUserRequest{ActivationToken: resp.GetActivationToken(), AppId: suite.AppID})
+	assert.NoError(t, err)
+}
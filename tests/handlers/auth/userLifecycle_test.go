@@ -0,0 +1,243 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+	"sso.service/internal/storage/postgres/models"
+	"sso.service/pkg/jwt"
+	"sso.service/tests/suite"
+)
+
+func TestUpdateUser(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	user := suite.CreateActiveTestUser(t, models.User)
+	existingUser := suite.CreateActiveTestUser(t, models.User)
+	newUsername := gofakeit.Username()
+	testCases := []struct {
+		name         string
+		req          *ssov1.UpdateUserRequest
+		expectedCode codes.Code
+	}{
+		{
+			name: "valid",
+			req: &ssov1.UpdateUserRequest{
+				UserId:     user.ID,
+				Username:   newUsername,
+				Email:      gofakeit.Email(),
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"username"}},
+			},
+			expectedCode: codes.OK,
+		},
+		{
+			name: "taken email",
+			req: &ssov1.UpdateUserRequest{
+				UserId:     user.ID,
+				Email:      existingUser.Email,
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"email"}},
+			},
+			expectedCode: codes.AlreadyExists,
+		},
+		{
+			name: "not found role",
+			req: &ssov1.UpdateUserRequest{
+				UserId:     user.ID,
+				Role:       gofakeit.UUID(),
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"role"}},
+			},
+			expectedCode: codes.NotFound,
+		},
+		{
+			name: "not found user",
+			req: &ssov1.UpdateUserRequest{
+				UserId:     suite.NotFoundUserID,
+				IsActive:   true,
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"is_active"}},
+			},
+			expectedCode: codes.NotFound,
+		},
+		{
+			name: "unknown field",
+			req: &ssov1.UpdateUserRequest{
+				UserId:     user.ID,
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"password"}},
+			},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "empty mask",
+			req:          &ssov1.UpdateUserRequest{UserId: user.ID, Username: newUsername},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name: "stale version",
+			req: &ssov1.UpdateUserRequest{
+				UserId:            user.ID,
+				Username:          gofakeit.Username(),
+				UpdateMask:        &fieldmaskpb.FieldMask{Paths: []string{"username"}},
+				ExpectedUpdatedAt: user.UpdatedAt.Add(-time.Hour).String(),
+			},
+			expectedCode: codes.Aborted,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := st.AuthClient.UpdateUser(adminCtx, tc.req)
+			respStatus := status.Code(err)
+			t.Log("Actual code", respStatus, "Expected", tc.expectedCode)
+			require.Equal(t, tc.expectedCode, respStatus)
+			if tc.expectedCode == codes.OK {
+				assert.Equal(t, newUsername, resp.GetUser().GetUsername())
+				// fields missing from the mask are left unchanged
+				assert.Equal(t, user.Email, resp.GetUser().GetEmail())
+			}
+		})
+	}
+}
+
+func TestUpdateUserConcurrentEdits(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	user := suite.CreateActiveTestUser(t, models.User)
+	getResp, err := st.AuthClient.GetUser(context.Background(), &ssov1.GetUserRequest{Id: user.ID, IsActive: true})
+	require.NoError(t, err)
+	version := getResp.GetUser().GetUpdatedAt()
+
+	req := &ssov1.UpdateUserRequest{
+		UserId:            user.ID,
+		Username:          gofakeit.Username(),
+		UpdateMask:        &fieldmaskpb.FieldMask{Paths: []string{"username"}},
+		ExpectedUpdatedAt: version,
+	}
+	_, err = st.AuthClient.UpdateUser(adminCtx, req)
+	require.NoError(t, err)
+	// the second edit is based on the same version, which is stale by now
+	req.Username = gofakeit.Username()
+	_, err = st.AuthClient.UpdateUser(adminCtx, req)
+	assert.Equal(t, codes.Aborted, status.Code(err))
+}
+
+func TestDeactivateUser(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	user := suite.CreateActiveTestUser(t, models.User)
+	ctx := context.Background()
+	loginReq := &ssov1.LoginRequest{Email: user.Email, Password: user.Password.Plaintext, AppId: suite.AppID}
+	loginResp, err := st.AuthClient.Login(ctx, loginReq)
+	require.NoError(t, err)
+
+	deactivateResp, err := st.AuthClient.DeactivateUser(adminCtx, &ssov1.DeactivateUserRequest{UserId: user.ID})
+	require.NoError(t, err)
+	assert.False(t, deactivateResp.GetUser().GetIsActive())
+	_, err = st.AuthClient.Login(ctx, loginReq)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = st.AuthClient.RenewAccessToken(ctx, &ssov1.RenewAccessTokenRequest{
+		RefreshToken: loginResp.GetRefreshToken(),
+		AppId:        suite.AppID,
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	reactivateResp, err := st.AuthClient.ReactivateUser(adminCtx, &ssov1.ReactivateUserRequest{UserId: user.ID})
+	require.NoError(t, err)
+	assert.True(t, reactivateResp.GetUser().GetIsActive())
+	_, err = st.AuthClient.Login(ctx, loginReq)
+	assert.NoError(t, err)
+}
+
+func TestDeactivatedUserCantActivateItself(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	user := suite.CreateActiveTestUser(t, models.User)
+	ctx := context.Background()
+	// activation token issued before the deactivation
+	activationToken, err := jwt.NewTokenProvider(suite.AppSecret, st.Cfg.TokenSigningAlg).NewToken(
+		st.Cfg.ActivationTokenTTL,
+		map[string]any{"uid": user.ID, "app_id": suite.AppID},
+	)
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.DeactivateUser(adminCtx, &ssov1.DeactivateUserRequest{UserId: user.ID})
+	require.NoError(t, err)
+	_, err = st.AuthClient.NewActivationToken(ctx, &ssov1.NewActivationTokenRequest{Email: user.Email, AppId: suite.AppID})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = st.AuthClient.ActivateUser(ctx, &ssov1.ActivateUserRequest{ActivationToken: activationToken, AppId: suite.AppID})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = st.AuthClient.Login(ctx, &ssov1.LoginRequest{Email: user.Email, Password: user.Password.Plaintext, AppId: suite.AppID})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = st.AuthClient.ReactivateUser(adminCtx, &ssov1.ReactivateUserRequest{UserId: user.ID})
+	require.NoError(t, err)
+	_, err = st.AuthClient.NewActivationToken(ctx, &ssov1.NewActivationTokenRequest{Email: user.Email, AppId: suite.AppID})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+}
+
+func TestDeleteUser(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	admin := suite.CreateTestAdmin(t, models)
+	adminCtx := st.AuthorizedContext(admin)
+	user := suite.CreateActiveTestUser(t, models.User)
+
+	_, err := st.AuthClient.DeleteUser(adminCtx, &ssov1.DeleteUserRequest{UserId: user.ID})
+	require.NoError(t, err)
+	_, err = st.AuthClient.GetUser(context.Background(), &ssov1.GetUserRequest{Id: user.ID, IsActive: true})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = st.AuthClient.DeleteUser(adminCtx, &ssov1.DeleteUserRequest{UserId: user.ID})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	userCtx := st.AuthorizedContext(suite.CreateActiveTestUser(t, models.User))
+	_, err = st.AuthClient.DeleteUser(userCtx, &ssov1.DeleteUserRequest{UserId: admin.ID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestUserChangeIsRolledBackWithItsAuditEvent(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	ctx := context.Background()
+	user, err := models.User.Get(ctx, dtos.GetUserOptionsDTO{ID: suite.CreateActiveTestUser(t, models.User).ID})
+	require.NoError(t, err)
+	// the payload can't be encoded, so saving the event fails
+	failingAudit := func(*entity.User) *entity.AuditEvent {
+		return &entity.AuditEvent{Type: entity.AuditUserUpdated, UserID: &user.ID, Payload: map[string]any{"invalid": func() {}}}
+	}
+
+	isActive := false
+	_, _, err = models.User.Patch(ctx, dtos.UpdateUserDTO{ID: user.ID, IsActive: &isActive}, func(_ *entity.User, updated *entity.User) *entity.AuditEvent {
+		return failingAudit(updated)
+	})
+	require.Error(t, err)
+	_, err = models.User.Delete(ctx, user.ID, nil, failingAudit)
+	require.Error(t, err)
+
+	stored, err := models.User.Get(ctx, dtos.GetUserOptionsDTO{ID: user.ID})
+	require.NoError(t, err)
+	assert.True(t, stored.IsActive)
+	assert.Equal(t, user.TokenVersion, stored.TokenVersion)
+}
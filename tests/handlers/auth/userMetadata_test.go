@@ -0,0 +1,122 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/entity"
+	"sso.service/internal/storage/postgres/models"
+	"sso.service/tests/suite"
+)
+
+func mustStruct(t *testing.T, value map[string]any) *structpb.Struct {
+	t.Helper()
+	converted, err := structpb.NewStruct(value)
+	require.NoError(t, err)
+	return converted
+}
+
+func TestPatchUserMetadata(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	user := suite.CreateActiveTestUser(t, models.User)
+	userCtx := st.AuthorizedContext(user)
+	app := suite.CreateTestApp(t, models, &entity.App{})
+
+	resp, err := st.AuthClient.PatchUserMetadata(userCtx, &ssov1.PatchUserMetadataRequest{
+		UserId: user.ID,
+		Public: mustStruct(t, map[string]any{"display_name": "Jane", "locale": "en"}),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Jane", resp.GetMetadata().GetPublic().AsMap()["display_name"])
+	assert.Nil(t, resp.GetMetadata().GetAdmin())
+	// users can't patch app-private and admin-only metadata
+	_, err = st.AuthClient.PatchUserMetadata(userCtx, &ssov1.PatchUserMetadataRequest{
+		UserId: user.ID,
+		Admin:  mustStruct(t, map[string]any{"note": "vip"}),
+	})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.AuthClient.PatchUserMetadata(adminCtx, &ssov1.PatchUserMetadataRequest{
+		UserId: user.ID,
+		AppId:  int32(app.ID),
+		Public: mustStruct(t, map[string]any{"locale": nil}),
+		App:    mustStruct(t, map[string]any{"plan_tier": "pro"}),
+		Admin:  mustStruct(t, map[string]any{"note": "vip"}),
+	})
+	require.NoError(t, err)
+
+	getResp, err := st.AuthClient.GetUserMetadata(userCtx, &ssov1.GetUserMetadataRequest{UserId: user.ID, AppId: int32(app.ID)})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"display_name": "Jane"}, getResp.GetMetadata().GetPublic().AsMap())
+	assert.Equal(t, map[string]any{"plan_tier": "pro"}, getResp.GetMetadata().GetApp().AsMap())
+	assert.Nil(t, getResp.GetMetadata().GetAdmin())
+	getResp, err = st.AuthClient.GetUserMetadata(adminCtx, &ssov1.GetUserMetadataRequest{UserId: user.ID})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"note": "vip"}, getResp.GetMetadata().GetAdmin().AsMap())
+	assert.Empty(t, getResp.GetMetadata().GetApp().AsMap())
+
+	userResp, err := st.AuthClient.GetUser(userCtx, &ssov1.GetUserRequest{Id: user.ID, IsActive: true})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"display_name": "Jane"}, userResp.GetUser().GetPublicMetadata().AsMap())
+
+	other := suite.CreateActiveTestUser(t, models.User)
+	_, err = st.AuthClient.GetUserMetadata(st.AuthorizedContext(other), &ssov1.GetUserMetadataRequest{UserId: user.ID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestAppMetadataSchema(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	user := suite.CreateActiveTestUser(t, models.User)
+	app := suite.CreateTestApp(t, models, &entity.App{})
+	schema := `{
+		"type": "object",
+		"properties": {"plan_tier": {"enum": ["free", "pro"]}, "seats": {"type": "integer", "minimum": 1}},
+		"additionalProperties": false
+	}`
+
+	_, err := st.AuthClient.SetAppMetadataSchema(st.AuthorizedContext(user), &ssov1.SetAppMetadataSchemaRequest{AppId: int32(app.ID), Schema: schema})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = st.AuthClient.SetAppMetadataSchema(adminCtx, &ssov1.SetAppMetadataSchemaRequest{AppId: int32(app.ID), Schema: `{"type": "nope"}`})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = st.AuthClient.SetAppMetadataSchema(adminCtx, &ssov1.SetAppMetadataSchemaRequest{AppId: int32(app.ID), Schema: schema})
+	require.NoError(t, err)
+
+	patch := func(value map[string]any) error {
+		_, err := st.AuthClient.PatchUserMetadata(adminCtx, &ssov1.PatchUserMetadataRequest{
+			UserId: user.ID,
+			AppId:  int32(app.ID),
+			App:    mustStruct(t, value),
+		})
+		return err
+	}
+	require.NoError(t, patch(map[string]any{"plan_tier": "pro", "seats": 5}))
+	assert.Equal(t, codes.InvalidArgument, status.Code(patch(map[string]any{"plan_tier": "gold"})))
+	assert.Equal(t, codes.InvalidArgument, status.Code(patch(map[string]any{"unknown": true})))
+	// the schema is checked against the merged metadata
+	assert.Equal(t, codes.InvalidArgument, status.Code(patch(map[string]any{"seats": 0})))
+	require.NoError(t, patch(map[string]any{"seats": nil}))
+
+	getResp, err := st.AuthClient.GetUserMetadata(adminCtx, &ssov1.GetUserMetadataRequest{UserId: user.ID, AppId: int32(app.ID)})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"plan_tier": "pro"}, getResp.GetMetadata().GetApp().AsMap())
+
+	// public metadata isn't subject to the app schema
+	_, err = st.AuthClient.PatchUserMetadata(adminCtx, &ssov1.PatchUserMetadataRequest{
+		UserId: user.ID,
+		Public: mustStruct(t, map[string]any{"unknown": true}),
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,223 @@
+package orgs_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/entity"
+	"sso.service/internal/storage/postgres/models"
+	"sso.service/tests/suite"
+)
+
+func TestOrgScopedGroups(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	ctx := context.Background()
+	m := models.New(st.NewTestStorage().DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, m))
+	user := suite.CreateActiveTestUser(t, m.User)
+	org := suite.CreateTestOrg(t, m)
+	otherOrg := suite.CreateTestOrg(t, m)
+	orgApp := suite.CreateTestApp(t, m, &entity.App{OrgID: org.ID})
+	otherOrgApp := suite.CreateTestApp(t, m, &entity.App{OrgID: otherOrg.ID})
+	code := "reports." + gofakeit.Username() + ":read"
+
+	groupResp, err := st.PermissionsClient.CreateGroup(adminCtx, &ssov1.CreateGroupRequest{
+		Name:  gofakeit.Company() + gofakeit.DigitN(6),
+		OrgId: org.ID,
+	})
+	require.NoError(t, err)
+	group := groupResp.GetGroup()
+	assert.Equal(t, org.ID, group.GetOrgId())
+	_, err = st.PermissionsClient.CreateGroup(adminCtx, &ssov1.CreateGroupRequest{Name: group.GetName(), OrgId: otherOrg.ID})
+	require.NoError(t, err, "groups of different orgs may share the name")
+	_, err = st.PermissionsClient.CreateGroup(adminCtx, &ssov1.CreateGroupRequest{Name: group.GetName(), OrgId: suite.NotFoundUserID})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	otherOrgGroup := &entity.Group{Name: gofakeit.Company() + gofakeit.DigitN(6), OrgID: otherOrg.ID}
+	otherOrgGroup.ID, err = m.Group.Create(ctx, otherOrgGroup)
+	require.NoError(t, err)
+	_, err = st.PermissionsClient.AddGroupMembers(adminCtx, &ssov1.AddGroupMembersRequest{GroupId: group.GetId(), GroupIds: []int64{otherOrgGroup.ID}})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = st.PermissionsClient.AddGroupMembers(adminCtx, &ssov1.AddGroupMembersRequest{GroupId: group.GetId(), UserIds: []int64{user.ID}})
+	require.NoError(t, err)
+	_, err = st.PermissionsClient.GrantGroupPermissions(adminCtx, &ssov1.GrantGroupPermissionsRequest{
+		GroupId:         group.GetId(),
+		AppId:           entity.GlobalAppID,
+		PermissionCodes: []string{code},
+	})
+	require.NoError(t, err)
+	testCases := []struct {
+		name     string
+		appID    int32
+		expected bool
+	}{
+		{name: "app of the org", appID: int32(orgApp.ID), expected: true},
+		{name: "app of another org", appID: int32(otherOrgApp.ID), expected: false},
+		{name: "shared app", appID: suite.AppID, expected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := st.PermissionsClient.CheckPermission(ctx, &ssov1.CheckPermissionRequest{
+				UserId:         user.ID,
+				AppId:          tc.appID,
+				PermissionCode: code,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, resp.GetHasPermission())
+		})
+	}
+}
+
+func TestOrgScopedPolicies(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	ctx := context.Background()
+	m := models.New(st.NewTestStorage().DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, m))
+	user := suite.CreateActiveTestUser(t, m.User)
+	org := suite.CreateTestOrg(t, m)
+	otherOrg := suite.CreateTestOrg(t, m)
+	orgApp := suite.CreateTestApp(t, m, &entity.App{OrgID: org.ID})
+	otherOrgApp := suite.CreateTestApp(t, m, &entity.App{OrgID: otherOrg.ID})
+	namespace := "invoices" + gofakeit.DigitN(8)
+
+	policyResp, err := st.PermissionsClient.CreatePolicy(adminCtx, &ssov1.CreatePolicyRequest{
+		AppId:   entity.GlobalAppID,
+		OrgId:   org.ID,
+		Name:    "everyone reads " + namespace,
+		Effect:  entity.PolicyAllow,
+		Actions: []string{namespace + ":read"},
+	})
+	require.NoError(t, err)
+	policy := policyResp.GetPolicy()
+	assert.Equal(t, org.ID, policy.GetOrgId())
+
+	listResp, err := st.PermissionsClient.ListPolicies(ctx, &ssov1.ListPoliciesRequest{AppId: entity.GlobalAppID, OrgId: otherOrg.ID})
+	require.NoError(t, err)
+	for _, listed := range listResp.GetPolicies() {
+		assert.NotEqual(t, policy.GetId(), listed.GetId(), "policies of another org are not listed")
+	}
+
+	testCases := []struct {
+		name     string
+		appID    int32
+		expected bool
+	}{
+		{name: "app of the org", appID: int32(orgApp.ID), expected: true},
+		{name: "app of another org", appID: int32(otherOrgApp.ID), expected: false},
+		{name: "shared app", appID: suite.AppID, expected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := st.PermissionsClient.Authorize(ctx, &ssov1.AuthorizeRequest{
+				SubjectId: user.ID,
+				AppId:     tc.appID,
+				Action:    namespace + ":read",
+				Resource:  &ssov1.Resource{Type: "invoice", Id: "1"},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, resp.GetAllowed())
+		})
+	}
+}
+
+func TestOrgScopedRelationTuples(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	ctx := context.Background()
+	m := models.New(st.NewTestStorage().DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, m))
+	user := suite.CreateActiveTestUser(t, m.User)
+	org := suite.CreateTestOrg(t, m)
+	otherOrg := suite.CreateTestOrg(t, m)
+	folderID := gofakeit.UUID()
+
+	_, err := st.RelationsClient.WriteTuples(adminCtx, &ssov1.WriteTuplesRequest{
+		Writes: []*ssov1.RelationTuple{{OrgId: org.ID, Namespace: "folder", ObjectId: folderID, Relation: "owner", SubjectUserId: user.ID}},
+	})
+	require.NoError(t, err)
+	_, err = st.RelationsClient.WriteTuples(adminCtx, &ssov1.WriteTuplesRequest{
+		Writes: []*ssov1.RelationTuple{
+			{OrgId: suite.NotFoundUserID, Namespace: "folder", ObjectId: folderID, Relation: "owner", SubjectUserId: user.ID},
+		},
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	testCases := []struct {
+		name     string
+		orgID    int64
+		expected bool
+	}{
+		{name: "org of the tuple", orgID: org.ID, expected: true},
+		{name: "another org", orgID: otherOrg.ID, expected: false},
+		{name: "no org", orgID: entity.NoOrgID, expected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			checkResp, err := st.RelationsClient.Check(ctx, &ssov1.CheckRequest{
+				OrgId:     tc.orgID,
+				Namespace: "folder",
+				ObjectId:  folderID,
+				Relation:  "owner",
+				UserId:    user.ID,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, checkResp.GetAllowed())
+
+			readResp, err := st.RelationsClient.ReadTuples(ctx, &ssov1.ReadTuplesRequest{OrgId: tc.orgID, Namespace: "folder", ObjectId: folderID})
+			require.NoError(t, err)
+			if tc.expected {
+				require.Len(t, readResp.GetTuples(), 1)
+				assert.Equal(t, org.ID, readResp.GetTuples()[0].GetOrgId())
+			} else {
+				assert.Empty(t, readResp.GetTuples())
+			}
+		})
+	}
+}
+
+func TestOrgScopedRoles(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	ctx := context.Background()
+	m := models.New(st.NewTestStorage().DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, m))
+	user := suite.CreateActiveTestUser(t, m.User)
+	org := suite.CreateTestOrg(t, m)
+	otherOrg := suite.CreateTestOrg(t, m)
+	require.NoError(t, m.Org.AddMember(ctx, &entity.OrgMember{OrgID: org.ID, UserID: user.ID, Role: entity.DefaultUserRole}))
+	require.NoError(t, m.Org.AddMember(ctx, &entity.OrgMember{OrgID: otherOrg.ID, UserID: user.ID, Role: entity.DefaultUserRole}))
+
+	roleResp, err := st.PermissionsClient.CreateRole(adminCtx, &ssov1.CreateRoleRequest{
+		Name:  gofakeit.JobTitle() + gofakeit.DigitN(6),
+		OrgId: org.ID,
+	})
+	require.NoError(t, err)
+	role := roleResp.GetRole()
+	assert.Equal(t, org.ID, role.GetOrgId())
+
+	listResp, err := st.PermissionsClient.ListRoles(ctx, &ssov1.ListRolesRequest{OrgId: otherOrg.ID})
+	require.NoError(t, err)
+	for _, listed := range listResp.GetRoles() {
+		assert.NotEqual(t, role.GetId(), listed.GetId(), "roles of another org are not listed")
+	}
+
+	_, err = st.OrgsClient.UpdateOrgMemberRole(adminCtx, &ssov1.UpdateOrgMemberRoleRequest{OrgId: otherOrg.ID, UserId: user.ID, Role: role.GetName()})
+	assert.Equal(t, codes.NotFound, status.Code(err), "role of another org")
+	_, err = m.User.SetRole(ctx, user.ID, role.GetName())
+	assert.Error(t, err, "org role can't be the global role")
+	_, err = m.Org.UpdateMemberRole(ctx, otherOrg.ID, user.ID, role.GetName())
+	assert.Error(t, err, "storage refuses role of another org too")
+
+	memberResp, err := st.OrgsClient.UpdateOrgMemberRole(adminCtx, &ssov1.UpdateOrgMemberRoleRequest{OrgId: org.ID, UserId: user.ID, Role: role.GetName()})
+	require.NoError(t, err)
+	assert.Equal(t, role.GetName(), memberResp.GetMember().GetRole())
+}
@@ -0,0 +1,221 @@
+package orgs_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/entity"
+	"sso.service/internal/storage/postgres/models"
+	jwtLib "sso.service/pkg/jwt"
+	"sso.service/tests/suite"
+)
+
+func TestOrgMembership(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	ctx := context.Background()
+	m := models.New(st.NewTestStorage().DB)
+	user := suite.CreateActiveTestUser(t, m.User)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, m))
+
+	createResp, err := st.OrgsClient.CreateOrg(adminCtx, &ssov1.CreateOrgRequest{Name: gofakeit.Company() + gofakeit.DigitN(6)})
+	require.NoError(t, err)
+	orgID := createResp.GetOrg().GetId()
+	_, err = st.OrgsClient.CreateOrg(adminCtx, &ssov1.CreateOrgRequest{Name: createResp.GetOrg().GetName()})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	addResp, err := st.OrgsClient.AddOrgMember(adminCtx, &ssov1.AddOrgMemberRequest{OrgId: orgID, UserId: user.ID})
+	require.NoError(t, err)
+	assert.Equal(t, entity.DefaultUserRole, addResp.GetMember().GetRole())
+	_, err = st.OrgsClient.AddOrgMember(adminCtx, &ssov1.AddOrgMemberRequest{OrgId: orgID, UserId: user.ID})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = st.OrgsClient.UpdateOrgMemberRole(adminCtx, &ssov1.UpdateOrgMemberRoleRequest{OrgId: orgID, UserId: user.ID, Role: "unknown-role"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = st.OrgsClient.UpdateOrgMemberRole(adminCtx, &ssov1.UpdateOrgMemberRoleRequest{OrgId: orgID, UserId: user.ID, Role: entity.RoleModerator})
+	require.NoError(t, err)
+
+	orgsResp, err := st.OrgsClient.ListUserOrgs(ctx, &ssov1.ListUserOrgsRequest{UserId: user.ID})
+	require.NoError(t, err)
+	require.Len(t, orgsResp.GetOrgs(), 1)
+	assert.Equal(t, orgID, orgsResp.GetOrgs()[0].GetId())
+
+	_, err = st.OrgsClient.RemoveOrgMember(adminCtx, &ssov1.RemoveOrgMemberRequest{OrgId: orgID, UserId: user.ID})
+	require.NoError(t, err)
+	membersResp, err := st.OrgsClient.ListOrgMembers(ctx, &ssov1.ListOrgMembersRequest{OrgId: orgID})
+	require.NoError(t, err)
+	assert.Empty(t, membersResp.GetMembers())
+}
+
+func TestOrgScopedPermissions(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	ctx := context.Background()
+	m := models.New(st.NewTestStorage().DB)
+	user := suite.CreateActiveTestUser(t, m.User)
+	org := suite.CreateTestOrg(t, m)
+	otherOrg := suite.CreateTestOrg(t, m)
+	orgApp := suite.CreateTestApp(t, m, &entity.App{OrgID: org.ID})
+	otherOrgApp := suite.CreateTestApp(t, m, &entity.App{OrgID: otherOrg.ID})
+	require.NoError(t, m.Org.AddMember(ctx, &entity.OrgMember{OrgID: org.ID, UserID: user.ID, Role: entity.DefaultUserRole}))
+	code := "invoices." + gofakeit.Username() + ":read"
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, m))
+
+	_, err := st.PermissionsClient.GrantPermissions(adminCtx, &ssov1.GrantPermissionsRequest{
+		UserId:          user.ID,
+		OrgId:           otherOrg.ID,
+		PermissionCodes: []string{code},
+	})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = st.PermissionsClient.GrantPermissions(adminCtx, &ssov1.GrantPermissionsRequest{
+		UserId:          user.ID,
+		OrgId:           org.ID,
+		PermissionCodes: []string{code},
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		appID    int32
+		expected bool
+	}{
+		{name: "app of the org", appID: int32(orgApp.ID), expected: true},
+		{name: "app of another org", appID: int32(otherOrgApp.ID), expected: false},
+		{name: "shared app", appID: suite.AppID, expected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := st.PermissionsClient.CheckPermission(ctx, &ssov1.CheckPermissionRequest{
+				UserId:         user.ID,
+				AppId:          tc.appID,
+				PermissionCode: code,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, resp.GetHasPermission())
+		})
+	}
+}
+
+func TestOrgManagementRequiresAdmin(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	m := models.New(st.NewTestStorage().DB)
+	org := suite.CreateTestOrg(t, m)
+	otherOrg := suite.CreateTestOrg(t, m)
+	user := suite.CreateActiveTestUser(t, m.User)
+	orgMember := suite.CreateActiveTestUser(t, m.User)
+	otherOrgAdmin := suite.CreateActiveTestUser(t, m.User)
+	require.NoError(t, m.Org.AddMember(context.Background(), &entity.OrgMember{OrgID: org.ID, UserID: user.ID, Role: entity.DefaultUserRole}))
+	require.NoError(t, m.Org.AddMember(context.Background(), &entity.OrgMember{OrgID: org.ID, UserID: orgMember.ID, Role: entity.RoleModerator}))
+	require.NoError(t, m.Org.AddMember(context.Background(), &entity.OrgMember{OrgID: otherOrg.ID, UserID: otherOrgAdmin.ID, Role: entity.RoleAdmin}))
+	calls := map[string]func(ctx context.Context) error{
+		"create": func(ctx context.Context) error {
+			_, err := st.OrgsClient.CreateOrg(ctx, &ssov1.CreateOrgRequest{Name: gofakeit.Company() + gofakeit.DigitN(6)})
+			return err
+		},
+		"delete": func(ctx context.Context) error {
+			_, err := st.OrgsClient.DeleteOrg(ctx, &ssov1.DeleteOrgRequest{Id: org.ID})
+			return err
+		},
+		"add member": func(ctx context.Context) error {
+			_, err := st.OrgsClient.AddOrgMember(ctx, &ssov1.AddOrgMemberRequest{OrgId: org.ID, UserId: otherOrgAdmin.ID})
+			return err
+		},
+		"update member role": func(ctx context.Context) error {
+			_, err := st.OrgsClient.UpdateOrgMemberRole(ctx, &ssov1.UpdateOrgMemberRoleRequest{OrgId: org.ID, UserId: user.ID, Role: entity.RoleAdmin})
+			return err
+		},
+		"remove member": func(ctx context.Context) error {
+			_, err := st.OrgsClient.RemoveOrgMember(ctx, &ssov1.RemoveOrgMemberRequest{OrgId: org.ID, UserId: orgMember.ID})
+			return err
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, codes.Unauthenticated, status.Code(call(context.Background())))
+			assert.Equal(t, codes.PermissionDenied, status.Code(call(st.AuthorizedContext(user))), "member of the org")
+			assert.Equal(t, codes.PermissionDenied, status.Code(call(st.AuthorizedContext(orgMember))), "moderator of the org")
+			assert.Equal(t, codes.PermissionDenied, status.Code(call(st.AuthorizedContext(otherOrgAdmin))), "admin of another org")
+		})
+	}
+	members, err := m.Org.ListMembers(context.Background(), org.ID)
+	require.NoError(t, err)
+	assert.Len(t, members, 2)
+}
+
+func TestOrgAdminManagesMembers(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	m := models.New(st.NewTestStorage().DB)
+	org := suite.CreateTestOrg(t, m)
+	orgAdmin := suite.CreateActiveTestUser(t, m.User)
+	user := suite.CreateActiveTestUser(t, m.User)
+	require.NoError(t, m.Org.AddMember(context.Background(), &entity.OrgMember{OrgID: org.ID, UserID: orgAdmin.ID, Role: entity.RoleAdmin}))
+	ctx := st.AuthorizedContext(orgAdmin)
+
+	_, err := st.OrgsClient.CreateOrg(ctx, &ssov1.CreateOrgRequest{Name: gofakeit.Company() + gofakeit.DigitN(6)})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "org admins can't create orgs")
+	_, err = st.OrgsClient.AddOrgMember(ctx, &ssov1.AddOrgMemberRequest{OrgId: org.ID, UserId: user.ID})
+	require.NoError(t, err)
+	_, err = st.OrgsClient.UpdateOrgMemberRole(ctx, &ssov1.UpdateOrgMemberRoleRequest{OrgId: org.ID, UserId: user.ID, Role: entity.RoleModerator})
+	require.NoError(t, err)
+	_, err = st.OrgsClient.RemoveOrgMember(ctx, &ssov1.RemoveOrgMemberRequest{OrgId: org.ID, UserId: user.ID})
+	require.NoError(t, err)
+	_, err = st.OrgsClient.DeleteOrg(ctx, &ssov1.DeleteOrgRequest{Id: org.ID})
+	require.NoError(t, err)
+}
+
+func TestLoginWithinOrg(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	ctx := context.Background()
+	m := models.New(st.NewTestStorage().DB)
+	member := suite.CreateActiveTestUser(t, m.User)
+	stranger := suite.CreateActiveTestUser(t, m.User)
+	org := suite.CreateTestOrg(t, m)
+	otherOrg := suite.CreateTestOrg(t, m)
+	orgApp := suite.CreateTestApp(t, m, &entity.App{OrgID: org.ID})
+	require.NoError(t, m.Org.AddMember(ctx, &entity.OrgMember{OrgID: org.ID, UserID: member.ID, Role: entity.RoleModerator}))
+	testCases := []struct {
+		name         string
+		user         *entity.User
+		appID        int32
+		orgID        int64
+		expectedCode codes.Code
+		expectedOrg  int64
+	}{
+		{name: "member in app of the org", user: member, appID: int32(orgApp.ID), expectedCode: codes.OK, expectedOrg: org.ID},
+		{name: "member in shared app with org", user: member, appID: suite.AppID, orgID: org.ID, expectedCode: codes.OK, expectedOrg: org.ID},
+		{name: "member in shared app without org", user: member, appID: suite.AppID, expectedCode: codes.OK},
+		{name: "not a member", user: stranger, appID: int32(orgApp.ID), expectedCode: codes.PermissionDenied},
+		{name: "org not owning the app", user: member, appID: int32(orgApp.ID), orgID: otherOrg.ID, expectedCode: codes.PermissionDenied},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := st.AuthClient.Login(ctx, &ssov1.LoginRequest{
+				Email:    tc.user.Email,
+				Password: tc.user.Password.Plaintext,
+				AppId:    tc.appID,
+				OrgId:    tc.orgID,
+			})
+			require.Equal(t, tc.expectedCode, status.Code(err))
+			if tc.expectedCode != codes.OK {
+				return
+			}
+			secret := suite.AppSecret
+			if tc.appID == int32(orgApp.ID) {
+				secret = orgApp.Secret
+			}
+			claims, err := jwtLib.NewTokenProvider(secret, st.Cfg.TokenSigningAlg).ParseClaimsFromToken(resp.GetAccessToken())
+			require.NoError(t, err)
+			orgID, ok := jwtLib.OrgIDFromClaims(claims)
+			assert.Equal(t, tc.expectedOrg != entity.NoOrgID, ok)
+			assert.Equal(t, tc.expectedOrg, orgID)
+		})
+	}
+}
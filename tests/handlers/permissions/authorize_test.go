@@ -0,0 +1,240 @@
+package permissions_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/entity"
+	"sso.service/internal/storage/postgres/models"
+	"sso.service/tests/suite"
+)
+
+func mustStruct(t *testing.T, m map[string]any) *structpb.Struct {
+	s, err := structpb.NewStruct(m)
+	require.NoError(t, err)
+	return s
+}
+
+func TestAuthorize(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	ctx := context.Background()
+	m := models.New(st.NewTestStorage().DB)
+	userModel := m.User
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, m))
+	owner := suite.CreateActiveTestUser(t, userModel)
+	stranger := suite.CreateActiveTestUser(t, userModel)
+	namespace := "posts" + gofakeit.DigitN(8)
+	allowOwner, err := st.PermissionsClient.CreatePolicy(adminCtx, &ssov1.CreatePolicyRequest{
+		AppId:     suite.AppID,
+		Name:      "owner edits " + namespace,
+		Effect:    entity.PolicyAllow,
+		Actions:   []string{namespace + ":*"},
+		Condition: `resource.owner_id == subject.id`,
+	})
+	require.NoError(t, err)
+	denyArchived, err := st.PermissionsClient.CreatePolicy(adminCtx, &ssov1.CreatePolicyRequest{
+		AppId:     entity.GlobalAppID,
+		Name:      "archived are read only " + namespace,
+		Effect:    entity.PolicyDeny,
+		Actions:   []string{namespace + ":edit"},
+		Condition: `resource.archived == true`,
+	})
+	require.NoError(t, err)
+	// comparing a missing level fails to evaluate
+	denyProtected, err := st.PermissionsClient.CreatePolicy(adminCtx, &ssov1.CreatePolicyRequest{
+		AppId:     suite.AppID,
+		Name:      "protected are not deleted " + namespace,
+		Effect:    entity.PolicyDeny,
+		Actions:   []string{namespace + ":delete"},
+		Condition: `resource.protection_level > 0`,
+	})
+	require.NoError(t, err)
+	testCases := []struct {
+		name             string
+		subjectID        int64
+		action           string
+		attributes       map[string]any
+		expectedCode     codes.Code
+		expectedAllowed  bool
+		expectedPolicyID int64
+	}{
+		{
+			name:             "owner",
+			subjectID:        owner.ID,
+			action:           namespace + ":edit",
+			attributes:       map[string]any{"owner_id": owner.ID},
+			expectedCode:     codes.OK,
+			expectedAllowed:  true,
+			expectedPolicyID: allowOwner.GetPolicy().GetId(),
+		},
+		{
+			name:         "not owner",
+			subjectID:    stranger.ID,
+			action:       namespace + ":edit",
+			attributes:   map[string]any{"owner_id": owner.ID},
+			expectedCode: codes.OK,
+		},
+		{
+			name:             "deny overrides allow",
+			subjectID:        owner.ID,
+			action:           namespace + ":edit",
+			attributes:       map[string]any{"owner_id": owner.ID, "archived": true},
+			expectedCode:     codes.OK,
+			expectedPolicyID: denyArchived.GetPolicy().GetId(),
+		},
+		{
+			name:             "deny applies to its actions only",
+			subjectID:        owner.ID,
+			action:           namespace + ":read",
+			attributes:       map[string]any{"owner_id": owner.ID, "archived": true},
+			expectedCode:     codes.OK,
+			expectedAllowed:  true,
+			expectedPolicyID: allowOwner.GetPolicy().GetId(),
+		},
+		{
+			name:             "deny without protection",
+			subjectID:        owner.ID,
+			action:           namespace + ":delete",
+			attributes:       map[string]any{"owner_id": owner.ID, "protection_level": 0},
+			expectedCode:     codes.OK,
+			expectedAllowed:  true,
+			expectedPolicyID: allowOwner.GetPolicy().GetId(),
+		},
+		{
+			name:             "deny failed to evaluate",
+			subjectID:        owner.ID,
+			action:           namespace + ":delete",
+			attributes:       map[string]any{"owner_id": owner.ID},
+			expectedCode:     codes.OK,
+			expectedPolicyID: denyProtected.GetPolicy().GetId(),
+		},
+		{
+			name:         "unknown subject",
+			subjectID:    suite.NotFoundUserID,
+			action:       namespace + ":edit",
+			expectedCode: codes.NotFound,
+		},
+		{
+			name:         "invalid action",
+			subjectID:    owner.ID,
+			action:       namespace + ":*",
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := st.PermissionsClient.Authorize(ctx, &ssov1.AuthorizeRequest{
+				SubjectId: tc.subjectID,
+				AppId:     suite.AppID,
+				Action:    tc.action,
+				Resource:  &ssov1.Resource{Type: "post", Id: "1", Attributes: mustStruct(t, tc.attributes)},
+			})
+			require.Equal(t, tc.expectedCode, status.Code(err))
+			assert.Equal(t, tc.expectedAllowed, resp.GetAllowed())
+			assert.Equal(t, tc.expectedPolicyID, resp.GetPolicy().GetId())
+		})
+	}
+}
+
+func TestDryRunAuthorize(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	ctx := context.Background()
+	user := suite.CreateActiveTestUser(t, models.New(st.NewTestStorage().DB).User)
+	namespace := "reports" + gofakeit.DigitN(8)
+	req := &ssov1.AuthorizeRequest{
+		SubjectId:         user.ID,
+		AppId:             suite.AppID,
+		Action:            namespace + ":read",
+		SubjectAttributes: mustStruct(t, map[string]any{"region": "eu"}),
+		Resource:          &ssov1.Resource{Type: "report", Attributes: mustStruct(t, map[string]any{"region": "eu"})},
+	}
+	draft := &ssov1.Policy{
+		Name:      "same region " + namespace,
+		Effect:    entity.PolicyAllow,
+		Actions:   []string{namespace + ".*"},
+		Condition: `subject.region == resource.region && subject.role == "user"`,
+		IsEnabled: true,
+	}
+	resp, err := st.PermissionsClient.DryRunAuthorize(ctx, &ssov1.DryRunAuthorizeRequest{
+		Request: req,
+		Drafts:  []*ssov1.Policy{draft},
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.GetAllowed())
+	assert.Equal(t, draft.GetName(), resp.GetPolicy().GetName())
+	require.Len(t, resp.GetEvaluations(), 1)
+	assert.True(t, resp.GetEvaluations()[0].GetMatched())
+	// draft is not saved
+	authorizeResp, err := st.PermissionsClient.Authorize(ctx, req)
+	require.NoError(t, err)
+	assert.False(t, authorizeResp.GetAllowed())
+
+	draft.Condition = `subject.region > 1`
+	resp, err = st.PermissionsClient.DryRunAuthorize(ctx, &ssov1.DryRunAuthorizeRequest{
+		Request: req,
+		Drafts:  []*ssov1.Policy{draft},
+	})
+	require.NoError(t, err)
+	assert.False(t, resp.GetAllowed())
+	require.Len(t, resp.GetEvaluations(), 1)
+	assert.NotEmpty(t, resp.GetEvaluations()[0].GetError())
+
+	draft.Condition = `subject.region ==`
+	_, err = st.PermissionsClient.DryRunAuthorize(ctx, &ssov1.DryRunAuthorizeRequest{
+		Request: req,
+		Drafts:  []*ssov1.Policy{draft},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestPolicyManagementRequiresAdmin(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	m := models.New(st.NewTestStorage().DB)
+	policy, err := st.PermissionsClient.CreatePolicy(st.AuthorizedContext(suite.CreateTestAdmin(t, m)), &ssov1.CreatePolicyRequest{
+		Name:    "requires admin " + gofakeit.LetterN(10),
+		Effect:  entity.PolicyDeny,
+		Actions: []string{"admin" + gofakeit.DigitN(8) + ":*"},
+	})
+	require.NoError(t, err)
+	userCtx := st.AuthorizedContext(suite.CreateActiveTestUser(t, m.User))
+	calls := map[string]func(ctx context.Context) error{
+		"create": func(ctx context.Context) error {
+			_, err := st.PermissionsClient.CreatePolicy(ctx, &ssov1.CreatePolicyRequest{
+				Name:    "allow everything " + gofakeit.LetterN(10),
+				Effect:  entity.PolicyAllow,
+				Actions: []string{"*"},
+			})
+			return err
+		},
+		"update": func(ctx context.Context) error {
+			_, err := st.PermissionsClient.UpdatePolicy(ctx, &ssov1.UpdatePolicyRequest{
+				Id:        policy.GetPolicy().GetId(),
+				Name:      policy.GetPolicy().GetName(),
+				Effect:    entity.PolicyAllow,
+				Actions:   []string{"*"},
+				IsEnabled: true,
+			})
+			return err
+		},
+		"delete": func(ctx context.Context) error {
+			_, err := st.PermissionsClient.DeletePolicy(ctx, &ssov1.DeletePolicyRequest{Id: policy.GetPolicy().GetId()})
+			return err
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, codes.Unauthenticated, status.Code(call(context.Background())))
+			assert.Equal(t, codes.PermissionDenied, status.Code(call(userCtx)))
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package permissions_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/storage/postgres/models"
+	"sso.service/tests/suite"
+)
+
+func TestGetCacheStats(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	ctx := context.Background()
+	models := models.New(st.NewTestStorage().DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	user := suite.CreateActiveTestUser(t, models.User)
+
+	_, err := st.PermissionsClient.GetCacheStats(ctx, &ssov1.GetCacheStatsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = st.PermissionsClient.GetCacheStats(st.AuthorizedContext(user), &ssov1.GetCacheStatsRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	before, err := st.PermissionsClient.GetCacheStats(adminCtx, &ssov1.GetCacheStatsRequest{})
+	require.NoError(t, err)
+	for range 2 {
+		_, err = st.PermissionsClient.CheckPermission(ctx, &ssov1.CheckPermissionRequest{
+			UserId:         user.ID,
+			PermissionCode: gofakeit.Username(),
+		})
+		require.NoError(t, err)
+	}
+	after, err := st.PermissionsClient.GetCacheStats(adminCtx, &ssov1.GetCacheStatsRequest{})
+	require.NoError(t, err)
+	// other tests use the cache concurrently, so only the growth of the counters is checked
+	assert.GreaterOrEqual(t, after.GetHits()+after.GetMisses(), before.GetHits()+before.GetMisses()+2)
+}
@@ -3,6 +3,7 @@ package permissions_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/brianvoe/gofakeit/v7"
 	"github.com/stretchr/testify/assert"
@@ -10,6 +11,8 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
 	"sso.service/internal/storage/postgres/models"
 	"sso.service/tests/suite"
 )
@@ -21,10 +24,35 @@ func TestCheckPermission(t *testing.T) {
 	models := models.New(storage.DB)
 	user := suite.CreateActiveTestUser(t, models.User)
 	grantedPerms := []string{gofakeit.Username(), gofakeit.Username()}
-	err := models.Permission.CreateManyIgnoreConflict(context.Background(), grantedPerms)
+	err := models.Permission.CreateManyIgnoreConflict(context.Background(), entity.GlobalAppID, grantedPerms)
 	require.NoError(t, err)
-	_, err = models.Permission.AddForUserIgnoreConflict(context.Background(), user.ID, grantedPerms)
+	_, err = models.Permission.AddForUserIgnoreConflict(context.Background(), user.ID, entity.GlobalAppID, grantedPerms)
 	require.NoError(t, err)
+	wildcardNamespace := gofakeit.Username()
+	wildcardPerms := []string{wildcardNamespace + ":*", "billing.*"}
+	userWithWildcards := suite.CreateActiveTestUser(t, models.User)
+	err = models.Permission.CreateManyIgnoreConflict(context.Background(), entity.GlobalAppID, wildcardPerms)
+	require.NoError(t, err)
+	_, err = models.Permission.AddForUserIgnoreConflict(context.Background(), userWithWildcards.ID, entity.GlobalAppID, wildcardPerms)
+	require.NoError(t, err)
+	expiredPerm := gofakeit.Username()
+	expiredAt := time.Now().Add(-time.Minute)
+	err = models.Permission.CreateManyIgnoreConflict(context.Background(), entity.GlobalAppID, []string{expiredPerm})
+	require.NoError(t, err)
+	_, err = models.Permission.GrantForUser(context.Background(), user.ID, entity.GlobalAppID, entity.NoOrgID, []dtos.PermissionGrantDTO{
+		{Code: expiredPerm, ExpiresAt: &expiredAt},
+	})
+	require.NoError(t, err)
+	appPerm := gofakeit.Username()
+	err = models.Permission.CreateManyIgnoreConflict(context.Background(), suite.AppID, []string{appPerm})
+	require.NoError(t, err)
+	_, err = models.Permission.AddForUserIgnoreConflict(context.Background(), user.ID, suite.AppID, []string{appPerm})
+	require.NoError(t, err)
+	rolePerm := gofakeit.Username()
+	role := suite.CreateTestRole(t, models, rolePerm)
+	userWithRole := suite.NewTestUser(t, true)
+	userWithRole.Role = role.Name
+	suite.SaveTestUser(t, models.User, userWithRole)
 	testCases := []struct {
 		name            string
 		req             *ssov1.CheckPermissionRequest
@@ -49,6 +77,97 @@ func TestCheckPermission(t *testing.T) {
 			expectedCode:    codes.OK,
 			expectedHasPerm: false,
 		},
+		{
+			name: "valid granted through role",
+			req: &ssov1.CheckPermissionRequest{
+				UserId:         userWithRole.ID,
+				PermissionCode: rolePerm,
+			},
+			expectedCode:    codes.OK,
+			expectedHasPerm: true,
+		},
+		{
+			name: "valid role perm not granted to other users",
+			req: &ssov1.CheckPermissionRequest{
+				UserId:         user.ID,
+				PermissionCode: rolePerm,
+			},
+			expectedCode:    codes.OK,
+			expectedHasPerm: false,
+		},
+		{
+			name: "valid covered by action wildcard",
+			req: &ssov1.CheckPermissionRequest{
+				UserId:         userWithWildcards.ID,
+				PermissionCode: wildcardNamespace + ":edit",
+			},
+			expectedCode:    codes.OK,
+			expectedHasPerm: true,
+		},
+		{
+			name: "valid covered by namespace wildcard",
+			req: &ssov1.CheckPermissionRequest{
+				UserId:         userWithWildcards.ID,
+				PermissionCode: "billing.invoices.read",
+			},
+			expectedCode:    codes.OK,
+			expectedHasPerm: true,
+		},
+		{
+			name: "valid not covered by wildcard",
+			req: &ssov1.CheckPermissionRequest{
+				UserId:         userWithWildcards.ID,
+				PermissionCode: wildcardNamespace + ".comments:edit",
+			},
+			expectedCode:    codes.OK,
+			expectedHasPerm: false,
+		},
+		{
+			name: "wildcard perm code",
+			req: &ssov1.CheckPermissionRequest{
+				UserId:         user.ID,
+				PermissionCode: "billing.*",
+			},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name: "valid global perm checked for app",
+			req: &ssov1.CheckPermissionRequest{
+				UserId:         user.ID,
+				AppId:          suite.AppID,
+				PermissionCode: grantedPerms[0],
+			},
+			expectedCode:    codes.OK,
+			expectedHasPerm: true,
+		},
+		{
+			name: "valid app perm checked for app",
+			req: &ssov1.CheckPermissionRequest{
+				UserId:         user.ID,
+				AppId:          suite.AppID,
+				PermissionCode: appPerm,
+			},
+			expectedCode:    codes.OK,
+			expectedHasPerm: true,
+		},
+		{
+			name: "valid app perm checked globally",
+			req: &ssov1.CheckPermissionRequest{
+				UserId:         user.ID,
+				PermissionCode: appPerm,
+			},
+			expectedCode:    codes.OK,
+			expectedHasPerm: false,
+		},
+		{
+			name: "valid expired grant",
+			req: &ssov1.CheckPermissionRequest{
+				UserId:         user.ID,
+				PermissionCode: expiredPerm,
+			},
+			expectedCode:    codes.OK,
+			expectedHasPerm: false,
+		},
 		{
 			name: "not found UserId",
 			req: &ssov1.CheckPermissionRequest{
@@ -0,0 +1,108 @@
+package permissions_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/entity"
+	"sso.service/internal/storage/postgres/models"
+	"sso.service/tests/suite"
+)
+
+func TestCheckPermissions(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	user := suite.CreateActiveTestUser(t, models.User)
+	grantedPerm := gofakeit.Username()
+	wildcardNamespace := gofakeit.Username()
+	grantedPerms := []string{grantedPerm, wildcardNamespace + ":*"}
+	err := models.Permission.CreateManyIgnoreConflict(context.Background(), entity.GlobalAppID, grantedPerms)
+	require.NoError(t, err)
+	_, err = models.Permission.AddForUserIgnoreConflict(context.Background(), user.ID, entity.GlobalAppID, grantedPerms)
+	require.NoError(t, err)
+	notGrantedPerm := gofakeit.Username()
+	testCases := []struct {
+		name            string
+		req             *ssov1.CheckPermissionsRequest
+		expectedCode    codes.Code
+		expectedResults map[string]bool
+	}{
+		{
+			name: "valid",
+			req: &ssov1.CheckPermissionsRequest{
+				UserId:          user.ID,
+				PermissionCodes: []string{grantedPerm, wildcardNamespace + ":edit", notGrantedPerm},
+			},
+			expectedCode: codes.OK,
+			expectedResults: map[string]bool{
+				grantedPerm:                 true,
+				wildcardNamespace + ":edit": true,
+				notGrantedPerm:              false,
+			},
+		},
+		{
+			name: "not found UserId",
+			req: &ssov1.CheckPermissionsRequest{
+				UserId:          suite.NotFoundUserID,
+				PermissionCodes: []string{grantedPerm},
+			},
+			expectedCode: codes.NotFound,
+		},
+		{
+			name: "empty codes",
+			req: &ssov1.CheckPermissionsRequest{
+				UserId: user.ID,
+			},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := st.PermissionsClient.CheckPermissions(context.Background(), tc.req)
+			respStatus := status.Code(err)
+			t.Log("Actual code", respStatus, "Expected", tc.expectedCode)
+			require.Equal(t, tc.expectedCode, respStatus)
+			if tc.expectedCode == codes.OK {
+				assert.Equal(t, tc.expectedResults, resp.GetResults())
+			}
+		})
+	}
+}
+
+func TestCheckPermissionsForUsers(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	userWithPerm := suite.CreateActiveTestUser(t, models.User)
+	userWithoutPerm := suite.CreateActiveTestUser(t, models.User)
+	perm := gofakeit.Username()
+	err := models.Permission.CreateManyIgnoreConflict(context.Background(), entity.GlobalAppID, []string{perm})
+	require.NoError(t, err)
+	_, err = models.Permission.AddForUserIgnoreConflict(context.Background(), userWithPerm.ID, entity.GlobalAppID, []string{perm})
+	require.NoError(t, err)
+	resp, err := st.PermissionsClient.CheckPermissionsForUsers(context.Background(), &ssov1.CheckPermissionsForUsersRequest{
+		UserIds:        []int64{userWithPerm.ID, suite.NotFoundUserID, userWithoutPerm.ID},
+		PermissionCode: perm,
+	})
+	require.NoError(t, err)
+	results := resp.GetResults()
+	require.Len(t, results, 3)
+	assert.Equal(t, userWithPerm.ID, results[0].GetUserId())
+	assert.True(t, results[0].GetUserFound())
+	assert.True(t, results[0].GetHasPermission())
+	assert.Equal(t, suite.NotFoundUserID, results[1].GetUserId())
+	assert.False(t, results[1].GetUserFound())
+	assert.False(t, results[1].GetHasPermission())
+	assert.Equal(t, userWithoutPerm.ID, results[2].GetUserId())
+	assert.True(t, results[2].GetUserFound())
+	assert.False(t, results[2].GetHasPermission())
+}
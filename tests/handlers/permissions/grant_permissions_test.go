@@ -3,6 +3,7 @@ package permissions_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/brianvoe/gofakeit/v7"
 	"github.com/stretchr/testify/assert"
@@ -21,6 +22,9 @@ func TestGrantPermissions(t *testing.T) {
 	models := models.New(storage.DB)
 	permCodes := []string{gofakeit.Username(), gofakeit.Username()}
 	user := suite.CreateActiveTestUser(t, models.User)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	wildcardNamespace := gofakeit.Username()
+	temporaryPermCode := gofakeit.Username()
 	testCases := []struct {
 		name              string
 		req               *ssov1.GrantPermissionsRequest
@@ -49,7 +53,7 @@ func TestGrantPermissions(t *testing.T) {
 			name: "not found UserId",
 			req: &ssov1.GrantPermissionsRequest{
 				UserId:          suite.NotFoundUserID,
-				PermissionCodes: []string{gofakeit.Email(), gofakeit.Email()},
+				PermissionCodes: []string{gofakeit.Username(), gofakeit.Username()},
 			},
 			expectedCode: codes.NotFound,
 		},
@@ -61,6 +65,73 @@ func TestGrantPermissions(t *testing.T) {
 			},
 			expectedCode: codes.InvalidArgument,
 		},
+		{
+			name: "valid with wildcard codes",
+			req: &ssov1.GrantPermissionsRequest{
+				UserId:          user.ID,
+				PermissionCodes: []string{wildcardNamespace + ".*"},
+			},
+			expectedCode:      codes.OK,
+			expectedPermCodes: []string{wildcardNamespace + ".*"},
+		},
+		{
+			name: "valid temporary grant",
+			req: &ssov1.GrantPermissionsRequest{
+				UserId: user.ID,
+				Grants: []*ssov1.PermissionGrant{
+					{Code: temporaryPermCode, TtlSeconds: 3600},
+				},
+			},
+			expectedCode:      codes.OK,
+			expectedPermCodes: []string{temporaryPermCode},
+		},
+		{
+			name: "valid app scoped grant",
+			req: &ssov1.GrantPermissionsRequest{
+				UserId:          user.ID,
+				AppId:           suite.AppID,
+				PermissionCodes: permCodes,
+			},
+			expectedCode:      codes.OK,
+			expectedPermCodes: permCodes,
+		},
+		{
+			name: "not found app",
+			req: &ssov1.GrantPermissionsRequest{
+				UserId:          user.ID,
+				AppId:           int32(suite.NotFoundUserID),
+				PermissionCodes: permCodes,
+			},
+			expectedCode: codes.NotFound,
+		},
+		{
+			name: "expiry in the past",
+			req: &ssov1.GrantPermissionsRequest{
+				UserId: user.ID,
+				Grants: []*ssov1.PermissionGrant{
+					{Code: gofakeit.Username(), ExpiresAt: time.Now().Add(-time.Hour).Unix()},
+				},
+			},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name: "both expires_at and ttl",
+			req: &ssov1.GrantPermissionsRequest{
+				UserId: user.ID,
+				Grants: []*ssov1.PermissionGrant{
+					{Code: gofakeit.Username(), ExpiresAt: time.Now().Add(time.Hour).Unix(), TtlSeconds: 60},
+				},
+			},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name: "invalid permission code",
+			req: &ssov1.GrantPermissionsRequest{
+				UserId:          user.ID,
+				PermissionCodes: []string{"articles::edit"},
+			},
+			expectedCode: codes.InvalidArgument,
+		},
 		{
 			name: "invalid user id",
 			req: &ssov1.GrantPermissionsRequest{
@@ -72,7 +143,7 @@ func TestGrantPermissions(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			resp, err := st.PermissionsClient.GrantPermissions(context.Background(), tc.req)
+			resp, err := st.PermissionsClient.GrantPermissions(adminCtx, tc.req)
 			respStatus := status.Code(err)
 			t.Log("Actual code", respStatus, "Expected", tc.expectedCode)
 			require.Equal(t, tc.expectedCode, respStatus)
@@ -92,3 +163,21 @@ func TestGrantPermissions(t *testing.T) {
 		})
 	}
 }
+
+func TestGrantPermissionsRequiresAdmin(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	user := suite.CreateActiveTestUser(t, models.User)
+	userCtx := st.AuthorizedContext(user)
+	req := &ssov1.GrantPermissionsRequest{UserId: user.ID, PermissionCodes: []string{gofakeit.Username()}}
+
+	_, err := st.PermissionsClient.GrantPermissions(context.Background(), req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = st.PermissionsClient.GrantPermissions(userCtx, req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "users can't grant permissions to themselves")
+	resp, err := st.PermissionsClient.ListUserPermissions(context.Background(), &ssov1.ListUserPermissionsRequest{UserId: user.ID})
+	require.NoError(t, err)
+	assert.Empty(t, resp.GetPermissions())
+}
@@ -0,0 +1,160 @@
+package permissions_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/entity"
+	"sso.service/internal/storage/postgres/models"
+	"sso.service/tests/suite"
+)
+
+func TestNestedGroupPermissions(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	ctx := context.Background()
+	user := suite.CreateActiveTestUser(t, models.User)
+	parent := suite.CreateTestGroup(t, models)
+	child := suite.CreateTestGroup(t, models)
+	permCode := gofakeit.Username() + ":read"
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+
+	_, err := st.PermissionsClient.AddGroupMembers(adminCtx, &ssov1.AddGroupMembersRequest{GroupId: parent.ID, GroupIds: []int64{child.ID}})
+	require.NoError(t, err)
+	_, err = st.PermissionsClient.AddGroupMembers(adminCtx, &ssov1.AddGroupMembersRequest{GroupId: child.ID, UserIds: []int64{user.ID}})
+	require.NoError(t, err)
+	_, err = st.PermissionsClient.GrantGroupPermissions(adminCtx, &ssov1.GrantGroupPermissionsRequest{
+		GroupId:         parent.ID,
+		AppId:           entity.GlobalAppID,
+		PermissionCodes: []string{permCode},
+	})
+	require.NoError(t, err)
+
+	checkResp, err := st.PermissionsClient.CheckPermission(ctx, &ssov1.CheckPermissionRequest{UserId: user.ID, PermissionCode: permCode})
+	require.NoError(t, err)
+	assert.True(t, checkResp.GetHasPermission())
+
+	listResp, err := st.PermissionsClient.ListUserPermissions(ctx, &ssov1.ListUserPermissionsRequest{UserId: user.ID})
+	require.NoError(t, err)
+	require.Len(t, listResp.GetPermissions(), 1)
+	perm := listResp.GetPermissions()[0]
+	assert.Equal(t, permCode, perm.GetPermission().GetCode())
+	assert.Equal(t, entity.PermissionSourceGroup, perm.GetSource())
+	assert.Equal(t, parent.ID, perm.GetSourceId())
+	assert.Equal(t, parent.Name, perm.GetSourceName())
+
+	_, err = st.PermissionsClient.RemoveGroupMembers(adminCtx, &ssov1.RemoveGroupMembersRequest{GroupId: parent.ID, GroupIds: []int64{child.ID}})
+	require.NoError(t, err)
+	checkResp, err = st.PermissionsClient.CheckPermission(ctx, &ssov1.CheckPermissionRequest{UserId: user.ID, PermissionCode: permCode})
+	require.NoError(t, err)
+	assert.False(t, checkResp.GetHasPermission())
+}
+
+func TestAddGroupMembers(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	user := suite.CreateActiveTestUser(t, models.User)
+	top := suite.CreateTestGroup(t, models)
+	middle := suite.CreateTestGroup(t, models)
+	bottom := suite.CreateTestGroup(t, models)
+	ctx := context.Background()
+	require.NoError(t, models.Group.AddMembers(ctx, top.ID, entity.GroupMembers{GroupIDs: []int64{middle.ID}}))
+	require.NoError(t, models.Group.AddMembers(ctx, middle.ID, entity.GroupMembers{GroupIDs: []int64{bottom.ID}}))
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	testCases := []struct {
+		name         string
+		req          *ssov1.AddGroupMembersRequest
+		expectedCode codes.Code
+	}{
+		{
+			name:         "valid",
+			req:          &ssov1.AddGroupMembersRequest{GroupId: bottom.ID, UserIds: []int64{user.ID}},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "indirect cycle",
+			req:          &ssov1.AddGroupMembersRequest{GroupId: bottom.ID, GroupIds: []int64{top.ID}},
+			expectedCode: codes.FailedPrecondition,
+		},
+		{
+			name:         "group into itself",
+			req:          &ssov1.AddGroupMembersRequest{GroupId: top.ID, GroupIds: []int64{top.ID}},
+			expectedCode: codes.FailedPrecondition,
+		},
+		{
+			name:         "user not found",
+			req:          &ssov1.AddGroupMembersRequest{GroupId: top.ID, UserIds: []int64{suite.NotFoundUserID}},
+			expectedCode: codes.NotFound,
+		},
+		{
+			name:         "group not found",
+			req:          &ssov1.AddGroupMembersRequest{GroupId: suite.NotFoundUserID, UserIds: []int64{user.ID}},
+			expectedCode: codes.NotFound,
+		},
+		{
+			name:         "no members",
+			req:          &ssov1.AddGroupMembersRequest{GroupId: top.ID},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := st.PermissionsClient.AddGroupMembers(adminCtx, tc.req)
+			respStatus := status.Code(err)
+			t.Log("Actual code", respStatus, "Expected", tc.expectedCode)
+			assert.Equal(t, tc.expectedCode, respStatus)
+		})
+	}
+}
+
+func TestGroupManagementRequiresAdmin(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	user := suite.CreateActiveTestUser(t, models.User)
+	group := suite.CreateTestGroup(t, models)
+	userCtx := st.AuthorizedContext(user)
+	calls := map[string]func(ctx context.Context) error{
+		"create": func(ctx context.Context) error {
+			_, err := st.PermissionsClient.CreateGroup(ctx, &ssov1.CreateGroupRequest{Name: "group-" + gofakeit.LetterN(10)})
+			return err
+		},
+		"delete": func(ctx context.Context) error {
+			_, err := st.PermissionsClient.DeleteGroup(ctx, &ssov1.DeleteGroupRequest{Id: group.ID})
+			return err
+		},
+		"add members": func(ctx context.Context) error {
+			_, err := st.PermissionsClient.AddGroupMembers(ctx, &ssov1.AddGroupMembersRequest{GroupId: group.ID, UserIds: []int64{user.ID}})
+			return err
+		},
+		"remove members": func(ctx context.Context) error {
+			_, err := st.PermissionsClient.RemoveGroupMembers(ctx, &ssov1.RemoveGroupMembersRequest{GroupId: group.ID, UserIds: []int64{user.ID}})
+			return err
+		},
+		"grant permissions": func(ctx context.Context) error {
+			_, err := st.PermissionsClient.GrantGroupPermissions(ctx, &ssov1.GrantGroupPermissionsRequest{GroupId: group.ID, PermissionCodes: []string{"*"}})
+			return err
+		},
+		"revoke permissions": func(ctx context.Context) error {
+			_, err := st.PermissionsClient.RevokeGroupPermissions(ctx, &ssov1.RevokeGroupPermissionsRequest{GroupId: group.ID, PermissionCodes: []string{"*"}})
+			return err
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, codes.Unauthenticated, status.Code(call(context.Background())))
+			assert.Equal(t, codes.PermissionDenied, status.Code(call(userCtx)))
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package permissions_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/require"
+	"sso.service/internal/entity"
+	"sso.service/internal/storage/postgres/models"
+	"sso.service/tests/suite"
+)
+
+func TestInvalidationListener(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	user := suite.CreateActiveTestUser(t, models.User)
+	invalidations := suite.ListenInvalidations(t, storage)
+
+	// changes made directly in the database are picked up as well as ones made by the replicas
+	_, err := storage.DB.Exec(context.Background(), "UPDATE users SET is_active = false WHERE id = $1", user.ID)
+	require.NoError(t, err)
+	invalidations.RequireReceived(t, strconv.FormatInt(user.ID, 10))
+}
+
+func TestOrgMembersInvalidation(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	ctx := context.Background()
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	user := suite.CreateActiveTestUser(t, models.User)
+	org := suite.CreateTestOrg(t, models)
+	invalidations := suite.ListenInvalidations(t, storage)
+	payload := strconv.FormatInt(user.ID, 10)
+
+	// permissions granted through the role in the org change with the membership
+	require.NoError(t, models.Org.AddMember(ctx, &entity.OrgMember{OrgID: org.ID, UserID: user.ID, Role: entity.DefaultUserRole}))
+	invalidations.RequireReceived(t, payload)
+	_, err := models.Org.UpdateMemberRole(ctx, org.ID, user.ID, entity.RoleModerator)
+	require.NoError(t, err)
+	invalidations.RequireReceived(t, payload)
+	require.NoError(t, models.Org.RemoveMember(ctx, org.ID, user.ID))
+	invalidations.RequireReceived(t, payload)
+}
+
+func TestGroupsInvalidation(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	ctx := context.Background()
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	user := suite.CreateActiveTestUser(t, models.User)
+	group := suite.CreateTestGroup(t, models)
+	permCode := gofakeit.Username() + ":read"
+	require.NoError(t, models.Permission.CreateManyIgnoreConflict(ctx, entity.GlobalAppID, []string{permCode}))
+	invalidations := suite.ListenInvalidations(t, storage)
+	// members of nested groups are affected as well, so every user's permissions are invalidated
+	const invalidateAll = "*"
+
+	require.NoError(t, models.Group.AddMembers(ctx, group.ID, entity.GroupMembers{UserIDs: []int64{user.ID}}))
+	invalidations.RequireReceived(t, invalidateAll)
+	require.NoError(t, models.Group.GrantPermissions(ctx, group.ID, entity.GlobalAppID, []string{permCode}))
+	invalidations.RequireReceived(t, invalidateAll)
+	require.NoError(t, models.Group.RevokePermissions(ctx, group.ID, entity.GlobalAppID, []string{permCode}))
+	invalidations.RequireReceived(t, invalidateAll)
+	require.NoError(t, models.Group.RemoveMembers(ctx, group.ID, entity.GroupMembers{UserIDs: []int64{user.ID}}))
+	invalidations.RequireReceived(t, invalidateAll)
+}
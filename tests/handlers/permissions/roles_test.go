@@ -0,0 +1,208 @@
+package permissions_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/entity"
+	"sso.service/internal/services/dtos"
+	"sso.service/internal/storage/postgres/models"
+	"sso.service/tests/suite"
+)
+
+func TestCreateRole(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	existingRole := suite.CreateTestRole(t, models)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	permCodes := []string{gofakeit.Username(), gofakeit.Username()}
+	testCases := []struct {
+		name              string
+		req               *ssov1.CreateRoleRequest
+		expectedCode      codes.Code
+		expectedPermCodes []string
+	}{
+		{
+			name: "valid",
+			req: &ssov1.CreateRoleRequest{
+				Name:            gofakeit.JobTitle() + gofakeit.DigitN(6),
+				Description:     gofakeit.Sentence(5),
+				PermissionCodes: permCodes,
+			},
+			expectedCode:      codes.OK,
+			expectedPermCodes: permCodes,
+		},
+		{
+			name: "already existing name",
+			req: &ssov1.CreateRoleRequest{
+				Name: existingRole.Name,
+			},
+			expectedCode: codes.AlreadyExists,
+		},
+		{
+			name: "builtin name",
+			req: &ssov1.CreateRoleRequest{
+				Name: entity.RoleAdmin,
+			},
+			expectedCode: codes.AlreadyExists,
+		},
+		{
+			name:         "empty name",
+			req:          &ssov1.CreateRoleRequest{},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := st.PermissionsClient.CreateRole(adminCtx, tc.req)
+			respStatus := status.Code(err)
+			t.Log("Actual code", respStatus, "Expected", tc.expectedCode)
+			require.Equal(t, tc.expectedCode, respStatus)
+			if tc.expectedCode == codes.OK {
+				assert.Equal(t, tc.req.GetName(), resp.GetRole().GetName())
+				assert.False(t, resp.GetRole().GetIsBuiltin())
+				var actualCodes []string
+				for _, perm := range resp.GetRole().GetPermissions() {
+					actualCodes = append(actualCodes, perm.GetCode())
+				}
+				assert.ElementsMatch(t, tc.expectedPermCodes, actualCodes)
+			}
+		})
+	}
+}
+
+func TestDeleteRole(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	unusedRole := suite.CreateTestRole(t, models)
+	usedRole := suite.CreateTestRole(t, models)
+	user := suite.NewTestUser(t, true)
+	user.Role = usedRole.Name
+	suite.SaveTestUser(t, models.User, user)
+	builtinRole, err := models.Role.Get(context.Background(), dtos.GetRoleOptionsDTO{Name: entity.RoleUser})
+	require.NoError(t, err)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	testCases := []struct {
+		name         string
+		req          *ssov1.DeleteRoleRequest
+		expectedCode codes.Code
+	}{
+		{
+			name:         "valid",
+			req:          &ssov1.DeleteRoleRequest{Id: unusedRole.ID},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "role assigned to users",
+			req:          &ssov1.DeleteRoleRequest{Id: usedRole.ID},
+			expectedCode: codes.FailedPrecondition,
+		},
+		{
+			name:         "builtin role",
+			req:          &ssov1.DeleteRoleRequest{Id: builtinRole.ID},
+			expectedCode: codes.FailedPrecondition,
+		},
+		{
+			name:         "not found",
+			req:          &ssov1.DeleteRoleRequest{Id: suite.NotFoundUserID},
+			expectedCode: codes.NotFound,
+		},
+		{
+			name:         "invalid id",
+			req:          &ssov1.DeleteRoleRequest{Id: 0},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := st.PermissionsClient.DeleteRole(adminCtx, tc.req)
+			respStatus := status.Code(err)
+			t.Log("Actual code", respStatus, "Expected", tc.expectedCode)
+			assert.Equal(t, tc.expectedCode, respStatus)
+		})
+	}
+}
+
+func TestSetRolePermissions(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	keptCode, removedCode := gofakeit.Username(), gofakeit.Username()
+	role := suite.CreateTestRole(t, models, keptCode, removedCode)
+	testCases := []struct {
+		name              string
+		permCodes         []string
+		expectedPermCodes []string
+	}{
+		{name: "replace", permCodes: []string{keptCode}, expectedPermCodes: []string{keptCode}},
+		{name: "clear", permCodes: nil, expectedPermCodes: nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := st.PermissionsClient.SetRolePermissions(adminCtx, &ssov1.SetRolePermissionsRequest{
+				RoleId:          role.ID,
+				AppId:           entity.GlobalAppID,
+				PermissionCodes: tc.permCodes,
+			})
+			require.NoError(t, err)
+			var actualCodes []string
+			for _, perm := range resp.GetRole().GetPermissions() {
+				actualCodes = append(actualCodes, perm.GetCode())
+			}
+			assert.ElementsMatch(t, tc.expectedPermCodes, actualCodes)
+		})
+	}
+}
+
+func TestRoleManagementRequiresAdmin(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	builtinRole, err := models.Role.Get(context.Background(), dtos.GetRoleOptionsDTO{Name: entity.RoleUser})
+	require.NoError(t, err)
+	userCtx := st.AuthorizedContext(suite.CreateActiveTestUser(t, models.User))
+	calls := map[string]func(ctx context.Context) error{
+		"create": func(ctx context.Context) error {
+			_, err := st.PermissionsClient.CreateRole(ctx, &ssov1.CreateRoleRequest{Name: gofakeit.JobTitle() + gofakeit.DigitN(6)})
+			return err
+		},
+		"update": func(ctx context.Context) error {
+			description := gofakeit.Sentence(3)
+			_, err := st.PermissionsClient.UpdateRole(ctx, &ssov1.UpdateRoleRequest{Id: builtinRole.ID, Description: &description})
+			return err
+		},
+		"delete": func(ctx context.Context) error {
+			_, err := st.PermissionsClient.DeleteRole(ctx, &ssov1.DeleteRoleRequest{Id: builtinRole.ID})
+			return err
+		},
+		"set permissions": func(ctx context.Context) error {
+			_, err := st.PermissionsClient.SetRolePermissions(ctx, &ssov1.SetRolePermissionsRequest{
+				RoleId:          builtinRole.ID,
+				PermissionCodes: []string{"*"},
+			})
+			return err
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, codes.Unauthenticated, status.Code(call(context.Background())))
+			assert.Equal(t, codes.PermissionDenied, status.Code(call(userCtx)))
+		})
+	}
+	role, err := models.Role.Get(context.Background(), dtos.GetRoleOptionsDTO{ID: builtinRole.ID})
+	require.NoError(t, err)
+	assert.Equal(t, builtinRole.Permissions, role.Permissions)
+}
@@ -0,0 +1,187 @@
+package relations_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/storage/postgres/models"
+	"sso.service/tests/suite"
+)
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	ctx := context.Background()
+	m := models.New(st.NewTestStorage().DB)
+	userModel := m.User
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, m))
+	folderOwner := suite.CreateActiveTestUser(t, userModel)
+	groupMember := suite.CreateActiveTestUser(t, userModel)
+	outsider := suite.CreateActiveTestUser(t, userModel)
+	folderID, documentID, groupID := gofakeit.UUID(), gofakeit.UUID(), gofakeit.UUID()
+	_, err := st.RelationsClient.WriteTuples(adminCtx, &ssov1.WriteTuplesRequest{
+		Writes: []*ssov1.RelationTuple{
+			{Namespace: "folder", ObjectId: folderID, Relation: "owner", SubjectUserId: folderOwner.ID},
+			{
+				Namespace:  "document",
+				ObjectId:   documentID,
+				Relation:   "parent",
+				SubjectSet: &ssov1.Userset{Namespace: "folder", ObjectId: folderID},
+			},
+			{Namespace: "group", ObjectId: groupID, Relation: "member", SubjectUserId: groupMember.ID},
+			{
+				Namespace:  "document",
+				ObjectId:   documentID,
+				Relation:   "viewer",
+				SubjectSet: &ssov1.Userset{Namespace: "group", ObjectId: groupID, Relation: "member"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	testCases := []struct {
+		name            string
+		req             *ssov1.CheckRequest
+		expectedCode    codes.Code
+		expectedAllowed bool
+	}{
+		{
+			name:            "direct tuple",
+			req:             &ssov1.CheckRequest{Namespace: "folder", ObjectId: folderID, Relation: "owner", UserId: folderOwner.ID},
+			expectedCode:    codes.OK,
+			expectedAllowed: true,
+		},
+		{
+			name:            "computed userset",
+			req:             &ssov1.CheckRequest{Namespace: "folder", ObjectId: folderID, Relation: "editor", UserId: folderOwner.ID},
+			expectedCode:    codes.OK,
+			expectedAllowed: true,
+		},
+		{
+			name:            "tuple to userset",
+			req:             &ssov1.CheckRequest{Namespace: "document", ObjectId: documentID, Relation: "viewer", UserId: folderOwner.ID},
+			expectedCode:    codes.OK,
+			expectedAllowed: true,
+		},
+		{
+			name:            "userset subject",
+			req:             &ssov1.CheckRequest{Namespace: "document", ObjectId: documentID, Relation: "viewer", UserId: groupMember.ID},
+			expectedCode:    codes.OK,
+			expectedAllowed: true,
+		},
+		{
+			name:         "userset subject grants nothing more",
+			req:          &ssov1.CheckRequest{Namespace: "document", ObjectId: documentID, Relation: "editor", UserId: groupMember.ID},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "no relation",
+			req:          &ssov1.CheckRequest{Namespace: "document", ObjectId: documentID, Relation: "viewer", UserId: outsider.ID},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "unknown namespace",
+			req:          &ssov1.CheckRequest{Namespace: "unknown", ObjectId: documentID, Relation: "viewer", UserId: outsider.ID},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "unknown relation",
+			req:          &ssov1.CheckRequest{Namespace: "document", ObjectId: documentID, Relation: "unknown", UserId: outsider.ID},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := st.RelationsClient.Check(ctx, tc.req)
+			require.Equal(t, tc.expectedCode, status.Code(err))
+			assert.Equal(t, tc.expectedAllowed, resp.GetAllowed())
+		})
+	}
+}
+
+func TestWriteAndReadTuples(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	ctx := context.Background()
+	m := models.New(st.NewTestStorage().DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, m))
+	user := suite.CreateActiveTestUser(t, m.User)
+	documentID := gofakeit.UUID()
+	tuple := &ssov1.RelationTuple{Namespace: "document", ObjectId: documentID, Relation: "owner", SubjectUserId: user.ID}
+	_, err := st.RelationsClient.WriteTuples(adminCtx, &ssov1.WriteTuplesRequest{Writes: []*ssov1.RelationTuple{tuple, tuple}})
+	require.NoError(t, err)
+	resp, err := st.RelationsClient.ReadTuples(ctx, &ssov1.ReadTuplesRequest{Namespace: "document", ObjectId: documentID})
+	require.NoError(t, err)
+	require.Len(t, resp.GetTuples(), 1)
+	assert.Equal(t, user.ID, resp.GetTuples()[0].GetSubjectUserId())
+
+	_, err = st.RelationsClient.WriteTuples(adminCtx, &ssov1.WriteTuplesRequest{Deletes: []*ssov1.RelationTuple{tuple}})
+	require.NoError(t, err)
+	resp, err = st.RelationsClient.ReadTuples(ctx, &ssov1.ReadTuplesRequest{Namespace: "document", ObjectId: documentID})
+	require.NoError(t, err)
+	assert.Empty(t, resp.GetTuples())
+
+	_, err = st.RelationsClient.WriteTuples(adminCtx, &ssov1.WriteTuplesRequest{Writes: []*ssov1.RelationTuple{
+		{Namespace: "document", ObjectId: documentID, Relation: "owner", SubjectUserId: suite.NotFoundUserID},
+	}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = st.RelationsClient.WriteTuples(adminCtx, &ssov1.WriteTuplesRequest{Writes: []*ssov1.RelationTuple{
+		{Namespace: "document", ObjectId: documentID, Relation: "unknown", SubjectUserId: user.ID},
+	}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	ctx := context.Background()
+	m := models.New(st.NewTestStorage().DB)
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, m))
+	user := suite.CreateActiveTestUser(t, m.User)
+	folderID, documentID := gofakeit.UUID(), gofakeit.UUID()
+	_, err := st.RelationsClient.WriteTuples(adminCtx, &ssov1.WriteTuplesRequest{
+		Writes: []*ssov1.RelationTuple{
+			{Namespace: "folder", ObjectId: folderID, Relation: "editor", SubjectUserId: user.ID},
+			{
+				Namespace:  "document",
+				ObjectId:   documentID,
+				Relation:   "parent",
+				SubjectSet: &ssov1.Userset{Namespace: "folder", ObjectId: folderID},
+			},
+		},
+	})
+	require.NoError(t, err)
+	resp, err := st.RelationsClient.Expand(ctx, &ssov1.ExpandRequest{Namespace: "document", ObjectId: documentID, Relation: "editor"})
+	require.NoError(t, err)
+	tree := resp.GetTree()
+	assert.Empty(t, tree.GetUserIds())
+	// owner of the document and editor of the parent folder
+	require.Len(t, tree.GetChildren(), 2)
+	folderEditors := tree.GetChildren()[1]
+	assert.Equal(t, folderID, folderEditors.GetUserset().GetObjectId())
+	assert.Equal(t, []int64{user.ID}, folderEditors.GetUserIds())
+}
+
+func TestWriteTuplesRequiresAdmin(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	m := models.New(st.NewTestStorage().DB)
+	user := suite.CreateActiveTestUser(t, m.User)
+	documentID := gofakeit.UUID()
+	// a user must not be able to grant themselves a relation
+	req := &ssov1.WriteTuplesRequest{Writes: []*ssov1.RelationTuple{
+		{Namespace: "document", ObjectId: documentID, Relation: "owner", SubjectUserId: user.ID},
+	}}
+	_, err := st.RelationsClient.WriteTuples(context.Background(), req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = st.RelationsClient.WriteTuples(st.AuthorizedContext(user), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	resp, err := st.RelationsClient.ReadTuples(context.Background(), &ssov1.ReadTuplesRequest{Namespace: "document", ObjectId: documentID})
+	require.NoError(t, err)
+	assert.Empty(t, resp.GetTuples())
+}
@@ -0,0 +1,92 @@
+package userdata_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ssov1 "sso.service/api/proto/gen/v1"
+	"sso.service/internal/entity"
+	"sso.service/internal/storage/postgres/models"
+	"sso.service/tests/suite"
+)
+
+func TestExportUserData(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	user := suite.CreateActiveTestUser(t, models.User)
+	perm := gofakeit.Username()
+	require.NoError(t, models.Permission.CreateManyIgnoreConflict(context.Background(), entity.GlobalAppID, []string{perm}))
+	_, err := models.Permission.AddForUserIgnoreConflict(context.Background(), user.ID, entity.GlobalAppID, []string{perm})
+	require.NoError(t, err)
+	_, err = models.User.UpdateMetadata(context.Background(), user.ID, suite.AppID, func(metadata *entity.UserMetadata) error {
+		metadata.Public["nickname"] = "public"
+		metadata.Admin["note"] = "admin"
+		metadata.App["theme"] = "app"
+		return nil
+	})
+	require.NoError(t, err)
+	userCtx := st.AuthorizedContext(user)
+
+	resp, err := st.UserDataClient.ExportUserData(userCtx, &ssov1.ExportUserDataRequest{UserId: user.ID})
+	require.NoError(t, err)
+	exportID := resp.GetExport().GetId()
+	var export *ssov1.DataExport
+	require.Eventually(t, func() bool {
+		resp, err := st.UserDataClient.GetDataExport(userCtx, &ssov1.GetDataExportRequest{Id: exportID})
+		require.NoError(t, err)
+		export = resp.GetExport()
+		return export.GetStatus() == string(entity.DataExportCompleted) || export.GetStatus() == string(entity.DataExportFailed)
+	}, 30*time.Second, 200*time.Millisecond)
+	require.Equal(t, string(entity.DataExportCompleted), export.GetStatus(), export.GetError())
+
+	var archive entity.UserDataArchive
+	require.NoError(t, json.Unmarshal(export.GetArchive(), &archive))
+	require.NotNil(t, archive.Profile)
+	assert.Equal(t, user.Email, archive.Profile.Email)
+	grantedCodes := []string{}
+	for _, grant := range archive.PermissionGrants {
+		grantedCodes = append(grantedCodes, grant.Permission.Code)
+	}
+	assert.Contains(t, grantedCodes, perm)
+	assert.Equal(t, "public", archive.PublicMetadata["nickname"])
+	assert.Equal(t, "admin", archive.AdminMetadata["note"])
+	require.NotEmpty(t, archive.Apps)
+	assert.Equal(t, int32(suite.AppID), archive.Apps[0].AppID)
+	assert.Equal(t, "app", archive.Apps[0].Metadata["theme"])
+	assert.NotEmpty(t, archive.Sessions)
+}
+
+func TestExportUserDataOfOtherUser(t *testing.T) {
+	t.Parallel()
+	st := suite.New(t)
+	storage := st.NewTestStorage()
+	models := models.New(storage.DB)
+	user := suite.CreateActiveTestUser(t, models.User)
+	other := suite.CreateActiveTestUser(t, models.User)
+	userCtx := st.AuthorizedContext(user)
+
+	_, err := st.UserDataClient.ExportUserData(context.Background(), &ssov1.ExportUserDataRequest{UserId: user.ID})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = st.UserDataClient.ExportUserData(userCtx, &ssov1.ExportUserDataRequest{UserId: other.ID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	adminCtx := st.AuthorizedContext(suite.CreateTestAdmin(t, models))
+	_, err = st.UserDataClient.ExportUserData(adminCtx, &ssov1.ExportUserDataRequest{UserId: suite.NotFoundUserID})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	resp, err := st.UserDataClient.ExportUserData(adminCtx, &ssov1.ExportUserDataRequest{UserId: other.ID})
+	require.NoError(t, err)
+	// the export is visible to the admin and the subject, not to anyone else
+	_, err = st.UserDataClient.GetDataExport(userCtx, &ssov1.GetDataExportRequest{Id: resp.GetExport().GetId()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = st.UserDataClient.GetDataExport(st.AuthorizedContext(other), &ssov1.GetDataExportRequest{Id: resp.GetExport().GetId()})
+	assert.NoError(t, err)
+}
@@ -42,3 +42,52 @@ func CreateActiveTestUser(t *testing.T, userModel *models.UserModel) *entity.Use
 	SaveTestUser(t, userModel, user)
 	return user
 }
+
+func CreateTestRole(t *testing.T, m *models.Models, permissionCodes ...string) *entity.RoleDetails {
+	ctx := context.Background()
+	role := entity.RoleDetails{Name: gofakeit.JobTitle() + gofakeit.DigitN(6)}
+	roleID, err := m.Role.Create(ctx, &role)
+	require.NoError(t, err)
+	role.ID = roleID
+	require.NoError(t, m.Permission.CreateManyIgnoreConflict(ctx, entity.GlobalAppID, permissionCodes))
+	require.NoError(t, m.Role.SetPermissions(ctx, roleID, entity.GlobalAppID, permissionCodes))
+	return &role
+}
+
+func CreateTestGroup(t *testing.T, m *models.Models) *entity.Group {
+	group := entity.Group{Name: gofakeit.Company() + gofakeit.DigitN(6)}
+	groupID, err := m.Group.Create(context.Background(), &group)
+	require.NoError(t, err)
+	group.ID = groupID
+	return &group
+}
+
+// CreateTestApp saves the app under a random name and with a random secret unless they are set.
+func CreateTestApp(t *testing.T, m *models.Models, app *entity.App) *entity.App {
+	if app.Name == "" {
+		app.Name = "test-app-" + gofakeit.UUID()
+	}
+	if app.Secret == "" {
+		app.Secret = gofakeit.Password(true, true, true, false, false, 32)
+	}
+	appID, err := m.App.Create(context.Background(), app)
+	require.NoError(t, err)
+	app.ID = appID
+	return app
+}
+
+func CreateTestOrg(t *testing.T, m *models.Models) *entity.Org {
+	org := entity.Org{Name: gofakeit.Company() + gofakeit.DigitN(6)}
+	orgID, err := m.Org.Create(context.Background(), &org)
+	require.NoError(t, err)
+	org.ID = orgID
+	return &org
+}
+
+func CreateTestAdmin(t *testing.T, m *models.Models) *entity.User {
+	user := CreateActiveTestUser(t, m.User)
+	_, err := m.User.SetRole(context.Background(), user.ID, entity.RoleAdmin)
+	require.NoError(t, err)
+	user.Role = entity.RoleAdmin
+	return user
+}
@@ -0,0 +1,66 @@
+package suite
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"sso.service/internal/storage/postgres"
+)
+
+const invalidationChannel = "permissions_invalidation"
+
+// Invalidations records payloads of the permissions invalidation notifications.
+type Invalidations struct {
+	mu       sync.Mutex
+	payloads []string
+}
+
+// ListenInvalidations runs the listener SSO replicas use to invalidate cached permissions until the end of the test.
+func ListenInvalidations(t *testing.T, storage *postgres.Storage) *Invalidations {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	invalidations := &Invalidations{}
+	listening := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- storage.Listen(ctx, invalidationChannel, func() { close(listening) }, invalidations.add)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+	})
+	select {
+	case <-listening:
+	case err := <-done:
+		t.Fatalf("listener failed: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener hasn't subscribed in time")
+	}
+	return invalidations
+}
+
+func (i *Invalidations) add(payload string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.payloads = append(i.payloads, payload)
+}
+
+// RequireReceived fails the test unless the notification with the payload is received shortly.
+// The notification is consumed, so every change has to be notified on its own.
+func (i *Invalidations) RequireReceived(t *testing.T, payload string) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		i.mu.Lock()
+		defer i.mu.Unlock()
+		idx := slices.Index(i.payloads, payload)
+		if idx < 0 {
+			return false
+		}
+		i.payloads = slices.Delete(i.payloads, idx, idx+1)
+		return true
+	}, 5*time.Second, 10*time.Millisecond, "no invalidation %q", payload)
+}
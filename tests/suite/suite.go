@@ -9,8 +9,10 @@ import (
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	ssov1 "sso.service/api/proto/gen/v1"
 	"sso.service/internal/config"
+	"sso.service/internal/entity"
 	"sso.service/internal/storage/postgres"
 )
 
@@ -19,6 +21,9 @@ type Suite struct {
 	Cfg               *config.Config
 	AuthClient        ssov1.AuthClient
 	PermissionsClient ssov1.PermissionsClient
+	RelationsClient   ssov1.RelationsClient
+	OrgsClient        ssov1.OrgsClient
+	UserDataClient    ssov1.UserDataClient
 }
 
 func New(t *testing.T) *Suite {
@@ -37,6 +42,9 @@ func New(t *testing.T) *Suite {
 		Cfg:               cfg,
 		AuthClient:        ssov1.NewAuthClient(conn),
 		PermissionsClient: ssov1.NewPermissionsClient(conn),
+		RelationsClient:   ssov1.NewRelationsClient(conn),
+		OrgsClient:        ssov1.NewOrgsClient(conn),
+		UserDataClient:    ssov1.NewUserDataClient(conn),
 	}
 }
 
@@ -51,3 +59,15 @@ func (self *Suite) NewTestStorage() *postgres.Storage {
 	})
 	return storage
 }
+
+// AuthorizedContext logs the user in to the test app and returns context carrying the access token.
+func (self *Suite) AuthorizedContext(user *entity.User) context.Context {
+	self.T.Helper()
+	resp, err := self.AuthClient.Login(context.Background(), &ssov1.LoginRequest{
+		Email:    user.Email,
+		Password: user.Password.Plaintext,
+		AppId:    AppID,
+	})
+	require.NoError(self.T, err)
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+resp.GetAccessToken())
+}